@@ -49,6 +49,8 @@ var (
 	DefaultAuthority = false
 	// DefaultRoles Default node roles
 	DefaultRoles = byte(1) // full node (see Table D.2)
+	// DefaultConsensus is the block production mode, either "babe" or "manual"
+	DefaultConsensus = string("babe")
 
 	// NetworkConfig
 
@@ -62,6 +64,18 @@ var (
 	DefaultNoBootstrap = false
 	// DefaultNoMDNS disables mDNS discovery
 	DefaultNoMDNS = false
+	// DefaultMinPeers is the number of peers we try to stay connected to
+	DefaultMinPeers = 5
+	// DefaultMaxPeers is the maximum number of peers we stay connected to
+	DefaultMaxPeers = 50
+	// DefaultMaxInbound is the maximum number of inbound connections we accept
+	DefaultMaxInbound = 25
+	// DefaultReservedNodes the peers the node always stays connected to
+	DefaultReservedNodes = []string(nil)
+	// DefaultReservedOnly only connects to reserved peers
+	DefaultReservedOnly = false
+	// DefaultNoCompression disables the compression of block responses
+	DefaultNoCompression = false
 
 	// RPCConfig
 
@@ -342,11 +342,22 @@ func setDotCoreConfig(ctx *cli.Context, cfg *dot.CoreConfig) {
 		cfg.GrandpaAuthority = false
 	}
 
+	// check --light flag and run as a light client, which cannot be an authority
+	if light := ctx.GlobalBool(LightFlag.Name); light {
+		cfg.Roles = dot.LightClientRoles
+		cfg.Authority = false
+	}
+
 	if !cfg.Authority {
 		cfg.BabeAuthority = false
 		cfg.GrandpaAuthority = false
 	}
 
+	// check --dev flag and build blocks on demand instead of using BABE
+	if dev := ctx.GlobalBool(DevFlag.Name); dev {
+		cfg.Consensus = dot.ManualConsensus
+	}
+
 	if thresholdStr, ok := cfg.BabeThreshold.(string); ok {
 		switch thresholdStr {
 		case "max":
@@ -365,6 +376,7 @@ func setDotCoreConfig(ctx *cli.Context, cfg *dot.CoreConfig) {
 		"babe-authority", cfg.BabeAuthority,
 		"grandpa-authority", cfg.GrandpaAuthority,
 		"babe-threshold", cfg.BabeThreshold,
+		"consensus", cfg.Consensus,
 	)
 }
 
@@ -400,6 +412,41 @@ func setDotNetworkConfig(ctx *cli.Context, cfg *dot.NetworkConfig) {
 		cfg.NoMDNS = true
 	}
 
+	// check --min-peers flag and update node configuration
+	if minPeers := ctx.GlobalInt(MinPeersFlag.Name); minPeers != 0 {
+		cfg.MinPeers = minPeers
+	}
+
+	// check --max-peers flag and update node configuration
+	if maxPeers := ctx.GlobalInt(MaxPeersFlag.Name); maxPeers != 0 {
+		cfg.MaxPeers = maxPeers
+	}
+
+	// check --max-inbound flag and update node configuration
+	if maxInbound := ctx.GlobalInt(MaxInboundFlag.Name); maxInbound != 0 {
+		cfg.MaxInbound = maxInbound
+	}
+
+	// check --reserved-nodes flag and update node configuration
+	if reservedNodes := ctx.GlobalString(ReservedNodesFlag.Name); reservedNodes != "" {
+		cfg.ReservedNodes = strings.Split(reservedNodes, ",")
+	}
+
+	// format reserved nodes
+	if len(cfg.ReservedNodes) == 0 {
+		cfg.ReservedNodes = []string(nil)
+	}
+
+	// check --reserved-only flag and update node configuration
+	if reservedOnly := ctx.GlobalBool(ReservedOnlyFlag.Name); reservedOnly {
+		cfg.ReservedOnly = true
+	}
+
+	// check --nocompression flag and update node configuration
+	if nocompression := ctx.GlobalBool(NoCompressionFlag.Name); nocompression {
+		cfg.NoCompression = true
+	}
+
 	logger.Debug(
 		"network configuration",
 		"port", cfg.Port,
@@ -407,6 +454,12 @@ func setDotNetworkConfig(ctx *cli.Context, cfg *dot.NetworkConfig) {
 		"protocol", cfg.ProtocolID,
 		"nobootstrap", cfg.NoBootstrap,
 		"nomdns", cfg.NoMDNS,
+		"min-peers", cfg.MinPeers,
+		"max-peers", cfg.MaxPeers,
+		"max-inbound", cfg.MaxInbound,
+		"reserved-nodes", cfg.ReservedNodes,
+		"reserved-only", cfg.ReservedOnly,
+		"nocompression", cfg.NoCompression,
 	)
 }
 
@@ -444,6 +497,11 @@ func setDotRPCConfig(ctx *cli.Context, cfg *dot.RPCConfig) {
 		cfg.WSEnabled = false
 	}
 
+	// check --dev flag and enable the dev module, which is used to request blocks
+	if dev := ctx.GlobalBool(DevFlag.Name); dev && !hasModule(cfg.Modules, "dev") {
+		cfg.Modules = append(cfg.Modules, "dev")
+	}
+
 	// format rpc modules
 	if len(cfg.Modules) == 0 {
 		cfg.Modules = []string(nil)
@@ -460,6 +518,17 @@ func setDotRPCConfig(ctx *cli.Context, cfg *dot.RPCConfig) {
 	)
 }
 
+// hasModule returns true if the module is in the list of rpc modules
+func hasModule(modules []string, module string) bool {
+	for _, mod := range modules {
+		if mod == module {
+			return true
+		}
+	}
+
+	return false
+}
+
 func setSystemInfoConfig(ctx *cli.Context, cfg *dot.Config) {
 	// load system information
 	if ctx.App != nil {
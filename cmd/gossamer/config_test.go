@@ -291,6 +291,7 @@ func TestCoreConfigFromFlags(t *testing.T) {
 				Roles:            4,
 				BabeAuthority:    true,
 				GrandpaAuthority: true,
+				Consensus:        dot.BabeConsensus,
 			},
 		},
 		{
@@ -302,6 +303,31 @@ func TestCoreConfigFromFlags(t *testing.T) {
 				Roles:            0,
 				BabeAuthority:    false,
 				GrandpaAuthority: false,
+				Consensus:        dot.BabeConsensus,
+			},
+		},
+		{
+			"Test gossamer --dev",
+			[]string{"config", "dev"},
+			[]interface{}{testCfgFile.Name(), true},
+			dot.CoreConfig{
+				Authority:        true,
+				Roles:            4,
+				BabeAuthority:    true,
+				GrandpaAuthority: true,
+				Consensus:        dot.ManualConsensus,
+			},
+		},
+		{
+			"Test gossamer --light",
+			[]string{"config", "light"},
+			[]interface{}{testCfgFile.Name(), true},
+			dot.CoreConfig{
+				Authority:        false,
+				Roles:            dot.LightClientRoles,
+				BabeAuthority:    false,
+				GrandpaAuthority: false,
+				Consensus:        dot.BabeConsensus,
 			},
 		},
 	}
@@ -340,11 +366,17 @@ func TestNetworkConfigFromFlags(t *testing.T) {
 			[]string{"config", "port"},
 			[]interface{}{testCfgFile.Name(), "1234"},
 			dot.NetworkConfig{
-				Port:        1234,
-				Bootnodes:   testCfg.Network.Bootnodes,
-				ProtocolID:  testCfg.Network.ProtocolID,
-				NoBootstrap: testCfg.Network.NoBootstrap,
-				NoMDNS:      testCfg.Network.NoMDNS,
+				Port:          1234,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
 			},
 		},
 		{
@@ -352,11 +384,17 @@ func TestNetworkConfigFromFlags(t *testing.T) {
 			[]string{"config", "bootnodes"},
 			[]interface{}{testCfgFile.Name(), "peer1,peer2"},
 			dot.NetworkConfig{
-				Port:        testCfg.Network.Port,
-				Bootnodes:   []string{"peer1", "peer2"},
-				ProtocolID:  testCfg.Network.ProtocolID,
-				NoBootstrap: testCfg.Network.NoBootstrap,
-				NoMDNS:      testCfg.Network.NoMDNS,
+				Port:          testCfg.Network.Port,
+				Bootnodes:     []string{"peer1", "peer2"},
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
 			},
 		},
 		{
@@ -364,11 +402,17 @@ func TestNetworkConfigFromFlags(t *testing.T) {
 			[]string{"config", "protocol"},
 			[]interface{}{testCfgFile.Name(), "/gossamer/test/0"},
 			dot.NetworkConfig{
-				Port:        testCfg.Network.Port,
-				Bootnodes:   testCfg.Network.Bootnodes,
-				ProtocolID:  "/gossamer/test/0",
-				NoBootstrap: testCfg.Network.NoBootstrap,
-				NoMDNS:      testCfg.Network.NoMDNS,
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    "/gossamer/test/0",
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
 			},
 		},
 		{
@@ -376,11 +420,17 @@ func TestNetworkConfigFromFlags(t *testing.T) {
 			[]string{"config", "nobootstrap"},
 			[]interface{}{testCfgFile.Name(), "true"},
 			dot.NetworkConfig{
-				Port:        testCfg.Network.Port,
-				Bootnodes:   testCfg.Network.Bootnodes,
-				ProtocolID:  testCfg.Network.ProtocolID,
-				NoBootstrap: true,
-				NoMDNS:      testCfg.Network.NoMDNS,
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   true,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
 			},
 		},
 		{
@@ -388,11 +438,71 @@ func TestNetworkConfigFromFlags(t *testing.T) {
 			[]string{"config", "nomdns"},
 			[]interface{}{testCfgFile.Name(), "true"},
 			dot.NetworkConfig{
-				Port:        testCfg.Network.Port,
-				Bootnodes:   testCfg.Network.Bootnodes,
-				ProtocolID:  testCfg.Network.ProtocolID,
-				NoBootstrap: testCfg.Network.NoBootstrap,
-				NoMDNS:      true,
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        true,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
+			},
+		},
+		{
+			"Test gossamer --max-peers",
+			[]string{"config", "max-peers"},
+			[]interface{}{testCfgFile.Name(), "10"},
+			dot.NetworkConfig{
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      10,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: testCfg.Network.NoCompression,
+			},
+		},
+		{
+			"Test gossamer --reserved-only",
+			[]string{"config", "reserved-only"},
+			[]interface{}{testCfgFile.Name(), "true"},
+			dot.NetworkConfig{
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  true,
+				NoCompression: testCfg.Network.NoCompression,
+			},
+		},
+		{
+			"Test gossamer --nocompression",
+			[]string{"config", "nocompression"},
+			[]interface{}{testCfgFile.Name(), "true"},
+			dot.NetworkConfig{
+				Port:          testCfg.Network.Port,
+				Bootnodes:     testCfg.Network.Bootnodes,
+				ProtocolID:    testCfg.Network.ProtocolID,
+				NoBootstrap:   testCfg.Network.NoBootstrap,
+				NoMDNS:        testCfg.Network.NoMDNS,
+				MinPeers:      testCfg.Network.MinPeers,
+				MaxPeers:      testCfg.Network.MaxPeers,
+				MaxInbound:    testCfg.Network.MaxInbound,
+				ReservedNodes: testCfg.Network.ReservedNodes,
+				ReservedOnly:  testCfg.Network.ReservedOnly,
+				NoCompression: true,
 			},
 		},
 	}
@@ -679,11 +789,17 @@ func TestUpdateConfigFromGenesisData(t *testing.T) {
 		Account: testCfg.Account,
 		Core:    testCfg.Core,
 		Network: dot.NetworkConfig{
-			Port:        testCfg.Network.Port,
-			Bootnodes:   []string{}, // TODO: improve cmd tests #687
-			ProtocolID:  testCfg.Network.ProtocolID,
-			NoBootstrap: testCfg.Network.NoBootstrap,
-			NoMDNS:      testCfg.Network.NoMDNS,
+			Port:          testCfg.Network.Port,
+			Bootnodes:     []string{}, // TODO: improve cmd tests #687
+			ProtocolID:    testCfg.Network.ProtocolID,
+			NoBootstrap:   testCfg.Network.NoBootstrap,
+			NoMDNS:        testCfg.Network.NoMDNS,
+			MinPeers:      testCfg.Network.MinPeers,
+			MaxPeers:      testCfg.Network.MaxPeers,
+			MaxInbound:    testCfg.Network.MaxInbound,
+			ReservedNodes: testCfg.Network.ReservedNodes,
+			ReservedOnly:  testCfg.Network.ReservedOnly,
+			NoCompression: testCfg.Network.NoCompression,
 		},
 		RPC:    testCfg.RPC,
 		System: testCfg.System,
@@ -78,11 +78,17 @@ func TestExportCommand(t *testing.T) {
 				Account: testCfg.Account,
 				Core:    testCfg.Core,
 				Network: dot.NetworkConfig{
-					Port:        testCfg.Network.Port,
-					Bootnodes:   []string{}, // TODO: improve cmd tests #687
-					ProtocolID:  testCfg.Network.ProtocolID,
-					NoBootstrap: testCfg.Network.NoBootstrap,
-					NoMDNS:      testCfg.Network.NoMDNS,
+					Port:          testCfg.Network.Port,
+					Bootnodes:     []string{}, // TODO: improve cmd tests #687
+					ProtocolID:    testCfg.Network.ProtocolID,
+					NoBootstrap:   testCfg.Network.NoBootstrap,
+					NoMDNS:        testCfg.Network.NoMDNS,
+					MinPeers:      testCfg.Network.MinPeers,
+					MaxPeers:      testCfg.Network.MaxPeers,
+					MaxInbound:    testCfg.Network.MaxInbound,
+					ReservedNodes: []string{},
+					ReservedOnly:  testCfg.Network.ReservedOnly,
+					NoCompression: testCfg.Network.NoCompression,
 				},
 				RPC: testCfg.RPC,
 			},
@@ -109,11 +115,17 @@ func TestExportCommand(t *testing.T) {
 				Account: testCfg.Account,
 				Core:    testCfg.Core,
 				Network: dot.NetworkConfig{
-					Port:        testCfg.Network.Port,
-					Bootnodes:   []string{testBootnode},
-					ProtocolID:  testCfg.Network.ProtocolID,
-					NoBootstrap: testCfg.Network.NoBootstrap,
-					NoMDNS:      testCfg.Network.NoMDNS,
+					Port:          testCfg.Network.Port,
+					Bootnodes:     []string{testBootnode},
+					ProtocolID:    testCfg.Network.ProtocolID,
+					NoBootstrap:   testCfg.Network.NoBootstrap,
+					NoMDNS:        testCfg.Network.NoMDNS,
+					MinPeers:      testCfg.Network.MinPeers,
+					MaxPeers:      testCfg.Network.MaxPeers,
+					MaxInbound:    testCfg.Network.MaxInbound,
+					ReservedNodes: []string{},
+					ReservedOnly:  testCfg.Network.ReservedOnly,
+					NoCompression: testCfg.Network.NoCompression,
 				},
 				RPC: testCfg.RPC,
 			},
@@ -140,11 +152,17 @@ func TestExportCommand(t *testing.T) {
 				Account: testCfg.Account,
 				Core:    testCfg.Core,
 				Network: dot.NetworkConfig{
-					Port:        testCfg.Network.Port,
-					Bootnodes:   []string{}, // TODO: improve cmd tests #687
-					ProtocolID:  testProtocol,
-					NoBootstrap: testCfg.Network.NoBootstrap,
-					NoMDNS:      testCfg.Network.NoMDNS,
+					Port:          testCfg.Network.Port,
+					Bootnodes:     []string{}, // TODO: improve cmd tests #687
+					ProtocolID:    testProtocol,
+					NoBootstrap:   testCfg.Network.NoBootstrap,
+					NoMDNS:        testCfg.Network.NoMDNS,
+					MinPeers:      testCfg.Network.MinPeers,
+					MaxPeers:      testCfg.Network.MaxPeers,
+					MaxInbound:    testCfg.Network.MaxInbound,
+					ReservedNodes: []string{},
+					ReservedOnly:  testCfg.Network.ReservedOnly,
+					NoCompression: testCfg.Network.NoCompression,
 				},
 				RPC: testCfg.RPC,
 			},
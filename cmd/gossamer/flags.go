@@ -43,6 +43,16 @@ var (
 		Name:  "roles",
 		Usage: "Roles of the gossamer node",
 	}
+	// DevFlag runs the node in development mode
+	DevFlag = cli.BoolFlag{
+		Name:  "dev",
+		Usage: "Run in development mode: blocks are built when transactions are submitted or on dev_createBlock, instead of every slot",
+	}
+	// LightFlag runs the node as a light client
+	LightFlag = cli.BoolFlag{
+		Name:  "light",
+		Usage: "Run as a light client: only block headers are synced, and state is read from full peers with proofs",
+	}
 )
 
 // Global node configuration flags
@@ -111,6 +121,36 @@ var (
 		Name:  "nomdns",
 		Usage: "Disables network mDNS discovery",
 	}
+	// MinPeersFlag Set the number of peers to stay connected to
+	MinPeersFlag = cli.IntFlag{
+		Name:  "min-peers",
+		Usage: "Set the number of peers the node tries to stay connected to",
+	}
+	// MaxPeersFlag Set the maximum number of peers
+	MaxPeersFlag = cli.IntFlag{
+		Name:  "max-peers",
+		Usage: "Set the maximum number of peers the node stays connected to",
+	}
+	// MaxInboundFlag Set the maximum number of inbound connections
+	MaxInboundFlag = cli.IntFlag{
+		Name:  "max-inbound",
+		Usage: "Set the maximum number of inbound connections the node accepts",
+	}
+	// ReservedNodesFlag Set the peers to always stay connected to
+	ReservedNodesFlag = cli.StringFlag{
+		Name:  "reserved-nodes",
+		Usage: "Comma separated node URLs the node always stays connected to",
+	}
+	// ReservedOnlyFlag Only connect to reserved peers
+	ReservedOnlyFlag = cli.BoolFlag{
+		Name:  "reserved-only",
+		Usage: "Only connect to reserved nodes, rejecting all other connections",
+	}
+	// NoCompressionFlag Disables the compression of block responses
+	NoCompressionFlag = cli.BoolFlag{
+		Name:  "nocompression",
+		Usage: "Disables the compression of block responses sent to and requested from peers",
+	}
 )
 
 // RPC service configuration flags
@@ -206,6 +246,10 @@ var (
 		KeyFlag,
 		UnlockFlag,
 
+		// core flags
+		DevFlag,
+		LightFlag,
+
 		// network flags
 		PortFlag,
 		BootnodesFlag,
@@ -213,6 +257,12 @@ var (
 		RolesFlag,
 		NoBootstrapFlag,
 		NoMDNSFlag,
+		MinPeersFlag,
+		MaxPeersFlag,
+		MaxInboundFlag,
+		ReservedNodesFlag,
+		ReservedOnlyFlag,
+		NoCompressionFlag,
 
 		// rpc flags
 		RPCEnabledFlag,
@@ -64,7 +64,7 @@ func newTestContext(description string, flags []string, values []interface{}) (*
 				return nil, fmt.Errorf("failed to set cli flag: %T", flags[i])
 			}
 		case uint:
-			err := ctx.Set(flags[i], string(v))
+			err := ctx.Set(flags[i], fmt.Sprint(v))
 			if err != nil {
 				return nil, fmt.Errorf("failed to set cli flag: %T", flags[i])
 			}
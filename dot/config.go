@@ -27,11 +27,22 @@ import (
 	"github.com/ChainSafe/gossamer/chain/gssmr"
 	"github.com/ChainSafe/gossamer/chain/ksmcc"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 
 	log "github.com/ChainSafe/log15"
 	"github.com/naoina/toml"
 )
 
+const (
+	// BabeConsensus produces blocks with BABE, once per slot the node is authorized for
+	BabeConsensus = "babe"
+	// ManualConsensus produces blocks on demand (see babe.ManualSealService), for development
+	ManualConsensus = "manual"
+)
+
+// LightClientRoles is the roles value of light clients, which only sync block headers (see Table D.2)
+const LightClientRoles = common.LightClientRole
+
 // Config is a collection of configurations throughout the system
 type Config struct {
 	Global  GlobalConfig     `toml:"global"`
@@ -78,11 +89,17 @@ type AccountConfig struct {
 
 // NetworkConfig is to marshal/unmarshal toml network config vars
 type NetworkConfig struct {
-	Port        uint32   `toml:"port"`
-	Bootnodes   []string `toml:"bootnodes"`
-	ProtocolID  string   `toml:"protocol"`
-	NoBootstrap bool     `toml:"nobootstrap"`
-	NoMDNS      bool     `toml:"nomdns"`
+	Port          uint32   `toml:"port"`
+	Bootnodes     []string `toml:"bootnodes"`
+	ProtocolID    string   `toml:"protocol"`
+	NoBootstrap   bool     `toml:"nobootstrap"`
+	NoMDNS        bool     `toml:"nomdns"`
+	MinPeers      int      `toml:"min-peers"`
+	MaxPeers      int      `toml:"max-peers"`
+	MaxInbound    int      `toml:"max-inbound"`
+	ReservedNodes []string `toml:"reserved-nodes"`
+	ReservedOnly  bool     `toml:"reserved-only"`
+	NoCompression bool     `toml:"nocompression"`
 }
 
 // CoreConfig is to marshal/unmarshal toml core config vars
@@ -93,6 +110,7 @@ type CoreConfig struct {
 	GrandpaAuthority bool        `toml:"grandpa-authority"`
 	BabeThreshold    interface{} `toml:"babe-threshold"`
 	SlotDuration     uint64      `toml:"slot-duration"`
+	Consensus        string      `toml:"consensus"`
 }
 
 // RPCConfig is to marshal/unmarshal toml RPC config vars
@@ -116,6 +134,21 @@ func NetworkServiceEnabled(cfg *Config) bool {
 	return cfg.Core.Roles != byte(0)
 }
 
+// LightClientEnabled returns true if the node runs as a light client
+func LightClientEnabled(cfg *Config) bool {
+	return cfg.Core.Roles == LightClientRoles
+}
+
+// ManualSealEnabled returns true if blocks are produced on demand instead of by BABE
+func ManualSealEnabled(cfg *Config) bool {
+	return cfg.Core.Consensus == ManualConsensus
+}
+
+// BlockProducerEnabled returns true if the node produces blocks, either with BABE or on demand
+func BlockProducerEnabled(cfg *Config) bool {
+	return ManualSealEnabled(cfg) || cfg.Core.BabeAuthority
+}
+
 // RPCServiceEnabled returns true if the rpc service is enabled
 func RPCServiceEnabled(cfg *Config) bool {
 	return cfg.RPC.Enabled
@@ -142,13 +175,20 @@ func GssmrConfig() *Config {
 			Roles:            gssmr.DefaultRoles,
 			BabeAuthority:    gssmr.DefaultBabeAuthority,
 			GrandpaAuthority: gssmr.DefaultGrandpaAuthority,
+			Consensus:        gssmr.DefaultConsensus,
 		},
 		Network: NetworkConfig{
-			Port:        gssmr.DefaultNetworkPort,
-			Bootnodes:   gssmr.DefaultNetworkBootnodes,
-			ProtocolID:  gssmr.DefaultNetworkProtocolID,
-			NoBootstrap: gssmr.DefaultNoBootstrap,
-			NoMDNS:      gssmr.DefaultNoMDNS,
+			Port:          gssmr.DefaultNetworkPort,
+			Bootnodes:     gssmr.DefaultNetworkBootnodes,
+			ProtocolID:    gssmr.DefaultNetworkProtocolID,
+			NoBootstrap:   gssmr.DefaultNoBootstrap,
+			NoMDNS:        gssmr.DefaultNoMDNS,
+			MinPeers:      gssmr.DefaultMinPeers,
+			MaxPeers:      gssmr.DefaultMaxPeers,
+			MaxInbound:    gssmr.DefaultMaxInbound,
+			ReservedNodes: gssmr.DefaultReservedNodes,
+			ReservedOnly:  gssmr.DefaultReservedOnly,
+			NoCompression: gssmr.DefaultNoCompression,
 		},
 		RPC: RPCConfig{
 			Port:    gssmr.DefaultRPCHTTPPort,
@@ -181,13 +221,20 @@ func KsmccConfig() *Config {
 		Core: CoreConfig{
 			Authority: ksmcc.DefaultAuthority,
 			Roles:     ksmcc.DefaultRoles,
+			Consensus: ksmcc.DefaultConsensus,
 		},
 		Network: NetworkConfig{
-			Port:        ksmcc.DefaultNetworkPort,
-			Bootnodes:   ksmcc.DefaultNetworkBootnodes,
-			ProtocolID:  ksmcc.DefaultNetworkProtocolID,
-			NoBootstrap: ksmcc.DefaultNoBootstrap,
-			NoMDNS:      ksmcc.DefaultNoMDNS,
+			Port:          ksmcc.DefaultNetworkPort,
+			Bootnodes:     ksmcc.DefaultNetworkBootnodes,
+			ProtocolID:    ksmcc.DefaultNetworkProtocolID,
+			NoBootstrap:   ksmcc.DefaultNoBootstrap,
+			NoMDNS:        ksmcc.DefaultNoMDNS,
+			MinPeers:      ksmcc.DefaultMinPeers,
+			MaxPeers:      ksmcc.DefaultMaxPeers,
+			MaxInbound:    ksmcc.DefaultMaxInbound,
+			ReservedNodes: ksmcc.DefaultReservedNodes,
+			ReservedOnly:  ksmcc.DefaultReservedOnly,
+			NoCompression: ksmcc.DefaultNoCompression,
 		},
 		RPC: RPCConfig{
 			Port:    ksmcc.DefaultRPCHTTPPort,
@@ -30,6 +30,7 @@ type DigestHandler struct {
 	blockState          BlockState
 	grandpa             FinalityGadget
 	babe                BlockProducer
+	verifiers           []FinalityVerifier
 	isFinalityAuthority bool
 	isBlockProducer     bool
 
@@ -54,7 +55,6 @@ type DigestHandler struct {
 	grandpaForcedChange    *grandpaChange
 	grandpaPause           *pause
 	grandpaResume          *resume
-	grandpaAuths           []*types.GrandpaAuthorityData // saved in case of pause
 }
 
 type babeChange struct {
@@ -75,8 +75,9 @@ type resume struct {
 	atBlock *big.Int
 }
 
-// NewDigestHandler returns a new DigestHandler
-func NewDigestHandler(blockState BlockState, babe BlockProducer, grandpa FinalityGadget) (*DigestHandler, error) {
+// NewDigestHandler returns a new DigestHandler. the GRANDPA authority changes are applied to the finality gadget, if
+// we're a GRANDPA authority, and to the given verifiers once they're enacted.
+func NewDigestHandler(blockState BlockState, babe BlockProducer, grandpa FinalityGadget, verifiers ...FinalityVerifier) (*DigestHandler, error) {
 	imported := make(chan *types.Block)
 	finalized := make(chan *types.Header)
 	iid, err := blockState.RegisterImportedChannel(imported)
@@ -96,6 +97,7 @@ func NewDigestHandler(blockState BlockState, babe BlockProducer, grandpa Finalit
 		blockState:          blockState,
 		grandpa:             grandpa,
 		babe:                babe,
+		verifiers:           verifiers,
 		isFinalityAuthority: isFinalityAuthority,
 		isBlockProducer:     isBlockProducer,
 		stopped:             true,
@@ -107,19 +109,21 @@ func NewDigestHandler(blockState BlockState, babe BlockProducer, grandpa Finalit
 }
 
 // Start starts the DigestHandler
-func (h *DigestHandler) Start() {
+func (h *DigestHandler) Start() error {
+	h.stopped = false
 	go h.handleBlockImport()
 	go h.handleBlockFinalization()
-	h.stopped = false
+	return nil
 }
 
 // Stop stops the DigestHandler
-func (h *DigestHandler) Stop() {
+func (h *DigestHandler) Stop() error {
 	h.stopped = true
 	h.blockState.UnregisterImportedChannel(h.importedID)
 	h.blockState.UnregisterFinalizedChannel(h.finalizedID)
 	close(h.imported)
 	close(h.finalized)
+	return nil
 }
 
 // HandleConsensusDigest is the function used by the syncer to handle a consensus digest
@@ -148,8 +152,8 @@ func (h *DigestHandler) handleBlockImport() {
 			return
 		}
 
-		if h.isFinalityAuthority {
-			h.handleGrandpaChangesOnImport(block.Header.Number)
+		if h.tracksFinality() {
+			h.handleGrandpaChangesOnImport(block.Header)
 		}
 
 		if h.isBlockProducer {
@@ -164,7 +168,7 @@ func (h *DigestHandler) handleBlockFinalization() {
 			return
 		}
 
-		if h.isFinalityAuthority {
+		if h.tracksFinality() {
 			h.handleGrandpaChangesOnFinalization(header.Number)
 		}
 
@@ -174,6 +178,18 @@ func (h *DigestHandler) handleBlockFinalization() {
 	}
 }
 
+// tracksFinality returns true if the GRANDPA authority changes are used, either to vote or to verify finality
+func (h *DigestHandler) tracksFinality() bool {
+	return h.isFinalityAuthority || len(h.verifiers) > 0
+}
+
+// applyToVerifiers applies an enacted change to the voter set to the verifiers
+func (h *DigestHandler) applyToVerifiers(auths []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	for _, v := range h.verifiers {
+		v.ApplyAuthorityChange(auths, atBlock)
+	}
+}
+
 func (h *DigestHandler) handleBABEChangesOnImport(num *big.Int) {
 	resume := h.babeResume
 	if resume != nil && num.Cmp(resume.atBlock) == 0 {
@@ -207,37 +223,60 @@ func (h *DigestHandler) handleBABEChangesOnFinalization(num *big.Int) {
 	h.babeForcedChange = nil
 }
 
-func (h *DigestHandler) handleGrandpaChangesOnImport(num *big.Int) {
+// handleGrandpaChangesOnImport applies forced changes and resumes once their block is imported on the best chain.
+// the set of a forced change finalizes the blocks after our latest finalized block.
+func (h *DigestHandler) handleGrandpaChangesOnImport(header *types.Header) {
 	resume := h.grandpaResume
-	if resume != nil && num.Cmp(resume.atBlock) == 0 {
-		h.grandpa.UpdateAuthorities(h.grandpaAuths)
+	if resume != nil && header.Number.Cmp(resume.atBlock) == 0 && h.isOnBestChain(header) {
+		if h.isFinalityAuthority {
+			h.grandpa.Resume()
+		}
 		h.grandpaResume = nil
 	}
 
 	fc := h.grandpaForcedChange
-	if fc != nil && num.Cmp(fc.atBlock) == 0 {
-		h.grandpa.UpdateAuthorities(fc.auths)
+	if fc != nil && header.Number.Cmp(fc.atBlock) == 0 && h.isOnBestChain(header) {
+		if h.isFinalityAuthority {
+			h.grandpa.ForceAuthorityChange(fc.auths)
+		}
+
+		finalized, err := h.blockState.GetFinalizedHeader(0)
+		if err == nil {
+			h.applyToVerifiers(fc.auths, finalized.Number)
+		}
 		h.grandpaForcedChange = nil
 	}
 }
 
+// handleGrandpaChangesOnFinalization applies pauses and scheduled changes to the verifiers once their block is
+// finalized. scheduled changes are enacted by the finality gadget itself, since it must stop finalizing blocks past
+// the change until it's enacted.
 func (h *DigestHandler) handleGrandpaChangesOnFinalization(num *big.Int) {
 	pause := h.grandpaPause
 	if pause != nil && num.Cmp(pause.atBlock) == 0 {
-		// save authority data for Resume
-		h.grandpaAuths = h.grandpa.Authorities()
-		h.grandpa.UpdateAuthorities([]*types.GrandpaAuthorityData{})
+		if h.isFinalityAuthority {
+			h.grandpa.Pause()
+		}
 		h.grandpaPause = nil
 	}
 
 	sc := h.grandpaScheduledChange
-	if sc != nil && num.Cmp(sc.atBlock) == 0 {
-		h.grandpa.UpdateAuthorities(sc.auths)
+	if sc != nil && num.Cmp(sc.atBlock) >= 0 {
+		h.applyToVerifiers(sc.auths, sc.atBlock)
 		h.grandpaScheduledChange = nil
 	}
 
-	// if blocks get finalized before forced change takes place, disregard it
-	h.grandpaForcedChange = nil
+	// if the forced change's block was finalized without being imported on our best chain, it was on a fork
+	fc := h.grandpaForcedChange
+	if fc != nil && num.Cmp(fc.atBlock) >= 0 {
+		h.grandpaForcedChange = nil
+	}
+}
+
+// isOnBestChain returns true if the given block is the best block or one of its ancestors
+func (h *DigestHandler) isOnBestChain(header *types.Header) bool {
+	is, err := h.blockState.IsDescendantOf(header.Hash(), h.blockState.BestBlockHash())
+	return err == nil && is
 }
 
 func (h *DigestHandler) handleScheduledChange(d *types.ConsensusDigest) error {
@@ -282,6 +321,9 @@ func (h *DigestHandler) handleScheduledChange(d *types.ConsensusDigest) error {
 		}
 
 		h.grandpaScheduledChange = c
+		if h.isFinalityAuthority {
+			h.grandpa.ScheduleAuthorityChange(c.auths, c.atBlock)
+		}
 	}
 
 	return nil
@@ -343,6 +385,10 @@ func (h *DigestHandler) handleOnDisabled(d *types.ConsensusDigest) error {
 	od = dec.(*types.OnDisabled)
 
 	if d.ConsensusEngineID == types.BabeEngineID {
+		if !h.isBlockProducer {
+			return nil
+		}
+
 		curr := h.babe.Authorities()
 		next := []*types.BABEAuthorityData{}
 
@@ -354,6 +400,10 @@ func (h *DigestHandler) handleOnDisabled(d *types.ConsensusDigest) error {
 
 		h.babe.SetAuthorities(next)
 	} else {
+		if !h.isFinalityAuthority {
+			return nil
+		}
+
 		curr := h.grandpa.Authorities()
 		next := []*types.GrandpaAuthorityData{}
 
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func newTestDigestHandler(t *testing.T, withBABE, withGrandpa bool) *DigestHandler { //nolint
+func newTestDigestHandler(t *testing.T, withBABE, withGrandpa bool, verifiers ...FinalityVerifier) *DigestHandler { //nolint
 	stateSrvc := state.NewService("", log.LvlInfo)
 	stateSrvc.UseMemDB()
 
@@ -55,7 +56,7 @@ func newTestDigestHandler(t *testing.T, withBABE, withGrandpa bool) *DigestHandl
 	}
 
 	time.Sleep(time.Second)
-	dh, err := NewDigestHandler(stateSrvc.Block, bp, fg)
+	dh, err := NewDigestHandler(stateSrvc.Block, bp, fg, verifiers...)
 	require.NoError(t, err)
 	return dh
 }
@@ -277,23 +278,26 @@ func TestDigestHandler_GrandpaScheduledChange(t *testing.T) {
 	err = handler.HandleConsensusDigest(d)
 	require.NoError(t, err)
 
-	headers := addTestBlocksToState(t, 2, handler.blockState)
-	for _, h := range headers {
-		handler.blockState.SetFinalizedHash(h.Hash(), 0)
-	}
+	// the change is passed to the finality gadget straight away, which enacts it when block 3 is finalized
+	fg := handler.grandpa.(*mockFinalityGadget)
+	require.Equal(t, big.NewInt(3), fg.scheduled)
+	require.Equal(t, 1, len(fg.Authorities()))
 
-	auths := handler.grandpa.Authorities()
-	require.Nil(t, auths)
+	// only one change can be scheduled at a time
+	err = handler.HandleConsensusDigest(d)
+	require.Error(t, err)
 
-	// authorities should change on start of block 3 from start
-	headers = addTestBlocksToState(t, 1, handler.blockState)
+	headers := addTestBlocksToState(t, 3, handler.blockState)
 	for _, h := range headers {
 		handler.blockState.SetFinalizedHash(h.Hash(), 0)
 	}
 
+	// once block 3 is finalized, another change can be scheduled
 	time.Sleep(time.Millisecond * 100)
-	auths = handler.grandpa.Authorities()
-	require.Equal(t, 1, len(auths))
+	require.Nil(t, handler.grandpaScheduledChange)
+	err = handler.HandleConsensusDigest(d)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(6), fg.scheduled)
 }
 
 func TestDigestHandler_GrandpaForcedChange(t *testing.T) {
@@ -333,6 +337,67 @@ func TestDigestHandler_GrandpaForcedChange(t *testing.T) {
 	require.Equal(t, 1, len(auths))
 }
 
+func TestDigestHandler_GrandpaChanges_Verifiers(t *testing.T) {
+	verifier := newMockFinalityVerifier()
+	handler := newTestDigestHandler(t, false, false, verifier)
+	handler.Start()
+	defer handler.Stop()
+	require.False(t, handler.isFinalityAuthority)
+
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	auths := []*types.GrandpaAuthorityDataRaw{
+		{Key: kr.Alice.Public().(*ed25519.PublicKey).AsBytes(), ID: 0},
+	}
+
+	data, err := (&types.GrandpaScheduledChange{Auths: auths, Delay: 3}).Encode()
+	require.NoError(t, err)
+	err = handler.HandleConsensusDigest(&types.ConsensusDigest{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              data,
+	})
+	require.NoError(t, err)
+
+	headers := addTestBlocksToState(t, 2, handler.blockState)
+	for _, h := range headers {
+		handler.blockState.SetFinalizedHash(h.Hash(), 0)
+	}
+
+	// the scheduled change is applied to the verifiers once block 3 is finalized
+	time.Sleep(time.Millisecond * 100)
+	require.Empty(t, verifier.changes)
+
+	headers = addTestBlocksToState(t, 1, handler.blockState)
+	handler.blockState.SetFinalizedHash(headers[0].Hash(), 0)
+
+	select {
+	case c := <-verifier.changes:
+		require.Equal(t, big.NewInt(3), c.atBlock)
+		require.Equal(t, 1, len(c.auths))
+	case <-time.After(time.Second):
+		t.Fatal("scheduled change was not applied to the verifier")
+	}
+
+	// the set of a forced change finalizes the blocks after the finalized block, once its block is imported
+	data, err = (&types.GrandpaForcedChange{Auths: auths, Delay: 2}).Encode()
+	require.NoError(t, err)
+	err = handler.HandleConsensusDigest(&types.ConsensusDigest{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              data,
+	})
+	require.NoError(t, err)
+
+	addTestBlocksToState(t, 2, handler.blockState)
+
+	select {
+	case c := <-verifier.changes:
+		require.Equal(t, big.NewInt(3), c.atBlock)
+	case <-time.After(time.Second):
+		t.Fatal("forced change was not applied to the verifier")
+	}
+}
+
 func TestDigestHandler_GrandpaOnDisabled(t *testing.T) {
 	handler := newTestDigestHandler(t, false, true)
 	handler.Start()
@@ -416,9 +481,11 @@ func TestDigestHandler_GrandpaPauseAndResume(t *testing.T) {
 		handler.blockState.SetFinalizedHash(h.Hash(), 0)
 	}
 
+	// pausing doesn't change the voter set
 	time.Sleep(time.Millisecond * 100)
-	auths := handler.grandpa.Authorities()
-	require.Equal(t, 0, len(auths))
+	fg := handler.grandpa.(*mockFinalityGadget)
+	require.True(t, fg.paused)
+	require.Equal(t, 1, len(fg.Authorities()))
 
 	r := &types.Resume{
 		Delay: 3,
@@ -437,6 +504,6 @@ func TestDigestHandler_GrandpaPauseAndResume(t *testing.T) {
 
 	addTestBlocksToState(t, 3, handler.blockState)
 	time.Sleep(time.Millisecond * 110)
-	auths = handler.grandpa.Authorities()
-	require.Equal(t, 1, len(auths))
+	require.False(t, fg.paused)
+	require.Equal(t, 1, len(fg.Authorities()))
 }
@@ -51,6 +51,16 @@ var ErrNilFinalityGadget = errors.New("cannot have nil FinalityGadget")
 // ErrNilConsensusMessageHandler is returned when trying to instantiate a Service without a FinalityMessageHandler
 var ErrNilConsensusMessageHandler = errors.New("cannot have nil ErrNilFinalityMessageHandler")
 
+// ErrNilLightNetwork is returned when trying to instantiate a LightClient without a network
+var ErrNilLightNetwork = errors.New("cannot have nil LightNetwork")
+
+// ErrNoLightPeers is returned when a light client has no full peers that have the block it wants to read state from
+var ErrNoLightPeers = errors.New("no full peers have the block")
+
+// ErrInvalidExecutionProof is returned when a remote call reads storage that isn't in its proof, or its result
+// differs from the one returned by the peer
+var ErrInvalidExecutionProof = errors.New("invalid execution proof")
+
 // ErrNilChannel is returned if a channel is nil
 func ErrNilChannel(s string) error {
 	return fmt.Errorf("cannot have nil channel %s", s)
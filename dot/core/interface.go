@@ -26,6 +26,8 @@ import (
 	"github.com/ChainSafe/gossamer/lib/services"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/ChainSafe/gossamer/lib/trie"
+
+	"github.com/libp2p/go-libp2p-core/peer"
 )
 
 // BlockState interface for block state methods
@@ -38,6 +40,8 @@ type BlockState interface {
 	GetAllBlocksAtDepth(hash common.Hash) []common.Hash
 	AddBlockWithArrivalTime(*types.Block, uint64) error
 	GetBlockByHash(common.Hash) (*types.Block, error)
+	GetHeader(common.Hash) (*types.Header, error)
+	GetHeaderByNumber(*big.Int) (*types.Header, error)
 	GetArrivalTime(common.Hash) (uint64, error)
 	GenesisHash() common.Hash
 	GetSlotForBlock(common.Hash) (uint64, error)
@@ -46,6 +50,7 @@ type BlockState interface {
 	GetFinalizedHeader(uint64) (*types.Header, error)
 	GetFinalizedHash(uint64) (common.Hash, error)
 	SetFinalizedHash(common.Hash, uint64) error
+	IsDescendantOf(parent, child common.Hash) (bool, error)
 	RegisterImportedChannel(ch chan<- *types.Block) (byte, error)
 	UnregisterImportedChannel(id byte)
 	RegisterFinalizedChannel(ch chan<- *types.Header) (byte, error)
@@ -58,6 +63,7 @@ type StorageState interface {
 	SetStorage([]byte, []byte) error
 	GetStorage([]byte) ([]byte, error)
 	StoreInDB() error
+	TrieAt(root common.Hash) (*trie.Trie, error)
 	LoadCode() ([]byte, error)
 	LoadCodeHash() (common.Hash, error)
 	SetStorageChild([]byte, *trie.Trie) error
@@ -85,7 +91,18 @@ type FinalityGadget interface {
 	GetVoteInChannel() chan<- FinalityMessage
 	GetFinalizedChannel() <-chan FinalityMessage
 	UpdateAuthorities(ad []*types.GrandpaAuthorityData)
+	ScheduleAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int)
+	ForceAuthorityChange(ad []*types.GrandpaAuthorityData)
 	Authorities() []*types.GrandpaAuthorityData
+	Pause()
+	Resume()
+}
+
+// FinalityVerifier is implemented by the GRANDPA components that verify finality without voting, ie. the observer and
+// the justification verifiers. they're told about changes to the voter set by the DigestHandler.
+type FinalityVerifier interface {
+	// ApplyAuthorityChange changes the voter set to the given authorities, which finalize the blocks after atBlock
+	ApplyAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int)
 }
 
 // FinalityMessage is the interface a finality message must implement
@@ -105,3 +122,10 @@ type BlockProducer interface {
 	Authorities() []*types.BABEAuthorityData
 	SetAuthorities(a []*types.BABEAuthorityData)
 }
+
+// LightNetwork is the interface for the network methods light clients use to read state from full peers
+type LightNetwork interface {
+	Peers() []common.PeerInfo
+	RemoteRead(p peer.ID, block common.Hash, keys [][]byte) ([][]byte, error)
+	RemoteCall(p peer.ID, block common.Hash, method string, data []byte) ([]byte, [][]byte, error)
+}
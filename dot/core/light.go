@@ -0,0 +1,256 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// ErrNoRuntimeCode is returned when the state of a block doesn't contain the runtime code
+var ErrNoRuntimeCode = errors.New("state does not contain runtime code")
+
+// ReadProof returns the proof of the values of the storage keys in the state of the block with the given hash
+func (s *Service) ReadProof(block common.Hash, keys [][]byte) ([][]byte, error) {
+	t, err := s.trieAt(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GenerateProof(keys)
+}
+
+// ExecutionProof calls the runtime method with the data in the state of the block with the given hash, using a new
+// runtime instance so that the state isn't changed by the call. it returns the result of the call and the proof of
+// the storage it read, including the runtime code, so that a light client can make the call itself to check the
+// result.
+func (s *Service) ExecutionProof(block common.Hash, method string, data []byte) ([]byte, [][]byte, error) {
+	t, err := s.trieAt(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rec := newReadRecorder(t)
+	code, err := rec.get(common.CodeKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(code) == 0 {
+		return nil, nil, ErrNoRuntimeCode
+	}
+
+	result, err := callRuntime(code, newCallStorage(rec.get, rec.entries), method, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := t.GenerateProof(rec.keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, proof, nil
+}
+
+// Call calls the runtime method with the data in the state of the block with the given hash, or of our best block if
+// the hash is empty, using a new runtime instance so that the state isn't changed by the call
+func (s *Service) Call(block common.Hash, method string, data []byte) ([]byte, error) {
+	if block == (common.Hash{}) {
+		block = s.blockState.BestBlockHash()
+	}
+
+	t, err := s.trieAt(block)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := t.Get(common.CodeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(code) == 0 {
+		return nil, ErrNoRuntimeCode
+	}
+
+	return callRuntime(code, newCallStorage(t.Get, t.Entries), method, data)
+}
+
+// trieAt returns a copy of the storage trie of the block with the given hash
+func (s *Service) trieAt(block common.Hash) (*trie.Trie, error) {
+	header, err := s.blockState.GetHeader(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storageState.TrieAt(header.StateRoot)
+}
+
+// callRuntime calls the runtime method with a new instance of the runtime code that uses the given storage
+func callRuntime(code []byte, storage runtime.Storage, method string, data []byte) ([]byte, error) {
+	rt, err := runtime.NewRuntime(code, &runtime.Config{
+		Storage:  storage,
+		Keystore: keystore.NewKeystore(),
+		Imports:  runtime.RegisterImports_NodeRuntime,
+		LogLvl:   -1, // don't change runtime package log level
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rt.Stop()
+
+	result, err := rt.Exec(method, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// the result is in the instance's memory, which is freed when it's stopped
+	return append([]byte{}, result...), nil
+}
+
+// readRecorder reads the storage of a trie, recording the keys that are read so that their proof can be generated
+type readRecorder struct {
+	t    *trie.Trie
+	keys [][]byte
+	seen map[string]bool
+}
+
+func newReadRecorder(t *trie.Trie) *readRecorder {
+	return &readRecorder{
+		t:    t,
+		seen: make(map[string]bool),
+	}
+}
+
+func (r *readRecorder) record(key []byte) {
+	if !r.seen[string(key)] {
+		r.seen[string(key)] = true
+		r.keys = append(r.keys, key)
+	}
+}
+
+func (r *readRecorder) get(key []byte) ([]byte, error) {
+	r.record(key)
+	return r.t.Get(key)
+}
+
+func (r *readRecorder) entries() map[string][]byte {
+	entries := r.t.Entries()
+	for k := range entries {
+		r.record([]byte(k))
+	}
+	return entries
+}
+
+// callState is the state of calls that mustn't change the state they're made in. the call's writes are kept in an
+// overlay, while the keys it reads that aren't in the overlay are read with the given functions.
+type callState struct {
+	read     func(key []byte) ([]byte, error)
+	entries  func() map[string][]byte
+	changes  map[string][]byte // deleted keys have nil values
+	children map[string]*trie.Trie
+}
+
+// newCallStorage returns the runtime storage of a call, whose writes are kept in an overlay over the given state
+func newCallStorage(read func(key []byte) ([]byte, error), entries func() map[string][]byte) *runtime.TrieStorage {
+	return runtime.NewTrieStorage(&callState{
+		read:     read,
+		entries:  entries,
+		changes:  make(map[string][]byte),
+		children: make(map[string]*trie.Trie),
+	})
+}
+
+// Put sets the value of the key in the overlay
+func (s *callState) Put(key, value []byte) error {
+	s.changes[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Get returns the value of the key in the overlay, or in the state if it hasn't been changed
+func (s *callState) Get(key []byte) ([]byte, error) {
+	if value, has := s.changes[string(key)]; has {
+		return value, nil
+	}
+
+	return s.read(key)
+}
+
+// Delete deletes the key in the overlay
+func (s *callState) Delete(key []byte) error {
+	s.changes[string(key)] = nil
+	return nil
+}
+
+// Entries returns the key-value pairs of the state with the overlay applied
+func (s *callState) Entries() map[string][]byte {
+	entries := s.entries()
+	for k, v := range s.changes {
+		if v == nil {
+			delete(entries, k)
+			continue
+		}
+		entries[k] = v
+	}
+	return entries
+}
+
+// Hash returns the root of the state with the overlay applied
+func (s *callState) Hash() (common.Hash, error) {
+	t := trie.NewEmptyTrie()
+	for k, v := range s.Entries() {
+		err := t.Put([]byte(k), v)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	}
+
+	return t.Hash()
+}
+
+// PutChild sets the child trie at the key in the overlay
+func (s *callState) PutChild(keyToChild []byte, child *trie.Trie) error {
+	s.children[string(keyToChild)] = child
+	return nil
+}
+
+// PutIntoChild sets the value of the key in the child trie in the overlay
+func (s *callState) PutIntoChild(keyToChild, key, value []byte) error {
+	child, has := s.children[string(keyToChild)]
+	if !has {
+		child = trie.NewEmptyTrie()
+		s.children[string(keyToChild)] = child
+	}
+
+	return child.Put(key, value)
+}
+
+// GetFromChild returns the value of the key in the child trie in the overlay. child tries aren't stored with the
+// state, so only those set by the call can be read.
+func (s *callState) GetFromChild(keyToChild, key []byte) ([]byte, error) {
+	child, has := s.children[string(keyToChild)]
+	if !has {
+		return nil, nil
+	}
+
+	return child.Get(key)
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// LightClientConfig is the configuration of the LightClient
+type LightClientConfig struct {
+	LogLvl     log.Lvl
+	BlockState BlockState
+	Network    LightNetwork
+}
+
+// LightClient reads the state of the blocks whose headers we've synced from full peers. it checks the proofs the
+// peers send against the state roots of the headers, so the peers don't have to be trusted.
+type LightClient struct {
+	logger     log.Logger
+	blockState BlockState
+	network    LightNetwork
+}
+
+// NewLightClient returns a new LightClient
+func NewLightClient(cfg *LightClientConfig) (*LightClient, error) {
+	if cfg.BlockState == nil {
+		return nil, ErrNilBlockState
+	}
+
+	if cfg.Network == nil {
+		return nil, ErrNilLightNetwork
+	}
+
+	logger := log.New("pkg", "core")
+	h := log.StreamHandler(os.Stdout, log.TerminalFormat())
+	logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+
+	return &LightClient{
+		logger:     logger,
+		blockState: cfg.BlockState,
+		network:    cfg.Network,
+	}, nil
+}
+
+// GetStorage returns the value of the key in the state of the block with the given hash, or of our best block if the
+// hash is empty
+func (lc *LightClient) GetStorage(block common.Hash, key []byte) ([]byte, error) {
+	header, err := lc.header(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	err = lc.request(header, func(p peer.ID) error {
+		proof, err := lc.network.RemoteRead(p, header.Hash(), [][]byte{key})
+		if err != nil {
+			return err
+		}
+
+		value, err = trie.VerifyProof(header.StateRoot, key, proof)
+		return err
+	})
+
+	return value, err
+}
+
+// Call returns the result of calling the runtime method with the data in the state of the block with the given hash,
+// or of our best block if the hash is empty. the call is made again with the storage in the peer's proof, so that
+// its result can be checked.
+func (lc *LightClient) Call(block common.Hash, method string, data []byte) ([]byte, error) {
+	header, err := lc.header(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	err = lc.request(header, func(p peer.ID) error {
+		remote, proof, err := lc.network.RemoteCall(p, header.Hash(), method, data)
+		if err != nil {
+			return err
+		}
+
+		result, err = checkExecutionProof(header.StateRoot, method, data, proof)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(result, remote) {
+			return ErrInvalidExecutionProof
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (lc *LightClient) header(block common.Hash) (*types.Header, error) {
+	if block == (common.Hash{}) {
+		return lc.blockState.BestBlockHeader()
+	}
+
+	return lc.blockState.GetHeader(block)
+}
+
+// request sends the request to the full peers whose best block is at least the block's number, in order of their
+// best blocks, until one of them answers it with a valid proof
+func (lc *LightClient) request(header *types.Header, send func(p peer.ID) error) error {
+	candidates := []common.PeerInfo{}
+	for _, p := range lc.network.Peers() {
+		if !p.StoresFullBlocks() || p.BestNumber < header.Number.Uint64() {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].BestNumber > candidates[j].BestNumber
+	})
+
+	err := ErrNoLightPeers
+	for _, c := range candidates {
+		p, decodeErr := peer.IDB58Decode(c.PeerID)
+		if decodeErr != nil {
+			lc.logger.Debug("failed to decode peer ID", "peer", c.PeerID, "error", decodeErr)
+			continue
+		}
+
+		err = send(p)
+		if err == nil {
+			return nil
+		}
+
+		lc.logger.Debug("failed to read state from peer", "peer", p, "block", header.Hash(), "error", err)
+	}
+
+	return err
+}
+
+// checkExecutionProof makes the call with the storage in the proof, which is checked against the state root, and
+// returns its result. it fails if the call reads storage that isn't in the proof.
+func checkExecutionProof(root common.Hash, method string, data []byte, proof [][]byte) ([]byte, error) {
+	pr := &proofReader{
+		root:  root,
+		proof: proof,
+	}
+
+	code, err := pr.get(common.CodeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(code) == 0 {
+		return nil, ErrNoRuntimeCode
+	}
+
+	result, err := callRuntime(code, newCallStorage(pr.get, pr.entries), method, data)
+
+	// the runtime doesn't always fail when storage can't be read, so check if any reads failed first
+	if pr.err != nil {
+		return nil, pr.err
+	}
+
+	return result, err
+}
+
+// proofReader reads storage from a proof, recording the first read that fails
+type proofReader struct {
+	root  common.Hash
+	proof [][]byte
+	err   error
+}
+
+func (r *proofReader) get(key []byte) ([]byte, error) {
+	value, err := trie.VerifyProof(r.root, key, r.proof)
+	if err != nil {
+		err = fmt.Errorf("%w: cannot read 0x%x: %s", ErrInvalidExecutionProof, key, err)
+		if r.err == nil {
+			r.err = err
+		}
+	}
+	return value, err
+}
+
+// entries fails, since a proof only contains the storage on the paths to the keys that were read
+func (r *proofReader) entries() map[string][]byte {
+	if r.err == nil {
+		r.err = ErrInvalidExecutionProof
+	}
+	return make(map[string][]byte)
+}
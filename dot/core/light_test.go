@@ -0,0 +1,227 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// addTestBlockWithState adds a block on top of the best block whose state root is the current storage root
+func addTestBlockWithState(t *testing.T, s *Service) *types.Header {
+	root, err := s.storageState.StorageRoot()
+	require.Nil(t, err)
+
+	err = s.storageState.StoreInDB()
+	require.Nil(t, err)
+
+	parent, err := s.blockState.BestBlockHeader()
+	require.Nil(t, err)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(0).Add(parent.Number, big.NewInt(1)),
+		StateRoot:  root,
+		Digest:     [][]byte{},
+	}
+
+	err = s.blockState.AddBlock(&types.Block{
+		Header: header,
+		Body:   types.NewBody([]byte{}),
+	})
+	require.Nil(t, err)
+
+	return header
+}
+
+func TestReadProof(t *testing.T) {
+	s := NewTestService(t, nil)
+
+	err := s.storageState.SetStorage([]byte("noot"), []byte("washere"))
+	require.Nil(t, err)
+	err = s.storageState.SetStorage([]byte("other"), []byte("value"))
+	require.Nil(t, err)
+
+	header := addTestBlockWithState(t, s)
+
+	proof, err := s.ReadProof(header.Hash(), [][]byte{[]byte("noot"), []byte("missing")})
+	require.Nil(t, err)
+
+	value, err := trie.VerifyProof(header.StateRoot, []byte("noot"), proof)
+	require.Nil(t, err)
+	require.Equal(t, []byte("washere"), value)
+
+	value, err = trie.VerifyProof(header.StateRoot, []byte("missing"), proof)
+	require.Nil(t, err)
+	require.Nil(t, value)
+
+	_, err = s.ReadProof(common.Hash{0x1}, [][]byte{[]byte("noot")})
+	require.NotNil(t, err)
+}
+
+func TestExecutionProof(t *testing.T) {
+	s := NewTestService(t, nil)
+
+	fp, _, _ := runtime.GetRuntimeVars(runtime.NODE_RUNTIME)
+	code, err := ioutil.ReadFile(fp)
+	require.Nil(t, err)
+
+	err = s.storageState.SetStorage(common.CodeKey, code)
+	require.Nil(t, err)
+
+	header := addTestBlockWithState(t, s)
+
+	result, proof, err := s.ExecutionProof(header.Hash(), runtime.CoreVersion, []byte{})
+	require.Nil(t, err)
+	require.NotEmpty(t, result)
+
+	expected, err := s.rt.Exec(runtime.CoreVersion, []byte{})
+	require.Nil(t, err)
+	require.Equal(t, expected, result)
+
+	proved, err := trie.VerifyProof(header.StateRoot, common.CodeKey, proof)
+	require.Nil(t, err)
+	require.Equal(t, code, proved)
+}
+
+func TestExecutionProof_NoCode(t *testing.T) {
+	s := NewTestService(t, nil)
+	header := addTestBlockWithState(t, s)
+
+	_, _, err := s.ExecutionProof(header.Hash(), runtime.CoreVersion, []byte{})
+	require.Equal(t, ErrNoRuntimeCode, err)
+}
+
+// mockLightNetwork answers light client requests from the full node's state
+type mockLightNetwork struct {
+	server *Service
+	peers  []common.PeerInfo
+	result []byte // if set, returned as the result of calls instead of the server's result
+}
+
+func (n *mockLightNetwork) Peers() []common.PeerInfo {
+	return n.peers
+}
+
+func (n *mockLightNetwork) RemoteRead(_ peer.ID, block common.Hash, keys [][]byte) ([][]byte, error) {
+	return n.server.ReadProof(block, keys)
+}
+
+func (n *mockLightNetwork) RemoteCall(_ peer.ID, block common.Hash, method string, data []byte) ([]byte, [][]byte, error) {
+	result, proof, err := n.server.ExecutionProof(block, method, data)
+	if n.result != nil {
+		result = n.result
+	}
+	return result, proof, err
+}
+
+func newTestLightClient(t *testing.T, server *Service, best uint64) (*LightClient, *mockLightNetwork) {
+	net := &mockLightNetwork{
+		server: server,
+		peers: []common.PeerInfo{{
+			PeerID:     "QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN",
+			Roles:      common.FullNodeRole,
+			BestNumber: best,
+		}},
+	}
+
+	lc, err := NewLightClient(&LightClientConfig{
+		BlockState: server.blockState,
+		Network:    net,
+	})
+	require.Nil(t, err)
+
+	return lc, net
+}
+
+func TestLightClient_GetStorage(t *testing.T) {
+	s := NewTestService(t, nil)
+
+	err := s.storageState.SetStorage([]byte("noot"), []byte("washere"))
+	require.Nil(t, err)
+
+	header := addTestBlockWithState(t, s)
+	lc, net := newTestLightClient(t, s, 1)
+
+	value, err := lc.GetStorage(header.Hash(), []byte("noot"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("washere"), value)
+
+	// the best block is used if no block is given
+	value, err = lc.GetStorage(common.Hash{}, []byte("noot"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("washere"), value)
+
+	// peers that don't have the block aren't asked for its state
+	net.peers[0].BestNumber = 0
+	_, err = lc.GetStorage(header.Hash(), []byte("noot"))
+	require.Equal(t, ErrNoLightPeers, err)
+}
+
+func TestLightClient_Call(t *testing.T) {
+	s := NewTestService(t, nil)
+
+	fp, _, _ := runtime.GetRuntimeVars(runtime.NODE_RUNTIME)
+	code, err := ioutil.ReadFile(fp)
+	require.Nil(t, err)
+
+	err = s.storageState.SetStorage(common.CodeKey, code)
+	require.Nil(t, err)
+
+	header := addTestBlockWithState(t, s)
+	lc, net := newTestLightClient(t, s, 1)
+
+	expected, err := s.rt.Exec(runtime.CoreVersion, []byte{})
+	require.Nil(t, err)
+
+	result, err := lc.Call(header.Hash(), runtime.CoreVersion, []byte{})
+	require.Nil(t, err)
+	require.Equal(t, expected, result)
+
+	// results that differ from the result of making the call with the proof are rejected
+	net.result = []byte("invalid")
+	_, err = lc.Call(header.Hash(), runtime.CoreVersion, []byte{})
+	require.Equal(t, ErrInvalidExecutionProof, err)
+}
+
+func TestCheckExecutionProof_IncompleteProof(t *testing.T) {
+	tr := trie.NewEmptyTrie()
+	err := tr.Put([]byte("noot"), []byte("washere"))
+	require.Nil(t, err)
+
+	root, err := tr.Hash()
+	require.Nil(t, err)
+
+	proof, err := tr.GenerateProof([][]byte{[]byte("noot")})
+	require.Nil(t, err)
+
+	_, err = checkExecutionProof(common.Hash{0x1}, runtime.CoreVersion, []byte{}, proof)
+	require.True(t, errors.Is(err, ErrInvalidExecutionProof))
+
+	_, err = checkExecutionProof(root, runtime.CoreVersion, []byte{}, proof)
+	require.Equal(t, ErrNoRuntimeCode, err)
+}
@@ -34,6 +34,7 @@ import (
 )
 
 var _ services.Service = &Service{}
+var _ network.TransactionHandler = &Service{}
 
 // Service is an overhead layer that allows communication between the runtime,
 // BABE session, and network service. It deals with the validation of transactions
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime"
@@ -137,7 +138,7 @@ func TestCheckForRuntimeChanges(t *testing.T) {
 	testRuntime, err := ioutil.ReadFile(runtime.TESTS_FP)
 	require.Nil(t, err)
 
-	err = s.storageState.SetStorage([]byte(":code"), testRuntime)
+	err = s.storageState.SetStorage(common.CodeKey, testRuntime)
 	require.Nil(t, err)
 
 	err = s.checkForRuntimeChanges()
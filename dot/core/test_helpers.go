@@ -82,6 +82,8 @@ type mockFinalityGadget struct {
 	out       chan FinalityMessage
 	finalized chan FinalityMessage
 	auths     []*types.GrandpaAuthorityData
+	scheduled *big.Int // block number of the scheduled authority change, if any
+	paused    bool
 }
 
 // Start mocks starting
@@ -118,10 +120,47 @@ func (fg *mockFinalityGadget) UpdateAuthorities(ad []*types.GrandpaAuthorityData
 	fg.auths = ad
 }
 
+func (fg *mockFinalityGadget) ScheduleAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	fg.auths = ad
+	fg.scheduled = atBlock
+}
+
+func (fg *mockFinalityGadget) ForceAuthorityChange(ad []*types.GrandpaAuthorityData) {
+	fg.auths = ad
+}
+
 func (fg *mockFinalityGadget) Authorities() []*types.GrandpaAuthorityData {
 	return fg.auths
 }
 
+func (fg *mockFinalityGadget) Pause() {
+	fg.paused = true
+}
+
+func (fg *mockFinalityGadget) Resume() {
+	fg.paused = false
+}
+
+// authorityChange is a change to the voter set applied to a mockFinalityVerifier
+type authorityChange struct {
+	auths   []*types.GrandpaAuthorityData
+	atBlock *big.Int
+}
+
+type mockFinalityVerifier struct {
+	changes chan *authorityChange
+}
+
+func newMockFinalityVerifier() *mockFinalityVerifier {
+	return &mockFinalityVerifier{
+		changes: make(chan *authorityChange, 4),
+	}
+}
+
+func (v *mockFinalityVerifier) ApplyAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	v.changes <- &authorityChange{auths: ad, atBlock: atBlock}
+}
+
 var testConsensusMessage = &network.ConsensusMessage{
 	ConsensusEngineID: types.GrandpaEngineID,
 	Data:              []byte("nootwashere"),
@@ -0,0 +1,184 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DataDog/zstd"
+	"github.com/golang/snappy"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// compression algorithms that block responses can be compressed with. the algorithms a node supports are advertised
+// as a bitfield in the first byte of the chain status of its status handshake, so nodes that don't support
+// compression, which send a zero byte, keep receiving uncompressed responses.
+const (
+	compressionNone   byte = 0
+	compressionSnappy byte = 1 << 0
+	compressionZstd   byte = 1 << 1
+)
+
+// supportedCompression is the bitfield of the compression algorithms we support
+const supportedCompression = compressionSnappy | compressionZstd
+
+// compressionSuffixes are appended to the sync protocol ID to request responses compressed with each algorithm, so
+// that the responder knows which algorithm to use from the substream it receives the request on
+var compressionSuffixes = map[byte]protocol.ID{
+	compressionSnappy: "/snappy",
+	compressionZstd:   "/zstd",
+}
+
+// errDecompressedTooLarge is returned when a compressed message decompresses to more than its protocol allows
+var errDecompressedTooLarge = errors.New("decompressed message too large")
+
+// negotiateCompression returns our preferred compression algorithm out of those supported by both us and the peer
+func negotiateCompression(ours, theirs byte) byte {
+	both := ours & theirs
+	switch {
+	case both&compressionZstd != 0:
+		return compressionZstd
+	case both&compressionSnappy != 0:
+		return compressionSnappy
+	default:
+		return compressionNone
+	}
+}
+
+// chainStatusCompression returns the compression algorithms advertised in a peer's status message
+func chainStatusCompression(msg *StatusMessage) byte {
+	if len(msg.ChainStatus) == 0 {
+		return compressionNone
+	}
+	return msg.ChainStatus[0] & supportedCompression
+}
+
+// compressedProtocol returns the sub-protocol used to request messages compressed with the given algorithm
+func compressedProtocol(sub protocol.ID, c byte) protocol.ID {
+	return sub + compressionSuffixes[c]
+}
+
+// protocolCompression returns the compression algorithm requested by the protocol of a substream
+func protocolCompression(pid protocol.ID) byte {
+	for c, suffix := range compressionSuffixes {
+		if strings.HasSuffix(string(pid), string(suffix)) {
+			return c
+		}
+	}
+	return compressionNone
+}
+
+// compressedSizeBound returns the maximum size of a compressed message whose uncompressed size is at most maxSize.
+// incompressible data grows slightly when compressed, and snappy's worst case is larger than zstd's.
+func compressedSizeBound(maxSize uint64) uint64 {
+	return maxSize + maxSize/6 + 32
+}
+
+// compress compresses the encoded message using the given algorithm
+func compress(c byte, in []byte) ([]byte, error) {
+	switch c {
+	case compressionNone:
+		return in, nil
+	case compressionSnappy:
+		return snappy.Encode(nil, in), nil
+	case compressionZstd:
+		return zstd.Compress(nil, in)
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %d", c)
+	}
+}
+
+// decompress decompresses the message using the given algorithm, failing if it decompresses to more than maxSize
+// bytes. the decompressed size is checked before it's allocated, so that small messages can't exhaust our memory.
+func decompress(c byte, in []byte, maxSize uint64) ([]byte, error) {
+	switch c {
+	case compressionNone:
+		return in, nil
+	case compressionSnappy:
+		length, err := snappy.DecodedLen(in)
+		if err != nil {
+			return nil, err
+		}
+
+		if uint64(length) > maxSize {
+			return nil, fmt.Errorf("%w: length %d, max %d", errDecompressedTooLarge, length, maxSize)
+		}
+
+		return snappy.Decode(nil, in)
+	case compressionZstd:
+		r := zstd.NewReader(bytes.NewReader(in))
+		defer r.Close() //nolint
+
+		out, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+		if err != nil {
+			return nil, err
+		}
+
+		if uint64(len(out)) > maxSize {
+			return nil, fmt.Errorf("%w: max %d", errDecompressedTooLarge, maxSize)
+		}
+
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %d", c)
+	}
+}
+
+// CompressionStats is the number of bytes of compressed messages we've sent and received, before and after they
+// were compressed
+type CompressionStats struct {
+	RawBytesSent            uint64
+	CompressedBytesSent     uint64
+	RawBytesReceived        uint64
+	CompressedBytesReceived uint64
+}
+
+// compressionStats counts the bytes of the compressed messages we send and receive
+type compressionStats struct {
+	rawSent            uint64
+	compressedSent     uint64
+	rawReceived        uint64
+	compressedReceived uint64
+}
+
+// sent records that we sent a message of raw bytes, compressed to compressed bytes
+func (cs *compressionStats) sent(raw, compressed int) {
+	atomic.AddUint64(&cs.rawSent, uint64(raw))
+	atomic.AddUint64(&cs.compressedSent, uint64(compressed))
+}
+
+// received records that we received a message of compressed bytes, that decompressed to raw bytes
+func (cs *compressionStats) received(raw, compressed int) {
+	atomic.AddUint64(&cs.rawReceived, uint64(raw))
+	atomic.AddUint64(&cs.compressedReceived, uint64(compressed))
+}
+
+// snapshot returns the current stats
+func (cs *compressionStats) snapshot() CompressionStats {
+	return CompressionStats{
+		RawBytesSent:            atomic.LoadUint64(&cs.rawSent),
+		CompressedBytesSent:     atomic.LoadUint64(&cs.compressedSent),
+		RawBytesReceived:        atomic.LoadUint64(&cs.rawReceived),
+		CompressedBytesReceived: atomic.LoadUint64(&cs.compressedReceived),
+	}
+}
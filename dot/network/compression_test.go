@@ -0,0 +1,123 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/libp2p/go-libp2p-core/protocol"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	require.Equal(t, compressionZstd, negotiateCompression(supportedCompression, supportedCompression))
+	require.Equal(t, compressionSnappy, negotiateCompression(supportedCompression, compressionSnappy))
+	require.Equal(t, compressionNone, negotiateCompression(supportedCompression, compressionNone))
+	require.Equal(t, compressionNone, negotiateCompression(compressionNone, supportedCompression))
+
+	// peers that don't support compression send a zero byte or no chain status at all
+	require.Equal(t, compressionNone, chainStatusCompression(&StatusMessage{ChainStatus: []byte{0}}))
+	require.Equal(t, compressionNone, chainStatusCompression(&StatusMessage{}))
+	require.Equal(t, compressionSnappy, chainStatusCompression(&StatusMessage{ChainStatus: []byte{0x81}}))
+}
+
+func TestCompressedProtocol(t *testing.T) {
+	for _, c := range []byte{compressionNone, compressionSnappy, compressionZstd} {
+		pid := protocol.ID(TestProtocolID) + compressedProtocol(syncID, c)
+		require.Equal(t, c, protocolCompression(pid))
+	}
+}
+
+func TestCompress(t *testing.T) {
+	raw := bytes.Repeat([]byte{1, 2, 3, 4}, 1024)
+
+	for _, c := range []byte{compressionNone, compressionSnappy, compressionZstd} {
+		enc, err := compress(c, raw)
+		require.NoError(t, err)
+		if c != compressionNone {
+			require.Less(t, len(enc), len(raw))
+		}
+
+		dec, err := decompress(c, enc, uint64(len(raw)))
+		require.NoError(t, err)
+		require.Equal(t, raw, dec)
+
+		// messages that decompress to more than the limit are rejected
+		if c != compressionNone {
+			_, err = decompress(c, enc, uint64(len(raw))-1)
+			require.True(t, errors.Is(err, errDecompressedTooLarge))
+		}
+
+		// incompressible data fits within the compressed size bound
+		random := make([]byte, 1<<16)
+		rand.New(rand.NewSource(1)).Read(random)
+		enc, err = compress(c, random)
+		require.NoError(t, err)
+		require.LessOrEqual(t, uint64(len(enc)), compressedSizeBound(1<<16))
+	}
+
+	_, err := decompress(compressionSnappy, []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 1}, 1<<20)
+	require.Error(t, err)
+	_, err = decompress(compressionZstd, bytes.Repeat([]byte{1, 2, 3, 4}, 16), 1<<20)
+	require.Error(t, err)
+}
+
+func TestBlockResponseCompression(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{}, &Config{NoCompression: true})
+	nodeA, nodeB, nodeC := nodes[0], nodes[1], nodes[2]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	connectTestPeer(t, nodeC, nodeA)
+
+	// the status handshakes advertise the compression algorithms each node supports
+	time.Sleep(TestMessageTimeout)
+	require.Equal(t, compressionZstd, nodeA.peerCompression(nodeB.host.id()))
+	require.Equal(t, compressionZstd, nodeB.peerCompression(nodeA.host.id()))
+	require.True(t, nodeA.status.confirmed(nodeC.host.id()))
+	require.True(t, nodeC.status.confirmed(nodeA.host.id()))
+	require.Equal(t, compressionNone, nodeA.peerCompression(nodeC.host.id()))
+	require.Equal(t, compressionNone, nodeC.peerCompression(nodeA.host.id()))
+
+	// node B compresses its response to node A's block request
+	nodeA.sendBlockRequest(nodeB.host.id(), TestBlockRequest)
+	time.Sleep(TestMessageTimeout)
+	require.False(t, nodeA.requestTracker.hasRequestedBlockID(TestBlockRequest.ID))
+
+	statsA, statsB := nodeA.CompressionStats(), nodeB.CompressionStats()
+	require.NotZero(t, statsA.RawBytesReceived)
+	require.Equal(t, statsA.RawBytesReceived, statsB.RawBytesSent)
+	require.Equal(t, statsA.CompressedBytesReceived, statsB.CompressedBytesSent)
+
+	// node C doesn't support compression, so node A's response is uncompressed
+	nodeC.sendBlockRequest(nodeA.host.id(), TestBlockRequest)
+	time.Sleep(TestMessageTimeout)
+	require.False(t, nodeC.requestTracker.hasRequestedBlockID(TestBlockRequest.ID))
+
+	require.Equal(t, CompressionStats{}, nodeC.CompressionStats())
+	require.Zero(t, nodeA.CompressionStats().RawBytesSent)
+}
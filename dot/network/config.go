@@ -47,6 +47,15 @@ const DefaultProtocolVersion = 0
 // DefaultRoles the default value for Config.Roles (0 = no network, 1 = full node)
 const DefaultRoles = byte(1)
 
+// DefaultMinPeers the default value for Config.MinPeers
+const DefaultMinPeers = 5
+
+// DefaultMaxPeers the default value for Config.MaxPeers
+const DefaultMaxPeers = 50
+
+// DefaultMaxInbound the default value for Config.MaxInbound
+const DefaultMaxInbound = 25
+
 // DefaultBootnodes the default value for Config.Bootnodes
 var DefaultBootnodes = []string(nil)
 
@@ -67,6 +76,16 @@ type Config struct {
 
 	Syncer Syncer
 
+	// GossipValidator the validator for consensus messages (optional; if nil, all consensus messages are gossiped)
+	GossipValidator GossipValidator
+	// TransactionHandler the handler for transactions received from peers (optional; if nil, transaction messages
+	// are sent to the core service on MsgSend)
+	TransactionHandler TransactionHandler
+	// FinalityProofProvider the provider of finality proofs for peers (optional; if nil, finality proof requests are ignored)
+	FinalityProofProvider FinalityProofProvider
+	// LightServer answers the remote requests of light clients (optional; if nil, we can't prove anything to them)
+	LightServer LightServer
+
 	// Port the network port used for listening
 	Port uint32
 	// RandSeed the seed used to generate the network p2p identity (0 = non-deterministic random seed)
@@ -85,6 +104,18 @@ type Config struct {
 	NoMDNS bool
 	// NoStatus disables the status message exchange protocol
 	NoStatus bool
+	// MinPeers the number of peers below which we try to connect to more peers
+	MinPeers int
+	// MaxPeers the maximum number of peers, above which our connections to the least valuable peers are trimmed
+	MaxPeers int
+	// MaxInbound the maximum number of peers that connected to us, above which inbound connections are rejected
+	MaxInbound int
+	// ReservedNodes the addresses of the peers we always keep connections to
+	ReservedNodes []string
+	// ReservedOnly only connects to reserved peers, rejecting all other connections
+	ReservedOnly bool
+	// NoCompression disables the compression of the block responses we send and request
+	NoCompression bool
 
 	// MsgRec is the message channel from the core service to the network service
 	MsgRec <-chan Message
@@ -117,6 +148,12 @@ func (c *Config) build() error {
 		c.Port = DefaultPort
 	}
 
+	// check peer slot configuration
+	err = c.buildPeerLimits()
+	if err != nil {
+		return err
+	}
+
 	// build identity configuration
 	err = c.buildIdentity()
 	if err != nil {
@@ -150,6 +187,41 @@ func (c *Config) checkState() (err error) {
 	return err
 }
 
+// buildPeerLimits applies the default peer slot limits where they aren't set, and checks that they're consistent
+func (c *Config) buildPeerLimits() error {
+	if c.MaxPeers == 0 {
+		c.MaxPeers = DefaultMaxPeers
+	}
+
+	if c.MinPeers == 0 {
+		c.MinPeers = DefaultMinPeers
+		if c.MinPeers > c.MaxPeers {
+			c.MinPeers = c.MaxPeers
+		}
+	}
+
+	if c.MaxInbound == 0 {
+		c.MaxInbound = DefaultMaxInbound
+		if c.MaxInbound > c.MaxPeers {
+			c.MaxInbound = c.MaxPeers
+		}
+	}
+
+	if c.MinPeers < 0 || c.MaxPeers < 0 || c.MaxInbound < 0 {
+		return errors.New("failed to build configuration: peer limits cannot be negative")
+	}
+
+	if c.MinPeers > c.MaxPeers {
+		return errors.New("failed to build configuration: MinPeers cannot be greater than MaxPeers")
+	}
+
+	if c.MaxInbound > c.MaxPeers {
+		return errors.New("failed to build configuration: MaxInbound cannot be greater than MaxPeers")
+	}
+
+	return nil
+}
+
 // buildIdentity attempts to load the private key required to start the network
 // service, if a key does not exist or cannot be loaded, it creates a new key
 // using the random seed (if random seed is not set, creates new random key)
@@ -114,4 +114,27 @@ func TestBuild(t *testing.T) {
 	require.Equal(t, DefaultProtocolID, cfg.ProtocolID)
 	require.Equal(t, false, cfg.NoBootstrap)
 	require.Equal(t, false, cfg.NoMDNS)
+	require.Equal(t, DefaultMinPeers, cfg.MinPeers)
+	require.Equal(t, DefaultMaxPeers, cfg.MaxPeers)
+	require.Equal(t, DefaultMaxInbound, cfg.MaxInbound)
+}
+
+func TestBuildPeerLimits(t *testing.T) {
+	cfg := &Config{MaxPeers: 2}
+	err := cfg.buildPeerLimits()
+	require.NoError(t, err)
+	require.Equal(t, 2, cfg.MinPeers)
+	require.Equal(t, 2, cfg.MaxInbound)
+
+	cfg = &Config{MinPeers: 3, MaxPeers: 2}
+	err = cfg.buildPeerLimits()
+	require.Error(t, err)
+
+	cfg = &Config{MaxPeers: 2, MaxInbound: 3}
+	err = cfg.buildPeerLimits()
+	require.Error(t, err)
+
+	cfg = &Config{MinPeers: -1}
+	err = cfg.buildPeerLimits()
+	require.Error(t, err)
 }
@@ -18,6 +18,9 @@ package network
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/connmgr"
 	"github.com/libp2p/go-libp2p-core/network"
@@ -26,8 +29,52 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 )
 
-// ConnManager implements connmgr.ConnManager
-type ConnManager struct{}
+var _ connmgr.ConnManager = &ConnManager{}
+
+// peerConnInfo is what the connection manager knows about a peer
+type peerConnInfo struct {
+	firstSeen  time.Time
+	lastActive time.Time // the last time a stream was opened or a message received
+	tags       map[string]int
+}
+
+// value returns the sum of the peer's tag values
+func (info *peerConnInfo) value() int {
+	v := 0
+	for _, tv := range info.tags {
+		v += tv
+	}
+	return v
+}
+
+// ConnManager implements connmgr.ConnManager. it limits the number of peers we are connected to, rejecting inbound
+// connections once we have maxInbound inbound peers, and trimming our connections once we have more than maxPeers
+// peers. when trimming, the peers with the lowest reputations are disconnected first, then the peers that have been
+// idle the longest. protected peers (eg. bootnodes) are never trimmed. in reserved-only mode, all connections to
+// peers that aren't reserved are closed.
+type ConnManager struct {
+	sync.Mutex
+	maxPeers     int
+	maxInbound   int
+	reservedOnly bool
+	reputations  *reputations
+
+	network   network.Network
+	peers     map[peer.ID]*peerConnInfo
+	protected map[peer.ID]map[string]struct{}
+}
+
+// newConnManager creates a new connection manager with the given peer slot limits
+func newConnManager(maxPeers, maxInbound int, reservedOnly bool, reputations *reputations) *ConnManager {
+	return &ConnManager{
+		maxPeers:     maxPeers,
+		maxInbound:   maxInbound,
+		reservedOnly: reservedOnly,
+		reputations:  reputations,
+		peers:        make(map[peer.ID]*peerConnInfo),
+		protected:    make(map[peer.ID]map[string]struct{}),
+	}
+}
 
 // Notifee is used to monitor changes to a connection
 func (cm *ConnManager) Notifee() network.Notifiee {
@@ -43,30 +90,224 @@ func (cm *ConnManager) Notifee() network.Notifiee {
 	return nb
 }
 
-// TagPeer peer
-func (*ConnManager) TagPeer(peer.ID, string, int) {}
+// getPeer returns the peer's info, creating it if the peer isn't known yet. the lock must be held.
+func (cm *ConnManager) getPeer(p peer.ID) *peerConnInfo {
+	info, has := cm.peers[p]
+	if !has {
+		now := time.Now()
+		info = &peerConnInfo{
+			firstSeen:  now,
+			lastActive: now,
+			tags:       make(map[string]int),
+		}
+		cm.peers[p] = info
+	}
+
+	return info
+}
+
+// TagPeer tags a peer with a string, associating a weight with the tag
+func (cm *ConnManager) TagPeer(p peer.ID, tag string, val int) {
+	cm.Lock()
+	defer cm.Unlock()
+	cm.getPeer(p).tags[tag] = val
+}
+
+// UntagPeer removes the tagged value from the peer
+func (cm *ConnManager) UntagPeer(p peer.ID, tag string) {
+	cm.Lock()
+	defer cm.Unlock()
+
+	if info, has := cm.peers[p]; has {
+		delete(info.tags, tag)
+	}
+}
+
+// UpsertTag updates an existing tag or inserts a new one
+func (cm *ConnManager) UpsertTag(p peer.ID, tag string, upsert func(int) int) {
+	cm.Lock()
+	defer cm.Unlock()
+
+	info := cm.getPeer(p)
+	info.tags[tag] = upsert(info.tags[tag])
+}
+
+// GetTagInfo returns the metadata associated with the peer, or nil if no metadata has been recorded for the peer
+func (cm *ConnManager) GetTagInfo(p peer.ID) *connmgr.TagInfo {
+	cm.Lock()
+	defer cm.Unlock()
+
+	info, has := cm.peers[p]
+	if !has {
+		return nil
+	}
+
+	ti := &connmgr.TagInfo{
+		FirstSeen: info.firstSeen,
+		Value:     info.value(),
+		Tags:      make(map[string]int),
+		Conns:     make(map[string]time.Time),
+	}
+
+	for tag, val := range info.tags {
+		ti.Tags[tag] = val
+	}
+
+	return ti
+}
+
+// TrimOpenConns disconnects from peers until we have at most maxPeers peers
+func (cm *ConnManager) TrimOpenConns(ctx context.Context) {
+	cm.Lock()
+	n := cm.network
+	cm.Unlock()
+
+	if n != nil {
+		cm.trim(n)
+	}
+}
+
+// Protect protects a peer from having its connection(s) pruned
+func (cm *ConnManager) Protect(p peer.ID, tag string) {
+	cm.Lock()
+	defer cm.Unlock()
+
+	tags, has := cm.protected[p]
+	if !has {
+		tags = make(map[string]struct{})
+		cm.protected[p] = tags
+	}
+
+	tags[tag] = struct{}{}
+}
+
+// Unprotect removes a protection that may have been placed on a peer, under the specified tag. it returns whether
+// the peer is still protected by a different tag.
+func (cm *ConnManager) Unprotect(p peer.ID, tag string) bool {
+	cm.Lock()
+	defer cm.Unlock()
 
-// UntagPeer peer
-func (*ConnManager) UntagPeer(peer.ID, string) {}
+	tags, has := cm.protected[p]
+	if !has {
+		return false
+	}
 
-// UpsertTag peer
-func (*ConnManager) UpsertTag(peer.ID, string, func(int) int) {}
+	delete(tags, tag)
+	if len(tags) == 0 {
+		delete(cm.protected, p)
+		return false
+	}
+
+	return true
+}
 
-// GetTagInfo peer
-func (*ConnManager) GetTagInfo(peer.ID) *connmgr.TagInfo { return &connmgr.TagInfo{} }
+// isProtected returns true if the peer is protected from having its connections pruned
+func (cm *ConnManager) isProtected(p peer.ID) bool {
+	cm.Lock()
+	defer cm.Unlock()
 
-// TrimOpenConns peer
-func (*ConnManager) TrimOpenConns(ctx context.Context) {}
+	_, has := cm.protected[p]
+	return has
+}
 
-// Protect peer
-func (*ConnManager) Protect(peer.ID, string) {}
+// isReserved returns true if the peer is one of our reserved peers
+func (cm *ConnManager) isReserved(p peer.ID) bool {
+	cm.Lock()
+	defer cm.Unlock()
 
-// Unprotect peer
-func (*ConnManager) Unprotect(peer.ID, string) bool { return false }
+	_, has := cm.protected[p][reservedTag]
+	return has
+}
 
 // Close peer
 func (*ConnManager) Close() error { return nil }
 
+// markActive records that we've received a message from the peer, so that it isn't trimmed as an idle peer
+func (cm *ConnManager) markActive(p peer.ID) {
+	cm.Lock()
+	defer cm.Unlock()
+
+	if info, has := cm.peers[p]; has {
+		info.lastActive = time.Now()
+	}
+}
+
+// inboundCount returns the number of peers that we only have inbound connections to
+func inboundCount(n network.Network) int {
+	count := 0
+	for _, p := range n.Peers() {
+		inbound := true
+		for _, c := range n.ConnsToPeer(p) {
+			if c.Stat().Direction != network.DirInbound {
+				inbound = false
+				break
+			}
+		}
+
+		if inbound {
+			count++
+		}
+	}
+
+	return count
+}
+
+// trim disconnects from the least valuable unprotected peers until we have at most maxPeers peers. peers are
+// ordered by reputation and tag value, then by how long they've been idle.
+func (cm *ConnManager) trim(n network.Network) {
+	peers := n.Peers()
+	excess := len(peers) - cm.maxPeers
+	if excess <= 0 {
+		return
+	}
+
+	type candidate struct {
+		id         peer.ID
+		score      int64
+		lastActive time.Time
+	}
+
+	candidates := []*candidate{}
+	cm.Lock()
+	for _, p := range peers {
+		if _, has := cm.protected[p]; has {
+			continue
+		}
+
+		c := &candidate{
+			id:    p,
+			score: int64(cm.reputations.value(p)),
+		}
+
+		if info, has := cm.peers[p]; has {
+			c.score += int64(info.value())
+			c.lastActive = info.lastActive
+		}
+
+		candidates = append(candidates, c)
+	}
+	cm.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].lastActive.Before(candidates[j].lastActive)
+	})
+
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+
+	for _, c := range candidates[:excess] {
+		logger.Debug("[network] Trimming connection to peer", "peer", c.id, "score", c.score)
+		err := n.ClosePeer(c.id)
+		if err != nil {
+			logger.Debug("[network] Failed to trim connection to peer", "peer", c.id, "error", err)
+		}
+	}
+}
+
 // Listen is called when network starts listening on an address
 func (cm *ConnManager) Listen(n network.Network, addr ma.Multiaddr) {
 	logger.Trace(
@@ -85,13 +326,42 @@ func (cm *ConnManager) ListenClose(n network.Network, addr ma.Multiaddr) {
 	)
 }
 
-// Connected is called when a connection opened
+// Connected is called when a connection opened. if we have too many inbound peers, the connection is closed if it's
+// inbound, and if we have too many peers, our connections are trimmed.
 func (cm *ConnManager) Connected(n network.Network, c network.Conn) {
 	logger.Trace(
 		"[network] Connected to peer",
 		"host", c.LocalPeer(),
 		"peer", c.RemotePeer(),
 	)
+
+	cm.Lock()
+	cm.network = n
+	cm.getPeer(c.RemotePeer())
+	cm.Unlock()
+
+	if cm.reservedOnly && !cm.isReserved(c.RemotePeer()) {
+		logger.Debug("[network] Rejecting connection to peer that isn't reserved", "peer", c.RemotePeer())
+		go func() {
+			_ = c.Close()
+		}()
+		return
+	}
+
+	if cm.isProtected(c.RemotePeer()) {
+		return
+	}
+
+	if c.Stat().Direction == network.DirInbound && inboundCount(n) > cm.maxInbound {
+		logger.Debug("[network] Rejecting inbound connection, too many inbound peers", "peer", c.RemotePeer())
+		// closing the connection from within the notification would block the swarm
+		go func() {
+			_ = c.Close()
+		}()
+		return
+	}
+
+	go cm.trim(n)
 }
 
 // Disconnected is called when a connection closed
@@ -101,6 +371,14 @@ func (cm *ConnManager) Disconnected(n network.Network, c network.Conn) {
 		"host", c.LocalPeer(),
 		"peer", c.RemotePeer(),
 	)
+
+	if n.Connectedness(c.RemotePeer()) == network.Connected {
+		return
+	}
+
+	cm.Lock()
+	defer cm.Unlock()
+	delete(cm.peers, c.RemotePeer())
 }
 
 // OpenedStream is called when a stream opened
@@ -111,6 +389,8 @@ func (cm *ConnManager) OpenedStream(n network.Network, s network.Stream) {
 		"peer", s.Conn().RemotePeer(),
 		"protocol", s.Protocol(),
 	)
+
+	cm.markActive(s.Conn().RemotePeer())
 }
 
 // ClosedStream is called when a stream closed
@@ -0,0 +1,144 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestPeers creates and starts a network service for each of the given configs, on consecutive ports
+func createTestPeers(t *testing.T, configs ...*Config) []*Service {
+	nodes := []*Service{}
+	for i, cfg := range configs {
+		cfg.BasePath = utils.NewTestBasePath(t, "node"+string(rune('A'+i)))
+		cfg.Port = uint32(7001 + i)
+		cfg.RandSeed = int64(1 + i)
+		cfg.NoBootstrap = true
+		cfg.NoMDNS = true
+		nodes = append(nodes, createTestService(t, cfg))
+	}
+	return nodes
+}
+
+// connectTestPeer connects node a to node b
+func connectTestPeer(t *testing.T, a, b *Service) {
+	addrInfos, err := b.host.addrInfos()
+	require.NoError(t, err)
+
+	err = a.host.connect(*addrInfos[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = a.host.connect(*addrInfos[0])
+	}
+	require.NoError(t, err)
+}
+
+func TestConnManager_Protect(t *testing.T) {
+	cm := newConnManager(1, 1, false, newReputations())
+	p := peer.ID("noot")
+
+	cm.Protect(p, "a")
+	cm.Protect(p, "b")
+	require.True(t, cm.isProtected(p))
+	require.True(t, cm.Unprotect(p, "a"))
+	require.False(t, cm.Unprotect(p, "b"))
+	require.False(t, cm.isProtected(p))
+}
+
+func TestConnManager_Tags(t *testing.T) {
+	cm := newConnManager(1, 1, false, newReputations())
+	p := peer.ID("noot")
+
+	require.Nil(t, cm.GetTagInfo(p))
+
+	cm.TagPeer(p, "a", 5)
+	cm.UpsertTag(p, "b", func(v int) int { return v + 3 })
+	cm.UpsertTag(p, "b", func(v int) int { return v + 3 })
+
+	info := cm.GetTagInfo(p)
+	require.Equal(t, 11, info.Value)
+	require.Equal(t, map[string]int{"a": 5, "b": 6}, info.Tags)
+
+	cm.UntagPeer(p, "a")
+	require.Equal(t, 6, cm.GetTagInfo(p).Value)
+}
+
+func TestConnManager_MaxPeers(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{MaxPeers: 1}, &Config{}, &Config{})
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodes[0], nodes[1])
+	require.Equal(t, 1, nodes[0].host.peerCount())
+
+	// node B has a lower reputation than node C, so it's trimmed
+	nodes[0].ReportPeer(nodes[1].host.id(), BadTransaction)
+	connectTestPeer(t, nodes[0], nodes[2])
+
+	require.Equal(t, 1, nodes[0].host.peerCount())
+	require.True(t, nodes[0].host.peerConnected(nodes[2].host.id()))
+}
+
+func TestConnManager_MaxPeers_Protected(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{MaxPeers: 1}, &Config{}, &Config{})
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	nodes[0].host.cm.Protect(nodes[1].host.id(), "test")
+	nodes[0].ReportPeer(nodes[1].host.id(), BadTransaction)
+
+	connectTestPeer(t, nodes[0], nodes[1])
+	connectTestPeer(t, nodes[0], nodes[2])
+
+	require.Equal(t, 1, nodes[0].host.peerCount())
+	require.True(t, nodes[0].host.peerConnected(nodes[1].host.id()))
+}
+
+func TestConnManager_MaxInbound(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{MaxInbound: 1}, &Config{}, &Config{})
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodes[1], nodes[0])
+	require.Equal(t, 1, nodes[0].host.peerCount())
+
+	// the second inbound connection is rejected
+	connectTestPeer(t, nodes[2], nodes[0])
+	time.Sleep(TestMessageTimeout / 10)
+	require.Equal(t, 1, nodes[0].host.peerCount())
+	require.True(t, nodes[0].host.peerConnected(nodes[1].host.id()))
+
+	// outbound connections are still allowed
+	connectTestPeer(t, nodes[0], nodes[2])
+	require.Equal(t, 2, nodes[0].host.peerCount())
+}
@@ -0,0 +1,194 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	libp2pdiscovery "github.com/libp2p/go-libp2p-discovery"
+)
+
+// kadID is the sub-protocol of the Kademlia DHT, so that the DHT only contains peers on the same chain
+const kadID = "/kad"
+
+// discoveryInterval is how often we run a random walk of the DHT and look for peers advertising the chain. the
+// first round runs after discoveryStartDelay, once we've had time to connect to the bootnodes.
+var (
+	discoveryInterval   = time.Minute
+	discoveryStartDelay = 5 * time.Second
+)
+
+// discoveryTimeout bounds how long each random walk and search for providers runs for
+var discoveryTimeout = 30 * time.Second
+
+// discoveredPeerTTL is how long we keep the addresses of discovered peers in the peerstore
+var discoveredPeerTTL = peerstore.ProviderAddrTTL
+
+// dhtProtocolID returns the protocol ID of the Kademlia DHT for the chain with the given protocol ID
+func dhtProtocolID(pid protocol.ID) protocol.ID {
+	return pid + kadID
+}
+
+// dhtDiscovery submodule. it advertises that we're on the chain in the host's DHT, and periodically runs random walks of
+// the DHT and looks for other peers advertising the chain. the peers it finds are added to the peerstore, and are
+// dialed when we have fewer than the minimum number of peers (the connection manager enforces the peer slot limits).
+type dhtDiscovery struct {
+	logger   log.Logger
+	host     *host
+	rd       *libp2pdiscovery.RoutingDiscovery
+	ns       string
+	minPeers int
+	cancel   context.CancelFunc
+
+	// nextAdvertise is when our advertisement needs to be renewed
+	nextAdvertise time.Time
+}
+
+// newDHTDiscovery creates a new discovery instance from the host
+func newDHTDiscovery(host *host, minPeers int) *dhtDiscovery {
+	return &dhtDiscovery{
+		logger:   host.logger.New("module", "discovery"),
+		host:     host,
+		rd:       libp2pdiscovery.NewRoutingDiscovery(host.dht),
+		ns:       string(host.protocolID),
+		minPeers: minPeers,
+	}
+}
+
+// start starts the discovery service
+func (d *dhtDiscovery) start() {
+	d.logger.Trace(
+		"Starting DHT discovery service...",
+		"host", d.host.id(),
+		"interval", discoveryInterval,
+		"protocol", dhtProtocolID(d.host.protocolID),
+	)
+
+	ctx, cancel := context.WithCancel(d.host.ctx)
+	d.cancel = cancel
+	go d.run(ctx)
+}
+
+// close stops the discovery service
+func (d *dhtDiscovery) close() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// run runs a round of discovery at each discovery interval, until the context is cancelled
+func (d *dhtDiscovery) run(ctx context.Context) {
+	delay := discoveryStartDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		d.advertise(ctx)
+		d.randomWalk(ctx)
+		d.findPeers(ctx)
+		delay = discoveryInterval
+	}
+}
+
+// advertise advertises that we're on the chain, if our previous advertisement has expired
+func (d *dhtDiscovery) advertise(ctx context.Context) {
+	if time.Now().Before(d.nextAdvertise) {
+		return
+	}
+
+	ttl, err := d.rd.Advertise(ctx, d.ns)
+	if err != nil {
+		d.logger.Debug("Failed to advertise in the DHT", "error", err)
+		return
+	}
+
+	// renew the advertisement before it expires
+	d.nextAdvertise = time.Now().Add(7 * ttl / 8)
+}
+
+// randomWalk looks up the peers closest to a random key, which fills our DHT routing table and peerstore with peers
+// from across the DHT
+func (d *dhtDiscovery) randomWalk(ctx context.Context) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		d.logger.Error("Failed to generate random walk key", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	peers, err := d.host.dht.GetClosestPeers(ctx, string(key))
+	if err != nil {
+		d.logger.Debug("Failed to run DHT random walk", "error", err)
+		return
+	}
+
+	for p := range peers {
+		d.found(d.host.h.Peerstore().PeerInfo(p))
+	}
+}
+
+// findPeers looks for the peers advertising the chain
+func (d *dhtDiscovery) findPeers(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	peers, err := d.rd.FindPeers(ctx, d.ns)
+	if err != nil {
+		d.logger.Debug("Failed to find peers in the DHT", "error", err)
+		return
+	}
+
+	for p := range peers {
+		d.found(p)
+	}
+}
+
+// found adds a discovered peer to the peerstore, and dials it if we have fewer than the minimum number of peers
+func (d *dhtDiscovery) found(p peer.AddrInfo) {
+	if p.ID == d.host.id() || len(p.Addrs) == 0 {
+		return
+	}
+
+	d.logger.Trace(
+		"Peer found using DHT discovery",
+		"host", d.host.id(),
+		"peer", p.ID,
+	)
+
+	d.host.h.Peerstore().AddAddrs(p.ID, p.Addrs, discoveredPeerTTL)
+
+	if d.host.peerCount() >= d.minPeers || d.host.peerConnected(p.ID) {
+		return
+	}
+
+	err := d.host.connect(p)
+	if err != nil {
+		d.logger.Debug("Failed to connect to peer found using DHT discovery", "peer", p.ID, "error", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+// test peers find each other using the DHT when they only know about a common bootnode
+func TestDHTDiscovery(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	startDelay, interval := discoveryStartDelay, discoveryInterval
+	discoveryStartDelay, discoveryInterval = TestMessageTimeout/10, TestMessageTimeout/2
+	defer func() {
+		discoveryStartDelay, discoveryInterval = startDelay, interval
+	}()
+
+	nodes := createTestPeers(t, &Config{NoBootstrap: true})
+	defer nodes[0].Stop()
+
+	// nodes B and C only know about node A, which doesn't bootstrap but still runs the DHT
+	bootnode := nodes[0].host.multiaddrs()[0].String()
+	for i := 1; i < 3; i++ {
+		node := createTestService(t, &Config{
+			BasePath:  utils.NewTestBasePath(t, "node"+string(rune('A'+i))),
+			Port:      uint32(7001 + i),
+			RandSeed:  int64(1 + i),
+			Bootnodes: []string{bootnode},
+			NoMDNS:    true,
+		})
+		defer node.Stop()
+		nodes = append(nodes, node)
+	}
+
+	require.True(t, nodes[1].host.peerConnected(nodes[0].host.id()))
+	require.True(t, nodes[2].host.peerConnected(nodes[0].host.id()))
+
+	// the DHT only contains peers on the same chain
+	protocols, err := nodes[1].host.h.Peerstore().SupportsProtocols(nodes[0].host.id(), string(dhtProtocolID(nodes[0].host.protocolID)))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(protocols))
+
+	for i := 0; i < 10; i++ {
+		if nodes[1].host.peerConnected(nodes[2].host.id()) {
+			break
+		}
+		time.Sleep(TestMessageTimeout)
+	}
+
+	require.True(t, nodes[1].host.peerConnected(nodes[2].host.id()) || nodes[2].host.peerConnected(nodes[1].host.id()))
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bufio"
+	"errors"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const finalityProofID = "/finality-proof/1"
+
+// finalityProofTimeout is how long we wait for a peer to respond to a finality proof request
+var finalityProofTimeout = 10 * time.Second
+
+// ErrNoFinalityProof is returned when a peer responds to a finality proof request without a proof
+var ErrNoFinalityProof = errors.New("peer cannot prove finality of block")
+
+// ErrFinalityProofTimeout is returned when a peer doesn't respond to a finality proof request in time
+var ErrFinalityProofTimeout = errors.New("timeout waiting for finality proof response")
+
+// RequestFinalityProof requests the proof that the block with the given hash is final from the given peer. it returns
+// the encoded proof, which must be verified by the caller.
+func (s *Service) RequestFinalityProof(p peer.ID, hash common.Hash) ([]byte, error) {
+	id, ch := s.finalityProofRequests.add()
+	defer s.finalityProofRequests.remove(id)
+
+	req := &FinalityProofRequestMessage{
+		ID:        id,
+		BlockHash: hash,
+	}
+
+	err := s.sendRequest(p, finalityProofID, req, FinalityProofResponseType, compressionNone, s.handleFinalityProofResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := s.finalityProofRequests.wait(ch, finalityProofTimeout, ErrFinalityProofTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := msg.(*FinalityProofResponseMessage)
+	if !ok || resp.BlockHash != hash || len(resp.Proof) == 0 {
+		return nil, ErrNoFinalityProof
+	}
+
+	return resp.Proof, nil
+}
+
+// handleFinalityProofStream handles inbound streams with the <protocol-id>/finality-proof/1 protocol ID. it reads
+// finality proof requests from the stream and writes the responses to it.
+func (s *Service) handleFinalityProofStream(stream libp2pnetwork.Stream) {
+	conn := stream.Conn()
+	if conn == nil {
+		s.logger.Error("Failed to get connection from stream")
+		return
+	}
+
+	p := conn.RemotePeer()
+
+	// create buffer stream for non-blocking read
+	r := bufio.NewReader(stream)
+
+	go s.host.readStream(r, p, stream.Protocol(), FinalityProofRequestType, compressionNone, func(_ peer.ID, msg Message) {
+		s.handleFinalityProofRequest(stream, msg)
+	})
+	// the stream stays open until closed or reset
+}
+
+// handleFinalityProofRequest responds to a finality proof request on the stream it was received on
+func (s *Service) handleFinalityProofRequest(stream libp2pnetwork.Stream, msg Message) {
+	req, ok := msg.(*FinalityProofRequestMessage)
+	if !ok || s.finalityProofProvider == nil {
+		return
+	}
+
+	resp := &FinalityProofResponseMessage{
+		ID:        req.ID,
+		BlockHash: req.BlockHash,
+		Proof:     []byte{},
+	}
+
+	proof, err := s.finalityProofProvider.ProveFinality(req.BlockHash)
+	if err != nil {
+		s.logger.Debug("cannot create finality proof for request", "peer", stream.Conn().RemotePeer(), "block", req.BlockHash, "error", err)
+	} else {
+		resp.Proof = proof
+	}
+
+	err = s.host.writeToStream(stream, resp)
+	if err != nil {
+		s.logger.Error("failed to send FinalityProofResponse message", "peer", stream.Conn().RemotePeer())
+	}
+}
+
+// handleFinalityProofResponse delivers the finality proof responses we receive to the requests we sent
+func (s *Service) handleFinalityProofResponse(_ peer.ID, msg Message) {
+	if resp, ok := msg.(*FinalityProofResponseMessage); ok {
+		s.finalityProofRequests.deliver(resp.ID, resp)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockFinalityProofProvider struct {
+	proofs map[common.Hash][]byte
+}
+
+func (p *mockFinalityProofProvider) ProveFinality(hash common.Hash) ([]byte, error) {
+	proof, has := p.proofs[hash]
+	if !has {
+		return nil, errors.New("block is not finalized")
+	}
+	return proof, nil
+}
+
+func TestRequestFinalityProof(t *testing.T) {
+	basePathA := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	nodeA := createTestService(t, &Config{
+		BasePath:    basePathA,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		NoStatus:    true,
+	})
+	defer nodeA.Stop()
+
+	known := common.Hash{0x1}
+	basePathB := utils.NewTestBasePath(t, "nodeB")
+	nodeB := createTestService(t, &Config{
+		BasePath:    basePathB,
+		Port:        7002,
+		RandSeed:    2,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		NoStatus:    true,
+		FinalityProofProvider: &mockFinalityProofProvider{
+			proofs: map[common.Hash][]byte{known: {1, 2, 3}},
+		},
+	})
+	defer nodeB.Stop()
+
+	addrInfosB, err := nodeB.host.addrInfos()
+	require.NoError(t, err)
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(*addrInfosB[0])
+	}
+	require.NoError(t, err)
+
+	proof, err := nodeA.RequestFinalityProof(nodeB.host.id(), known)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, proof)
+
+	_, err = nodeA.RequestFinalityProof(nodeB.host.id(), common.Hash{0x2})
+	require.Equal(t, ErrNoFinalityProof, err)
+}
+
+func TestRequestFinalityProof_NoProvider(t *testing.T) {
+	basePathA := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	nodeA := createTestService(t, &Config{
+		BasePath:    basePathA,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		NoStatus:    true,
+	})
+	defer nodeA.Stop()
+
+	basePathB := utils.NewTestBasePath(t, "nodeB")
+	nodeB := createTestService(t, &Config{
+		BasePath:    basePathB,
+		Port:        7002,
+		RandSeed:    2,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		NoStatus:    true,
+	})
+	defer nodeB.Stop()
+
+	addrInfosB, err := nodeB.host.addrInfos()
+	require.NoError(t, err)
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(*addrInfosB[0])
+	}
+	require.NoError(t, err)
+
+	timeout := finalityProofTimeout
+	finalityProofTimeout = time.Second
+	defer func() {
+		finalityProofTimeout = timeout
+	}()
+
+	_, err = nodeA.RequestFinalityProof(nodeB.host.id(), common.Hash{0x1})
+	require.Equal(t, ErrFinalityProofTimeout, err)
+}
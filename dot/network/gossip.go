@@ -9,7 +9,7 @@
 // The gossamer library is distributed in the hope that it will be useful,
 // but WITHOUT ANY WARRANTY; without even the implied warranty of
 // MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
-// GNU Lesser General Public License for more detailg.
+// GNU Lesser General Public License for more details.
 //
 // You should have received a copy of the GNU Lesser General Public License
 // along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
@@ -17,44 +17,178 @@
 package network
 
 import (
+	"container/list"
 	"sync"
+	"time"
 
 	log "github.com/ChainSafe/log15"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
 
+// seenCacheSize is the maximum number of message IDs in the seen cache, above which the oldest are evicted
+const seenCacheSize = 1 << 13
+
+// knownMessagesSize is the maximum number of message IDs we remember each peer knowing about
+const knownMessagesSize = 1 << 10
+
+// seenCacheTTL is how long a message ID stays in the seen cache. a message received again after it has expired is
+// gossiped again.
+var seenCacheTTL = 5 * time.Minute
+
+// seenEntry is a message ID in a seenCache, and when it was added
+type seenEntry struct {
+	id    string
+	added time.Time
+}
+
+// seenCache is a bounded set of message IDs whose entries expire after a while
+type seenCache struct {
+	sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // entries in the order they were added, oldest first
+}
+
+func newSeenCache(capacity int, ttl time.Duration) *seenCache {
+	return &seenCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// add adds the message ID to the cache. it returns false if the ID was already in the cache.
+func (c *seenCache) add(id string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	c.expire(time.Now())
+	if _, has := c.entries[id]; has {
+		return false
+	}
+
+	c.entries[id] = c.order.PushBack(&seenEntry{
+		id:    id,
+		added: time.Now(),
+	})
+
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Front())
+	}
+
+	return true
+}
+
+// has returns true if the message ID is in the cache
+func (c *seenCache) has(id string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	c.expire(time.Now())
+	_, has := c.entries[id]
+	return has
+}
+
+// len returns the number of message IDs in the cache
+func (c *seenCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	c.expire(time.Now())
+	return c.order.Len()
+}
+
+// expire removes the entries that were added more than ttl ago. the lock must be held.
+func (c *seenCache) expire(now time.Time) {
+	for e := c.order.Front(); e != nil; e = c.order.Front() {
+		if now.Sub(e.Value.(*seenEntry).added) < c.ttl {
+			return
+		}
+		c.remove(e)
+	}
+}
+
+// remove removes the entry from the cache. the lock must be held.
+func (c *seenCache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.entries, e.Value.(*seenEntry).id)
+}
+
+// knownMessages tracks the messages each peer knows about, because it sent them to us or we sent them to it, so that
+// we don't send peers messages they already have
+type knownMessages struct {
+	sync.Mutex
+	peers map[peer.ID]*seenCache
+}
+
+func newKnownMessages() *knownMessages {
+	return &knownMessages{
+		peers: make(map[peer.ID]*seenCache),
+	}
+}
+
+// add records that the peer knows about the message. it returns false if we already knew that it did.
+func (k *knownMessages) add(p peer.ID, id string) bool {
+	k.Lock()
+	known, has := k.peers[p]
+	if !has {
+		known = newSeenCache(knownMessagesSize, seenCacheTTL)
+		k.peers[p] = known
+	}
+	k.Unlock()
+
+	return known.add(id)
+}
+
+// has returns true if the peer knows about the message
+func (k *knownMessages) has(p peer.ID, id string) bool {
+	k.Lock()
+	known, has := k.peers[p]
+	k.Unlock()
+
+	return has && known.has(id)
+}
+
+// removePeer forgets the messages the peer knows about
+func (k *knownMessages) removePeer(p peer.ID) {
+	k.Lock()
+	defer k.Unlock()
+	delete(k.peers, p)
+}
+
 // gossip submodule
 type gossip struct {
-	logger  log.Logger
-	host    *host
-	hasSeen *sync.Map
+	logger log.Logger
+	host   *host
+	seen   *seenCache
 }
 
 // newGossip creates a new gossip instance from the host
 func newGossip(host *host) *gossip {
 	return &gossip{
-		logger:  host.logger.New("module", "gossip"),
-		host:    host,
-		hasSeen: &sync.Map{},
+		logger: host.logger.New("module", "gossip"),
+		host:   host,
+		seen:   newSeenCache(seenCacheSize, seenCacheTTL),
 	}
 }
 
-// handleMessage broadcasts messages that have not been seen
+// handleMessage broadcasts messages that have not been seen to the peers that don't know about them
 func (g *gossip) handleMessage(msg Message, from peer.ID) {
 
-	// check if message has not been seen
-	if seen, ok := g.hasSeen.Load(msg.IDString()); !ok || !seen.(bool) {
-
-		// set message to has been seen
-		g.hasSeen.Store(msg.IDString(), true)
+	// check if message has not been seen, and set it to has been seen
+	if !g.seen.add(msg.IDString()) {
+		return
+	}
 
-		g.logger.Trace(
-			"Gossiping message from peer",
-			"host", g.host.id(),
-			"type", msg.GetType(),
-		)
+	g.logger.Trace(
+		"Gossiping message from peer",
+		"host", g.host.id(),
+		"peer", from,
+		"type", msg.GetType(),
+	)
 
-		// broadcast message to connected peers
-		g.host.broadcastExcluding(msg, from)
-	}
+	// broadcast message to connected peers (the peer that sent it to us already knows about it)
+	g.host.broadcast(msg)
 }
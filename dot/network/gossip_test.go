@@ -17,10 +17,15 @@
 package network
 
 import (
+	"errors"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
 )
 
 // test gossip messages to connected peers
@@ -122,7 +127,8 @@ func TestGossip(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = nodeA.host.send(addrInfosB[0].ID, "", TestMessage)
+	np := nodeA.host.notificationsProtocols[TransactionMsgType]
+	err = nodeA.host.sendNotification(addrInfosB[0].ID, np, TestMessage)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -155,27 +161,95 @@ func TestGossip(t *testing.T) {
 		t.Error("node A timeout waiting for message")
 	}
 
-	if hasSeenB, ok := nodeB.gossip.hasSeen.Load(TestMessage.IDString()); !ok || hasSeenB.(bool) == false {
-		t.Error(
-			"node B did not receive block request message from node A",
-			"\nreceived:", hasSeenB,
-			"\nexpected:", true,
-		)
+	if !nodeB.gossip.seen.has(TestMessage.IDString()) {
+		t.Error("node B did not receive block request message from node A")
+	}
+
+	if !nodeC.gossip.seen.has(TestMessage.IDString()) {
+		t.Error("node C did not receive block request message from node B")
 	}
 
-	if hasSeenC, ok := nodeC.gossip.hasSeen.Load(TestMessage.IDString()); !ok || hasSeenC.(bool) == false {
-		t.Error(
-			"node C did not receive block request message from node B",
-			"\nreceived:", hasSeenC,
-			"\nexpected:", true,
-		)
+	if !nodeA.gossip.seen.has(TestMessage.IDString()) {
+		t.Error("node A did not receive block request message from node C")
 	}
 
-	if hasSeenA, ok := nodeA.gossip.hasSeen.Load(TestMessage.IDString()); !ok || hasSeenA.(bool) == false {
-		t.Error(
-			"node A did not receive block request message from node C",
-			"\nreceived:", hasSeenA,
-			"\nexpected:", true,
-		)
+	// every peer now knows about the message, so it isn't sent again
+	for _, node := range []*Service{nodeA, nodeB, nodeC} {
+		for _, p := range node.host.peers() {
+			if !node.host.known.has(p, TestMessage.IDString()) {
+				t.Error("peer does not know about message", "host", node.host.id(), "peer", p)
+			}
+		}
 	}
 }
+
+func TestSeenCache(t *testing.T) {
+	c := newSeenCache(2, time.Hour)
+	require.True(t, c.add("a"))
+	require.False(t, c.add("a"))
+	require.True(t, c.add("b"))
+	require.True(t, c.has("a"))
+
+	// the oldest entry is evicted once the cache is full
+	require.True(t, c.add("c"))
+	require.Equal(t, 2, c.len())
+	require.False(t, c.has("a"))
+	require.True(t, c.has("b"))
+	require.True(t, c.has("c"))
+}
+
+func TestSeenCache_Expiry(t *testing.T) {
+	c := newSeenCache(2, time.Millisecond*50)
+	require.True(t, c.add("a"))
+	require.True(t, c.has("a"))
+
+	time.Sleep(time.Millisecond * 100)
+	require.False(t, c.has("a"))
+	require.Equal(t, 0, c.len())
+	require.True(t, c.add("a"))
+}
+
+func TestKnownMessages(t *testing.T) {
+	k := newKnownMessages()
+	a, b := peer.ID("a"), peer.ID("b")
+
+	require.True(t, k.add(a, "msg"))
+	require.False(t, k.add(a, "msg"))
+	require.True(t, k.has(a, "msg"))
+	require.False(t, k.has(b, "msg"))
+
+	k.removePeer(a)
+	require.False(t, k.has(a, "msg"))
+}
+
+func TestValidateBlockAnnounce(t *testing.T) {
+	s := &Service{
+		blockState: newMockBlockState(big.NewInt(10)),
+	}
+
+	msg := &BlockAnnounceMessage{
+		Number: big.NewInt(11),
+		Digest: [][]byte{},
+	}
+
+	process, propagate, err := s.validateBlockAnnounce(msg)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.True(t, propagate)
+
+	// announces too far ahead of our best block are processed but not gossiped
+	msg.Number = big.NewInt(11 + maxBlockAnnounceDistance)
+	process, propagate, err = s.validateBlockAnnounce(msg)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.False(t, propagate)
+
+	msg.Number = big.NewInt(0)
+	_, _, err = s.validateBlockAnnounce(msg)
+	require.True(t, errors.Is(err, errInvalidBlockAnnounce))
+
+	msg.Number = big.NewInt(11)
+	msg.Digest = [][]byte{{9, 9}}
+	_, _, err = s.validateBlockAnnounce(msg)
+	require.True(t, errors.Is(err, errInvalidBlockAnnounce))
+}
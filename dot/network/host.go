@@ -17,8 +17,12 @@
 package network
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	log "github.com/ChainSafe/log15"
 	ds "github.com/ipfs/go-datastore"
@@ -30,6 +34,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/peerstore"
 	"github.com/libp2p/go-libp2p-core/protocol"
 	kaddht "github.com/libp2p/go-libp2p-kad-dht"
+	dhtopts "github.com/libp2p/go-libp2p-kad-dht/opts"
 	rhost "github.com/libp2p/go-libp2p/p2p/host/routed"
 	ma "github.com/multiformats/go-multiaddr"
 )
@@ -42,6 +47,24 @@ type host struct {
 	dht        *kaddht.IpfsDHT
 	bootnodes  []peer.AddrInfo
 	protocolID protocol.ID
+	validator  GossipValidator
+	cm         *ConnManager
+	reserved   *reservedPeers
+	known      *knownMessages // the messages each peer knows about
+	limiter    *rateLimiter   // limits the rate of messages we accept from each peer
+
+	// compression is the bitfield of compression algorithms we support for block responses
+	compression      byte
+	compressionStats *compressionStats
+
+	// traffic counts the bytes and messages we send and receive, per protocol and per peer
+	traffic *trafficCounter
+
+	// reputations tracks the reputations of our peers, which are reported by the services that handle their messages
+	reputations *reputations
+
+	// notificationsProtocols maps message types to the notifications protocol they're sent on
+	notificationsProtocols map[int]*notificationsProtocol
 }
 
 // newHost creates a host wrapper with a new libp2p host instance
@@ -57,7 +80,11 @@ func newHost(ctx context.Context, cfg *Config, logger log.Logger) (*host, error)
 	}
 
 	// set connection manager
-	cm := &ConnManager{}
+	reputations := newReputations()
+	cm := newConnManager(cfg.MaxPeers, cfg.MaxInbound, cfg.ReservedOnly, reputations)
+
+	// count the bandwidth of every stream
+	traffic := newTrafficCounter()
 
 	// set libp2p host options
 	opts := []libp2p.Option{
@@ -66,6 +93,7 @@ func newHost(ctx context.Context, cfg *Config, logger log.Logger) (*host, error)
 		libp2p.Identity(cfg.privateKey),
 		libp2p.NATPortMap(),
 		libp2p.ConnectionManager(cm),
+		libp2p.BandwidthReporter(traffic.bandwidth),
 	}
 
 	// create libp2p host instance
@@ -74,8 +102,19 @@ func newHost(ctx context.Context, cfg *Config, logger log.Logger) (*host, error)
 		return nil, err
 	}
 
-	// create DHT service
-	dht := kaddht.NewDHT(ctx, h, sync.MutexWrap(ds.NewMapDatastore()))
+	// format protocol id
+	pid := protocol.ID(cfg.ProtocolID)
+
+	// create DHT service, scoped to the chain's protocol ID so that it only contains peers on the same chain
+	dht, err := kaddht.New(
+		ctx,
+		h,
+		dhtopts.Datastore(sync.MutexWrap(ds.NewMapDatastore())),
+		dhtopts.Protocols(dhtProtocolID(pid)),
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	// wrap host and DHT service with routed host
 	h = rhost.Wrap(h, dht)
@@ -86,19 +125,49 @@ func newHost(ctx context.Context, cfg *Config, logger log.Logger) (*host, error)
 		return nil, err
 	}
 
-	// format protocol id
-	pid := protocol.ID(cfg.ProtocolID)
+	// bootnodes are never trimmed by the connection manager
+	for _, bn := range bns {
+		cm.Protect(bn.ID, "bootnode")
+	}
+
+	// format reserved nodes
+	reservedNodes, err := stringsToAddrInfos(cfg.ReservedNodes)
+	if err != nil {
+		return nil, err
+	}
 
 	logger = logger.New("module", "host")
 
-	return &host{
+	host := &host{
 		logger:     logger,
 		ctx:        ctx,
 		h:          h,
 		dht:        dht,
 		bootnodes:  bns,
 		protocolID: pid,
-	}, nil
+		validator:  cfg.GossipValidator,
+		cm:         cm,
+		reserved:   newReservedPeers(),
+		known:      newKnownMessages(),
+		limiter:    newRateLimiter(inboundMessageRate, inboundMessageBurst),
+
+		compression:      supportedCompression,
+		compressionStats: &compressionStats{},
+		traffic:          traffic,
+
+		reputations:            reputations,
+		notificationsProtocols: make(map[int]*notificationsProtocol),
+	}
+
+	for _, addrInfo := range reservedNodes {
+		host.addReservedPeer(addrInfo)
+	}
+
+	if cfg.NoCompression {
+		host.compression = compressionNone
+	}
+
+	return host, nil
 
 }
 
@@ -127,6 +196,15 @@ func (h *host) registerConnHandler(handler func(libp2pnetwork.Conn)) {
 	h.h.Network().SetConnHandler(handler)
 }
 
+// registerDisconnectHandler registers the handler called when a connection to a peer is closed
+func (h *host) registerDisconnectHandler(handler func(libp2pnetwork.Conn)) {
+	h.h.Network().Notify(&libp2pnetwork.NotifyBundle{
+		DisconnectedF: func(_ libp2pnetwork.Network, conn libp2pnetwork.Conn) {
+			handler(conn)
+		},
+	})
+}
+
 // registerStreamHandler registers the stream handler, appending the given sub-protocol to the main protocol ID
 func (h *host) registerStreamHandler(sub protocol.ID, handler func(libp2pnetwork.Stream)) {
 	h.h.SetStreamHandler(h.protocolID+sub, handler)
@@ -134,6 +212,14 @@ func (h *host) registerStreamHandler(sub protocol.ID, handler func(libp2pnetwork
 
 // connect connects the host to a specific peer address
 func (h *host) connect(p peer.AddrInfo) (err error) {
+	if h.reputations.banned(p.ID) {
+		return errPeerBanned
+	}
+
+	if h.cm.reservedOnly && !h.cm.isReserved(p.ID) {
+		return errNotReserved
+	}
+
 	h.h.Peerstore().AddAddrs(p.ID, p.Addrs, peerstore.PermanentAddrTTL)
 	err = h.h.Connect(h.ctx, p)
 	return err
@@ -157,71 +243,214 @@ func (h *host) ping(peer peer.ID) error {
 // send writes the given message to the outbound message stream for the given
 // peer (gets the already opened outbound message stream or opens a new one).
 func (h *host) send(p peer.ID, sub protocol.ID, msg Message) (err error) {
+	s, _, err := h.getOrOpenStream(p, sub)
+	if err != nil {
+		return err
+	}
+
+	return h.writeToStream(s, msg)
+}
 
+// getOrOpenStream returns the outbound message stream for the given peer and sub-protocol, opening a new one if we
+// don't have one yet. it returns whether the stream was newly opened.
+func (h *host) getOrOpenStream(p peer.ID, sub protocol.ID) (s libp2pnetwork.Stream, opened bool, err error) {
 	// get outbound stream for given peer
-	s := h.getStream(p, sub)
+	s = h.getStream(p, sub)
+	if s != nil {
+		return s, false, nil
+	}
 
-	// check if stream needs to be opened
-	if s == nil {
+	// open outbound stream with host protocol id
+	s, err = h.h.NewStream(h.ctx, p, h.protocolID+sub)
+	if err != nil {
+		return nil, false, err
+	}
 
-		// open outbound stream with host protocol id
-		s, err = h.h.NewStream(h.ctx, p, h.protocolID+sub)
-		if err != nil {
-			return err
-		}
+	h.logger.Trace(
+		"Opened stream",
+		"host", h.id(),
+		"peer", p,
+		"protocol", s.Protocol(),
+	)
 
-		h.logger.Trace(
-			"Opened stream",
-			"host", h.id(),
-			"peer", p,
-			"protocol", s.Protocol(),
-		)
-	}
+	return s, true, nil
+}
 
-	encMsg, err := msg.Encode()
+// writeToStream writes the message to the stream, without its type byte since each protocol only carries messages
+// of known types
+func (h *host) writeToStream(s libp2pnetwork.Stream, msg Message) error {
+	return h.writeCompressedToStream(s, msg, compressionNone)
+}
+
+// writeCompressedToStream writes the message to the stream without its type byte, compressed with the given algorithm
+func (h *host) writeCompressedToStream(s libp2pnetwork.Stream, msg Message, c byte) error {
+	encMsg, err := encodeMessage(msg)
 	if err != nil {
 		return err
 	}
 
-	msgLen := uint64(len(encMsg))
-	lenBytes := uint64ToLEB128(msgLen)
-	encMsg = append(lenBytes, encMsg...)
+	if c != compressionNone {
+		raw := len(encMsg)
+		encMsg, err = compress(c, encMsg)
+		if err != nil {
+			return err
+		}
+
+		h.compressionStats.sent(raw, len(encMsg))
+	}
+
+	return h.writeEncodedToStream(s, msg, encMsg)
+}
 
-	_, err = s.Write(encMsg)
+// writeEncodedToStream writes the encoded message to the stream
+func (h *host) writeEncodedToStream(s libp2pnetwork.Stream, msg Message, encMsg []byte) error {
+	err := h.writeBytesToStream(s, encMsg)
 	if err != nil {
 		return err
 	}
 
+	h.traffic.messageSent(s.Conn().RemotePeer(), s.Protocol())
+
 	h.logger.Trace(
 		"Sent message to peer",
 		"host", h.id(),
-		"peer", p,
+		"peer", s.Conn().RemotePeer(),
+		"protocol", s.Protocol(),
 		"type", msg.GetType(),
 	)
 
 	return nil
 }
 
-// broadcast sends a message to each connected peer
-func (h *host) broadcast(msg Message) {
-	for _, p := range h.peers() {
-		err := h.send(p, "", msg)
+// writeBytesToStream writes the LEB128 length-prefixed bytes to the stream
+func (h *host) writeBytesToStream(s libp2pnetwork.Stream, in []byte) error {
+	lenBytes := uint64ToLEB128(uint64(len(in)))
+	_, err := s.Write(append(lenBytes, in...))
+	return err
+}
+
+// readFromStream reads the next LEB128 length-prefixed message from the stream, failing without reading the message
+// if its length is greater than maxSize
+func (h *host) readFromStream(r *bufio.Reader, maxSize uint64) ([]byte, error) {
+	length, err := readLEB128ToUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > maxSize {
+		return nil, fmt.Errorf("%w: length %d, max %d", errMessageTooLarge, length, maxSize)
+	}
+
+	msgBytes := make([]byte, length)
+	_, err = io.ReadFull(r, msgBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entire message of length %d: %w", length, err)
+	}
+
+	return msgBytes, nil
+}
+
+// readStream reads messages of the given type, compressed with the given algorithm, from the stream with the given
+// protocol ID until it's closed or reset, and passes them to the handler. peers that send oversized or undecodable messages are reported and
+// the stream is no longer read, while messages from peers that exceed their inbound rate limit are dropped.
+func (h *host) readStream(r *bufio.Reader, peer peer.ID, pid protocol.ID, msgType byte, c byte, handler func(peer peer.ID, msg Message)) {
+	h.readDecodedStream(r, peer, pid, msgType, newMessageDecoder(msgType), c, handler)
+}
+
+// readDecodedStream is readStream for protocols whose messages are decoded with the given decoder
+func (h *host) readDecodedStream(r *bufio.Reader, peer peer.ID, pid protocol.ID, msgType byte, decoder messageDecoder, c byte, handler func(peer peer.ID, msg Message)) {
+	maxSize := maxMessageSize(msgType)
+
+	frameSize := maxSize
+	if c != compressionNone {
+		frameSize = compressedSizeBound(maxSize)
+	}
+
+	for {
+		msgBytes, err := h.readFromStream(r, frameSize)
+		if err == nil && c != compressionNone {
+			compressed := len(msgBytes)
+			msgBytes, err = decompress(c, msgBytes, maxSize)
+			if err != nil && !errors.Is(err, errDecompressedTooLarge) {
+				h.logger.Error("Failed to decompress message from peer", "peer", peer, "err", err)
+				h.reportPeer(peer, UndecodableMessage)
+				return
+			}
+
+			if err == nil {
+				h.compressionStats.received(len(msgBytes), compressed)
+			}
+		}
+
+		if errors.Is(err, errMessageTooLarge) || errors.Is(err, errDecompressedTooLarge) {
+			h.logger.Debug("Peer sent oversized message", "peer", peer, "type", msgType, "error", err)
+			h.reportPeer(peer, OversizedMessage)
+			return
+		}
+		if err != nil {
+			h.logger.Debug("Failed to read message from stream", "peer", peer, "error", err)
+			return
+		}
+
+		if !h.limiter.allow(peer, time.Now()) {
+			h.logger.Debug("Dropping message from peer exceeding its rate limit", "peer", peer, "type", msgType)
+			h.reportPeer(peer, ExcessiveMessages)
+			continue
+		}
+
+		// decode message based on the stream's protocol
+		msg, err := decoder(msgBytes)
 		if err != nil {
-			h.logger.Error("Failed to broadcast message to peer", "peer", p, "error", err)
+			h.logger.Error("Failed to decode message from peer", "peer", peer, "err", err)
+			h.reportPeer(peer, UndecodableMessage)
+			return // exit
 		}
+
+		h.cm.markActive(peer)
+		h.traffic.messageReceived(peer, pid)
+
+		// handle message based on peer status and message type
+		handler(peer, msg)
 	}
 }
 
-// broadcastExcluding sends a message to each connected peer except specified peer
-func (h *host) broadcastExcluding(msg Message, peer peer.ID) {
+// broadcast sends a message to each connected peer that doesn't know about it yet, using the message's notifications
+// protocol
+func (h *host) broadcast(msg Message) {
+	np, has := h.notificationsProtocols[msg.GetType()]
+	if !has {
+		h.logger.Error("Cannot broadcast message without a notifications protocol", "type", msg.GetType())
+		return
+	}
+
+	// messages that the protocol doesn't carry, such as consensus messages for engines other than GRANDPA, are dropped
+	if _, err := np.codec.encode(msg); err != nil {
+		h.logger.Debug("Cannot broadcast message", "protocol", np.id, "error", err)
+		return
+	}
+
+	shouldSend := h.sendFilter(msg)
+	peers := []peer.ID{}
 	for _, p := range h.peers() {
-		if p != peer {
-			err := h.send(p, "", msg)
-			if err != nil {
-				h.logger.Error("Failed to send message during broadcast", "peer", p, "err", err)
-			}
+		if h.known.has(p, msg.IDString()) || !shouldSend(p) {
+			continue
 		}
+
+		peers = append(peers, p)
+	}
+
+	h.sendNotifications(peers, np, msg)
+}
+
+// sendFilter returns a function that returns whether the message should be sent to a peer. consensus messages are
+// filtered by the gossip validator, while other messages are sent to all peers.
+func (h *host) sendFilter(msg Message) func(p peer.ID) bool {
+	cm, ok := msg.(*ConsensusMessage)
+	if !ok || h.validator == nil {
+		return func(peer.ID) bool { return true }
 	}
+
+	return h.validator.SendFilter(cm)
 }
 
 // getStream returns the outbound message stream for the given peer or returns
@@ -246,6 +475,30 @@ func (h *host) getStream(p peer.ID, sub protocol.ID) (stream libp2pnetwork.Strea
 	return nil
 }
 
+// reportPeer applies the reputation change to the peer, disconnecting it if it's banned as a result
+func (h *host) reportPeer(p peer.ID, change ReputationChange) {
+	value, banned := h.reputations.report(p, change)
+
+	h.logger.Debug(
+		"Changed peer reputation",
+		"peer", p,
+		"change", change.Value,
+		"reason", change.Reason,
+		"reputation", value,
+	)
+
+	if !banned {
+		return
+	}
+
+	h.logger.Info("Banning peer", "peer", p, "reason", change.Reason, "duration", banDuration)
+
+	err := h.closePeer(p)
+	if err != nil {
+		h.logger.Error("Failed to disconnect banned peer", "peer", p, "error", err)
+	}
+}
+
 // closePeer closes the peer connection
 func (h *host) closePeer(peer peer.ID) error {
 	err := h.h.Network().ClosePeer(peer)
@@ -272,6 +525,25 @@ func (h *host) peerConnected(peer peer.ID) bool {
 	return false
 }
 
+// connectToPeers dials the peers in our peerstore that we aren't connected to, until we've tried all of them or we
+// have at least the given number of peers. banned peers are skipped.
+func (h *host) connectToPeers(minPeers int) {
+	for _, p := range h.h.Peerstore().PeersWithAddrs() {
+		if h.peerCount() >= minPeers {
+			return
+		}
+
+		if p == h.id() || h.peerConnected(p) || h.reputations.banned(p) {
+			continue
+		}
+
+		err := h.connect(h.h.Peerstore().PeerInfo(p))
+		if err != nil {
+			h.logger.Trace("Failed to connect to peer", "peer", p, "error", err)
+		}
+	}
+}
+
 // peerCount returns the number of connected peers
 func (h *host) peerCount() int {
 	peers := h.h.Network().Peers()
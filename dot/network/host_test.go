@@ -17,11 +17,18 @@
 package network
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/stretchr/testify/require"
 )
 
 // test host connect method
@@ -254,15 +261,12 @@ func TestSend(t *testing.T) {
 
 	basePathB := utils.NewTestBasePath(t, "nodeB")
 
-	msgSendB := make(chan Message)
-
 	configB := &Config{
 		BasePath:    basePathB,
 		Port:        7002,
 		RandSeed:    2,
 		NoBootstrap: true,
 		NoMDNS:      true,
-		MsgSend:     msgSendB,
 	}
 
 	nodeB := createTestService(t, configB)
@@ -286,26 +290,17 @@ func TestSend(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = nodeA.host.send(addrInfosB[0].ID, "", TestMessage)
-	if err != nil {
-		t.Fatal(err)
-	}
+	// node B responds to the block request on the same stream
+	nodeA.sendBlockRequest(addrInfosB[0].ID, TestBlockRequest)
+	require.True(t, nodeA.requestTracker.hasRequestedBlockID(TestBlockRequest.ID))
 
-	select {
-	case msg := <-msgSendB:
-		if !reflect.DeepEqual(msg, TestMessage) {
-			t.Error(
-				"node B received unexpected message from node A",
-				"\nexpected:", TestMessage,
-				"\nreceived:", msg,
-			)
-		}
-	case <-time.After(TestMessageTimeout):
-		t.Error("node B timeout waiting for message from node A")
-	}
+	time.Sleep(TestMessageTimeout)
+	require.False(t, nodeA.requestTracker.hasRequestedBlockID(TestBlockRequest.ID))
+
+	// node B does not open a stream to respond
+	require.Nil(t, nodeB.host.getStream(nodeA.host.id(), syncID))
 }
 
-// test host broadcast method
 func TestBroadcast(t *testing.T) {
 	basePathA := utils.NewTestBasePath(t, "nodeA")
 
@@ -487,13 +482,15 @@ func TestExistingStream(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	stream := nodeA.host.getStream(nodeB.host.id(), "")
-	if stream != nil {
-		t.Error("node A should not have an outbound stream")
+	npA := nodeA.host.notificationsProtocols[TransactionMsgType]
+	npB := nodeB.host.notificationsProtocols[TransactionMsgType]
+
+	if _, has := npA.outbound[nodeB.host.id()]; has {
+		t.Error("node A should not have an outbound substream")
 	}
 
-	// node A opens the stream to send the first message
-	err = nodeA.host.send(addrInfosB[0].ID, "", TestMessage)
+	// node A opens the substream to send the first message
+	err = nodeA.host.sendNotification(addrInfosB[0].ID, npA, TestMessage)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -504,13 +501,13 @@ func TestExistingStream(t *testing.T) {
 		t.Error("node B timeout waiting for message from node A")
 	}
 
-	stream = nodeA.host.getStream(nodeB.host.id(), "")
-	if stream == nil {
-		t.Error("node A should have an outbound stream")
+	stream, has := npA.outbound[nodeB.host.id()]
+	if !has {
+		t.Error("node A should have an outbound substream")
 	}
 
-	// node A uses the stream to send a second message
-	err = nodeA.host.send(addrInfosB[0].ID, "", TestMessage)
+	// node A uses the substream to send a second message
+	err = nodeA.host.sendNotification(addrInfosB[0].ID, npA, TestMessage)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -521,18 +518,16 @@ func TestExistingStream(t *testing.T) {
 		t.Error("node B timeout waiting for message from node A")
 	}
 
-	stream = nodeA.host.getStream(nodeB.host.id(), "")
-	if stream == nil {
-		t.Error("node A should have an outbound stream")
+	if npA.outbound[nodeB.host.id()] != stream {
+		t.Error("node A should have reused its outbound substream")
 	}
 
-	stream = nodeB.host.getStream(nodeA.host.id(), "")
-	if stream != nil {
-		t.Error("node B should not have an outbound stream")
+	if _, has = npB.outbound[nodeA.host.id()]; has {
+		t.Error("node B should not have an outbound substream")
 	}
 
-	// node B opens the stream to send the first message
-	err = nodeB.host.send(addrInfosA[0].ID, "", TestMessage)
+	// node B opens its own substream to send the first message
+	err = nodeB.host.sendNotification(addrInfosA[0].ID, npB, TestMessage)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -543,13 +538,13 @@ func TestExistingStream(t *testing.T) {
 		t.Error("node A timeout waiting for message from node B")
 	}
 
-	stream = nodeB.host.getStream(nodeA.host.id(), "")
-	if stream == nil {
-		t.Error("node B should have an outbound stream")
+	stream, has = npB.outbound[nodeA.host.id()]
+	if !has {
+		t.Error("node B should have an outbound substream")
 	}
 
-	// node B uses the stream to send a second message
-	err = nodeB.host.send(addrInfosA[0].ID, "", TestMessage)
+	// node B uses the substream to send a second message
+	err = nodeB.host.sendNotification(addrInfosA[0].ID, npB, TestMessage)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -560,8 +555,34 @@ func TestExistingStream(t *testing.T) {
 		t.Error("node A timeout waiting for message from node B")
 	}
 
-	stream = nodeB.host.getStream(nodeA.host.id(), "")
-	if stream == nil {
-		t.Error("node B should have an outbound stream")
+	if npB.outbound[nodeA.host.id()] != stream {
+		t.Error("node B should have reused its outbound substream")
 	}
 }
+
+func TestReadFromStream(t *testing.T) {
+	h := &host{}
+	msg := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	enc := append(uint64ToLEB128(uint64(len(msg))), msg...)
+
+	// the message is read in full even if the stream returns it a byte at a time
+	r := bufio.NewReader(iotest.OneByteReader(bytes.NewReader(enc)))
+	res, err := h.readFromStream(r, uint64(len(msg)))
+	require.NoError(t, err)
+	require.Equal(t, msg, res)
+
+	// messages larger than the limit aren't read
+	r = bufio.NewReader(bytes.NewReader(enc))
+	_, err = h.readFromStream(r, uint64(len(msg))-1)
+	require.True(t, errors.Is(err, errMessageTooLarge))
+
+	// a length prefix claiming far more data than is sent is rejected before anything is allocated
+	r = bufio.NewReader(bytes.NewReader(append(uint64ToLEB128(1<<60), msg...)))
+	_, err = h.readFromStream(r, maxMessageSize(BlockResponseMsgType))
+	require.True(t, errors.Is(err, errMessageTooLarge))
+
+	// the stream ends before the whole message is received
+	r = bufio.NewReader(bytes.NewReader(enc[:len(enc)-1]))
+	_, err = h.readFromStream(r, uint64(len(msg)))
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
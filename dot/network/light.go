@@ -0,0 +1,212 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bufio"
+	"errors"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// the request/response protocols of light clients, each of which carries one type of request
+const (
+	remoteReadID = "/light/read/1"
+	remoteCallID = "/light/call/1"
+)
+
+// lightProtocols maps the light client protocols we serve to the type of the requests they carry. remote header
+// requests must be answered with a proof against a canonical hash trie, and remote changes requests with a proof
+// against the changes tries, neither of which we build, so we don't serve them. peers that send them fail to open a
+// stream for their protocol, rather than receiving responses without proofs.
+var lightProtocols = map[protocol.ID]byte{
+	remoteReadID: RemoteReadRequestType,
+	remoteCallID: RemoteCallRequestType,
+}
+
+// lightRequestTimeout is how long we wait for a peer to respond to a light client request
+var lightRequestTimeout = 15 * time.Second
+
+// ErrNoRemoteProof is returned when a peer responds to a light client request without a proof, because it cannot
+// answer the request
+var ErrNoRemoteProof = errors.New("peer cannot answer remote request")
+
+// ErrLightRequestTimeout is returned when a peer doesn't respond to a light client request in time
+var ErrLightRequestTimeout = errors.New("timeout waiting for response to remote request")
+
+// RemoteRead requests the proof of the values of the storage keys in the state of the block with the given hash from
+// the given peer. the proof must be verified by the caller against the block's state root.
+func (s *Service) RemoteRead(p peer.ID, block common.Hash, keys [][]byte) ([][]byte, error) {
+	id, ch := s.lightRequests.add()
+	defer s.lightRequests.remove(id)
+
+	req := &RemoteReadRequestMessage{
+		ID:    id,
+		Block: block,
+		Keys:  keys,
+	}
+
+	msg, err := s.sendLightRequest(p, remoteReadID, req, RemoteReadResponseType, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := msg.(*RemoteReadResponseMessage)
+	if !ok || len(resp.Proof) == 0 {
+		return nil, ErrNoRemoteProof
+	}
+
+	return resp.Proof, nil
+}
+
+// RemoteCall requests the result of calling the runtime method with the data in the state of the block with the given
+// hash from the given peer. it returns the result and the proof of the storage the call read, which must be verified
+// by the caller by making the call itself.
+func (s *Service) RemoteCall(p peer.ID, block common.Hash, method string, data []byte) ([]byte, [][]byte, error) {
+	id, ch := s.lightRequests.add()
+	defer s.lightRequests.remove(id)
+
+	req := &RemoteCallRequestMessage{
+		ID:     id,
+		Block:  block,
+		Method: method,
+		Data:   data,
+	}
+
+	msg, err := s.sendLightRequest(p, remoteCallID, req, RemoteCallResponseType, ch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, ok := msg.(*RemoteCallResponseMessage)
+	if !ok || len(resp.Proof) == 0 {
+		return nil, nil, ErrNoRemoteProof
+	}
+
+	return resp.Result, resp.Proof, nil
+}
+
+// sendLightRequest sends the light client request to the peer and waits for the response to be delivered on the
+// request's channel
+func (s *Service) sendLightRequest(p peer.ID, sub protocol.ID, req Message, respType byte, ch chan Message) (Message, error) {
+	err := s.sendRequest(p, sub, req, respType, compressionNone, s.handleLightResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.lightRequests.wait(ch, lightRequestTimeout, ErrLightRequestTimeout)
+}
+
+// handleLightResponse delivers the responses to light client requests we receive to the requests we sent
+func (s *Service) handleLightResponse(_ peer.ID, msg Message) {
+	switch resp := msg.(type) {
+	case *RemoteReadResponseMessage:
+		s.lightRequests.deliver(resp.ID, resp)
+	case *RemoteCallResponseMessage:
+		s.lightRequests.deliver(resp.ID, resp)
+	}
+}
+
+// registerLightHandlers registers the stream handlers of the light client protocols, through which we answer the
+// requests of light clients
+func (s *Service) registerLightHandlers() {
+	for sub, reqType := range lightProtocols {
+		reqType := reqType
+		s.host.registerStreamHandler(sub, func(stream libp2pnetwork.Stream) {
+			s.handleLightStream(stream, reqType)
+		})
+	}
+}
+
+// handleLightStream handles inbound streams with the <protocol-id>/light/<request>/1 protocol IDs. it reads light
+// client requests of the given type from the stream and writes the responses to it.
+func (s *Service) handleLightStream(stream libp2pnetwork.Stream, reqType byte) {
+	conn := stream.Conn()
+	if conn == nil {
+		s.logger.Error("Failed to get connection from stream")
+		return
+	}
+
+	p := conn.RemotePeer()
+
+	// create buffer stream for non-blocking read
+	r := bufio.NewReader(stream)
+
+	go s.host.readStream(r, p, stream.Protocol(), reqType, compressionNone, func(_ peer.ID, msg Message) {
+		resp := s.handleLightRequest(p, msg)
+		if resp == nil {
+			return
+		}
+
+		err := s.host.writeToStream(stream, resp)
+		if err != nil {
+			s.logger.Error("failed to send response to light client request", "peer", p, "type", resp.GetType())
+		}
+	})
+	// the stream stays open until closed or reset
+}
+
+// handleLightRequest returns the response to a light client request, whose proof is empty if we cannot answer it
+func (s *Service) handleLightRequest(p peer.ID, msg Message) Message {
+	switch req := msg.(type) {
+	case *RemoteReadRequestMessage:
+		resp := &RemoteReadResponseMessage{
+			ID:    req.ID,
+			Proof: [][]byte{},
+		}
+
+		if s.lightServer == nil {
+			return resp
+		}
+
+		proof, err := s.lightServer.ReadProof(req.Block, req.Keys)
+		if err != nil {
+			s.logger.Debug("cannot create read proof for request", "peer", p, "block", req.Block, "error", err)
+			return resp
+		}
+
+		resp.Proof = proof
+		return resp
+	case *RemoteCallRequestMessage:
+		resp := &RemoteCallResponseMessage{
+			ID:     req.ID,
+			Result: []byte{},
+			Proof:  [][]byte{},
+		}
+
+		if s.lightServer == nil {
+			return resp
+		}
+
+		result, proof, err := s.lightServer.ExecutionProof(req.Block, req.Method, req.Data)
+		if err != nil {
+			s.logger.Debug("cannot create execution proof for request", "peer", p, "block", req.Block, "method", req.Method, "error", err)
+			return resp
+		}
+
+		resp.Result = result
+		resp.Proof = proof
+		return resp
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLightServer answers light client requests with the state of a single block
+type mockLightServer struct {
+	block  common.Hash
+	t      *trie.Trie
+	header *types.Header
+}
+
+func newMockLightServer(t *testing.T) *mockLightServer {
+	tr := trie.NewEmptyTrie()
+	err := tr.Put([]byte("noot"), []byte("washere"))
+	require.NoError(t, err)
+	err = tr.Put([]byte("other"), []byte("value"))
+	require.NoError(t, err)
+
+	root, err := tr.Hash()
+	require.NoError(t, err)
+
+	header := &types.Header{
+		ParentHash: common.Hash{0x1},
+		Number:     big.NewInt(7),
+		StateRoot:  root,
+		Digest:     [][]byte{},
+	}
+
+	return &mockLightServer{
+		block:  header.Hash(),
+		t:      tr,
+		header: header,
+	}
+}
+
+func (m *mockLightServer) ReadProof(block common.Hash, keys [][]byte) ([][]byte, error) {
+	if block != m.block {
+		return nil, errors.New("unknown block")
+	}
+	return m.t.GenerateProof(keys)
+}
+
+func (m *mockLightServer) ExecutionProof(block common.Hash, method string, data []byte) ([]byte, [][]byte, error) {
+	proof, err := m.ReadProof(block, [][]byte{[]byte("noot")})
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]byte(method), data...), proof, nil
+}
+
+func TestRemoteRequests(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	server := newMockLightServer(t)
+	nodes := createTestPeers(t, &Config{}, &Config{LightServer: server})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	p := nodeB.host.id()
+
+	proof, err := nodeA.RemoteRead(p, server.block, [][]byte{[]byte("noot")})
+	require.NoError(t, err)
+
+	value, err := trie.VerifyProof(server.header.StateRoot, []byte("noot"), proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte("washere"), value)
+
+	result, proof, err := nodeA.RemoteCall(p, server.block, "Core_version", []byte{0x1})
+	require.NoError(t, err)
+	require.Equal(t, append([]byte("Core_version"), 0x1), result)
+	require.NotEmpty(t, proof)
+
+	// the server cannot answer requests for blocks it doesn't have
+	_, err = nodeA.RemoteRead(p, common.Hash{0x2}, [][]byte{[]byte("noot")})
+	require.Equal(t, ErrNoRemoteProof, err)
+}
+
+func TestRemoteRequests_NoLightServer(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	p := nodeB.host.id()
+
+	_, err := nodeA.RemoteRead(p, common.Hash{0x1}, [][]byte{[]byte("noot")})
+	require.Equal(t, ErrNoRemoteProof, err)
+
+	_, _, err = nodeA.RemoteCall(p, common.Hash{0x1}, "Core_version", []byte{})
+	require.Equal(t, ErrNoRemoteProof, err)
+}
+
+func TestRemoteRequests_Unsupported(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{LightServer: newMockLightServer(t)})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+
+	// we can't prove headers or changes, so their protocols aren't served
+	for _, sub := range []protocol.ID{"/light/header/1", "/light/changes/1"} {
+		_, err := nodeA.host.h.NewStream(nodeA.ctx, nodeB.host.id(), nodeA.host.protocolID+sub)
+		require.Error(t, err, sub)
+	}
+
+	resp := nodeB.handleLightRequest("", &RemoteChangesRequestMessage{
+		ID:  9,
+		Key: []byte("noot"),
+	})
+	require.Nil(t, resp)
+}
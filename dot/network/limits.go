@@ -0,0 +1,129 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// maxHandshakeSize is the maximum size of a notifications substream handshake
+const maxHandshakeSize = 1 << 14
+
+// defaultMaxMessageSize is the maximum size of a message whose type has no specific limit
+const defaultMaxMessageSize = 1 << 20
+
+// maxMessageSizes is the maximum size of each type of message we can receive, excluding its length prefix. block
+// responses and transactions may carry full blocks, so they are allowed to be much larger than requests.
+var maxMessageSizes = map[byte]uint64{
+	StatusMsgType:             maxHandshakeSize,
+	BlockRequestMsgType:       1 << 10,
+	BlockResponseMsgType:      16 << 20,
+	BlockAnnounceMsgType:      1 << 20,
+	TransactionMsgType:        16 << 20,
+	ConsensusMsgType:          1 << 20,
+	RemoteCallRequestType:     1 << 20,
+	RemoteCallResponseType:    16 << 20,
+	RemoteReadRequestType:     1 << 20,
+	RemoteReadResponseType:    16 << 20,
+	RemoteHeaderRequestType:   1 << 10,
+	RemoteHeaderResponseType:  1 << 20,
+	RemoteChangesRequestType:  1 << 12,
+	RemoteChangesResponseType: 16 << 20,
+	FinalityProofRequestType:  1 << 10,
+	FinalityProofResponseType: 16 << 20,
+}
+
+// maxMessageSize returns the maximum size of a message of the given type
+func maxMessageSize(msgType byte) uint64 {
+	if size, has := maxMessageSizes[msgType]; has {
+		return size
+	}
+	return defaultMaxMessageSize
+}
+
+// errMessageTooLarge is returned when a peer sends a message that is larger than the protocol allows
+var errMessageTooLarge = errors.New("message too large")
+
+// inboundMessageRate is the number of messages per second we accept from each peer on average
+var inboundMessageRate = 100
+
+// inboundMessageBurst is the number of messages we accept from a peer in a burst before rate limiting it
+var inboundMessageBurst = 1000
+
+// tokenBucket refills at a constant rate up to its capacity, and allows a message for each token taken from it
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter limits the number of messages we accept from each peer, using a token bucket per peer
+type rateLimiter struct {
+	sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[peer.ID]*tokenBucket
+}
+
+// newRateLimiter creates a rate limiter that allows rate messages per second per peer, in bursts of at most burst
+func newRateLimiter(rate, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		buckets: make(map[peer.ID]*tokenBucket),
+	}
+}
+
+// allow returns true if we can accept another message from the peer, taking a token from its bucket
+func (rl *rateLimiter) allow(p peer.ID, now time.Time) bool {
+	rl.Lock()
+	defer rl.Unlock()
+
+	b, has := rl.buckets[p]
+	if !has {
+		b = &tokenBucket{
+			tokens:  rl.burst,
+			updated: now,
+		}
+		rl.buckets[p] = b
+	}
+
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rl.rate
+		if b.tokens > rl.burst {
+			b.tokens = rl.burst
+		}
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// removePeer forgets the peer's bucket, once we're disconnected from it
+func (rl *rateLimiter) removePeer(p peer.ID) {
+	rl.Lock()
+	defer rl.Unlock()
+	delete(rl.buckets, p)
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxMessageSize(t *testing.T) {
+	require.Equal(t, uint64(16<<20), maxMessageSize(BlockResponseMsgType))
+	require.Equal(t, uint64(1<<10), maxMessageSize(BlockRequestMsgType))
+	require.Equal(t, uint64(defaultMaxMessageSize), maxMessageSize(RemoteCallRequestType))
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter(10, 5)
+	p := peer.ID("noot")
+	now := time.Now()
+
+	// the whole burst is allowed at once
+	for i := 0; i < 5; i++ {
+		require.True(t, rl.allow(p, now))
+	}
+	require.False(t, rl.allow(p, now))
+
+	// other peers have their own buckets
+	require.True(t, rl.allow(peer.ID("other"), now))
+
+	// tokens are refilled at the rate, up to the burst
+	now = now.Add(time.Second / 5)
+	require.True(t, rl.allow(p, now))
+	require.True(t, rl.allow(p, now))
+	require.False(t, rl.allow(p, now))
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 5; i++ {
+		require.True(t, rl.allow(p, now))
+	}
+	require.False(t, rl.allow(p, now))
+
+	// a removed peer starts again with a full bucket
+	rl.removePeer(p)
+	require.True(t, rl.allow(p, now))
+}
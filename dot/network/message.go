@@ -19,9 +19,11 @@ package network
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
 
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -46,6 +48,8 @@ const (
 	RemoteHeaderResponseType  = 11
 	RemoteChangesRequestType  = 12
 	RemoteChangesResponseType = 13
+	FinalityProofRequestType  = 14
+	FinalityProofResponseType = 15
 	ChainSpecificMsgType      = 255
 )
 
@@ -84,6 +88,36 @@ func decodeMessage(r io.Reader) (m Message, err error) {
 	case ConsensusMsgType:
 		m = new(ConsensusMessage)
 		err = m.Decode(r)
+	case RemoteCallRequestType:
+		m = new(RemoteCallRequestMessage)
+		err = m.Decode(r)
+	case RemoteCallResponseType:
+		m = new(RemoteCallResponseMessage)
+		err = m.Decode(r)
+	case RemoteReadRequestType:
+		m = new(RemoteReadRequestMessage)
+		err = m.Decode(r)
+	case RemoteReadResponseType:
+		m = new(RemoteReadResponseMessage)
+		err = m.Decode(r)
+	case RemoteHeaderRequestType:
+		m = new(RemoteHeaderRequestMessage)
+		err = m.Decode(r)
+	case RemoteHeaderResponseType:
+		m = new(RemoteHeaderResponseMessage)
+		err = m.Decode(r)
+	case RemoteChangesRequestType:
+		m = new(RemoteChangesRequestMessage)
+		err = m.Decode(r)
+	case RemoteChangesResponseType:
+		m = new(RemoteChangesResponseMessage)
+		err = m.Decode(r)
+	case FinalityProofRequestType:
+		m = new(FinalityProofRequestMessage)
+		err = m.Decode(r)
+	case FinalityProofResponseType:
+		m = new(FinalityProofResponseMessage)
+		err = m.Decode(r)
 	default:
 		return nil, fmt.Errorf("unsupported message type %d", msgType)
 	}
@@ -102,6 +136,31 @@ func decodeMessageBytes(in []byte) (m Message, err error) {
 	return decodeMessage(r)
 }
 
+// messageDecoder decodes a message received on a protocol
+type messageDecoder func(in []byte) (Message, error)
+
+// newMessageDecoder returns a decoder for messages received on a protocol that only carries messages of the given
+// type. these messages are sent without their type byte.
+func newMessageDecoder(msgType byte) messageDecoder {
+	return func(in []byte) (Message, error) {
+		return decodeMessageBytes(append([]byte{msgType}, in...))
+	}
+}
+
+// encodeMessage encodes a message to be sent on a protocol, without its type byte
+func encodeMessage(msg Message) ([]byte, error) {
+	enc, err := msg.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(enc) == 0 {
+		return nil, fmt.Errorf("cannot encode empty message")
+	}
+
+	return enc[1:], nil
+}
+
 // StatusMessage struct
 type StatusMessage struct {
 	ProtocolVersion     uint32
@@ -279,7 +338,7 @@ func (bm *BlockRequestMessage) Decode(r io.Reader) error {
 
 // IDString Returns the ID of the block
 func (bm *BlockRequestMessage) IDString() string {
-	return string(bm.ID)
+	return strconv.FormatUint(bm.ID, 10)
 }
 
 // BlockAnnounceMessage is a state block header
@@ -382,7 +441,7 @@ func (bm *BlockResponseMessage) Decode(r io.Reader) error {
 
 // IDString returns the ID of BlockResponseMessage
 func (bm *BlockResponseMessage) IDString() string {
-	return string(bm.ID)
+	return strconv.FormatUint(bm.ID, 10)
 }
 
 // TransactionMessage is a struct that holds reference to Extrinsics
@@ -515,3 +574,593 @@ func (cm *ConsensusMessage) IDString() string {
 	}
 	return hash.String()
 }
+
+// errNotGrandpaMessage is returned when trying to send a consensus message for an engine other than GRANDPA on the
+// GRANDPA protocol
+var errNotGrandpaMessage = errors.New("consensus message is not a GRANDPA message")
+
+// grandpaCodec is the codec of the GRANDPA protocol, which carries raw GRANDPA messages as in Substrate. they're
+// wrapped in consensus messages for the GRANDPA engine when they're received.
+var grandpaCodec = &messageCodec{
+	encode: encodeGrandpaMessage,
+	decode: decodeGrandpaMessage,
+}
+
+// encodeGrandpaMessage returns the GRANDPA message carried by the consensus message
+func encodeGrandpaMessage(msg Message) ([]byte, error) {
+	cm, ok := msg.(*ConsensusMessage)
+	if !ok || cm.ConsensusEngineID != types.GrandpaEngineID {
+		return nil, errNotGrandpaMessage
+	}
+
+	if len(cm.Data) == 0 {
+		return nil, fmt.Errorf("cannot encode empty message")
+	}
+
+	return cm.Data, nil
+}
+
+// decodeGrandpaMessage wraps the GRANDPA message in a consensus message for the GRANDPA engine
+func decodeGrandpaMessage(in []byte) (Message, error) {
+	if len(in) == 0 {
+		return nil, errors.New("cannot decode empty GRANDPA message")
+	}
+
+	return &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              in,
+	}, nil
+}
+
+// FinalityProofRequestMessage requests the proof that a block is final
+type FinalityProofRequestMessage struct {
+	ID        uint64
+	BlockHash common.Hash
+}
+
+// GetType returns the FinalityProofRequestType
+func (fm *FinalityProofRequestMessage) GetType() int {
+	return FinalityProofRequestType
+}
+
+// String formats a FinalityProofRequestMessage as a string
+func (fm *FinalityProofRequestMessage) String() string {
+	return fmt.Sprintf("FinalityProofRequestMessage ID=%d BlockHash=%s", fm.ID, fm.BlockHash)
+}
+
+// Encode encodes a finality proof request message using SCALE and appends the type byte to the start
+func (fm *FinalityProofRequestMessage) Encode() ([]byte, error) {
+	encMsg := []byte{FinalityProofRequestType}
+
+	encID := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encID, fm.ID)
+	encMsg = append(encMsg, encID...)
+
+	return append(encMsg, fm.BlockHash[:]...), nil
+}
+
+// Decode the message into a FinalityProofRequestMessage, it assumes the type byte has been removed
+func (fm *FinalityProofRequestMessage) Decode(r io.Reader) error {
+	var err error
+	fm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	fm.BlockHash, err = common.ReadHash(r)
+	return err
+}
+
+// IDString returns the ID of the FinalityProofRequestMessage
+func (fm *FinalityProofRequestMessage) IDString() string {
+	return strconv.FormatUint(fm.ID, 10)
+}
+
+// FinalityProofResponseMessage is the response to a FinalityProofRequestMessage. the proof is empty if the peer
+// cannot prove that the block is final.
+type FinalityProofResponseMessage struct {
+	ID        uint64
+	BlockHash common.Hash
+	Proof     []byte
+}
+
+// GetType returns the FinalityProofResponseType
+func (fm *FinalityProofResponseMessage) GetType() int {
+	return FinalityProofResponseType
+}
+
+// String formats a FinalityProofResponseMessage as a string
+func (fm *FinalityProofResponseMessage) String() string {
+	return fmt.Sprintf("FinalityProofResponseMessage ID=%d BlockHash=%s Proof=0x%x", fm.ID, fm.BlockHash, fm.Proof)
+}
+
+// Encode encodes a finality proof response message using SCALE and appends the type byte to the start
+func (fm *FinalityProofResponseMessage) Encode() ([]byte, error) {
+	encMsg := []byte{FinalityProofResponseType}
+
+	encID := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encID, fm.ID)
+	encMsg = append(encMsg, encID...)
+	encMsg = append(encMsg, fm.BlockHash[:]...)
+
+	encProof, err := scale.Encode(fm.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encMsg, encProof...), nil
+}
+
+// Decode the message into a FinalityProofResponseMessage, it assumes the type byte has been removed
+func (fm *FinalityProofResponseMessage) Decode(r io.Reader) error {
+	var err error
+	fm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	fm.BlockHash, err = common.ReadHash(r)
+	if err != nil {
+		return err
+	}
+
+	sd := scale.Decoder{Reader: r}
+	fm.Proof, err = sd.DecodeByteArray()
+	return err
+}
+
+// IDString returns the ID of the FinalityProofResponseMessage
+func (fm *FinalityProofResponseMessage) IDString() string {
+	return strconv.FormatUint(fm.ID, 10)
+}
+
+// decodeByteArrays decodes a SCALE encoded array of byte arrays, such as a proof
+func decodeByteArrays(r io.Reader) ([][]byte, error) {
+	sd := scale.Decoder{Reader: r}
+	out, err := sd.Decode([][]byte{})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.([][]byte), nil
+}
+
+// encodeRequestID appends the message type and the request ID to the start of an encoded request or response
+func encodeRequestID(msgType byte, id uint64) []byte {
+	encMsg := []byte{msgType}
+	encID := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encID, id)
+	return append(encMsg, encID...)
+}
+
+// RemoteReadRequestMessage requests the proof of the values of storage keys in the state of a block
+type RemoteReadRequestMessage struct {
+	ID    uint64
+	Block common.Hash
+	Keys  [][]byte
+}
+
+// GetType returns the RemoteReadRequestType
+func (rm *RemoteReadRequestMessage) GetType() int {
+	return RemoteReadRequestType
+}
+
+// String formats a RemoteReadRequestMessage as a string
+func (rm *RemoteReadRequestMessage) String() string {
+	return fmt.Sprintf("RemoteReadRequestMessage ID=%d Block=%s Keys=%d", rm.ID, rm.Block, len(rm.Keys))
+}
+
+// Encode encodes a remote read request message using SCALE and appends the type byte to the start
+func (rm *RemoteReadRequestMessage) Encode() ([]byte, error) {
+	encMsg := append(encodeRequestID(RemoteReadRequestType, rm.ID), rm.Block[:]...)
+
+	encKeys, err := scale.Encode(rm.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encMsg, encKeys...), nil
+}
+
+// Decode the message into a RemoteReadRequestMessage, it assumes the type byte has been removed
+func (rm *RemoteReadRequestMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Block, err = common.ReadHash(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Keys, err = decodeByteArrays(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteReadRequestMessage
+func (rm *RemoteReadRequestMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteReadResponseMessage is the response to a RemoteReadRequestMessage. the proof is empty if the peer cannot
+// prove the values of the keys, eg. because it doesn't have the state of the block.
+type RemoteReadResponseMessage struct {
+	ID    uint64
+	Proof [][]byte
+}
+
+// GetType returns the RemoteReadResponseType
+func (rm *RemoteReadResponseMessage) GetType() int {
+	return RemoteReadResponseType
+}
+
+// String formats a RemoteReadResponseMessage as a string
+func (rm *RemoteReadResponseMessage) String() string {
+	return fmt.Sprintf("RemoteReadResponseMessage ID=%d Proof=%d nodes", rm.ID, len(rm.Proof))
+}
+
+// Encode encodes a remote read response message using SCALE and appends the type byte to the start
+func (rm *RemoteReadResponseMessage) Encode() ([]byte, error) {
+	encProof, err := scale.Encode(rm.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encodeRequestID(RemoteReadResponseType, rm.ID), encProof...), nil
+}
+
+// Decode the message into a RemoteReadResponseMessage, it assumes the type byte has been removed
+func (rm *RemoteReadResponseMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Proof, err = decodeByteArrays(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteReadResponseMessage
+func (rm *RemoteReadResponseMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteCallRequestMessage requests the result of a runtime call in the state of a block, and the proof of the
+// storage it read
+type RemoteCallRequestMessage struct {
+	ID     uint64
+	Block  common.Hash
+	Method string
+	Data   []byte
+}
+
+// GetType returns the RemoteCallRequestType
+func (rm *RemoteCallRequestMessage) GetType() int {
+	return RemoteCallRequestType
+}
+
+// String formats a RemoteCallRequestMessage as a string
+func (rm *RemoteCallRequestMessage) String() string {
+	return fmt.Sprintf("RemoteCallRequestMessage ID=%d Block=%s Method=%s Data=0x%x", rm.ID, rm.Block, rm.Method, rm.Data)
+}
+
+// Encode encodes a remote call request message using SCALE and appends the type byte to the start
+func (rm *RemoteCallRequestMessage) Encode() ([]byte, error) {
+	encMsg := append(encodeRequestID(RemoteCallRequestType, rm.ID), rm.Block[:]...)
+
+	encMethod, err := scale.Encode(rm.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	encData, err := scale.Encode(rm.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	encMsg = append(encMsg, encMethod...)
+	return append(encMsg, encData...), nil
+}
+
+// Decode the message into a RemoteCallRequestMessage, it assumes the type byte has been removed
+func (rm *RemoteCallRequestMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Block, err = common.ReadHash(r)
+	if err != nil {
+		return err
+	}
+
+	sd := scale.Decoder{Reader: r}
+	method, err := sd.DecodeByteArray()
+	if err != nil {
+		return err
+	}
+	rm.Method = string(method)
+
+	rm.Data, err = sd.DecodeByteArray()
+	return err
+}
+
+// IDString returns the ID of the RemoteCallRequestMessage
+func (rm *RemoteCallRequestMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteCallResponseMessage is the response to a RemoteCallRequestMessage. the proof is empty if the peer cannot make
+// the call, eg. because it doesn't have the state of the block.
+type RemoteCallResponseMessage struct {
+	ID     uint64
+	Result []byte
+	Proof  [][]byte
+}
+
+// GetType returns the RemoteCallResponseType
+func (rm *RemoteCallResponseMessage) GetType() int {
+	return RemoteCallResponseType
+}
+
+// String formats a RemoteCallResponseMessage as a string
+func (rm *RemoteCallResponseMessage) String() string {
+	return fmt.Sprintf("RemoteCallResponseMessage ID=%d Result=0x%x Proof=%d nodes", rm.ID, rm.Result, len(rm.Proof))
+}
+
+// Encode encodes a remote call response message using SCALE and appends the type byte to the start
+func (rm *RemoteCallResponseMessage) Encode() ([]byte, error) {
+	encResult, err := scale.Encode(rm.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	encProof, err := scale.Encode(rm.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	encMsg := append(encodeRequestID(RemoteCallResponseType, rm.ID), encResult...)
+	return append(encMsg, encProof...), nil
+}
+
+// Decode the message into a RemoteCallResponseMessage, it assumes the type byte has been removed
+func (rm *RemoteCallResponseMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	sd := scale.Decoder{Reader: r}
+	rm.Result, err = sd.DecodeByteArray()
+	if err != nil {
+		return err
+	}
+
+	rm.Proof, err = decodeByteArrays(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteCallResponseMessage
+func (rm *RemoteCallResponseMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteHeaderRequestMessage requests the header of the block with the given number on the peer's best chain
+type RemoteHeaderRequestMessage struct {
+	ID    uint64
+	Block uint64
+}
+
+// GetType returns the RemoteHeaderRequestType
+func (rm *RemoteHeaderRequestMessage) GetType() int {
+	return RemoteHeaderRequestType
+}
+
+// String formats a RemoteHeaderRequestMessage as a string
+func (rm *RemoteHeaderRequestMessage) String() string {
+	return fmt.Sprintf("RemoteHeaderRequestMessage ID=%d Block=%d", rm.ID, rm.Block)
+}
+
+// Encode encodes a remote header request message using SCALE and appends the type byte to the start
+func (rm *RemoteHeaderRequestMessage) Encode() ([]byte, error) {
+	encBlock := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encBlock, rm.Block)
+	return append(encodeRequestID(RemoteHeaderRequestType, rm.ID), encBlock...), nil
+}
+
+// Decode the message into a RemoteHeaderRequestMessage, it assumes the type byte has been removed
+func (rm *RemoteHeaderRequestMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Block, err = common.ReadUint64(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteHeaderRequestMessage
+func (rm *RemoteHeaderRequestMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteHeaderResponseMessage is the response to a RemoteHeaderRequestMessage. the header is the SCALE encoded header,
+// which is empty if the peer doesn't have the block. since we don't build canonical hash tries, the proof is always
+// empty, and the header must be checked against the headers the light client has verified.
+type RemoteHeaderResponseMessage struct {
+	ID     uint64
+	Header []byte
+	Proof  [][]byte
+}
+
+// GetType returns the RemoteHeaderResponseType
+func (rm *RemoteHeaderResponseMessage) GetType() int {
+	return RemoteHeaderResponseType
+}
+
+// String formats a RemoteHeaderResponseMessage as a string
+func (rm *RemoteHeaderResponseMessage) String() string {
+	return fmt.Sprintf("RemoteHeaderResponseMessage ID=%d Header=0x%x Proof=%d nodes", rm.ID, rm.Header, len(rm.Proof))
+}
+
+// Encode encodes a remote header response message using SCALE and appends the type byte to the start
+func (rm *RemoteHeaderResponseMessage) Encode() ([]byte, error) {
+	encHeader, err := scale.Encode(rm.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	encProof, err := scale.Encode(rm.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	encMsg := append(encodeRequestID(RemoteHeaderResponseType, rm.ID), encHeader...)
+	return append(encMsg, encProof...), nil
+}
+
+// Decode the message into a RemoteHeaderResponseMessage, it assumes the type byte has been removed
+func (rm *RemoteHeaderResponseMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	sd := scale.Decoder{Reader: r}
+	rm.Header, err = sd.DecodeByteArray()
+	if err != nil {
+		return err
+	}
+
+	rm.Proof, err = decodeByteArrays(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteHeaderResponseMessage
+func (rm *RemoteHeaderResponseMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteChangesRequestMessage requests the blocks in the range from FirstBlock to LastBlock in which the value of
+// the storage key changed, and the proof of those changes
+type RemoteChangesRequestMessage struct {
+	ID         uint64
+	FirstBlock common.Hash
+	LastBlock  common.Hash
+	Min        common.Hash
+	Max        common.Hash
+	Key        []byte
+}
+
+// GetType returns the RemoteChangesRequestType
+func (rm *RemoteChangesRequestMessage) GetType() int {
+	return RemoteChangesRequestType
+}
+
+// String formats a RemoteChangesRequestMessage as a string
+func (rm *RemoteChangesRequestMessage) String() string {
+	return fmt.Sprintf("RemoteChangesRequestMessage ID=%d FirstBlock=%s LastBlock=%s Min=%s Max=%s Key=0x%x",
+		rm.ID, rm.FirstBlock, rm.LastBlock, rm.Min, rm.Max, rm.Key)
+}
+
+// Encode encodes a remote changes request message using SCALE and appends the type byte to the start
+func (rm *RemoteChangesRequestMessage) Encode() ([]byte, error) {
+	encMsg := encodeRequestID(RemoteChangesRequestType, rm.ID)
+	for _, hash := range []common.Hash{rm.FirstBlock, rm.LastBlock, rm.Min, rm.Max} {
+		encMsg = append(encMsg, hash[:]...)
+	}
+
+	encKey, err := scale.Encode(rm.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encMsg, encKey...), nil
+}
+
+// Decode the message into a RemoteChangesRequestMessage, it assumes the type byte has been removed
+func (rm *RemoteChangesRequestMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range []*common.Hash{&rm.FirstBlock, &rm.LastBlock, &rm.Min, &rm.Max} {
+		*hash, err = common.ReadHash(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	sd := scale.Decoder{Reader: r}
+	rm.Key, err = sd.DecodeByteArray()
+	return err
+}
+
+// IDString returns the ID of the RemoteChangesRequestMessage
+func (rm *RemoteChangesRequestMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
+
+// RemoteChangesResponseMessage is the response to a RemoteChangesRequestMessage. Max is the number of the last block
+// the changes were looked up in. the proof is empty if the peer cannot prove the changes.
+type RemoteChangesResponseMessage struct {
+	ID    uint64
+	Max   uint64
+	Proof [][]byte
+}
+
+// GetType returns the RemoteChangesResponseType
+func (rm *RemoteChangesResponseMessage) GetType() int {
+	return RemoteChangesResponseType
+}
+
+// String formats a RemoteChangesResponseMessage as a string
+func (rm *RemoteChangesResponseMessage) String() string {
+	return fmt.Sprintf("RemoteChangesResponseMessage ID=%d Max=%d Proof=%d nodes", rm.ID, rm.Max, len(rm.Proof))
+}
+
+// Encode encodes a remote changes response message using SCALE and appends the type byte to the start
+func (rm *RemoteChangesResponseMessage) Encode() ([]byte, error) {
+	encMax := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encMax, rm.Max)
+
+	encProof, err := scale.Encode(rm.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	encMsg := append(encodeRequestID(RemoteChangesResponseType, rm.ID), encMax...)
+	return append(encMsg, encProof...), nil
+}
+
+// Decode the message into a RemoteChangesResponseMessage, it assumes the type byte has been removed
+func (rm *RemoteChangesResponseMessage) Decode(r io.Reader) error {
+	var err error
+	rm.ID, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Max, err = common.ReadUint64(r)
+	if err != nil {
+		return err
+	}
+
+	rm.Proof, err = decodeByteArrays(r)
+	return err
+}
+
+// IDString returns the ID of the RemoteChangesResponseMessage
+func (rm *RemoteChangesResponseMessage) IDString() string {
+	return strconv.FormatUint(rm.ID, 10)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/optional"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzIterations is the number of random inputs decoded for each message type
+const fuzzIterations = 2000
+
+// fuzzSeed seeds the random inputs, so that failures can be reproduced
+const fuzzSeed = 42
+
+// fuzzMessages returns a valid message of each type that can be received, used to derive malformed inputs
+func fuzzMessages(t *testing.T) []Message {
+	testHash := common.NewHash([]byte{1, 2, 3})
+
+	header := &optional.CoreHeader{
+		ParentHash:     testHash,
+		Number:         big.NewInt(1),
+		StateRoot:      testHash,
+		ExtrinsicsRoot: testHash,
+		Digest:         [][]byte{{0xe, 0xf}},
+	}
+
+	bd := &types.BlockData{
+		Hash:          testHash,
+		Header:        optional.NewHeader(true, header),
+		Body:          optional.NewBody(true, []byte{4, 1, 2}),
+		Receipt:       optional.NewBytes(true, []byte{3}),
+		MessageQueue:  optional.NewBytes(false, nil),
+		Justification: optional.NewBytes(true, []byte{5, 6}),
+	}
+
+	return []Message{
+		&StatusMessage{
+			ProtocolVersion:     1,
+			MinSupportedVersion: 1,
+			Roles:               4,
+			BestBlockNumber:     77,
+			BestBlockHash:       testHash,
+			GenesisHash:         testHash,
+			ChainStatus:         []byte{0},
+		},
+		TestBlockRequest,
+		&BlockResponseMessage{
+			ID:        7,
+			BlockData: []*types.BlockData{bd},
+		},
+		&BlockAnnounceMessage{
+			ParentHash:     testHash,
+			Number:         big.NewInt(128 * 7),
+			StateRoot:      testHash,
+			ExtrinsicsRoot: testHash,
+			Digest:         [][]byte{{1, 2}, {3}},
+		},
+		TestMessage,
+		&ConsensusMessage{
+			ConsensusEngineID: types.BabeEngineID,
+			Data:              []byte{1, 2, 3},
+		},
+		&FinalityProofRequestMessage{
+			ID:        3,
+			BlockHash: testHash,
+		},
+		&FinalityProofResponseMessage{
+			ID:        3,
+			BlockHash: testHash,
+			Proof:     []byte{9, 8, 7},
+		},
+		&RemoteReadRequestMessage{
+			ID:    4,
+			Block: testHash,
+			Keys:  [][]byte{{1, 2}, {3}},
+		},
+		&RemoteReadResponseMessage{
+			ID:    4,
+			Proof: [][]byte{{1, 2, 3}, {4}},
+		},
+		&RemoteCallRequestMessage{
+			ID:     5,
+			Block:  testHash,
+			Method: "Core_version",
+			Data:   []byte{1},
+		},
+		&RemoteCallResponseMessage{
+			ID:     5,
+			Result: []byte{1, 2},
+			Proof:  [][]byte{{3}},
+		},
+		&RemoteHeaderRequestMessage{
+			ID:    6,
+			Block: 77,
+		},
+		&RemoteHeaderResponseMessage{
+			ID:     6,
+			Header: []byte{1, 2, 3},
+			Proof:  [][]byte{},
+		},
+		&RemoteChangesRequestMessage{
+			ID:         7,
+			FirstBlock: testHash,
+			LastBlock:  testHash,
+			Min:        testHash,
+			Max:        testHash,
+			Key:        []byte{1},
+		},
+		&RemoteChangesResponseMessage{
+			ID:    7,
+			Max:   77,
+			Proof: [][]byte{{1}},
+		},
+	}
+}
+
+// mutate returns a malformed copy of the valid encoding: random bytes, a truncation, flipped bits, or a large
+// length prefix spliced into the encoding
+func mutate(r *rand.Rand, valid []byte) []byte {
+	switch r.Intn(4) {
+	case 0:
+		out := make([]byte, r.Intn(2*len(valid)+1))
+		r.Read(out)
+		return out
+	case 1:
+		return append([]byte{}, valid[:r.Intn(len(valid)+1)]...)
+	case 2:
+		out := append([]byte{}, valid...)
+		for i := r.Intn(4) + 1; i > 0 && len(out) > 0; i-- {
+			out[r.Intn(len(out))] ^= 1 << uint(r.Intn(8))
+		}
+		return out
+	default:
+		// a big-integer mode compact length, which claims far more data than the input has
+		out := append([]byte{}, valid[:r.Intn(len(valid)+1)]...)
+		out = append(out, 0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f)
+		return append(out, valid[len(out)-9:]...)
+	}
+}
+
+// decodeWithoutPanic decodes the input as a message of the given type, failing the test if the decoder panics
+func decodeWithoutPanic(t *testing.T, msgType byte, in []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("decoding message type %d panicked: %v\ninput: 0x%s", msgType, r, hex.EncodeToString(in))
+		}
+	}()
+
+	_, _ = newMessageDecoder(msgType)(in)
+}
+
+func TestDecodeMessage_Fuzz(t *testing.T) {
+	r := rand.New(rand.NewSource(fuzzSeed))
+
+	for _, msg := range fuzzMessages(t) {
+		msgType := byte(msg.GetType())
+
+		t.Run(fmt.Sprintf("type %d", msgType), func(t *testing.T) {
+			valid, err := encodeMessage(msg)
+			require.NoError(t, err)
+
+			// the valid encoding decodes to the original message
+			res, err := newMessageDecoder(msgType)(valid)
+			require.NoError(t, err)
+			require.Equal(t, msg.IDString(), res.IDString())
+
+			decodeWithoutPanic(t, msgType, nil)
+			for i := 0; i < fuzzIterations; i++ {
+				decodeWithoutPanic(t, msgType, mutate(r, valid))
+			}
+		})
+	}
+}
@@ -575,3 +575,131 @@ func TestDecodeConsensusMessage(t *testing.T) {
 	require.Equal(t, encMsg, encodedMessage[1:])
 
 }
+
+func TestFinalityProofMessages_EncodeDecode(t *testing.T) {
+	req := &FinalityProofRequestMessage{
+		ID:        7,
+		BlockHash: common.Hash{0xa, 0xb},
+	}
+
+	enc, err := req.Encode()
+	require.NoError(t, err)
+
+	res, err := decodeMessageBytes(enc)
+	require.NoError(t, err)
+	require.Equal(t, req, res)
+
+	resp := &FinalityProofResponseMessage{
+		ID:        7,
+		BlockHash: common.Hash{0xa, 0xb},
+		Proof:     []byte{1, 2, 3},
+	}
+
+	enc, err = resp.Encode()
+	require.NoError(t, err)
+
+	res, err = decodeMessageBytes(enc)
+	require.NoError(t, err)
+	require.Equal(t, resp, res)
+}
+
+func TestRemoteMessages_EncodeDecode(t *testing.T) {
+	msgs := []Message{
+		&RemoteReadRequestMessage{
+			ID:    1,
+			Block: common.Hash{0xa},
+			Keys:  [][]byte{{1, 2}, {3}},
+		},
+		&RemoteReadResponseMessage{
+			ID:    1,
+			Proof: [][]byte{{1, 2, 3}, {4}},
+		},
+		&RemoteCallRequestMessage{
+			ID:     2,
+			Block:  common.Hash{0xb},
+			Method: "Core_version",
+			Data:   []byte{1, 2},
+		},
+		&RemoteCallResponseMessage{
+			ID:     2,
+			Result: []byte{5, 6},
+			Proof:  [][]byte{{7}},
+		},
+		&RemoteHeaderRequestMessage{
+			ID:    3,
+			Block: 77,
+		},
+		&RemoteHeaderResponseMessage{
+			ID:     3,
+			Header: []byte{1, 2, 3},
+			Proof:  [][]byte{},
+		},
+		&RemoteChangesRequestMessage{
+			ID:         4,
+			FirstBlock: common.Hash{1},
+			LastBlock:  common.Hash{2},
+			Min:        common.Hash{3},
+			Max:        common.Hash{4},
+			Key:        []byte{9},
+		},
+		&RemoteChangesResponseMessage{
+			ID:    4,
+			Max:   99,
+			Proof: [][]byte{{8}},
+		},
+	}
+
+	for _, msg := range msgs {
+		enc, err := msg.Encode()
+		require.NoError(t, err)
+		require.Equal(t, byte(msg.GetType()), enc[0])
+
+		res, err := decodeMessageBytes(enc)
+		require.NoError(t, err)
+		require.Equal(t, msg, res)
+	}
+}
+
+func TestEncodeMessage_WithoutType(t *testing.T) {
+	msg := &BlockAnnounceMessage{
+		ParentHash: common.Hash{1},
+		Number:     big.NewInt(77),
+		Digest:     [][]byte{},
+	}
+
+	enc, err := encodeMessage(msg)
+	require.NoError(t, err)
+
+	full, err := msg.Encode()
+	require.NoError(t, err)
+	require.Equal(t, full[1:], enc)
+
+	res, err := newMessageDecoder(BlockAnnounceMsgType)(enc)
+	require.NoError(t, err)
+	require.Equal(t, msg, res)
+}
+
+func TestGrandpaCodec(t *testing.T) {
+	cm := &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              []byte{2, 1, 3, 5},
+	}
+
+	// GRANDPA messages are sent without the consensus message's type byte and engine ID
+	enc, err := grandpaCodec.encode(cm)
+	require.NoError(t, err)
+	require.Equal(t, cm.Data, enc)
+
+	msg, err := grandpaCodec.decode(enc)
+	require.NoError(t, err)
+	require.Equal(t, cm, msg)
+
+	_, err = grandpaCodec.encode(&ConsensusMessage{ConsensusEngineID: types.BabeEngineID, Data: []byte{1}})
+	require.Equal(t, errNotGrandpaMessage, err)
+
+	_, err = grandpaCodec.encode(TestMessage)
+	require.Equal(t, errNotGrandpaMessage, err)
+
+	_, err = grandpaCodec.decode([]byte{})
+	require.Error(t, err)
+}
@@ -0,0 +1,336 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// handshakeTimeout is how long we wait for a peer's handshake after opening or accepting a notifications substream
+var handshakeTimeout = 10 * time.Second
+
+// errInvalidStatus is returned when a peer's status handshake is not compatible with ours
+var errInvalidStatus = errors.New("invalid status message")
+
+// errInvalidRolesHandshake is returned when a peer's roles handshake is not a single byte
+var errInvalidRolesHandshake = errors.New("invalid roles handshake")
+
+// handshakeGetter returns our encoded handshake for a notifications protocol
+type handshakeGetter func() ([]byte, error)
+
+// handshakeValidator checks the encoded handshake received from a peer
+type handshakeValidator func(peer peer.ID, hs []byte) error
+
+// messageCodec encodes and decodes the messages sent on a notifications protocol
+type messageCodec struct {
+	encode func(msg Message) ([]byte, error)
+	decode messageDecoder
+}
+
+// newMessageCodec returns the codec for a protocol that only carries messages of the given type, which are sent
+// without their type byte
+func newMessageCodec(msgType byte) *messageCodec {
+	return &messageCodec{
+		encode: encodeMessage,
+		decode: newMessageDecoder(msgType),
+	}
+}
+
+// notificationsProtocol is a protocol used to send a single type of message to peers, such as block announces. to
+// send notifications to a peer, we open an outbound substream and send our handshake, then wait for the peer's
+// handshake before sending notifications. the peer does the same to send us notifications, so each substream is
+// only written to by the peer that opened it (after the handshake).
+type notificationsProtocol struct {
+	id                protocol.ID
+	msgType           byte
+	codec             *messageCodec
+	getHandshake      handshakeGetter
+	validateHandshake handshakeValidator
+	handler           func(peer peer.ID, msg Message)
+
+	outboundLock sync.Mutex                       // guards outbound and peerLocks, never held while waiting on a peer
+	outbound     map[peer.ID]libp2pnetwork.Stream // outbound substreams that have completed the handshake
+	peerLocks    map[peer.ID]*sync.Mutex          // held while opening or writing to the outbound substream to a peer
+}
+
+// peerLock returns the lock for our outbound substream to the peer
+func (np *notificationsProtocol) peerLock(p peer.ID) *sync.Mutex {
+	np.outboundLock.Lock()
+	defer np.outboundLock.Unlock()
+
+	lock, has := np.peerLocks[p]
+	if !has {
+		lock = new(sync.Mutex)
+		np.peerLocks[p] = lock
+	}
+
+	return lock
+}
+
+// stream returns our outbound substream to the peer, if it has been opened
+func (np *notificationsProtocol) stream(p peer.ID) (libp2pnetwork.Stream, bool) {
+	np.outboundLock.Lock()
+	defer np.outboundLock.Unlock()
+
+	stream, has := np.outbound[p]
+	return stream, has
+}
+
+// registerNotificationsProtocol registers a notifications protocol with the given ID for messages of the given type,
+// which are encoded with the given codec. notifications received from peers are passed to the handler.
+func (h *host) registerNotificationsProtocol(id protocol.ID, msgType byte, codec *messageCodec, getHandshake handshakeGetter, validateHandshake handshakeValidator, handler func(peer peer.ID, msg Message)) {
+	np := &notificationsProtocol{
+		id:                id,
+		msgType:           msgType,
+		codec:             codec,
+		getHandshake:      getHandshake,
+		validateHandshake: validateHandshake,
+		handler:           handler,
+		outbound:          make(map[peer.ID]libp2pnetwork.Stream),
+		peerLocks:         make(map[peer.ID]*sync.Mutex),
+	}
+
+	h.notificationsProtocols[int(msgType)] = np
+	h.h.SetStreamHandler(id, func(stream libp2pnetwork.Stream) {
+		h.handleNotificationsStream(np, stream)
+	})
+}
+
+// handleNotificationsStream handles inbound notifications substreams. it reads and validates the peer's handshake,
+// responds with ours, then reads notifications until the substream is closed or reset.
+func (h *host) handleNotificationsStream(np *notificationsProtocol, stream libp2pnetwork.Stream) {
+	conn := stream.Conn()
+	if conn == nil {
+		h.logger.Error("Failed to get connection from stream")
+		return
+	}
+
+	p := conn.RemotePeer()
+
+	// create buffer stream for non-blocking read
+	r := bufio.NewReader(stream)
+
+	err := h.acceptHandshake(np, stream, r)
+	if err != nil {
+		h.logger.Debug("Failed to accept notifications substream", "peer", p, "protocol", np.id, "error", err)
+		_ = stream.Reset()
+		return
+	}
+
+	// the stream stays open until closed or reset
+	go h.readDecodedStream(r, p, stream.Protocol(), np.msgType, np.codec.decode, compressionNone, np.handler)
+}
+
+// acceptHandshake reads and validates the handshake on an inbound substream, then responds with our handshake
+func (h *host) acceptHandshake(np *notificationsProtocol, stream libp2pnetwork.Stream, r *bufio.Reader) error {
+	theirs, err := h.readHandshake(stream, r)
+	if err != nil {
+		return err
+	}
+
+	ours, err := np.getHandshake()
+	if err != nil {
+		return err
+	}
+
+	err = np.validateHandshake(stream.Conn().RemotePeer(), theirs)
+	if err != nil {
+		return err
+	}
+
+	return h.writeBytesToStream(stream, ours)
+}
+
+// openNotificationsStream returns our outbound notifications substream to the peer, opening it and exchanging
+// handshakes if we haven't already
+func (h *host) openNotificationsStream(p peer.ID, np *notificationsProtocol) (libp2pnetwork.Stream, error) {
+	lock := np.peerLock(p)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return h.openNotificationsStreamLocked(p, np)
+}
+
+// openNotificationsStreamLocked is openNotificationsStream for callers holding the peer's lock. only the peer's lock is
+// held while we wait for the handshake, so opening a substream doesn't hold up notifications to other peers.
+func (h *host) openNotificationsStreamLocked(p peer.ID, np *notificationsProtocol) (libp2pnetwork.Stream, error) {
+	if stream, has := np.stream(p); has {
+		return stream, nil
+	}
+
+	// substreams are only opened on existing connections, so that we don't redial peers that have disconnected or
+	// rejected us
+	ctx, cancel := context.WithTimeout(h.ctx, handshakeTimeout)
+	defer cancel()
+	ctx = libp2pnetwork.WithNoDial(ctx, "notifications substream")
+
+	stream, err := h.h.NewStream(ctx, p, np.id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.sendHandshake(np, stream)
+	if err != nil {
+		_ = stream.Reset()
+		return nil, err
+	}
+
+	h.logger.Trace(
+		"Opened notifications substream",
+		"host", h.id(),
+		"peer", p,
+		"protocol", stream.Protocol(),
+	)
+
+	np.outboundLock.Lock()
+	defer np.outboundLock.Unlock()
+
+	// the peer's lock is replaced if it disconnects, so a substream may have been opened since it reconnected
+	if existing, has := np.outbound[p]; has {
+		_ = stream.Reset()
+		return existing, nil
+	}
+
+	np.outbound[p] = stream
+	return stream, nil
+}
+
+// sendHandshake sends our handshake on an outbound substream, then reads and validates the peer's handshake
+func (h *host) sendHandshake(np *notificationsProtocol, stream libp2pnetwork.Stream) error {
+	ours, err := np.getHandshake()
+	if err != nil {
+		return err
+	}
+
+	err = h.writeBytesToStream(stream, ours)
+	if err != nil {
+		return err
+	}
+
+	theirs, err := h.readHandshake(stream, bufio.NewReader(stream))
+	if err != nil {
+		return err
+	}
+
+	return np.validateHandshake(stream.Conn().RemotePeer(), theirs)
+}
+
+// readHandshake reads a handshake from the substream, failing if the peer doesn't send it in time
+func (h *host) readHandshake(stream libp2pnetwork.Stream, r *bufio.Reader) ([]byte, error) {
+	err := stream.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stream.SetReadDeadline(time.Time{})
+	}()
+
+	return h.readFromStream(r, maxHandshakeSize)
+}
+
+// sendNotification sends the message to the peer on our outbound substream for the notifications protocol. the
+// peer's lock is held while writing, so that concurrent notifications to the peer aren't interleaved.
+func (h *host) sendNotification(p peer.ID, np *notificationsProtocol, msg Message) error {
+	lock := np.peerLock(p)
+	lock.Lock()
+	defer lock.Unlock()
+
+	stream, err := h.openNotificationsStreamLocked(p, np)
+	if err != nil {
+		return err
+	}
+
+	enc, err := np.codec.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	err = h.writeEncodedToStream(stream, msg, enc)
+	if err != nil {
+		// the substream is unusable, a new one will be opened for the next notification
+		np.outboundLock.Lock()
+		np.resetStream(p)
+		np.outboundLock.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// sendNotifications sends the message to the peers concurrently, marking it as known to each peer once it has been
+// sent. opening a substream waits on the peer's handshake, so peers we don't have a substream to yet are sent the
+// message in the background once it is open, rather than holding up the other peers.
+func (h *host) sendNotifications(peers []peer.ID, np *notificationsProtocol, msg Message) {
+	send := func(p peer.ID) {
+		err := h.sendNotification(p, np, msg)
+		if err != nil {
+			h.logger.Error("Failed to send message during broadcast", "peer", p, "protocol", np.id, "err", err)
+			return
+		}
+
+		h.known.add(p, msg.IDString())
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		if _, open := np.stream(p); !open {
+			go send(p)
+			continue
+		}
+
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			send(p)
+		}(p)
+	}
+
+	wg.Wait()
+}
+
+// resetStream resets and forgets our outbound substream to the peer. the outbound lock must be held.
+func (np *notificationsProtocol) resetStream(p peer.ID) {
+	if stream, has := np.outbound[p]; has {
+		_ = stream.Reset()
+		delete(np.outbound, p)
+	}
+}
+
+// closeNotificationsStream resets our outbound substream to the peer for the notifications protocol
+func (h *host) closeNotificationsStream(p peer.ID, np *notificationsProtocol) {
+	np.outboundLock.Lock()
+	defer np.outboundLock.Unlock()
+
+	np.resetStream(p)
+	delete(np.peerLocks, p)
+}
+
+// closeNotificationsStreams resets our outbound substreams to the peer for every notifications protocol
+func (h *host) closeNotificationsStreams(p peer.ID) {
+	for _, np := range h.notificationsProtocols {
+		h.closeNotificationsStream(p, np)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStatusHandshake(t *testing.T) {
+	basePath := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	s := createTestService(t, &Config{
+		BasePath:    basePath,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+	})
+	defer s.Stop()
+
+	hs, err := s.getStatusHandshake()
+	require.NoError(t, err)
+
+	msg, err := newMessageDecoder(StatusMsgType)(hs)
+	require.NoError(t, err)
+	require.Equal(t, s.blockState.GenesisHash(), msg.(*StatusMessage).GenesisHash)
+
+	peerID := peer.ID("noot")
+	err = s.validateStatusHandshake(peerID, hs)
+	require.NoError(t, err)
+	require.True(t, s.status.confirmed(peerID))
+
+	// a peer on a different chain is rejected
+	status := msg.(*StatusMessage)
+	status.GenesisHash = common.Hash{1}
+	hs, err = encodeMessage(status)
+	require.NoError(t, err)
+
+	other := peer.ID("other")
+	err = s.validateStatusHandshake(other, hs)
+	require.Equal(t, errInvalidStatus, err)
+	require.False(t, s.status.confirmed(other))
+}
+
+func TestValidateRolesHandshake(t *testing.T) {
+	basePath := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	s := createTestService(t, &Config{
+		BasePath:    basePath,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		Roles:       4,
+	})
+	defer s.Stop()
+
+	hs, err := s.getRolesHandshake()
+	require.NoError(t, err)
+	require.Equal(t, []byte{4}, hs)
+
+	require.NoError(t, s.validateRolesHandshake(peer.ID("noot"), hs))
+	require.Equal(t, errInvalidRolesHandshake, s.validateRolesHandshake(peer.ID("noot"), []byte{}))
+}
+
+func TestSendNotifications(t *testing.T) {
+	basePathA := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	nodeA := createTestService(t, &Config{
+		BasePath:    basePathA,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+	})
+	defer nodeA.Stop()
+
+	nodeA.noGossip = true
+	nodeA.noStatus = true
+
+	msgSendB := make(chan Message, 1)
+	nodeB := createTestService(t, &Config{
+		BasePath:    utils.NewTestBasePath(t, "nodeB"),
+		Port:        7002,
+		RandSeed:    2,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		MsgSend:     msgSendB,
+	})
+	defer nodeB.Stop()
+
+	nodeB.noGossip = true
+	nodeB.noStatus = true
+
+	addrInfosB, err := nodeB.host.addrInfos()
+	require.NoError(t, err)
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(*addrInfosB[0])
+	}
+	require.NoError(t, err)
+
+	np := nodeA.host.notificationsProtocols[TransactionMsgType]
+
+	// a substream being opened to another peer doesn't hold up notifications to node B
+	other := peer.ID("noot")
+	np.peerLock(other).Lock()
+	defer np.peerLock(other).Unlock()
+
+	_, err = nodeA.host.openNotificationsStream(nodeB.host.id(), np)
+	require.NoError(t, err)
+
+	// we have no substream to the other peer, so it's sent the message in the background rather than holding up node
+	// B, and the message isn't known to it until it has been sent
+	nodeA.host.sendNotifications([]peer.ID{nodeB.host.id(), other}, np, TestMessage)
+
+	select {
+	case <-msgSendB:
+	case <-time.After(TestMessageTimeout):
+		t.Fatal("node B timeout waiting for message")
+	}
+
+	require.True(t, nodeA.host.known.has(nodeB.host.id(), TestMessage.IDString()))
+	require.False(t, nodeA.host.known.has(other, TestMessage.IDString()))
+
+	_, open := np.stream(other)
+	require.False(t, open)
+}
+
+func TestBroadcast_Grandpa(t *testing.T) {
+	basePathA := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	nodeA := createTestService(t, &Config{
+		BasePath:    basePathA,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+	})
+	defer nodeA.Stop()
+
+	nodeA.noGossip = true
+	nodeA.noStatus = true
+
+	msgSendB := make(chan Message, 1)
+	nodeB := createTestService(t, &Config{
+		BasePath:    utils.NewTestBasePath(t, "nodeB"),
+		Port:        7002,
+		RandSeed:    2,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		MsgSend:     msgSendB,
+	})
+	defer nodeB.Stop()
+
+	nodeB.noGossip = true
+	nodeB.noStatus = true
+
+	addrInfosB, err := nodeB.host.addrInfos()
+	require.NoError(t, err)
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(*addrInfosB[0])
+	}
+	require.NoError(t, err)
+
+	// consensus messages for other engines aren't sent on the GRANDPA protocol
+	nodeA.host.broadcast(&ConsensusMessage{ConsensusEngineID: types.BabeEngineID, Data: []byte{1}})
+
+	select {
+	case msg := <-msgSendB:
+		t.Fatalf("node B received unexpected message %s", msg)
+	case <-time.After(TestMessageTimeout / 10):
+	}
+
+	cm := &ConsensusMessage{ConsensusEngineID: types.GrandpaEngineID, Data: []byte{2, 1, 3, 5}}
+	nodeA.host.broadcast(cm)
+
+	select {
+	case msg := <-msgSendB:
+		require.Equal(t, cm, msg)
+	case <-time.After(TestMessageTimeout):
+		t.Fatal("node B timeout waiting for message")
+	}
+
+	stream, open := nodeA.host.notificationsProtocols[ConsensusMsgType].stream(nodeB.host.id())
+	require.True(t, open)
+	require.Equal(t, protocol.ID("/paritytech/grandpa/1"), stream.Protocol())
+}
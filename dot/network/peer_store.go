@@ -0,0 +1,220 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DefaultPeersFile is the file in the base path that the peers we know about are saved to
+const DefaultPeersFile = "peers.json"
+
+// maxStoredPeers is the maximum number of peers saved to the peers file, the most recently seen are kept
+const maxStoredPeers = 1000
+
+// storedPeerExpiry is how long a peer is kept in the peers file after we last saw it
+var storedPeerExpiry = 7 * 24 * time.Hour
+
+// savePeersInterval is how often the peers file is saved while the service is running
+var savePeersInterval = time.Minute
+
+// storedPeer is a peer as saved in the peers file
+type storedPeer struct {
+	ID         string    `json:"id"`
+	Addrs      []string  `json:"addrs"`
+	LastSeen   time.Time `json:"lastSeen"`
+	Reputation int32     `json:"reputation"`
+}
+
+// peerStore keeps the addresses, last-seen times and reputations of the peers we know about, so that they can be
+// saved to the base path and redialled when the node restarts
+type peerStore struct {
+	sync.Mutex
+	path  string
+	peers map[peer.ID]*storedPeer
+}
+
+func newPeerStore(basePath string) *peerStore {
+	return &peerStore{
+		path:  path.Join(filepath.Clean(basePath), DefaultPeersFile),
+		peers: make(map[peer.ID]*storedPeer),
+	}
+}
+
+// load reads the peers file, if there is one, adding the peers' addresses to the host's peerstore and restoring
+// their reputations. peers that haven't been seen since storedPeerExpiry are dropped.
+func (ps *peerStore) load(h *host) error {
+	data, err := ioutil.ReadFile(ps.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored []*storedPeer
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	now := time.Now()
+	for _, sp := range stored {
+		p, err := peer.IDB58Decode(sp.ID)
+		if err != nil || p == h.id() || now.Sub(sp.LastSeen) > storedPeerExpiry {
+			continue
+		}
+
+		addrs := []ma.Multiaddr{}
+		for _, a := range sp.Addrs {
+			addr, err := ma.NewMultiaddr(a)
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+
+		if len(addrs) == 0 {
+			continue
+		}
+
+		h.h.Peerstore().AddAddrs(p, addrs, peerstore.AddressTTL)
+		h.reputations.restore(p, sp.Reputation)
+		ps.peers[p] = sp
+	}
+
+	return nil
+}
+
+// seen records that we are, or were just, connected to the peer
+func (ps *peerStore) seen(p peer.ID) {
+	ps.Lock()
+	defer ps.Unlock()
+
+	sp, has := ps.peers[p]
+	if !has {
+		sp = &storedPeer{
+			ID: p.Pretty(),
+		}
+		ps.peers[p] = sp
+	}
+
+	sp.LastSeen = time.Now()
+}
+
+// save updates the peers' addresses and reputations from the host and writes the most recently seen peers to the
+// peers file
+func (ps *peerStore) save(h *host) error {
+	ps.Lock()
+
+	stored := []*storedPeer{}
+	now := time.Now()
+	for p, sp := range ps.peers {
+		if now.Sub(sp.LastSeen) > storedPeerExpiry {
+			delete(ps.peers, p)
+			continue
+		}
+
+		// addresses are only kept in the peerstore for a while after we disconnect, so we keep the last known ones
+		if addrs := h.h.Peerstore().Addrs(p); len(addrs) > 0 {
+			sp.Addrs = sp.Addrs[:0]
+			for _, addr := range addrs {
+				sp.Addrs = append(sp.Addrs, addr.String())
+			}
+		}
+
+		if len(sp.Addrs) == 0 {
+			continue
+		}
+
+		sp.Reputation = h.reputations.value(p)
+		stored = append(stored, sp)
+	}
+
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].LastSeen.After(stored[j].LastSeen)
+	})
+
+	if len(stored) > maxStoredPeers {
+		stored = stored[:maxStoredPeers]
+	}
+
+	data, err := json.Marshal(stored)
+	ps.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that the peers file is never left partially written
+	tmp := ps.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, ps.path)
+}
+
+// best returns up to n of the stored peers to redial on startup, ordered by reputation and then by how recently we
+// saw them. peers with negative reputations are skipped.
+func (ps *peerStore) best(h *host, n int) []peer.ID {
+	ps.Lock()
+	defer ps.Unlock()
+
+	type candidate struct {
+		id         peer.ID
+		reputation int32
+		lastSeen   time.Time
+	}
+
+	candidates := []candidate{}
+	for p, sp := range ps.peers {
+		rep := h.reputations.value(p)
+		if rep < 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{p, rep, sp.LastSeen})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].reputation != candidates[j].reputation {
+			return candidates[i].reputation > candidates[j].reputation
+		}
+		return candidates[i].lastSeen.After(candidates[j].lastSeen)
+	})
+
+	peers := []peer.ID{}
+	for i := 0; i < len(candidates) && i < n; i++ {
+		peers = append(peers, candidates[i].id)
+	}
+
+	return peers
+}
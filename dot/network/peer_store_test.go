@@ -0,0 +1,111 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerStore_SaveLoad(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{}, &Config{})
+	nodeA, nodeB, nodeC := nodes[0], nodes[1], nodes[2]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	connectTestPeer(t, nodeA, nodeC)
+	time.Sleep(TestMessageTimeout)
+
+	nodeA.ReportPeer(nodeB.host.id(), ReputationChange{Value: 1 << 20, Reason: "test"})
+	nodeA.ReportPeer(nodeC.host.id(), BadBlockResponse)
+
+	err := nodeA.peerStore.save(nodeA.host)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(path.Join(nodeA.cfg.BasePath, DefaultPeersFile))
+	require.NoError(t, err)
+	require.Contains(t, string(data), nodeB.host.id().Pretty())
+
+	// loading the file restores the peers' addresses and reputations
+	ps := newPeerStore(nodeA.cfg.BasePath)
+	nodeA.host.reputations = newReputations()
+	err = ps.load(nodeA.host)
+	require.NoError(t, err)
+	require.Len(t, ps.peers, 2)
+
+	stored := ps.peers[nodeB.host.id()]
+	require.NotNil(t, stored)
+	require.NotEmpty(t, stored.Addrs)
+	require.WithinDuration(t, time.Now(), stored.LastSeen, time.Minute)
+	require.Greater(t, nodeA.host.reputations.value(nodeB.host.id()), int32(0))
+	require.Less(t, nodeA.host.reputations.value(nodeC.host.id()), int32(0))
+
+	// peers with negative reputations aren't redialled
+	require.Equal(t, []peer.ID{nodeB.host.id()}, ps.best(nodeA.host, 5))
+
+	// expired peers are dropped when the file is loaded
+	ps.peers[nodeB.host.id()].LastSeen = time.Now().Add(-storedPeerExpiry - time.Hour)
+	ps.peers[nodeC.host.id()].LastSeen = time.Now().Add(-storedPeerExpiry - time.Hour)
+	err = ps.save(nodeA.host)
+	require.NoError(t, err)
+
+	ps = newPeerStore(nodeA.cfg.BasePath)
+	err = ps.load(nodeA.host)
+	require.NoError(t, err)
+	require.Empty(t, ps.peers)
+}
+
+func TestPeerStore_RedialOnRestart(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{})
+	nodeA, nodeB := nodes[0], nodes[1]
+	defer nodeB.Stop()
+
+	connectTestPeer(t, nodeA, nodeB)
+	time.Sleep(TestMessageTimeout)
+
+	// stopping the service saves the peers file
+	err := nodeA.Stop()
+	require.NoError(t, err)
+	time.Sleep(TestMessageTimeout)
+	require.False(t, nodeB.host.peerConnected(nodeA.host.id()))
+
+	// the restarted node redials node B without being told about it
+	nodeA = createTestService(t, &Config{
+		BasePath: nodeA.cfg.BasePath,
+		Port:     nodeA.cfg.Port,
+		RandSeed: nodeA.cfg.RandSeed,
+		MinPeers: 1,
+		NoMDNS:   true,
+	})
+	defer nodeA.Stop()
+
+	time.Sleep(TestMessageTimeout)
+	require.True(t, nodeA.host.peerConnected(nodeB.host.id()))
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// peerView is what we know about a peer's chain, from its status handshake and the blocks it has announced since
+type peerView struct {
+	roles           byte
+	protocolVersion uint32
+	bestHash        common.Hash
+	bestNumber      uint64
+	finalizedNumber uint64
+}
+
+// peerViews tracks the chain views of the peers whose status we've confirmed
+type peerViews struct {
+	sync.RWMutex
+	views map[peer.ID]*peerView
+}
+
+func newPeerViews() *peerViews {
+	return &peerViews{
+		views: make(map[peer.ID]*peerView),
+	}
+}
+
+// handleStatus sets the peer's view from its status message
+func (pv *peerViews) handleStatus(p peer.ID, msg *StatusMessage) {
+	pv.Lock()
+	defer pv.Unlock()
+
+	pv.views[p] = &peerView{
+		roles:           msg.Roles,
+		protocolVersion: msg.ProtocolVersion,
+		bestHash:        msg.BestBlockHash,
+		bestNumber:      msg.BestBlockNumber,
+		finalizedNumber: chainStatusFinalized(msg),
+	}
+}
+
+// handleBlockAnnounce updates the peer's best block if the announced block is higher. announces from peers whose
+// status we haven't confirmed are ignored.
+func (pv *peerViews) handleBlockAnnounce(p peer.ID, msg *BlockAnnounceMessage) {
+	pv.Lock()
+	defer pv.Unlock()
+
+	view, has := pv.views[p]
+	if !has || !msg.Number.IsUint64() || msg.Number.Uint64() <= view.bestNumber {
+		return
+	}
+
+	header, err := types.NewHeader(msg.ParentHash, msg.Number, msg.StateRoot, msg.ExtrinsicsRoot, msg.Digest)
+	if err != nil {
+		return
+	}
+
+	view.bestHash = header.Hash()
+	view.bestNumber = msg.Number.Uint64()
+}
+
+// get returns a copy of the peer's view
+func (pv *peerViews) get(p peer.ID) (peerView, bool) {
+	pv.RLock()
+	defer pv.RUnlock()
+
+	view, has := pv.views[p]
+	if !has {
+		return peerView{}, false
+	}
+
+	return *view, true
+}
+
+// remove forgets the peer's view, once we're disconnected from it
+func (pv *peerViews) remove(p peer.ID) {
+	pv.Lock()
+	defer pv.Unlock()
+	delete(pv.views, p)
+}
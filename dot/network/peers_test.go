@@ -0,0 +1,130 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainStatus(t *testing.T) {
+	msg := &StatusMessage{
+		ChainStatus: encodeChainStatus(compressionSnappy, 77),
+	}
+	require.Equal(t, compressionSnappy, chainStatusCompression(msg))
+	require.Equal(t, uint64(77), chainStatusFinalized(msg))
+
+	// older peers only send the compression byte, or a zero byte
+	msg.ChainStatus = []byte{compressionZstd}
+	require.Equal(t, compressionZstd, chainStatusCompression(msg))
+	require.Zero(t, chainStatusFinalized(msg))
+}
+
+func TestPeerViews(t *testing.T) {
+	pv := newPeerViews()
+	p := peer.ID("noot")
+
+	announce := &BlockAnnounceMessage{
+		Number: big.NewInt(12),
+		Digest: [][]byte{},
+	}
+
+	// announces from peers without a status are ignored
+	pv.handleBlockAnnounce(p, announce)
+	_, has := pv.get(p)
+	require.False(t, has)
+
+	pv.handleStatus(p, &StatusMessage{
+		ProtocolVersion: 2,
+		Roles:           4,
+		BestBlockNumber: 10,
+		BestBlockHash:   common.Hash{1},
+		ChainStatus:     encodeChainStatus(compressionNone, 8),
+	})
+
+	view, has := pv.get(p)
+	require.True(t, has)
+	require.Equal(t, peerView{
+		roles:           4,
+		protocolVersion: 2,
+		bestHash:        common.Hash{1},
+		bestNumber:      10,
+		finalizedNumber: 8,
+	}, view)
+
+	// announces of higher blocks update the peer's best block
+	pv.handleBlockAnnounce(p, announce)
+	header, err := types.NewHeader(announce.ParentHash, announce.Number, announce.StateRoot, announce.ExtrinsicsRoot, announce.Digest)
+	require.NoError(t, err)
+
+	view, _ = pv.get(p)
+	require.Equal(t, uint64(12), view.bestNumber)
+	require.Equal(t, header.Hash(), view.bestHash)
+
+	pv.handleBlockAnnounce(p, &BlockAnnounceMessage{Number: big.NewInt(11)})
+	view, _ = pv.get(p)
+	require.Equal(t, uint64(12), view.bestNumber)
+
+	pv.remove(p)
+	_, has = pv.get(p)
+	require.False(t, has)
+}
+
+func TestService_Peers(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{BlockState: newMockBlockState(big.NewInt(3))})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	time.Sleep(TestMessageTimeout)
+
+	peers := nodeA.Peers()
+	require.Len(t, peers, 1)
+	require.Equal(t, nodeB.host.id().String(), peers[0].PeerID)
+	require.Equal(t, nodeB.cfg.Roles, peers[0].Roles)
+	require.Equal(t, uint64(3), peers[0].BestNumber)
+
+	best, err := nodeB.blockState.BestBlockHeader()
+	require.NoError(t, err)
+	require.Equal(t, best.Hash(), peers[0].BestHash)
+
+	// node B announces a higher block
+	announce := &BlockAnnounceMessage{
+		Number: big.NewInt(4),
+		Digest: [][]byte{},
+	}
+	nodeA.handleMessage(nodeB.host.id(), announce)
+	require.Equal(t, uint64(4), nodeA.Peers()[0].BestNumber)
+
+	// the view is removed once the peer disconnects
+	err = nodeA.host.closePeer(nodeB.host.id())
+	require.NoError(t, err)
+	time.Sleep(TestMessageTimeout / 10)
+	require.Empty(t, nodeA.Peers())
+}
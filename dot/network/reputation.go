@@ -0,0 +1,213 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// BannedThreshold is the reputation below which a peer is disconnected and banned
+const BannedThreshold = 82 * (math.MinInt32 / 100)
+
+// reputationDecayInterval is how often a peer's reputation decays towards zero
+const reputationDecayInterval = time.Second
+
+// reputationDecayDivisor is the fraction of a peer's reputation that is removed at each decay interval
+const reputationDecayDivisor = 50
+
+// banDuration is how long a peer is banned for once its reputation falls below BannedThreshold
+var banDuration = 5 * time.Minute
+
+// errPeerBanned is returned when trying to connect to a banned peer
+var errPeerBanned = errors.New("peer is banned")
+
+// ReputationChange is a change to a peer's reputation, reported when the peer behaves well or badly
+type ReputationChange struct {
+	Value  int32
+	Reason string
+}
+
+// reputation changes reported by the network service and by the services that handle the messages it receives
+var (
+	// UndecodableMessage is reported when a peer sends a message that cannot be decoded
+	UndecodableMessage = ReputationChange{Value: -(1 << 12), Reason: "undecodable message"}
+	// OversizedMessage is reported when a peer sends a message that is larger than its protocol allows
+	OversizedMessage = ReputationChange{Value: -(1 << 16), Reason: "oversized message"}
+	// ExcessiveMessages is reported for each message a peer sends beyond its inbound rate limit
+	ExcessiveMessages = ReputationChange{Value: -(1 << 8), Reason: "excessive messages"}
+	// BadHandshake is reported when a peer sends an invalid notifications substream handshake
+	BadHandshake = ReputationChange{Value: -(1 << 12), Reason: "bad handshake"}
+	// GenesisMismatch is reported when a peer's status handshake is for a different chain
+	GenesisMismatch = ReputationChange{Value: math.MinInt32, Reason: "genesis mismatch"}
+	// BadBlockResponse is reported when a peer responds to a block request with blocks that cannot be verified
+	BadBlockResponse = ReputationChange{Value: -(1 << 29), Reason: "bad block response"}
+	// BadBlockAnnounce is reported when a peer announces a block whose header cannot be decoded
+	BadBlockAnnounce = ReputationChange{Value: -(1 << 12), Reason: "bad block announce"}
+	// BadTransaction is reported when a peer sends a transaction that fails validation
+	BadTransaction = ReputationChange{Value: -(1 << 12), Reason: "bad transaction"}
+	// BadConsensusMessage is reported when a peer sends a consensus message that is invalid, eg. badly signed
+	BadConsensusMessage = ReputationChange{Value: -(1 << 16), Reason: "bad consensus message"}
+)
+
+// reputation is a peer's reputation, as of the last time it was updated
+type reputation struct {
+	value       int32
+	updated     time.Time
+	bannedUntil time.Time
+}
+
+// decay moves the reputation towards zero for each decay interval that has passed since it was last updated
+func (r *reputation) decay(now time.Time) {
+	intervals := int64(now.Sub(r.updated) / reputationDecayInterval)
+	if intervals <= 0 {
+		return
+	}
+
+	r.updated = r.updated.Add(time.Duration(intervals) * reputationDecayInterval)
+
+	for ; intervals > 0 && r.value != 0; intervals-- {
+		diff := r.value / reputationDecayDivisor
+		switch {
+		case diff == 0 && r.value < 0:
+			diff = -1
+		case diff == 0:
+			diff = 1
+		}
+
+		r.value -= diff
+	}
+}
+
+// reputations tracks the reputations of the peers we've received reports about
+type reputations struct {
+	sync.Mutex
+	peers map[peer.ID]*reputation
+}
+
+func newReputations() *reputations {
+	return &reputations{
+		peers: make(map[peer.ID]*reputation),
+	}
+}
+
+// report applies the reputation change to the peer. it returns the peer's new reputation, and whether the peer has
+// just been banned because its reputation fell below BannedThreshold.
+func (r *reputations) report(p peer.ID, change ReputationChange) (int32, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	rep, has := r.peers[p]
+	if !has {
+		rep = &reputation{
+			updated: now,
+		}
+		r.peers[p] = rep
+	}
+
+	rep.decay(now)
+	rep.value = saturatingAdd(rep.value, change.Value)
+
+	if rep.value >= BannedThreshold || now.Before(rep.bannedUntil) {
+		return rep.value, false
+	}
+
+	rep.bannedUntil = now.Add(banDuration)
+	return rep.value, true
+}
+
+// value returns the peer's current reputation
+func (r *reputations) value(p peer.ID) int32 {
+	r.Lock()
+	defer r.Unlock()
+
+	rep, has := r.peers[p]
+	if !has {
+		return 0
+	}
+
+	rep.decay(time.Now())
+	return rep.value
+}
+
+// restore sets the reputation of a peer that was saved to the peers file. peers saved below BannedThreshold are
+// banned again for banDuration.
+func (r *reputations) restore(p peer.ID, value int32) {
+	if value == 0 {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	rep := &reputation{
+		value:   value,
+		updated: now,
+	}
+
+	if value < BannedThreshold {
+		rep.bannedUntil = now.Add(banDuration)
+	}
+
+	r.peers[p] = rep
+}
+
+// banned returns true if the peer is currently banned
+func (r *reputations) banned(p peer.ID) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	rep, has := r.peers[p]
+	if !has {
+		return false
+	}
+
+	return time.Now().Before(rep.bannedUntil)
+}
+
+// prune removes the peers whose reputations have decayed to zero and that are no longer banned
+func (r *reputations) prune() {
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	for p, rep := range r.peers {
+		rep.decay(now)
+		if rep.value == 0 && !now.Before(rep.bannedUntil) {
+			delete(r.peers, p)
+		}
+	}
+}
+
+// saturatingAdd returns a + b, clamped to the range of an int32
+func saturatingAdd(a, b int32) int32 {
+	sum := int64(a) + int64(b)
+	switch {
+	case sum > math.MaxInt32:
+		return math.MaxInt32
+	case sum < math.MinInt32:
+		return math.MinInt32
+	}
+
+	return int32(sum)
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReputation_Decay(t *testing.T) {
+	now := time.Now()
+
+	rep := &reputation{value: -1000, updated: now}
+	rep.decay(now.Add(reputationDecayInterval / 2))
+	require.Equal(t, int32(-1000), rep.value)
+
+	rep.decay(now.Add(reputationDecayInterval))
+	require.Equal(t, int32(-980), rep.value)
+
+	rep = &reputation{value: 10, updated: now}
+	rep.decay(now.Add(2 * reputationDecayInterval))
+	require.Equal(t, int32(8), rep.value)
+
+	rep = &reputation{value: math.MinInt32, updated: now}
+	rep.decay(now.Add(time.Hour))
+	require.Equal(t, int32(0), rep.value)
+}
+
+func TestReputations_Report(t *testing.T) {
+	r := newReputations()
+	p := peer.ID("noot")
+
+	for i := 0; i < 3; i++ {
+		_, banned := r.report(p, BadBlockResponse)
+		require.False(t, banned)
+	}
+	require.False(t, r.banned(p))
+
+	value, banned := r.report(p, BadBlockResponse)
+	require.True(t, banned)
+	require.True(t, r.banned(p))
+	require.Equal(t, int32(math.MinInt32), value)
+
+	// the peer is only banned once
+	_, banned = r.report(p, BadBlockResponse)
+	require.False(t, banned)
+	require.Equal(t, int32(math.MinInt32), r.value(p))
+}
+
+func TestReputations_Prune(t *testing.T) {
+	r := newReputations()
+	good := peer.ID("good")
+	bad := peer.ID("bad")
+
+	r.report(good, BadTransaction)
+	r.report(bad, GenesisMismatch)
+	r.peers[good].updated = time.Now().Add(-time.Hour)
+	r.peers[bad].updated = time.Now().Add(-time.Hour)
+
+	r.prune()
+	require.NotContains(t, r.peers, good)
+	require.Contains(t, r.peers, bad)
+	require.True(t, r.banned(bad))
+	require.Equal(t, int32(0), r.value(bad))
+}
+
+func TestSaturatingAdd(t *testing.T) {
+	require.Equal(t, int32(math.MinInt32), saturatingAdd(math.MinInt32+1, -2))
+	require.Equal(t, int32(math.MaxInt32), saturatingAdd(math.MaxInt32-1, 2))
+	require.Equal(t, int32(-1), saturatingAdd(1, -2))
+}
+
+func TestService_ReportPeer(t *testing.T) {
+	basePathA := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	configA := &Config{
+		BasePath:    basePathA,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+	}
+
+	nodeA := createTestService(t, configA)
+	defer nodeA.Stop()
+
+	basePathB := utils.NewTestBasePath(t, "nodeB")
+
+	configB := &Config{
+		BasePath:    basePathB,
+		Port:        7002,
+		RandSeed:    2,
+		NoBootstrap: true,
+		NoMDNS:      true,
+	}
+
+	nodeB := createTestService(t, configB)
+	defer nodeB.Stop()
+
+	addrInfosB, err := nodeB.host.addrInfos()
+	require.NoError(t, err)
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(*addrInfosB[0])
+	}
+	require.NoError(t, err)
+
+	nodeA.ReportPeer(nodeB.host.id(), BadTransaction)
+	require.Equal(t, BadTransaction.Value, nodeA.host.reputations.value(nodeB.host.id()))
+	require.True(t, nodeA.host.peerConnected(nodeB.host.id()))
+
+	// the peer is disconnected and can't be reconnected to while banned
+	nodeA.ReportPeer(nodeB.host.id(), GenesisMismatch)
+	require.False(t, nodeA.host.peerConnected(nodeB.host.id()))
+
+	err = nodeA.host.connect(*addrInfosB[0])
+	require.Equal(t, errPeerBanned, err)
+}
+
+func TestHandleMessage_Reputation(t *testing.T) {
+	basePath := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	msgSend := make(chan Message, 4)
+	validator := &mockGossipValidator{
+		err: errors.New("bad signature"),
+	}
+	transactionHandler := &mockTransactionHandler{
+		err: errors.New("invalid transaction"),
+	}
+
+	config := &Config{
+		BasePath:           basePath,
+		Port:               7001,
+		RandSeed:           1,
+		NoBootstrap:        true,
+		NoMDNS:             true,
+		NoStatus:           true,
+		MsgSend:            msgSend,
+		GossipValidator:    validator,
+		TransactionHandler: transactionHandler,
+	}
+
+	s := createTestService(t, config)
+	defer s.Stop()
+
+	peerID := peer.ID("noot")
+	s.handleMessage(peerID, &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              []byte{0, 1, 2},
+	})
+	require.Equal(t, BadConsensusMessage.Value, s.host.reputations.value(peerID))
+
+	s.handleMessage(peerID, TestMessage)
+	require.Equal(t, BadConsensusMessage.Value+BadTransaction.Value, s.host.reputations.value(peerID))
+
+	// invalid messages aren't sent to the core service
+	select {
+	case <-msgSend:
+		t.Fatal("should not have sent invalid message to core service")
+	case <-time.After(TestMessageTimeout / 10):
+	}
+}
+
+func TestHandleSyncMessage_BadBlockResponse(t *testing.T) {
+	basePath := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	syncer := newMockSyncer()
+	syncer.responseErr = errors.New("invalid block")
+
+	config := &Config{
+		BasePath:    basePath,
+		Port:        7001,
+		RandSeed:    1,
+		NoBootstrap: true,
+		NoMDNS:      true,
+		NoStatus:    true,
+		Syncer:      syncer,
+	}
+
+	s := createTestService(t, config)
+	defer s.Stop()
+
+	peerID := peer.ID("noot")
+	s.requestTracker.addRequestedBlockID(17)
+	s.handleSyncMessage(peerID, &BlockResponseMessage{ID: 17})
+	require.Equal(t, BadBlockResponse.Value, s.host.reputations.value(peerID))
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// pendingRequests tracks the requests we've sent that are waiting for a response. each request is given an ID, which
+// the peer includes in its response, so that the response can be delivered to the request it answers.
+type pendingRequests struct {
+	sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan Message
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		pending: make(map[uint64]chan Message),
+	}
+}
+
+// add returns a new request ID and the channel its response will be delivered on
+func (r *pendingRequests) add() (uint64, chan Message) {
+	r.Lock()
+	defer r.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	ch := make(chan Message, 1)
+	r.pending[id] = ch
+	return id, ch
+}
+
+func (r *pendingRequests) remove(id uint64) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.pending, id)
+}
+
+// deliver sends the response to the request with the given ID, if we're still waiting for it
+func (r *pendingRequests) deliver(id uint64, resp Message) {
+	r.Lock()
+	defer r.Unlock()
+
+	ch, has := r.pending[id]
+	if !has {
+		return
+	}
+
+	delete(r.pending, id)
+	ch <- resp
+}
+
+// wait waits for the response to be delivered on the request's channel, returning the given error if it isn't
+// delivered before the timeout
+func (r *pendingRequests) wait(ch chan Message, timeout time.Duration, timeoutErr error) (Message, error) {
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-time.After(timeout):
+		return nil, timeoutErr
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingRequests(t *testing.T) {
+	r := newPendingRequests()
+	errTimeout := errors.New("timeout")
+
+	id0, ch0 := r.add()
+	id1, ch1 := r.add()
+	require.NotEqual(t, id0, id1)
+
+	// responses are delivered to the request they answer
+	resp := &RemoteReadResponseMessage{ID: id1}
+	r.deliver(id1, resp)
+
+	msg, err := r.wait(ch1, time.Second, errTimeout)
+	require.NoError(t, err)
+	require.Equal(t, resp, msg)
+
+	// a second response to the same request is dropped
+	r.deliver(id1, resp)
+	require.Empty(t, ch1)
+
+	_, err = r.wait(ch0, 10*time.Millisecond, errTimeout)
+	require.Equal(t, errTimeout, err)
+
+	// responses to requests that are no longer pending are dropped
+	r.remove(id0)
+	r.deliver(id0, resp)
+	require.Empty(t, ch0)
+	require.Empty(t, r.pending)
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+)
+
+// reservedTag is the tag reserved peers are protected with in the connection manager
+const reservedTag = "reserved"
+
+// reservedPeersInterval is how often we check that we're connected to our reserved peers
+var reservedPeersInterval = time.Second
+
+// minReservedPeerBackoff and maxReservedPeerBackoff bound how long we wait before redialing a reserved peer after a
+// failed dial. the backoff doubles after each failure.
+var (
+	minReservedPeerBackoff = time.Second
+	maxReservedPeerBackoff = time.Minute
+)
+
+// errNotReserved is returned when trying to connect to a peer that isn't reserved in reserved-only mode
+var errNotReserved = errors.New("peer is not reserved")
+
+// errReserveSelf is returned when trying to add our own peer ID to our reserved peers
+var errReserveSelf = errors.New("cannot reserve our own peer ID")
+
+// ErrReservedPeerNotFound is returned when removing a peer that isn't reserved
+var ErrReservedPeerNotFound = errors.New("peer is not a reserved peer")
+
+// reservedPeer is a peer we always keep a connection to
+type reservedPeer struct {
+	addrInfo peer.AddrInfo
+	backoff  time.Duration // how long we wait before redialing the peer after the next failed dial
+	nextDial time.Time
+}
+
+// reservedPeers is the set of peers we always keep connections to
+type reservedPeers struct {
+	sync.Mutex
+	peers map[peer.ID]*reservedPeer
+}
+
+func newReservedPeers() *reservedPeers {
+	return &reservedPeers{
+		peers: make(map[peer.ID]*reservedPeer),
+	}
+}
+
+// add adds the peer to the set, replacing its addresses if it's already reserved
+func (r *reservedPeers) add(addrInfo peer.AddrInfo) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.peers[addrInfo.ID] = &reservedPeer{
+		addrInfo: addrInfo,
+		backoff:  minReservedPeerBackoff,
+	}
+}
+
+// remove removes the peer from the set. it returns false if the peer wasn't reserved.
+func (r *reservedPeers) remove(p peer.ID) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, has := r.peers[p]; !has {
+		return false
+	}
+
+	delete(r.peers, p)
+	return true
+}
+
+// due returns the reserved peers whose backoff has expired as of now
+func (r *reservedPeers) due(now time.Time) []peer.AddrInfo {
+	r.Lock()
+	defer r.Unlock()
+
+	addrInfos := []peer.AddrInfo{}
+	for _, rp := range r.peers {
+		if !now.Before(rp.nextDial) {
+			addrInfos = append(addrInfos, rp.addrInfo)
+		}
+	}
+
+	return addrInfos
+}
+
+// dialed records the result of dialing the peer. after a failed dial, the peer isn't redialed until its backoff has
+// expired, and its backoff is doubled.
+func (r *reservedPeers) dialed(p peer.ID, now time.Time, err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	rp, has := r.peers[p]
+	if !has {
+		return
+	}
+
+	if err == nil {
+		rp.backoff = minReservedPeerBackoff
+		rp.nextDial = time.Time{}
+		return
+	}
+
+	rp.nextDial = now.Add(rp.backoff)
+	rp.backoff *= 2
+	if rp.backoff > maxReservedPeerBackoff {
+		rp.backoff = maxReservedPeerBackoff
+	}
+}
+
+// addReservedPeer adds the peer to our reserved peers, protecting it from being trimmed by the connection manager
+func (h *host) addReservedPeer(addrInfo peer.AddrInfo) {
+	h.h.Peerstore().AddAddrs(addrInfo.ID, addrInfo.Addrs, peerstore.PermanentAddrTTL)
+	h.reserved.add(addrInfo)
+	h.cm.Protect(addrInfo.ID, reservedTag)
+}
+
+// removeReservedPeer removes the peer from our reserved peers. in reserved-only mode, we disconnect from the peer.
+func (h *host) removeReservedPeer(p peer.ID) error {
+	if !h.reserved.remove(p) {
+		return ErrReservedPeerNotFound
+	}
+
+	h.cm.Unprotect(p, reservedTag)
+
+	if h.cm.reservedOnly && h.peerConnected(p) {
+		return h.closePeer(p)
+	}
+
+	return nil
+}
+
+// dialReservedPeers dials the reserved peers we aren't connected to, unless we're backing off from them
+func (h *host) dialReservedPeers() {
+	now := time.Now()
+	for _, addrInfo := range h.reserved.due(now) {
+		if h.peerConnected(addrInfo.ID) {
+			h.reserved.dialed(addrInfo.ID, now, nil)
+			continue
+		}
+
+		err := h.connect(addrInfo)
+		if err != nil {
+			h.logger.Debug("Failed to connect to reserved peer", "peer", addrInfo.ID, "error", err)
+		}
+
+		h.reserved.dialed(addrInfo.ID, now, err)
+	}
+}
+
+// maintainReservedPeers keeps us connected to our reserved peers, redialing them with backoff when they disconnect
+func (s *Service) maintainReservedPeers() {
+	for {
+		if s.closed {
+			return
+		}
+
+		s.host.dialReservedPeers()
+		time.Sleep(reservedPeersInterval)
+	}
+}
+
+// AddReservedPeer adds the peer with the given multiaddress (including its peer ID) to our reserved peers. we always
+// keep a connection to reserved peers, and in reserved-only mode we only connect to reserved peers.
+func (s *Service) AddReservedPeer(addr string) error {
+	addrInfo, err := stringToAddrInfo(addr)
+	if err != nil {
+		return err
+	}
+
+	if addrInfo.ID == s.host.id() {
+		return errReserveSelf
+	}
+
+	s.host.addReservedPeer(addrInfo)
+	return nil
+}
+
+// RemoveReservedPeer removes the peer with the given base58 encoded ID from our reserved peers
+func (s *Service) RemoveReservedPeer(id string) error {
+	p, err := peer.IDB58Decode(id)
+	if err != nil {
+		return err
+	}
+
+	return s.host.removeReservedPeer(p)
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedPeers_Backoff(t *testing.T) {
+	r := newReservedPeers()
+	p := peer.ID("noot")
+	r.add(peer.AddrInfo{ID: p})
+
+	now := time.Now()
+	require.Equal(t, 1, len(r.due(now)))
+
+	r.dialed(p, now, errors.New("failed to dial"))
+	require.Equal(t, 0, len(r.due(now)))
+	require.Equal(t, 1, len(r.due(now.Add(minReservedPeerBackoff))))
+
+	// the backoff doubles after each failed dial, up to the maximum
+	r.dialed(p, now, errors.New("failed to dial"))
+	require.Equal(t, 0, len(r.due(now.Add(minReservedPeerBackoff))))
+	require.Equal(t, 1, len(r.due(now.Add(2*minReservedPeerBackoff))))
+
+	for i := 0; i < 10; i++ {
+		r.dialed(p, now, errors.New("failed to dial"))
+	}
+	require.Equal(t, maxReservedPeerBackoff, r.peers[p].backoff)
+
+	// a successful dial resets the backoff
+	r.dialed(p, now, nil)
+	require.Equal(t, 1, len(r.due(now)))
+	require.Equal(t, minReservedPeerBackoff, r.peers[p].backoff)
+
+	require.True(t, r.remove(p))
+	require.False(t, r.remove(p))
+	require.Equal(t, 0, len(r.due(now)))
+}
+
+func TestReservedPeers_Reconnect(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	interval := reservedPeersInterval
+	reservedPeersInterval = TestMessageTimeout / 10
+	defer func() {
+		reservedPeersInterval = interval
+	}()
+
+	nodes := createTestPeers(t, &Config{}, &Config{})
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	err := nodes[0].AddReservedPeer(nodes[1].host.multiaddrs()[0].String())
+	require.NoError(t, err)
+	require.True(t, nodes[0].host.cm.isProtected(nodes[1].host.id()))
+
+	time.Sleep(TestMessageTimeout)
+	require.True(t, nodes[0].host.peerConnected(nodes[1].host.id()))
+
+	// we reconnect to the reserved peer after it disconnects
+	err = nodes[1].host.closePeer(nodes[0].host.id())
+	require.NoError(t, err)
+	time.Sleep(TestMessageTimeout)
+	require.True(t, nodes[0].host.peerConnected(nodes[1].host.id()))
+
+	err = nodes[0].RemoveReservedPeer(nodes[1].host.id().Pretty())
+	require.NoError(t, err)
+	require.False(t, nodes[0].host.cm.isProtected(nodes[1].host.id()))
+
+	err = nodes[0].RemoveReservedPeer(nodes[1].host.id().Pretty())
+	require.Equal(t, ErrReservedPeerNotFound, err)
+
+	err = nodes[0].AddReservedPeer(nodes[0].host.multiaddrs()[0].String())
+	require.Equal(t, errReserveSelf, err)
+}
+
+func TestReservedOnly(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{ReservedOnly: true}, &Config{}, &Config{})
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	err := nodes[0].AddReservedPeer(nodes[1].host.multiaddrs()[0].String())
+	require.NoError(t, err)
+
+	// we don't dial peers that aren't reserved
+	addrInfos, err := nodes[2].host.addrInfos()
+	require.NoError(t, err)
+	err = nodes[0].host.connect(*addrInfos[0])
+	require.Equal(t, errNotReserved, err)
+
+	// connections from peers that aren't reserved are rejected
+	connectTestPeer(t, nodes[2], nodes[0])
+	connectTestPeer(t, nodes[1], nodes[0])
+	time.Sleep(TestMessageTimeout / 10)
+	require.Equal(t, 1, nodes[0].host.peerCount())
+	require.True(t, nodes[0].host.peerConnected(nodes[1].host.id()))
+
+	// removing a reserved peer disconnects it
+	err = nodes[0].RemoveReservedPeer(nodes[1].host.id().Pretty())
+	require.NoError(t, err)
+	require.Equal(t, 0, nodes[0].host.peerCount())
+}
@@ -20,11 +20,13 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/services"
 
@@ -32,11 +34,35 @@ import (
 	"github.com/libp2p/go-libp2p-core/network"
 	libp2pnetwork "github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
 )
 
 // NetworkStateTimeout is the set time interval that we update network state
 const NetworkStateTimeout = time.Minute
-const syncID = "/sync/2"
+
+// maxBlockAnnounceDistance is how far ahead of our best block an announced block can be for us to gossip the announce
+const maxBlockAnnounceDistance = 1 << 16
+
+// errInvalidBlockAnnounce is returned when a block announce's header cannot be decoded
+var errInvalidBlockAnnounce = errors.New("invalid block announce")
+
+// blockRequestInterval is how often we send the block requests that the syncer has scheduled, including those for
+// chunks of blocks whose previous requests timed out
+var blockRequestInterval = time.Second
+
+// connectToPeersInterval is how often we check whether we have fewer than the minimum number of peers
+var connectToPeersInterval = 30 * time.Second
+
+// sub-protocols, appended to the main protocol ID
+const (
+	syncID          = "/sync/2"            // request/response protocol for blocks
+	blockAnnounceID = "/block-announces/1" // notifications protocol for block announces, with a status handshake
+	transactionsID  = "/transactions/1"    // notifications protocol for transactions, with a roles handshake
+)
+
+// grandpaID is the notifications protocol for GRANDPA messages, with a roles handshake. it's the protocol used by
+// Substrate, which isn't specific to the chain, so it isn't appended to the main protocol ID.
+const grandpaID = "/paritytech/grandpa/1"
 
 var _ services.Service = &Service{}
 var logger = log.New("pkg", "network")
@@ -48,15 +74,25 @@ type Service struct {
 	cfg            *Config
 	host           *host
 	mdns           *mdns
+	discovery      *dhtDiscovery
 	status         *status
 	gossip         *gossip
 	requestTracker *requestTracker
+	peerViews      *peerViews // the chain views of our peers, used by the syncer to pick which peers to request from
+	peerStore      *peerStore // the peers we know about, saved to the base path so they can be redialled on restart
+
+	finalityProofRequests *pendingRequests
+	lightRequests         *pendingRequests
 
 	// Service interfaces
 	blockState   BlockState
 	networkState NetworkState
 	syncer       Syncer
 
+	transactionHandler    TransactionHandler
+	finalityProofProvider FinalityProofProvider
+	lightServer           LightServer
+
 	// Channels for inter-process communication
 	// as well as a lock for safe channel closures
 	msgRec  <-chan Message
@@ -109,9 +145,12 @@ func NewService(cfg *Config) (*Service, error) {
 		cfg:            cfg,
 		host:           host,
 		mdns:           newMDNS(host),
+		discovery:      newDHTDiscovery(host, cfg.MinPeers),
 		status:         newStatus(host),
 		gossip:         newGossip(host),
 		requestTracker: newRequestTracker(host.logger),
+		peerViews:      newPeerViews(),
+		peerStore:      newPeerStore(cfg.BasePath),
 		blockState:     cfg.BlockState,
 		networkState:   cfg.NetworkState,
 		msgRec:         cfg.MsgRec,
@@ -121,9 +160,21 @@ func NewService(cfg *Config) (*Service, error) {
 		noMDNS:         cfg.NoMDNS,
 		noStatus:       cfg.NoStatus,
 		syncer:         cfg.Syncer,
+
+		finalityProofRequests: newPendingRequests(),
+		lightRequests:         newPendingRequests(),
+		transactionHandler:    cfg.TransactionHandler,
+		finalityProofProvider: cfg.FinalityProofProvider,
+		lightServer:           cfg.LightServer,
 	}
 
-	return network, err
+	// a missing or corrupt peers file isn't fatal, we just find our peers again
+	err = network.peerStore.load(host)
+	if err != nil {
+		logger.Warn("Failed to load peers file", "path", network.peerStore.path, "error", err)
+	}
+
+	return network, nil
 }
 
 // Start starts the network service
@@ -134,23 +185,49 @@ func (s *Service) Start() error {
 	// receive messages from core service
 	go s.receiveCoreMessages()
 
+	// connect to more peers when we have too few
+	go s.connectToPeers()
+
+	// stay connected to our reserved peers
+	go s.maintainReservedPeers()
+
+	// save the peers we know about periodically
+	go s.savePeers()
+
+	// send the block requests scheduled by the syncer periodically
+	go s.requestBlocks()
+
 	s.host.registerConnHandler(s.handleConn)
-	s.host.registerStreamHandler("", s.handleStream)
+	s.host.registerDisconnectHandler(s.handleDisconnect)
 	s.host.registerStreamHandler(syncID, s.handleSyncStream)
+	for c, suffix := range compressionSuffixes {
+		if s.host.compression&c != 0 {
+			s.host.registerStreamHandler(syncID+suffix, s.handleSyncStream)
+		}
+	}
+	s.host.registerStreamHandler(finalityProofID, s.handleFinalityProofStream)
+	s.registerLightHandlers()
+
+	s.host.registerNotificationsProtocol(s.host.protocolID+blockAnnounceID, BlockAnnounceMsgType, newMessageCodec(BlockAnnounceMsgType), s.getStatusHandshake, s.validateStatusHandshake, s.handleMessage)
+	s.host.registerNotificationsProtocol(s.host.protocolID+transactionsID, TransactionMsgType, newMessageCodec(TransactionMsgType), s.getRolesHandshake, s.validateRolesHandshake, s.handleMessage)
+	s.host.registerNotificationsProtocol(grandpaID, ConsensusMsgType, grandpaCodec, s.getRolesHandshake, s.validateRolesHandshake, s.handleMessage)
 
 	// log listening addresses to console
 	for _, addr := range s.host.multiaddrs() {
 		s.logger.Info("Started listening", "address", addr)
 	}
 
-	if !s.noBootstrap {
+	// in reserved-only mode, we only connect to our reserved peers, so we don't bootstrap or discover peers
+	if !s.noBootstrap && !s.cfg.ReservedOnly {
 		s.host.bootstrap()
+		go s.dialStoredPeers()
+		s.discovery.start()
 	}
 
 	// TODO: ensure bootstrap has connected to bootnodes and addresses have been
 	// registered by the host before mDNS attempts to connect to bootnodes
 
-	if !s.noMDNS {
+	if !s.noMDNS && !s.cfg.ReservedOnly {
 		s.mdns.start()
 	}
 
@@ -169,6 +246,15 @@ func (s *Service) Stop() error {
 		s.logger.Error("Failed to close mDNS discovery service", "error", err)
 	}
 
+	// close DHT discovery service
+	s.discovery.close()
+
+	// save the peers we know about while their addresses are still in the peerstore
+	err = s.peerStore.save(s.host)
+	if err != nil {
+		s.logger.Error("Failed to save peers file", "path", s.peerStore.path, "error", err)
+	}
+
 	// close host and host services
 	err = s.host.close()
 	if err != nil {
@@ -210,6 +296,57 @@ func (s *Service) updateNetworkState() {
 	}
 }
 
+// connectToPeers dials the peers we know about at the set time interval, if we have fewer than the minimum number
+// of peers
+func (s *Service) connectToPeers() {
+	for {
+		time.Sleep(connectToPeersInterval)
+
+		if s.closed {
+			return
+		}
+
+		if s.host.peerCount() < s.cfg.MinPeers {
+			s.host.connectToPeers(s.cfg.MinPeers)
+		}
+	}
+}
+
+// savePeers saves the peers we know about to the peers file at the set time interval
+func (s *Service) savePeers() {
+	for {
+		time.Sleep(savePeersInterval)
+
+		if s.closed {
+			return
+		}
+
+		err := s.peerStore.save(s.host)
+		if err != nil {
+			s.logger.Warn("Failed to save peers file", "path", s.peerStore.path, "error", err)
+		}
+	}
+}
+
+// dialStoredPeers redials the best of the peers we knew about before the node was restarted, up to the minimum
+// number of peers
+func (s *Service) dialStoredPeers() {
+	for _, p := range s.peerStore.best(s.host, s.cfg.MinPeers) {
+		if s.host.peerCount() >= s.cfg.MinPeers {
+			return
+		}
+
+		if s.host.peerConnected(p) || s.host.reputations.banned(p) {
+			continue
+		}
+
+		err := s.host.connect(s.host.h.Peerstore().PeerInfo(p))
+		if err != nil {
+			s.logger.Debug("Failed to redial stored peer", "peer", p, "error", err)
+		}
+	}
+}
+
 // receiveCoreMessages broadcasts messages from the core service
 func (s *Service) receiveCoreMessages() {
 	for {
@@ -226,6 +363,9 @@ func (s *Service) receiveCoreMessages() {
 			"type", msg.GetType(),
 		)
 
+		// our own messages are not gossiped back to our peers if they send them to us
+		s.gossip.seen.add(msg.IDString())
+
 		// broadcast message to connected peers
 		s.host.broadcast(msg)
 	}
@@ -241,56 +381,167 @@ func (s *Service) safeMsgSend(msg Message) error {
 	return nil
 }
 
-// handleConn starts processes that manage the connection
+// handleConn opens our block announces substream to the peer, which exchanges status messages with it. connections
+// from banned peers are closed.
 func (s *Service) handleConn(conn network.Conn) {
-	// check if status is enabled
-	if !s.noStatus {
+	if s.host.reputations.banned(conn.RemotePeer()) {
+		s.logger.Debug("Closing connection from banned peer", "peer", conn.RemotePeer())
+		_ = conn.Close()
+		return
+	}
 
-		// get latest block header from block state
-		latestBlock, err := s.blockState.BestBlockHeader()
-		if err != nil || (latestBlock == nil || latestBlock.Number == nil) {
-			s.logger.Error("Failed to get chain head", "error", err)
-			return
-		}
+	s.peerStore.seen(conn.RemotePeer())
+
+	np, has := s.host.notificationsProtocols[BlockAnnounceMsgType]
+	if !has {
+		return
+	}
 
-		// update host status message
-		msg := &StatusMessage{
-			ProtocolVersion:     s.cfg.ProtocolVersion,
-			MinSupportedVersion: s.cfg.MinSupportedVersion,
-			Roles:               s.cfg.Roles,
-			BestBlockNumber:     latestBlock.Number.Uint64(),
-			BestBlockHash:       latestBlock.Hash(),
-			GenesisHash:         s.blockState.GenesisHash(),
-			ChainStatus:         []byte{0}, // TODO
+	go func() {
+		_, err := s.host.openNotificationsStream(conn.RemotePeer(), np)
+		if err != nil {
+			s.logger.Debug("Failed to open block announces substream", "peer", conn.RemotePeer(), "error", err)
 		}
+	}()
+}
 
-		// update host status message
-		s.status.setHostMessage(msg)
+// handleDisconnect cleans up the peer's status and substreams once we have no more connections to it
+func (s *Service) handleDisconnect(conn network.Conn) {
+	p := conn.RemotePeer()
+	if s.host.peerConnected(p) {
+		return
+	}
 
-		// manage status messages for new connection
-		s.status.handleConn(conn)
+	s.peerStore.seen(p)
+	s.status.removePeer(p)
+	s.peerViews.remove(p)
+	s.host.closeNotificationsStreams(p)
+	s.host.known.removePeer(p)
+	s.host.limiter.removePeer(p)
+	s.host.traffic.removePeer(p)
+	s.host.reputations.prune()
+
+	if s.host.validator != nil {
+		s.host.validator.PeerDisconnected(p)
 	}
 }
 
-// handleStream starts reading from the inbound message stream and continues
-// reading until the inbound message stream is closed or reset.
-func (s *Service) handleStream(stream libp2pnetwork.Stream) {
-	conn := stream.Conn()
-	if conn == nil {
-		s.logger.Error("Failed to get connection from stream")
-		return
+// getStatusMessage returns our status message, which is the handshake of the block announces protocol
+func (s *Service) getStatusMessage() (*StatusMessage, error) {
+	msg := &StatusMessage{
+		ProtocolVersion:     s.cfg.ProtocolVersion,
+		MinSupportedVersion: s.cfg.MinSupportedVersion,
+		Roles:               s.cfg.Roles,
+		ChainStatus:         encodeChainStatus(s.host.compression, 0),
 	}
 
-	peer := conn.RemotePeer()
+	// without a block state, we only send our roles and protocol versions
+	if s.blockState == nil {
+		return msg, nil
+	}
 
-	// create buffer stream for non-blocking read
-	r := bufio.NewReader(stream)
+	// get latest block header from block state
+	latestBlock, err := s.blockState.BestBlockHeader()
+	if err != nil {
+		return nil, err
+	}
 
-	go s.readStream(r, peer, s.handleMessage)
-	// the stream stays open until closed or reset
+	if latestBlock == nil || latestBlock.Number == nil {
+		return nil, errors.New("failed to get chain head")
+	}
+
+	msg.BestBlockNumber = latestBlock.Number.Uint64()
+	msg.BestBlockHash = latestBlock.Hash()
+	msg.GenesisHash = s.blockState.GenesisHash()
+	msg.ChainStatus = encodeChainStatus(s.host.compression, s.finalizedNumber())
+	return msg, nil
+}
+
+// finalizedNumber returns the number of our latest finalized block, or zero if it's unknown
+func (s *Service) finalizedNumber() uint64 {
+	round, err := s.blockState.GetRound()
+	if err != nil {
+		s.logger.Debug("Failed to get latest finalized round", "error", err)
+		return 0
+	}
+
+	header, err := s.blockState.GetFinalizedHeader(round)
+	if err != nil || header.Number == nil {
+		s.logger.Debug("Failed to get latest finalized header", "round", round, "error", err)
+		return 0
+	}
+
+	return header.Number.Uint64()
+}
+
+// getStatusHandshake returns the encoded handshake of the block announces protocol
+func (s *Service) getStatusHandshake() ([]byte, error) {
+	msg, err := s.getStatusMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	// update host status message
+	s.status.setHostMessage(msg)
+
+	return encodeMessage(msg)
+}
+
+// validateStatusHandshake checks the peer's status message and confirms the peer. if the peer has a higher best
+// block than us, it requests the missing blocks from it.
+func (s *Service) validateStatusHandshake(p peer.ID, hs []byte) error {
+	msg, err := newMessageDecoder(StatusMsgType)(hs)
+	if err != nil {
+		s.host.reportPeer(p, BadHandshake)
+		return err
+	}
+
+	// check if status is enabled
+	if s.noStatus {
+		return nil
+	}
+
+	statusMessage := msg.(*StatusMessage)
+	if !s.status.handleMessage(p, statusMessage) {
+		if statusMessage.GenesisHash != s.blockState.GenesisHash() {
+			s.host.reportPeer(p, GenesisMismatch)
+		} else {
+			s.host.reportPeer(p, BadHandshake)
+		}
+		return errInvalidStatus
+	}
+
+	s.peerViews.handleStatus(p, statusMessage)
+
+	// send a block request message if peer best block number is greater than host best block number
+	req := s.handleStatusMesssage(statusMessage)
+	if req != nil {
+		go func() {
+			s.sendBlockRequest(p, req)
+			s.sendScheduledBlockRequests()
+		}()
+	}
+
+	return nil
+}
+
+// getRolesHandshake returns the encoded handshake of the transactions and GRANDPA protocols, which is our roles
+func (s *Service) getRolesHandshake() ([]byte, error) {
+	return []byte{s.cfg.Roles}, nil
 }
 
-// handleSyncStream handles streams with the <protcol-id>/sync/2 protocol ID
+// validateRolesHandshake checks that the handshake of the transactions and GRANDPA protocols contains the peer's roles
+func (s *Service) validateRolesHandshake(p peer.ID, hs []byte) error {
+	if len(hs) != 1 {
+		s.host.reportPeer(p, BadHandshake)
+		return errInvalidRolesHandshake
+	}
+
+	return nil
+}
+
+// handleSyncStream handles inbound streams with the <protocol-id>/sync/2 protocol ID. it reads block requests from
+// the stream and writes the responses to it.
 func (s *Service) handleSyncStream(stream libp2pnetwork.Stream) {
 	conn := stream.Conn()
 	if conn == nil {
@@ -298,48 +549,94 @@ func (s *Service) handleSyncStream(stream libp2pnetwork.Stream) {
 		return
 	}
 
-	peer := conn.RemotePeer()
+	p := conn.RemotePeer()
 
 	// create buffer stream for non-blocking read
 	r := bufio.NewReader(stream)
 
-	go s.readStream(r, peer, s.handleSyncMessage)
+	// the peer requests compressed responses by opening the substream on a compressed sync protocol
+	c := protocolCompression(stream.Protocol())
+
+	go s.host.readStream(r, p, stream.Protocol(), BlockRequestMsgType, compressionNone, func(_ peer.ID, msg Message) {
+		s.handleBlockRequest(stream, msg, c)
+	})
 	// the stream stays open until closed or reset
 }
 
-func (s *Service) readStream(r *bufio.Reader, peer peer.ID, handler func(peer peer.ID, msg Message)) {
-	for {
-		length, err := readLEB128ToUint64(r)
-		if err != nil {
-			s.logger.Error("Failed to read LEB128 encoding", "error", err)
-			return
-		}
+// handleBlockRequest responds to a block request on the stream it was received on, compressing the response with the
+// given algorithm
+func (s *Service) handleBlockRequest(stream libp2pnetwork.Stream, msg Message, c byte) {
+	req, ok := msg.(*BlockRequestMessage)
+	if !ok {
+		return
+	}
 
-		msgBytes := make([]byte, length)
-		n, err := r.Read(msgBytes)
-		if err != nil {
-			s.logger.Error("Failed to read message from stream", "error", err)
-			return
-		}
+	resp, err := s.syncer.CreateBlockResponse(req)
+	if err != nil {
+		s.logger.Debug("cannot create response for request", "id", req.ID)
+		return
+	}
 
-		if uint64(n) != length {
-			s.logger.Error("Failed to read entire message", "length", length, "read", n)
-			return
-		}
+	err = s.host.writeCompressedToStream(stream, resp, c)
+	if err != nil {
+		s.logger.Error("failed to send BlockResponse message", "peer", stream.Conn().RemotePeer())
+	}
+}
 
-		// decode message based on message type
-		msg, err := decodeMessageBytes(msgBytes)
-		if err != nil {
-			s.logger.Error("Failed to decode message from peer", "peer", peer, "err", err)
-			return // exit
-		}
+// sendRequest sends the request to the peer on our outbound stream for the request/response protocol. if the stream
+// is newly opened, the responses received on it are decompressed with the given algorithm, decoded as messages of the
+// given type and passed to the handler.
+func (s *Service) sendRequest(p peer.ID, sub protocol.ID, req Message, respType byte, c byte, handler func(peer peer.ID, msg Message)) error {
+	stream, opened, err := s.host.getOrOpenStream(p, sub)
+	if err != nil {
+		return err
+	}
+
+	if opened {
+		go s.host.readStream(bufio.NewReader(stream), p, stream.Protocol(), respType, c, handler)
+	}
+
+	return s.host.writeToStream(stream, req)
+}
 
-		// handle message based on peer status and message type
-		handler(peer, msg)
+// sendBlockRequest tracks and sends the block request to the peer picked by the syncer, or to the given peer if the
+// syncer doesn't pick one, requesting a compressed response if the peer supports one of our compression algorithms
+func (s *Service) sendBlockRequest(p peer.ID, req *BlockRequestMessage) {
+	if selected, ok := s.syncer.SelectPeer(req, s.Peers()); ok {
+		p = selected
 	}
+
+	s.sendBlockRequestTo(p, req)
 }
 
-// handleSyncMessage handles synchronization message types (BlockRequest and BlockResponse)
+// sendBlockRequestTo tracks and sends the block request to the given peer
+func (s *Service) sendBlockRequestTo(p peer.ID, req *BlockRequestMessage) {
+	s.requestTracker.addRequestedBlockID(req.ID)
+	c := s.peerCompression(p)
+	err := s.sendRequest(p, compressedProtocol(syncID, c), req, BlockResponseMsgType, c, s.handleSyncMessage)
+	if err != nil {
+		s.logger.Error("failed to send BlockRequest message", "peer", p, "error", err)
+	}
+}
+
+// peerCompression returns the compression algorithm to request block responses from the peer with, given the
+// algorithms it advertised in its status handshake
+func (s *Service) peerCompression(p peer.ID) byte {
+	msg, has := s.status.peerMessage.Load(p)
+	if !has {
+		return compressionNone
+	}
+
+	return negotiateCompression(s.host.compression, chainStatusCompression(msg.(*StatusMessage)))
+}
+
+// CompressionStats returns the number of bytes of the compressed block responses we've sent and received, before and
+// after they were compressed
+func (s *Service) CompressionStats() CompressionStats {
+	return s.host.compressionStats.snapshot()
+}
+
+// handleSyncMessage handles the block responses we receive for our block requests
 func (s *Service) handleSyncMessage(peer peer.ID, msg Message) {
 	if msg == nil {
 		return
@@ -349,32 +646,81 @@ func (s *Service) handleSyncMessage(peer peer.ID, msg Message) {
 	// message to the sync service
 	if resp, ok := msg.(*BlockResponseMessage); ok && s.requestTracker.hasRequestedBlockID(resp.ID) {
 		s.requestTracker.removeRequestedBlockID(resp.ID)
-		req := s.syncer.HandleBlockResponse(resp)
-		if req != nil {
-			s.requestTracker.addRequestedBlockID(req.ID)
-			err := s.host.send(peer, syncID, req)
-			if err != nil {
-				s.logger.Error("failed to send BlockRequest message", "peer", peer)
-			}
+		req, err := s.syncer.HandleBlockResponse(resp)
+		if err != nil {
+			s.host.reportPeer(peer, BadBlockResponse)
+		} else if req != nil {
+			s.sendBlockRequest(peer, req)
 		}
+
+		s.sendScheduledBlockRequests()
 	}
+}
 
-	// if it's a BlockRequest, call core for processing
-	if req, ok := msg.(*BlockRequestMessage); ok {
-		resp, err := s.syncer.CreateBlockResponse(req)
-		if err != nil {
-			s.logger.Debug("cannot create response for request", "id", req.ID)
+// requestBlocks periodically sends the block requests scheduled by the syncer
+func (s *Service) requestBlocks() {
+	for {
+		time.Sleep(blockRequestInterval)
+
+		if s.closed {
 			return
 		}
 
-		err = s.host.send(peer, syncID, resp)
+		s.sendScheduledBlockRequests()
+	}
+}
+
+// sendScheduledBlockRequests sends the block requests scheduled by the syncer concurrently, each to the peer the
+// syncer picked for it
+func (s *Service) sendScheduledBlockRequests() {
+	for _, req := range s.syncer.BlockRequests(s.Peers()) {
+		go s.sendBlockRequestTo(req.Peer, req.Request)
+	}
+}
+
+// validateMessage returns whether a message received from a peer should be processed and gossiped. block announces
+// are validated by validateBlockAnnounce, and consensus messages by the gossip validator if one was provided.
+func (s *Service) validateMessage(peer peer.ID, msg Message) (process, propagate bool, err error) {
+	switch m := msg.(type) {
+	case *BlockAnnounceMessage:
+		return s.validateBlockAnnounce(m)
+	case *ConsensusMessage:
+		if s.host.validator != nil {
+			return s.host.validator.Validate(peer, m)
+		}
+	}
+
+	return true, true, nil
+}
+
+// validateBlockAnnounce checks that the announced header can be decoded. announces for blocks too far ahead of our
+// best block to be plausible are processed, so that we can sync them, but aren't gossiped.
+func (s *Service) validateBlockAnnounce(msg *BlockAnnounceMessage) (process, propagate bool, err error) {
+	if msg.Number == nil || msg.Number.Sign() <= 0 {
+		return false, false, errInvalidBlockAnnounce
+	}
+
+	for _, d := range msg.Digest {
+		_, err = types.DecodeDigestItem(d)
 		if err != nil {
-			s.logger.Error("failed to send BlockResponse message", "peer", peer)
+			return false, false, fmt.Errorf("%w: %s", errInvalidBlockAnnounce, err)
 		}
 	}
+
+	if s.blockState == nil {
+		return true, true, nil
+	}
+
+	best, err := s.blockState.BestBlockHeader()
+	if err != nil {
+		return true, false, nil
+	}
+
+	limit := big.NewInt(0).Add(best.Number, big.NewInt(maxBlockAnnounceDistance))
+	return true, msg.Number.Cmp(limit) <= 0, nil
 }
 
-// handleMessage handles the message based on peer status and message type
+// handleMessage handles the notifications received from peers based on peer status and message type
 func (s *Service) handleMessage(peer peer.ID, msg Message) {
 	s.logger.Trace(
 		"Received message from peer",
@@ -383,57 +729,74 @@ func (s *Service) handleMessage(peer peer.ID, msg Message) {
 		"type", msg.GetType(),
 	)
 
-	if msg.GetType() != StatusMsgType {
-
-		// check if status is disabled or peer status is confirmed
-		if s.noStatus || s.status.confirmed(peer) {
-			if an, ok := msg.(*BlockAnnounceMessage); ok {
-				req := s.syncer.HandleBlockAnnounce(an)
-				if req != nil {
-					s.requestTracker.addRequestedBlockID(req.ID)
-					err := s.host.send(peer, syncID, req)
-					if err != nil {
-						s.logger.Error("failed to send BlockRequest message", "peer", peer)
-					}
-				}
-			} else {
-				err := s.safeMsgSend(msg)
-				if err != nil {
-					s.logger.Error("Failed to send message", "error", err)
-				}
-			}
-		}
-
-		// check if gossip is enabled
-		if !s.noGossip {
+	// the peer knows about the message, so we don't send it back
+	s.host.known.add(peer, msg.IDString())
 
-			// handle non-status message from peer with gossip submodule
-			s.gossip.handleMessage(msg, peer)
+	process, propagate, err := s.validateMessage(peer, msg)
+	if err != nil {
+		s.logger.Debug("Received invalid message from peer", "peer", peer, "type", msg.GetType(), "error", err)
+		if _, ok := msg.(*BlockAnnounceMessage); ok {
+			s.host.reportPeer(peer, BadBlockAnnounce)
+		} else {
+			s.host.reportPeer(peer, BadConsensusMessage)
 		}
+		return
+	}
 
-	} else {
-
-		// check if status is enabled
-		if !s.noStatus {
+	// check if status is disabled or peer status is confirmed
+	if process && (s.noStatus || s.status.confirmed(peer)) {
+		switch m := msg.(type) {
+		case *BlockAnnounceMessage:
+			s.peerViews.handleBlockAnnounce(peer, m)
+			req := s.syncer.HandleBlockAnnounce(m)
+			if req != nil {
+				s.sendBlockRequest(peer, req)
+			}
+		case *TransactionMessage:
+			if !s.handleTransactionMessage(peer, m) {
+				return
+			}
+		default:
+			err = s.safeMsgSend(msg)
+			if err != nil {
+				s.logger.Error("Failed to send message", "error", err)
+			}
+		}
+	}
 
-			// handle status message from peer with status submodule
-			s.status.handleMessage(peer, msg.(*StatusMessage))
+	// check if gossip is enabled
+	if propagate && !s.noGossip {
 
-			// check if peer status confirmed
-			if s.status.confirmed(peer) {
+		// handle non-status message from peer with gossip submodule
+		s.gossip.handleMessage(msg, peer)
+	}
+}
 
-				// send a block request message if peer best block number is greater than host best block number
-				req := s.handleStatusMesssage(msg.(*StatusMessage))
-				if req != nil {
-					s.requestTracker.addRequestedBlockID(req.ID)
-					err := s.host.send(peer, syncID, req)
-					if err != nil {
-						s.logger.Error("failed to send BlockRequest message", "peer", peer)
-					}
-				}
-			}
+// handleTransactionMessage passes the transactions to the transaction handler, or to the core service if there is
+// none. it returns false if the transactions are invalid, in which case the peer is penalised.
+func (s *Service) handleTransactionMessage(peer peer.ID, msg *TransactionMessage) bool {
+	if s.transactionHandler == nil {
+		err := s.safeMsgSend(msg)
+		if err != nil {
+			s.logger.Error("Failed to send message", "error", err)
 		}
+		return true
 	}
+
+	err := s.transactionHandler.ProcessTransactionMessage(msg)
+	if err != nil {
+		s.logger.Debug("Received invalid transaction from peer", "peer", peer, "error", err)
+		s.host.reportPeer(peer, BadTransaction)
+		return false
+	}
+
+	return true
+}
+
+// ReportPeer applies the reputation change to the peer. if the peer's reputation falls below BannedThreshold, it is
+// disconnected and banned for a while.
+func (s *Service) ReportPeer(p peer.ID, change ReputationChange) {
+	s.host.reportPeer(p, change)
 }
 
 // handleStatusMesssage returns a block request message if peer best block
@@ -473,27 +836,32 @@ func (s *Service) NetworkState() common.NetworkState {
 	}
 }
 
-// Peers returns information about connected peers needed for the rpc server
+// NetworkStats returns the bytes and messages the host has sent and received, in total, per protocol and per
+// connected peer, needed for the rpc server and the metrics endpoint
+func (s *Service) NetworkStats() common.NetworkStats {
+	return s.host.traffic.stats(s.host.peers())
+}
+
+// Peers returns our view of the chains of the connected peers whose status we've confirmed, which is kept up to date
+// from their status handshakes and block announces
 func (s *Service) Peers() []common.PeerInfo {
 	peers := []common.PeerInfo{}
 
 	for _, p := range s.host.peers() {
-		if s.status.confirmed(p) {
-			if m, ok := s.status.peerMessage.Load(p); ok {
-				msg, ok := m.(*StatusMessage)
-				if !ok {
-					return peers
-				}
-
-				peers = append(peers, common.PeerInfo{
-					PeerID:          p.String(),
-					Roles:           msg.Roles,
-					ProtocolVersion: msg.ProtocolVersion,
-					BestHash:        msg.BestBlockHash,
-					BestNumber:      msg.BestBlockNumber,
-				})
-			}
+		view, has := s.peerViews.get(p)
+		if !has {
+			continue
 		}
+
+		peers = append(peers, common.PeerInfo{
+			PeerID:          p.String(),
+			Roles:           view.roles,
+			ProtocolVersion: view.protocolVersion,
+			BestHash:        view.bestHash,
+			BestNumber:      view.bestNumber,
+			FinalizedNumber: view.finalizedNumber,
+			Reputation:      s.host.reputations.value(p),
+		})
 	}
 	return peers
 }
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/common/optional"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
@@ -33,8 +34,13 @@ import (
 
 var TestProtocolID = "/gossamer/test/0"
 
+// arbitrary transaction message, which is sent on the transactions notifications protocol
+var TestMessage = &TransactionMessage{
+	Extrinsics: []types.Extrinsic{{1, 2, 3}, {4, 5, 6}},
+}
+
 // arbitrary block request message
-var TestMessage = &BlockRequestMessage{
+var TestBlockRequest = &BlockRequestMessage{
 	ID:            1,
 	RequestedData: 1,
 	// TODO: investigate starting block mismatch with different slice length
@@ -218,6 +224,80 @@ func TestHandleMessage_BlockAnnounce(t *testing.T) {
 	require.True(t, s.requestTracker.hasRequestedBlockID(99))
 }
 
+func TestHandleMessage_GossipValidator(t *testing.T) {
+	basePath := utils.NewTestBasePath(t, "nodeA")
+	defer utils.RemoveTestDir(t)
+
+	msgSend := make(chan Message, 4)
+	validator := &mockGossipValidator{}
+
+	config := &Config{
+		BasePath:        basePath,
+		Port:            7001,
+		RandSeed:        1,
+		NoBootstrap:     true,
+		NoMDNS:          true,
+		NoStatus:        true,
+		MsgSend:         msgSend,
+		GossipValidator: validator,
+	}
+
+	s := createTestService(t, config)
+
+	peerID := peer.ID("noot")
+	msg := &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              []byte{0, 1, 2},
+	}
+
+	// the message is dropped
+	s.handleMessage(peerID, msg)
+	select {
+	case <-msgSend:
+		t.Fatal("should not have sent invalid message to core service")
+	case <-time.After(TestMessageTimeout / 10):
+	}
+
+	validator.process = true
+	s.handleMessage(peerID, msg)
+	select {
+	case m := <-msgSend:
+		require.Equal(t, msg, m)
+	case <-time.After(TestMessageTimeout):
+		t.Fatal("did not send valid message to core service")
+	}
+
+	// only consensus messages are filtered by the validator
+	require.True(t, s.host.sendFilter(TestMessage)(peerID))
+	require.False(t, s.host.sendFilter(msg)(peerID))
+	validator.send = true
+	require.True(t, s.host.sendFilter(msg)(peerID))
+}
+
+func TestHandleDisconnect_GossipValidator(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	validator := &mockGossipValidator{disconnected: make(chan peer.ID, 1)}
+	nodes := createTestPeers(t, &Config{GossipValidator: validator}, &Config{})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	time.Sleep(TestMessageTimeout)
+
+	err := nodeA.host.closePeer(nodeB.host.id())
+	require.NoError(t, err)
+
+	select {
+	case p := <-validator.disconnected:
+		require.Equal(t, nodeB.host.id(), p)
+	case <-time.After(TestMessageTimeout):
+		t.Fatal("validator was not notified of the disconnection")
+	}
+}
+
 func TestHandleSyncMessage_BlockResponse(t *testing.T) {
 	basePath := utils.NewTestBasePath(t, "nodeA")
 	defer utils.RemoveTestDir(t)
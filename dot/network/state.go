@@ -21,12 +21,16 @@ import (
 
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/libp2p/go-libp2p-core/peer"
 )
 
 // BlockState interface for block state methods
 type BlockState interface {
 	BestBlockHeader() (*types.Header, error)
 	GenesisHash() common.Hash
+	GetRound() (uint64, error)
+	GetFinalizedHeader(uint64) (*types.Header, error)
 }
 
 // NetworkState interface for network state methods
@@ -44,7 +48,8 @@ type Syncer interface {
 
 	// HandleBlockResponse is called upon receipt of BlockResponseMessage to process it.
 	// If another request needs to be sent to the peer, this function will return it.
-	HandleBlockResponse(*BlockResponseMessage) *BlockRequestMessage
+	// If the response contains blocks that cannot be decoded or verified, it returns an error and the peer is penalised.
+	HandleBlockResponse(*BlockResponseMessage) (*BlockRequestMessage, error)
 
 	// HandleBlockAnnounce is called upon receipt of a BlockAnnounceMessage to process it.
 	// If a request needs to be sent to the peer to retrieve the full block, this function will return it.
@@ -52,4 +57,61 @@ type Syncer interface {
 
 	// HandleSeenBlocks is called upon receiving a StatusMessage from a peer that has a higher chain head than us
 	HandleSeenBlocks(*big.Int) *BlockRequestMessage
+
+	// BlockRequests is called periodically and after each BlockResponseMessage is handled, with our peers whose chain
+	// views are known, to get the requests for the chunks of the missing range of blocks that need to be sent, eg.
+	// because the previous requests for them timed out. each request is returned with the peer to send it to, and
+	// chunks that none of the peers is suitable for are left to be requested later.
+	BlockRequests(peers []common.PeerInfo) []*ScheduledBlockRequest
+
+	// SelectPeer is called before a BlockRequestMessage is sent, to pick the peer to send it to out of our peers
+	// whose chain views are known. it returns false if none of them is suitable, in which case the request is sent to
+	// the peer whose message caused it.
+	SelectPeer(req *BlockRequestMessage, peers []common.PeerInfo) (peer.ID, bool)
+}
+
+// ScheduledBlockRequest is a block request scheduled by the syncer, along with the peer it's sent to
+type ScheduledBlockRequest struct {
+	Peer    peer.ID
+	Request *BlockRequestMessage
+}
+
+// GossipValidator is implemented by the finality gadget to filter the consensus messages it gossips
+type GossipValidator interface {
+	// Validate is called upon receipt of a ConsensusMessage from a peer. it returns whether the message should be
+	// sent to the core service to be processed, and whether it should be gossiped to our other peers. if the message
+	// is invalid, eg. it cannot be decoded or is badly signed, it returns an error and the peer is penalised.
+	Validate(from peer.ID, msg *ConsensusMessage) (process, propagate bool, err error)
+
+	// SendFilter is called once each time a ConsensusMessage is broadcast. it returns a function that's called for
+	// each of our peers, and returns whether the message should be sent to the peer.
+	SendFilter(msg *ConsensusMessage) (shouldSend func(to peer.ID) bool)
+
+	// PeerDisconnected is called once we have no more connections to the peer, so that its state can be removed
+	PeerDisconnected(p peer.ID)
+}
+
+// FinalityProofProvider is implemented by the finality gadget to prove to peers that a block is final
+type FinalityProofProvider interface {
+	// ProveFinality returns the encoded proof that the block with the given hash is final
+	ProveFinality(hash common.Hash) ([]byte, error)
+}
+
+// TransactionHandler is implemented by the core service to validate the transactions received from peers
+type TransactionHandler interface {
+	// ProcessTransactionMessage validates the transactions in the message and adds them to the transaction queue.
+	// if a transaction is invalid, it returns an error and the peer is penalised.
+	ProcessTransactionMessage(*TransactionMessage) error
+}
+
+// LightServer is implemented by the core service to answer the remote requests of light clients, with proofs from the
+// state of the requested block
+type LightServer interface {
+	// ReadProof returns the proof of the values of the storage keys in the state of the block with the given hash
+	ReadProof(block common.Hash, keys [][]byte) ([][]byte, error)
+
+	// ExecutionProof calls the runtime method with the data in the state of the block with the given hash. it returns
+	// the result of the call and the proof of the storage it read, which lets the light client check the result by
+	// making the call itself.
+	ExecutionProof(block common.Hash, method string, data []byte) ([]byte, [][]byte, error)
 }
@@ -47,13 +47,14 @@ func (mbs *MockBlockState) BestBlockHeader() (*types.Header, error) {
 		return nil, err
 	}
 
-	if mbs.number == nil {
-		mbs.number = big.NewInt(1)
+	number := mbs.number
+	if number == nil {
+		number = big.NewInt(1)
 	}
 
 	return &types.Header{
 		ParentHash:     parentHash,
-		Number:         mbs.number,
+		Number:         number,
 		StateRoot:      stateRoot,
 		ExtrinsicsRoot: extrinsicsRoot,
 		Digest:         [][]byte{{}},
@@ -64,6 +65,16 @@ func (mbs *MockBlockState) GenesisHash() common.Hash {
 	return common.NewHash([]byte{})
 }
 
+func (mbs *MockBlockState) GetRound() (uint64, error) {
+	return 0, nil
+}
+
+func (mbs *MockBlockState) GetFinalizedHeader(round uint64) (*types.Header, error) {
+	return &types.Header{
+		Number: big.NewInt(0),
+	}, nil
+}
+
 // MockNetworkState for testing purposes
 type MockNetworkState struct {
 	Health       common.Health
@@ -18,26 +18,41 @@ package network
 
 import (
 	"bytes"
-	"context"
+	"encoding/binary"
 	"sync"
 	"time"
 
 	log "github.com/ChainSafe/log15"
-	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
 
-// SendStatusInterval is the time between sending status messages
-const SendStatusInterval = 5 * time.Minute
+// chainStatusLength is the length of the chain status we send in our status handshake: a byte with the compression
+// algorithms we support, followed by the little-endian number of our latest finalized block. peers running older
+// versions send shorter chain statuses, from which the missing fields are treated as zero.
+const chainStatusLength = 9
+
+// encodeChainStatus returns the chain status of our status handshake
+func encodeChainStatus(compression byte, finalized uint64) []byte {
+	cs := make([]byte, chainStatusLength)
+	cs[0] = compression
+	binary.LittleEndian.PutUint64(cs[1:], finalized)
+	return cs
+}
 
-// ExpireStatusInterval is the time between expiring status messages
-const ExpireStatusInterval = SendStatusInterval + time.Minute
+// chainStatusFinalized returns the finalized block number from the chain status of a peer's status message
+func chainStatusFinalized(msg *StatusMessage) uint64 {
+	if len(msg.ChainStatus) < chainStatusLength {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(msg.ChainStatus[1:chainStatusLength])
+}
 
 // status submodule
 type status struct {
 	logger        log.Logger
 	host          *host
 	hostMessage   *StatusMessage
+	hostLock      sync.RWMutex
 	peerConfirmed *sync.Map //map of peer.ID to time.Time
 	peerMessage   *sync.Map //map of peer.ID to *StatusMessage
 }
@@ -63,44 +78,15 @@ func (status *status) confirmed(peer peer.ID) bool {
 
 // setHostMessage sets the host status message
 func (status *status) setHostMessage(msg Message) {
+	status.hostLock.Lock()
+	defer status.hostLock.Unlock()
 	status.hostMessage = msg.(*StatusMessage)
 }
 
-// handleConn starts status processes upon connection
-func (status *status) handleConn(conn network.Conn) {
-	ctx := context.Background()
-	remotePeer := conn.RemotePeer()
-
-	// check if host message set
-	if status.hostMessage != nil {
-
-		// send initial host status message to peer upon connection
-		err := status.host.send(remotePeer, "", status.hostMessage)
-		if err != nil {
-			status.logger.Error(
-				"Failed to send status message to peer",
-				"peer", remotePeer,
-				"error", err,
-			)
-		}
-
-		// handle status message expiration
-		go status.expireStatus(ctx, remotePeer)
-
-	} else {
-		status.logger.Error(
-			"Failed to send status message to peer",
-			"peer", remotePeer,
-			"error", "host status message not set",
-		)
-	}
-}
-
 // handleMessage checks if the peer status message is compatible with the host
-// status message, then either manages peer status or closes peer connection
-func (status *status) handleMessage(peer peer.ID, msg *StatusMessage) {
-	ctx := context.Background()
-
+// status message, then either confirms the peer or closes the peer connection.
+// it returns whether the status message is valid.
+func (status *status) handleMessage(peer peer.ID, msg *StatusMessage) bool {
 	// check if valid status message
 	if status.validMessage(msg) {
 
@@ -110,21 +96,23 @@ func (status *status) handleMessage(peer peer.ID, msg *StatusMessage) {
 		// update peer status message
 		status.peerMessage.Store(peer, msg)
 
-		// wait then send next host status message
-		go status.sendNextMessage(ctx, peer)
-
-	} else {
+		return true
+	}
 
-		// close connection with peer if status message is not valid
-		err := status.closePeer(ctx, peer)
-		if err != nil {
-			status.logger.Error("Failed to close peer with invalid status message", "error", err)
-		}
+	// close connection with peer if status message is not valid
+	err := status.closePeer(peer)
+	if err != nil {
+		status.logger.Error("Failed to close peer with invalid status message", "error", err)
 	}
+
+	return false
 }
 
 // validMessage confirms the status message is valid
 func (status *status) validMessage(msg *StatusMessage) bool {
+	status.hostLock.RLock()
+	defer status.hostLock.RUnlock()
+
 	if status.hostMessage == nil {
 		return false
 	}
@@ -154,82 +142,17 @@ func (status *status) validMessage(msg *StatusMessage) bool {
 	return true
 }
 
-// sendNextMessage waits a set time between receiving a valid peer message and
-// sending the next host message. The "next" host message is after the initial
-// host message sent on connection and all host messages sent through the same
-// process; this event should occur at every set time for every connected peer
-// using the same 'send on connect' and 'send on receive' protocol). After set
-// time, if the peer is still connected, sendNextMessage sends the next host
-// message and starts a process that will manage expiratation.
-func (status *status) sendNextMessage(ctx context.Context, peer peer.ID) {
-
-	// wait between sending status messages
-	time.Sleep(SendStatusInterval)
-
-	// check if peer is still connected
-	if status.host.peerConnected(peer) {
-
-		// send host status message to peer
-		err := status.host.send(peer, "", status.hostMessage)
-		if err != nil {
-			status.logger.Error(
-				"Failed to send host status message to peer",
-				"peer", peer,
-				"error", err,
-			)
-		}
-
-		// handle status message expiration
-		go status.expireStatus(ctx, peer)
-
-	} else {
-
-		// delete peer mappings
-		status.peerConfirmed.Delete(peer)
-		status.peerMessage.Delete(peer)
-
-		ctx.Done() // cancel running processes
-
-	}
-}
-
-// expireStatus closes peer connection if status message has exipred
-func (status *status) expireStatus(ctx context.Context, peer peer.ID) {
-
-	// wait to check for expired status message
-	// TODO: this wakes up every 6 minutes, while sendNextMessage wakes up every 5 minutes.
-	// this may cause the messages to get out of sync, investigate
-	time.Sleep(ExpireStatusInterval)
-
-	// get time of last confirmed status message
-	lastConfirmed, ok := status.peerConfirmed.Load(peer)
-	if !ok {
-		return
-	}
-
-	// check if status message has expired
-	if time.Since(lastConfirmed.(time.Time)) > SendStatusInterval {
-
-		// update peer information and close connection
-		err := status.closePeer(ctx, peer)
-		if err != nil {
-			status.logger.Error("Failed to close peer with expired status message", "error", err)
-		}
-	}
+// removePeer deletes the peer's status, eg. once it has disconnected
+func (status *status) removePeer(peer peer.ID) {
+	status.peerConfirmed.Delete(peer)
+	status.peerMessage.Delete(peer)
 }
 
 // closePeer updates status state and closes the connection
-func (status *status) closePeer(ctx context.Context, peer peer.ID) error {
-
-	// cancel running processes
-	ctx.Done()
-
+func (status *status) closePeer(peer peer.ID) error {
 	// delete peer mappings
-	status.peerConfirmed.Delete(peer)
-	status.peerMessage.Delete(peer)
+	status.removePeer(peer)
 
 	// close connection with peer
-	err := status.host.closePeer(peer)
-
-	return err
+	return status.host.closePeer(peer)
 }
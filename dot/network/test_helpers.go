@@ -2,10 +2,17 @@ package network
 
 import (
 	"math/big"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/optional"
+	"github.com/ChainSafe/gossamer/lib/common/variadic"
+
+	"github.com/libp2p/go-libp2p-core/peer"
 )
 
 type mockSyncer struct {
 	highestSeen *big.Int
+	responseErr error
 }
 
 func newMockSyncer() *mockSyncer {
@@ -15,11 +22,13 @@ func newMockSyncer() *mockSyncer {
 }
 
 func (s *mockSyncer) CreateBlockResponse(msg *BlockRequestMessage) (*BlockResponseMessage, error) {
-	return nil, nil
+	return &BlockResponseMessage{
+		ID: msg.ID,
+	}, nil
 }
 
-func (s *mockSyncer) HandleBlockResponse(msg *BlockResponseMessage) *BlockRequestMessage {
-	return nil
+func (s *mockSyncer) HandleBlockResponse(msg *BlockResponseMessage) (*BlockRequestMessage, error) {
+	return nil, s.responseErr
 }
 
 func (s *mockSyncer) HandleBlockAnnounce(msg *BlockAnnounceMessage) *BlockRequestMessage {
@@ -27,14 +36,58 @@ func (s *mockSyncer) HandleBlockAnnounce(msg *BlockAnnounceMessage) *BlockReques
 		s.highestSeen = msg.Number
 	}
 
+	start, _ := variadic.NewUint64OrHash(msg.Number.Uint64())
+
 	return &BlockRequestMessage{
-		ID: 99,
+		ID:            99,
+		RequestedData: 3,
+		StartingBlock: start,
+		EndBlockHash:  optional.NewHash(false, common.Hash{}),
+		Direction:     1,
+		Max:           optional.NewUint32(false, 0),
 	}
 }
 
+func (s *mockSyncer) BlockRequests(peers []common.PeerInfo) []*ScheduledBlockRequest {
+	return nil
+}
+
+func (s *mockSyncer) SelectPeer(req *BlockRequestMessage, peers []common.PeerInfo) (peer.ID, bool) {
+	return "", false
+}
+
 func (s *mockSyncer) HandleSeenBlocks(num *big.Int) *BlockRequestMessage {
 	if num.Cmp(s.highestSeen) > 0 {
 		s.highestSeen = num
 	}
 	return nil
 }
+
+type mockGossipValidator struct {
+	process, propagate bool
+	send               bool
+	err                error
+	disconnected       chan peer.ID // if set, receives the peers passed to PeerDisconnected
+}
+
+func (v *mockGossipValidator) Validate(from peer.ID, msg *ConsensusMessage) (bool, bool, error) {
+	return v.process, v.propagate, v.err
+}
+
+func (v *mockGossipValidator) SendFilter(msg *ConsensusMessage) func(to peer.ID) bool {
+	return func(peer.ID) bool { return v.send }
+}
+
+func (v *mockGossipValidator) PeerDisconnected(p peer.ID) {
+	if v.disconnected != nil {
+		v.disconnected <- p
+	}
+}
+
+type mockTransactionHandler struct {
+	err error
+}
+
+func (h *mockTransactionHandler) ProcessTransactionMessage(msg *TransactionMessage) error {
+	return h.err
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// trafficIdleTimeout is how long the bandwidth of a peer or protocol is kept after we last sent or received anything
+var trafficIdleTimeout = time.Hour
+
+// messageCounts is the number of messages sent and received over a protocol or with a peer
+type messageCounts struct {
+	in, out uint64
+}
+
+// trafficCounter counts the bytes and messages we send and receive, per protocol and per peer. bytes are counted by
+// libp2p's bandwidth reporter, which sees every stream of the host including those of libp2p's own protocols, while
+// messages are counted as we read and write them.
+type trafficCounter struct {
+	sync.Mutex
+	bandwidth *metrics.BandwidthCounter
+	total     messageCounts
+	protocols map[protocol.ID]*messageCounts
+	peers     map[peer.ID]*messageCounts
+}
+
+func newTrafficCounter() *trafficCounter {
+	return &trafficCounter{
+		bandwidth: metrics.NewBandwidthCounter(),
+		protocols: make(map[protocol.ID]*messageCounts),
+		peers:     make(map[peer.ID]*messageCounts),
+	}
+}
+
+// messageReceived counts a message received from the peer over the protocol
+func (tc *trafficCounter) messageReceived(p peer.ID, pid protocol.ID) {
+	tc.Lock()
+	defer tc.Unlock()
+
+	tc.total.in++
+	tc.protocolCounts(pid).in++
+	tc.peerCounts(p).in++
+}
+
+// messageSent counts a message sent to the peer over the protocol
+func (tc *trafficCounter) messageSent(p peer.ID, pid protocol.ID) {
+	tc.Lock()
+	defer tc.Unlock()
+
+	tc.total.out++
+	tc.protocolCounts(pid).out++
+	tc.peerCounts(p).out++
+}
+
+func (tc *trafficCounter) protocolCounts(pid protocol.ID) *messageCounts {
+	counts, has := tc.protocols[pid]
+	if !has {
+		counts = &messageCounts{}
+		tc.protocols[pid] = counts
+	}
+	return counts
+}
+
+func (tc *trafficCounter) peerCounts(p peer.ID) *messageCounts {
+	counts, has := tc.peers[p]
+	if !has {
+		counts = &messageCounts{}
+		tc.peers[p] = counts
+	}
+	return counts
+}
+
+// removePeer drops the message counts of a peer we've disconnected from, and the bandwidth of peers and protocols
+// that have been idle for longer than trafficIdleTimeout
+func (tc *trafficCounter) removePeer(p peer.ID) {
+	tc.Lock()
+	delete(tc.peers, p)
+	tc.Unlock()
+
+	tc.bandwidth.TrimIdle(time.Now().Add(-trafficIdleTimeout))
+}
+
+// stats returns our traffic in total, per protocol and per connected peer
+func (tc *trafficCounter) stats(connected []peer.ID) common.NetworkStats {
+	tc.Lock()
+	defer tc.Unlock()
+
+	stats := common.NetworkStats{
+		Total:     trafficStats(tc.bandwidth.GetBandwidthTotals(), &tc.total),
+		Protocols: make(map[string]common.TrafficStats),
+		Peers:     make(map[string]common.TrafficStats),
+	}
+
+	for pid, bw := range tc.bandwidth.GetBandwidthByProtocol() {
+		stats.Protocols[string(pid)] = trafficStats(bw, tc.protocols[pid])
+	}
+
+	// protocols we've counted messages for always have bandwidth, unless it's been trimmed
+	for pid, counts := range tc.protocols {
+		if _, has := stats.Protocols[string(pid)]; !has {
+			stats.Protocols[string(pid)] = trafficStats(metrics.Stats{}, counts)
+		}
+	}
+
+	for _, p := range connected {
+		stats.Peers[p.String()] = trafficStats(tc.bandwidth.GetBandwidthForPeer(p), tc.peers[p])
+	}
+
+	return stats
+}
+
+// trafficStats combines the bandwidth reported by libp2p with our message counts, which may be nil
+func trafficStats(bw metrics.Stats, counts *messageCounts) common.TrafficStats {
+	stats := common.TrafficStats{
+		BytesIn:  bw.TotalIn,
+		BytesOut: bw.TotalOut,
+		RateIn:   bw.RateIn,
+		RateOut:  bw.RateOut,
+	}
+
+	if counts != nil {
+		stats.MessagesIn = counts.in
+		stats.MessagesOut = counts.out
+	}
+
+	return stats
+}
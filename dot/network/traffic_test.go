@@ -0,0 +1,89 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkStats(t *testing.T) {
+	defer utils.RemoveTestDir(t)
+
+	nodes := createTestPeers(t, &Config{}, &Config{})
+	nodeA, nodeB := nodes[0], nodes[1]
+	for _, node := range nodes {
+		defer node.Stop()
+	}
+
+	connectTestPeer(t, nodeA, nodeB)
+	time.Sleep(TestMessageTimeout)
+
+	nodeA.sendBlockRequest(nodeB.host.id(), TestBlockRequest)
+	time.Sleep(TestMessageTimeout)
+
+	statsA, statsB := nodeA.NetworkStats(), nodeB.NetworkStats()
+	require.NotZero(t, statsA.Total.BytesIn)
+	require.NotZero(t, statsA.Total.BytesOut)
+
+	// node A sent one block request and received one response over the sync protocol
+	syncA, has := statsA.Protocols[string(nodeA.host.protocolID+compressedProtocol(syncID, compressionZstd))]
+	require.True(t, has)
+	require.Equal(t, uint64(1), syncA.MessagesOut)
+	require.Equal(t, uint64(1), syncA.MessagesIn)
+	require.NotZero(t, syncA.BytesOut)
+	require.NotZero(t, syncA.BytesIn)
+
+	// libp2p's own protocols are included
+	require.Greater(t, len(statsA.Protocols), 1)
+
+	peerB, has := statsA.Peers[nodeB.host.id().String()]
+	require.True(t, has)
+	require.GreaterOrEqual(t, peerB.MessagesOut, uint64(1))
+	require.NotZero(t, peerB.BytesIn)
+
+	peerA, has := statsB.Peers[nodeA.host.id().String()]
+	require.True(t, has)
+	require.Equal(t, peerB.MessagesOut, peerA.MessagesIn)
+	require.Equal(t, peerB.MessagesIn, peerA.MessagesOut)
+}
+
+func TestTrafficCounter_RemovePeer(t *testing.T) {
+	tc := newTrafficCounter()
+	p := peer.ID("noot")
+
+	tc.messageReceived(p, syncID)
+	tc.messageSent(p, syncID)
+	tc.messageSent(p, syncID)
+
+	stats := tc.stats([]peer.ID{p})
+	require.Equal(t, uint64(1), stats.Total.MessagesIn)
+	require.Equal(t, uint64(2), stats.Protocols[string(syncID)].MessagesOut)
+	require.Equal(t, uint64(2), stats.Peers[p.String()].MessagesOut)
+
+	// disconnected peers are dropped, while the totals are kept
+	tc.removePeer(p)
+	stats = tc.stats(nil)
+	require.Empty(t, stats.Peers)
+	require.Equal(t, uint64(2), stats.Total.MessagesOut)
+	require.Equal(t, uint64(1), stats.Protocols[string(syncID)].MessagesIn)
+}
@@ -26,9 +26,11 @@ import (
 
 	"github.com/ChainSafe/gossamer/dot/core"
 	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/grandpa"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/services"
 
@@ -186,6 +188,11 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 		return nil, fmt.Errorf("no keys provided for authority node")
 	}
 
+	// light clients don't have the state needed to produce or finalize blocks
+	if LightClientEnabled(cfg) && (BlockProducerEnabled(cfg) || cfg.Core.GrandpaAuthority) {
+		return nil, ErrLightClientAuthority
+	}
+
 	// Node Services
 
 	logger.Info(
@@ -220,9 +227,9 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 	var bp BlockProducer
 	var fg core.FinalityGadget
 
-	if cfg.Core.BabeAuthority {
-		// create BABE service
-		bp, err = createBABEService(cfg, rt, stateSrvc, ks)
+	if BlockProducerEnabled(cfg) {
+		// create BABE or manual seal service
+		bp, err = createBlockProducer(cfg, rt, stateSrvc, ks)
 		if err != nil {
 			return nil, err
 		}
@@ -239,8 +246,23 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 		nodeSrvcs = append(nodeSrvcs, fg)
 	}
 
+	// nodes that aren't GRANDPA authorities follow finality with an observer
+	var observer *grandpa.Observer
+	if !cfg.Core.GrandpaAuthority {
+		observer, err = createGRANDPAObserver(cfg, rt, stateSrvc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dh, err := createDigestHandler(stateSrvc, bp, fg, observer)
+	if err != nil {
+		return nil, err
+	}
+	nodeSrvcs = append(nodeSrvcs, dh)
+
 	// Syncer
-	syncer, err := createSyncService(cfg, stateSrvc, bp, fg, rt)
+	syncer, err := createSyncService(cfg, stateSrvc, bp, fg, observer, dh, rt)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +270,7 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 	// Core Service
 
 	// create core service and append core service to node services
-	coreSrvc, err := createCoreService(cfg, bp, fg, rt, ks, stateSrvc, coreMsgs, networkMsgs)
+	coreSrvc, err := createCoreService(cfg, bp, fg, observer, rt, ks, stateSrvc, coreMsgs, networkMsgs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create core service: %s", err)
 	}
@@ -262,7 +284,7 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 	if enabled := NetworkServiceEnabled(cfg); enabled {
 
 		// create network service and append network service to node services
-		networkSrvc, err = createNetworkService(cfg, stateSrvc, coreMsgs, networkMsgs, syncer)
+		networkSrvc, err = createNetworkService(cfg, stateSrvc, coreMsgs, networkMsgs, syncer, fg, observer, coreSrvc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create network service: %s", err)
 		}
@@ -275,6 +297,17 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 
 	}
 
+	// Light Client
+
+	// light clients read state from full peers, since they only sync block headers
+	var lightAPI modules.LightAPI
+	if LightClientEnabled(cfg) {
+		lightAPI, err = createLightClient(cfg, stateSrvc, networkSrvc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create light client: %s", err)
+		}
+	}
+
 	// System Service
 
 	// create system service and append to node services
@@ -287,7 +320,7 @@ func NewNode(cfg *Config, ks *keystore.Keystore) (*Node, error) {
 	if enabled := RPCServiceEnabled(cfg); enabled {
 
 		// create rpc service and append rpc service to node services
-		rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, bp, rt, sysSrvc)
+		rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, bp, rt, sysSrvc, fg, lightAPI)
 		if err != nil {
 			return nil, err
 		}
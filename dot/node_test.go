@@ -143,6 +143,39 @@ func TestNewNode_Authority(t *testing.T) {
 	require.NotNil(t, fg)
 }
 
+func TestNewNode_ManualSeal(t *testing.T) {
+	cfg := NewTestConfig(t)
+	require.NotNil(t, cfg)
+
+	genFile := NewTestGenesisFile(t, cfg)
+	require.NotNil(t, genFile)
+
+	defer utils.RemoveTestDir(t)
+
+	cfg.Init.Genesis = genFile.Name()
+
+	err := InitNode(cfg)
+	require.Nil(t, err)
+
+	ks, err := keystore.LoadKeystore("alice")
+	require.Nil(t, err)
+	require.NotNil(t, ks)
+
+	// --dev produces blocks on demand, without the node being a BABE authority
+	cfg.Core.Authority = false
+	cfg.Core.BabeAuthority = false
+	cfg.Core.GrandpaAuthority = false
+	cfg.Core.BabeThreshold = nil
+	cfg.Core.Consensus = ManualConsensus
+
+	node, err := NewNode(cfg, ks)
+	require.Nil(t, err)
+
+	bp := node.Services.Get(&babe.ManualSealService{})
+	require.NotNil(t, bp)
+	require.Nil(t, node.Services.Get(&babe.Service{}))
+}
+
 // TestStartNode
 func TestStartNode(t *testing.T) {
 	cfg := NewTestConfig(t)
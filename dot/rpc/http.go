@@ -49,6 +49,9 @@ type HTTPServerConfig struct {
 	TransactionQueueAPI modules.TransactionQueueAPI
 	RPCAPI              modules.RPCAPI
 	SystemAPI           modules.SystemAPI
+	GrandpaAPI          modules.GrandpaAPI
+	FinalityProofAPI    modules.FinalityProofAPI
+	LightAPI            modules.LightAPI
 	Host                string
 	RPCPort             uint32
 	WSEnabled           bool
@@ -100,11 +103,13 @@ func (h *HTTPServer) RegisterModules(mods []string) {
 		case "chain":
 			srvc = modules.NewChainModule(h.serverConfig.BlockAPI)
 		case "state":
-			srvc = modules.NewStateModule(h.serverConfig.NetworkAPI, h.serverConfig.StorageAPI, h.serverConfig.CoreAPI)
+			srvc = modules.NewStateModule(h.serverConfig.NetworkAPI, h.serverConfig.StorageAPI, h.serverConfig.CoreAPI, h.serverConfig.LightAPI)
 		case "rpc":
 			srvc = modules.NewRPCModule(h.serverConfig.RPCAPI)
 		case "dev":
 			srvc = modules.NewDevModule(h.serverConfig.BlockProducerAPI, h.serverConfig.NetworkAPI)
+		case "grandpa":
+			srvc = modules.NewGrandpaModule(h.serverConfig.GrandpaAPI, h.serverConfig.FinalityProofAPI)
 		default:
 			h.logger.Warn("Unrecognized module", "module", mod)
 			continue
@@ -131,6 +136,9 @@ func (h *HTTPServer) Start() error {
 	h.logger.Info("Starting HTTP Server...", "host", h.serverConfig.Host, "port", h.serverConfig.RPCPort)
 	r := mux.NewRouter()
 	r.Handle("/", h.rpcServer)
+	if h.serverConfig.NetworkAPI != nil {
+		r.Handle("/metrics", &metricsHandler{stats: h.serverConfig.NetworkAPI.NetworkStats})
+	}
 	go func() {
 		err := http.ListenAndServe(fmt.Sprintf(":%d", h.serverConfig.RPCPort), r)
 		if err != nil {
@@ -0,0 +1,100 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4"
+
+// metricsHandler serves the network traffic counters at /metrics in the Prometheus text exposition format. the
+// counters of each peer aren't exported, since labelling series by peer ID would create a new series for every peer
+// the node ever connects to; they're only available from system_networkStats.
+type metricsHandler struct {
+	stats func() common.NetworkStats
+}
+
+// ServeHTTP writes the current network traffic counters to the response
+func (m *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stats := m.stats()
+	buf := new(bytes.Buffer)
+
+	writeMetricHeader(buf, "gossamer_network_bytes_total", "counter", "Bytes sent and received by the node")
+	writeTrafficMetric(buf, "gossamer_network_bytes_total", "", "", float64(stats.Total.BytesIn), float64(stats.Total.BytesOut))
+
+	writeMetricHeader(buf, "gossamer_network_messages_total", "counter", "Messages sent and received by the node")
+	writeTrafficMetric(buf, "gossamer_network_messages_total", "", "", float64(stats.Total.MessagesIn), float64(stats.Total.MessagesOut))
+
+	writeMetricHeader(buf, "gossamer_network_bandwidth_bytes_per_second", "gauge", "Current bandwidth of the node")
+	writeTrafficMetric(buf, "gossamer_network_bandwidth_bytes_per_second", "", "", stats.Total.RateIn, stats.Total.RateOut)
+
+	writeMetricHeader(buf, "gossamer_network_protocol_bytes_total", "counter", "Bytes sent and received per protocol")
+	for _, pid := range sortedKeys(stats.Protocols) {
+		s := stats.Protocols[pid]
+		writeTrafficMetric(buf, "gossamer_network_protocol_bytes_total", "protocol", pid, float64(s.BytesIn), float64(s.BytesOut))
+	}
+
+	writeMetricHeader(buf, "gossamer_network_protocol_messages_total", "counter", "Messages sent and received per protocol")
+	for _, pid := range sortedKeys(stats.Protocols) {
+		s := stats.Protocols[pid]
+		writeTrafficMetric(buf, "gossamer_network_protocol_messages_total", "protocol", pid, float64(s.MessagesIn), float64(s.MessagesOut))
+	}
+
+	w.Header().Set("Content-Type", metricsContentType)
+	_, err := w.Write(buf.Bytes())
+	if err != nil {
+		logger.Debug("failed to write metrics response", "error", err)
+	}
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, kind, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeTrafficMetric writes the inbound and outbound samples of the metric, with the given label if it's not empty
+func writeTrafficMetric(buf *bytes.Buffer, name, label, value string, in, out float64) {
+	labels := ""
+	if label != "" {
+		labels = fmt.Sprintf(",%s=\"%s\"", label, escapeLabelValue(value))
+	}
+
+	fmt.Fprintf(buf, "%s{direction=\"in\"%s} %g\n", name, labels, in)
+	fmt.Fprintf(buf, "%s{direction=\"out\"%s} %g\n", name, labels, out)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func sortedKeys(m map[string]common.TrafficStats) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
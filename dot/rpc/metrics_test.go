@@ -0,0 +1,63 @@
+// Copyright 2020 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	stats := common.NetworkStats{
+		Total: common.TrafficStats{BytesIn: 300, BytesOut: 200, MessagesIn: 3, MessagesOut: 2, RateIn: 1.5},
+		Protocols: map[string]common.TrafficStats{
+			"/gossamer/gssmr/0/sync/2": {BytesIn: 300, BytesOut: 200, MessagesIn: 3, MessagesOut: 2},
+		},
+		Peers: map[string]common.TrafficStats{
+			"12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu": {BytesIn: 300, BytesOut: 200, MessagesIn: 3, MessagesOut: 2},
+		},
+	}
+
+	handler := &metricsHandler{stats: func() common.NetworkStats { return stats }}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, metricsContentType, rec.Header().Get("Content-Type"))
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	expected := []string{
+		"# TYPE gossamer_network_bytes_total counter\n",
+		"gossamer_network_bytes_total{direction=\"in\"} 300\n",
+		"gossamer_network_messages_total{direction=\"out\"} 2\n",
+		"gossamer_network_bandwidth_bytes_per_second{direction=\"in\"} 1.5\n",
+		"gossamer_network_protocol_bytes_total{direction=\"out\",protocol=\"/gossamer/gssmr/0/sync/2\"} 200\n",
+		"gossamer_network_protocol_messages_total{direction=\"in\",protocol=\"/gossamer/gssmr/0/sync/2\"} 3\n",
+	}
+	for _, line := range expected {
+		require.Contains(t, string(body), line)
+	}
+	require.NotContains(t, string(body), "12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu")
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	require.Equal(t, `a\"b\\c\nd`, escapeLabelValue("a\"b\\c\nd"))
+}
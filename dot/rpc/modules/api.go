@@ -7,6 +7,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/grandpa"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 )
@@ -35,7 +36,10 @@ type NetworkAPI interface {
 	Health() common.Health
 	NetworkState() common.NetworkState
 	Peers() []common.PeerInfo
+	NetworkStats() common.NetworkStats
 	NodeRoles() byte
+	AddReservedPeer(addr string) error
+	RemoveReservedPeer(id string) error
 	Stop() error
 	Start() error
 	IsStopped() bool
@@ -47,6 +51,11 @@ type BlockProducerAPI interface {
 	Resume() error
 }
 
+// BlockSealAPI is the interface for block producers that build blocks on demand
+type BlockSealAPI interface {
+	SealBlock(createEmpty, finalize bool) (common.Hash, error)
+}
+
 // TransactionQueueAPI ...
 type TransactionQueueAPI interface {
 	Push(*transaction.ValidTransaction) (common.Hash, error)
@@ -63,6 +72,13 @@ type CoreAPI interface {
 	IsBlockProducer() bool
 	HandleSubmittedExtrinsic(types.Extrinsic) error
 	GetMetadata() ([]byte, error)
+	Call(block common.Hash, method string, data []byte) ([]byte, error)
+}
+
+// LightAPI is the interface for light clients, which read state from full peers
+type LightAPI interface {
+	GetStorage(block common.Hash, key []byte) ([]byte, error)
+	Call(block common.Hash, method string, data []byte) ([]byte, error)
 }
 
 // RPCAPI is the interface for methods related to RPC service
@@ -83,3 +99,13 @@ type SystemAPI interface {
 	NodeName() string
 	Properties() map[string]interface{}
 }
+
+// GrandpaAPI is the interface for the GRANDPA finality gadget
+type GrandpaAPI interface {
+	Equivocations() []*grandpa.Equivocation
+}
+
+// FinalityProofAPI is the interface for building finality proofs
+type FinalityProofAPI interface {
+	ProveFinality(hash common.Hash) ([]byte, error)
+}
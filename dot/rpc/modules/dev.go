@@ -3,6 +3,8 @@ package modules
 import (
 	"errors"
 	"net/http"
+
+	"github.com/ChainSafe/gossamer/lib/common"
 )
 
 var blockProducerStoppedMsg = "babe service stopped"
@@ -16,6 +18,14 @@ type DevModule struct {
 	blockProducerAPI BlockProducerAPI
 }
 
+// DevCreateBlockRequest holds the optional [createEmpty, finalize] parameters of dev_createBlock
+type DevCreateBlockRequest []bool
+
+// DevCreateBlockResponse is the response of dev_createBlock
+type DevCreateBlockResponse struct {
+	Hash common.Hash `json:"hash"`
+}
+
 // NewDevModule creates a new Dev module.
 func NewDevModule(bp BlockProducerAPI, net NetworkAPI) *DevModule {
 	return &DevModule{
@@ -54,3 +64,31 @@ func (m *DevModule) Control(r *http.Request, req *[]string, res *string) error {
 	}
 	return err
 }
+
+// CreateBlock builds a block on demand if the block producer supports manual sealing.
+// By default an empty block is built if there are no pending extrinsics and the block is not finalized.
+func (m *DevModule) CreateBlock(r *http.Request, req *DevCreateBlockRequest, res *DevCreateBlockResponse) error {
+	sealer, ok := m.blockProducerAPI.(BlockSealAPI)
+	if !ok {
+		return errors.New("block producer does not support manual sealing")
+	}
+
+	createEmpty, finalize := true, false
+	if req != nil {
+		reqA := *req
+		if len(reqA) > 0 {
+			createEmpty = reqA[0]
+		}
+		if len(reqA) > 1 {
+			finalize = reqA[1]
+		}
+	}
+
+	hash, err := sealer.SealBlock(createEmpty, finalize)
+	if err != nil {
+		return err
+	}
+
+	res.Hash = hash
+	return nil
+}
@@ -75,3 +75,42 @@ func TestDevControl_Network(t *testing.T) {
 	require.Equal(t, networkStartedMsg, res)
 	require.False(t, net.IsStopped())
 }
+
+type mockBlockSealer struct {
+	createEmpty bool
+	finalize    bool
+}
+
+func (m *mockBlockSealer) Pause() error  { return nil }
+func (m *mockBlockSealer) Resume() error { return nil }
+
+func (m *mockBlockSealer) SealBlock(createEmpty, finalize bool) (common.Hash, error) {
+	m.createEmpty = createEmpty
+	m.finalize = finalize
+	return common.Hash{1}, nil
+}
+
+func TestDevCreateBlock(t *testing.T) {
+	sealer := &mockBlockSealer{}
+	m := NewDevModule(sealer, nil)
+
+	var res DevCreateBlockResponse
+	err := m.CreateBlock(nil, &DevCreateBlockRequest{}, &res)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{1}, res.Hash)
+	require.True(t, sealer.createEmpty)
+	require.False(t, sealer.finalize)
+
+	err = m.CreateBlock(nil, &DevCreateBlockRequest{false, true}, &res)
+	require.NoError(t, err)
+	require.False(t, sealer.createEmpty)
+	require.True(t, sealer.finalize)
+}
+
+func TestDevCreateBlock_NotSupported(t *testing.T) {
+	m := NewDevModule(nil, nil)
+
+	var res DevCreateBlockResponse
+	err := m.CreateBlock(nil, &DevCreateBlockRequest{}, &res)
+	require.Error(t, err)
+}
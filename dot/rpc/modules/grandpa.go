@@ -0,0 +1,109 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/grandpa"
+)
+
+// GrandpaModule is an RPC module that provides information about GRANDPA finality
+type GrandpaModule struct {
+	grandpaAPI       GrandpaAPI
+	finalityProofAPI FinalityProofAPI
+}
+
+// GrandpaVoteResponse is a signed vote in an equivocation
+type GrandpaVoteResponse struct {
+	Hash      common.Hash `json:"hash"`
+	Number    uint64      `json:"number"`
+	Signature string      `json:"signature"`
+}
+
+// GrandpaEquivocationResponse is the evidence of an equivocation by a GRANDPA voter
+type GrandpaEquivocationResponse struct {
+	SetID     uint64               `json:"setId"`
+	Round     uint64               `json:"round"`
+	Stage     string               `json:"stage"`
+	Authority string               `json:"authority"`
+	First     *GrandpaVoteResponse `json:"first"`
+	Second    *GrandpaVoteResponse `json:"second"`
+}
+
+// NewGrandpaModule creates a new Grandpa module.
+func NewGrandpaModule(api GrandpaAPI, finalityProofAPI FinalityProofAPI) *GrandpaModule {
+	return &GrandpaModule{
+		grandpaAPI:       api,
+		finalityProofAPI: finalityProofAPI,
+	}
+}
+
+// Equivocations returns the evidence of the equivocations observed by the node's GRANDPA voter
+func (gm *GrandpaModule) Equivocations(r *http.Request, req *EmptyRequest, res *[]*GrandpaEquivocationResponse) error {
+	if gm.grandpaAPI == nil {
+		return errors.New("not a grandpa voter")
+	}
+
+	evs := gm.grandpaAPI.Equivocations()
+	resp := make([]*GrandpaEquivocationResponse, len(evs))
+	for i, ev := range evs {
+		offender := ev.Offender()
+		resp[i] = &GrandpaEquivocationResponse{
+			SetID:     ev.SetID,
+			Round:     ev.Round,
+			Stage:     ev.Stage.String(),
+			Authority: common.BytesToHex(offender[:]),
+			First:     newGrandpaVoteResponse(ev.First),
+			Second:    newGrandpaVoteResponse(ev.Second),
+		}
+	}
+
+	*res = resp
+	return nil
+}
+
+// ProveFinality returns the hex encoded proof that the block with the given hash is final, which consists of the
+// justification of the block or of its first finalized descendant that has one, and the headers linking them
+func (gm *GrandpaModule) ProveFinality(r *http.Request, req *ChainHashRequest, res *string) error {
+	if gm.finalityProofAPI == nil {
+		return errors.New("finality proofs are not available")
+	}
+
+	hash, err := common.HexToHash(string(*req))
+	if err != nil {
+		return err
+	}
+
+	proof, err := gm.finalityProofAPI.ProveFinality(hash)
+	if err != nil {
+		return err
+	}
+
+	*res = common.BytesToHex(proof)
+	return nil
+}
+
+func newGrandpaVoteResponse(j *grandpa.Justification) *GrandpaVoteResponse {
+	return &GrandpaVoteResponse{
+		Hash:      j.Vote.Hash(),
+		Number:    j.Vote.Number(),
+		Signature: common.BytesToHex(j.Signature[:]),
+	}
+}
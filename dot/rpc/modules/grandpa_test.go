@@ -0,0 +1,113 @@
+// Copyright 2020 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/grandpa"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockFinalityProofAPI struct {
+	proof []byte
+}
+
+func (api *mockFinalityProofAPI) ProveFinality(hash common.Hash) ([]byte, error) {
+	if hash != (common.Hash{1}) {
+		return nil, grandpa.ErrBlockNotFinalized
+	}
+	return api.proof, nil
+}
+
+type mockGrandpaAPI struct {
+	equivocations []*grandpa.Equivocation
+}
+
+func (api *mockGrandpaAPI) Equivocations() []*grandpa.Equivocation {
+	return api.equivocations
+}
+
+func TestGrandpaModule_Equivocations(t *testing.T) {
+	authority := [32]byte{1}
+	ev := &grandpa.Equivocation{
+		SetID: 1,
+		Round: 77,
+		First: &grandpa.Justification{
+			Vote:        grandpa.NewVote(common.Hash{2}, 3),
+			Signature:   [64]byte{4},
+			AuthorityID: authority,
+		},
+		Second: &grandpa.Justification{
+			Vote:        grandpa.NewVote(common.Hash{5}, 3),
+			Signature:   [64]byte{6},
+			AuthorityID: authority,
+		},
+	}
+
+	m := NewGrandpaModule(&mockGrandpaAPI{equivocations: []*grandpa.Equivocation{ev}}, nil)
+
+	var res []*GrandpaEquivocationResponse
+	err := m.Equivocations(nil, nil, &res)
+	require.NoError(t, err)
+
+	expected := []*GrandpaEquivocationResponse{{
+		SetID:     1,
+		Round:     77,
+		Stage:     "prevote",
+		Authority: common.BytesToHex(authority[:]),
+		First: &GrandpaVoteResponse{
+			Hash:      common.Hash{2},
+			Number:    3,
+			Signature: common.BytesToHex(ev.First.Signature[:]),
+		},
+		Second: &GrandpaVoteResponse{
+			Hash:      common.Hash{5},
+			Number:    3,
+			Signature: common.BytesToHex(ev.Second.Signature[:]),
+		},
+	}}
+	require.Equal(t, expected, res)
+}
+
+func TestGrandpaModule_Equivocations_NotVoter(t *testing.T) {
+	m := NewGrandpaModule(nil, nil)
+
+	var res []*GrandpaEquivocationResponse
+	err := m.Equivocations(nil, nil, &res)
+	require.Error(t, err)
+}
+
+func TestGrandpaModule_ProveFinality(t *testing.T) {
+	m := NewGrandpaModule(nil, &mockFinalityProofAPI{proof: []byte{1, 2, 3}})
+
+	var res string
+	req := ChainHashRequest(common.Hash{1}.String())
+	err := m.ProveFinality(nil, &req, &res)
+	require.NoError(t, err)
+	require.Equal(t, "0x010203", res)
+
+	req = ChainHashRequest(common.Hash{2}.String())
+	err = m.ProveFinality(nil, &req, &res)
+	require.Equal(t, grandpa.ErrBlockNotFinalized, err)
+
+	m = NewGrandpaModule(nil, nil)
+	err = m.ProveFinality(nil, &req, &res)
+	require.Error(t, err)
+}
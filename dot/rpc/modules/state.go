@@ -115,14 +115,17 @@ type StateModule struct {
 	networkAPI NetworkAPI
 	storageAPI StorageAPI
 	coreAPI    CoreAPI
+	lightAPI   LightAPI
 }
 
-// NewStateModule creates a new State module.
-func NewStateModule(net NetworkAPI, storage StorageAPI, core CoreAPI) *StateModule {
+// NewStateModule creates a new State module. light is nil unless we're a light client, which reads state from full
+// peers instead of storage.
+func NewStateModule(net NetworkAPI, storage StorageAPI, core CoreAPI, light LightAPI) *StateModule {
 	return &StateModule{
 		networkAPI: net,
 		storageAPI: storage,
 		coreAPI:    core,
+		lightAPI:   light,
 	}
 }
 
@@ -154,10 +157,22 @@ func (sm *StateModule) GetPairs(r *http.Request, req *[]string, res *[]interface
 	return nil
 }
 
-// Call isn't implemented properly yet.
-func (sm *StateModule) Call(r *http.Request, req *StateCallRequest, res *StateCallResponse) {
-	_ = sm.networkAPI
-	_ = sm.storageAPI
+// Call returns the result of calling the runtime method with the data in the state of the block. If no block hash is
+// provided, the best block is used.
+func (sm *StateModule) Call(r *http.Request, req *StateCallRequest, res *StateCallResponse) error {
+	var result []byte
+	var err error
+	if sm.lightAPI != nil {
+		result, err = sm.lightAPI.Call(req.Block, req.Method, req.Data)
+	} else {
+		result, err = sm.coreAPI.Call(req.Block, req.Method, req.Data)
+	}
+	if err != nil {
+		return err
+	}
+
+	res.StateCallResponse = result
+	return nil
 }
 
 // GetChildKeys isn't implemented properly yet.
@@ -215,9 +230,7 @@ func (sm *StateModule) GetRuntimeVersion(r *http.Request, req *StateBlockHashQue
 // GetStorage Returns a storage entry at a specific block's state. If not block hash is provided, the latest value is returned.
 func (sm *StateModule) GetStorage(r *http.Request, req *[]string, res *interface{}) error {
 	// TODO implement change storage trie so that block hash parameter works (See issue #834)
-	pReq := *req
-	reqBytes, _ := common.HexToBytes(pReq[0]) // no need to catch error here
-	item, err := sm.storageAPI.GetStorage(reqBytes)
+	item, err := sm.getStorage(*req)
 	if err != nil {
 		return err
 	}
@@ -235,10 +248,7 @@ func (sm *StateModule) GetStorage(r *http.Request, req *[]string, res *interface
 //  If no block hash is provided, the latest value is returned.
 //  TODO implement change storage trie so that block hash parameter works (See issue #834)
 func (sm *StateModule) GetStorageHash(r *http.Request, req *[]string, res *interface{}) error {
-	pReq := *req
-	reqByte, _ := common.HexToBytes(pReq[0])
-
-	item, err := sm.storageAPI.GetStorage(reqByte)
+	item, err := sm.getStorage(*req)
 	if err != nil {
 		return err
 	}
@@ -256,10 +266,7 @@ func (sm *StateModule) GetStorageHash(r *http.Request, req *[]string, res *inter
 //  If no block hash is provided, the latest value is used.
 // TODO implement change storage trie so that block hash parameter works (See issue #834)
 func (sm *StateModule) GetStorageSize(r *http.Request, req *[]string, res *interface{}) error {
-	pReq := *req
-	reqByte, _ := common.HexToBytes(pReq[0])
-
-	item, err := sm.storageAPI.GetStorage(reqByte)
+	item, err := sm.getStorage(*req)
 	if err != nil {
 		return err
 	}
@@ -273,6 +280,26 @@ func (sm *StateModule) GetStorageSize(r *http.Request, req *[]string, res *inter
 	return nil
 }
 
+// getStorage returns the value of the key in the request. light clients read it from full peers, in the state of the
+// block in the request if one is provided.
+func (sm *StateModule) getStorage(req []string) ([]byte, error) {
+	key, _ := common.HexToBytes(req[0]) // no need to catch error here
+	if sm.lightAPI == nil {
+		return sm.storageAPI.GetStorage(key)
+	}
+
+	var block common.Hash
+	if len(req) > 1 {
+		var err error
+		block, err = common.HexToHash(req[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sm.lightAPI.GetStorage(block, key)
+}
+
 // QueryStorage isn't implemented properly yet.
 func (sm *StateModule) QueryStorage(r *http.Request, req *StateStorageQueryRangeRequest, res *StorageChangeSetResponse) {
 	// TODO implement change storage trie so that block hash parameter works (See issue #834)
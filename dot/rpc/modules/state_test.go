@@ -181,5 +181,50 @@ func setupStateModule(t *testing.T) *StateModule {
 	require.NoError(t, err)
 
 	core := newCoreService(t)
-	return NewStateModule(net, chain.Storage, core)
+	return NewStateModule(net, chain.Storage, core, nil)
+}
+
+// mockLightAPI reads state from a map, keyed by block hash and storage key
+type mockLightAPI struct {
+	storage map[common.Hash]map[string][]byte
+}
+
+func (m *mockLightAPI) GetStorage(block common.Hash, key []byte) ([]byte, error) {
+	return m.storage[block][string(key)], nil
+}
+
+func (m *mockLightAPI) Call(block common.Hash, method string, data []byte) ([]byte, error) {
+	return append([]byte(method), data...), nil
+}
+
+func TestStateModule_LightClient(t *testing.T) {
+	block := common.Hash{0x1}
+	sm := NewStateModule(nil, nil, nil, &mockLightAPI{
+		storage: map[common.Hash]map[string][]byte{
+			{}:    {":key1": []byte("value1")},
+			block: {":key1": []byte("value2")},
+		},
+	})
+
+	var res interface{}
+	err := sm.GetStorage(nil, &[]string{"0x3a6b657931"}, &res)
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHex([]byte("value1")), res)
+
+	err = sm.GetStorage(nil, &[]string{"0x3a6b657931", block.String()}, &res)
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHex([]byte("value2")), res)
+
+	err = sm.GetStorageSize(nil, &[]string{"0x3a6b657931", block.String()}, &res)
+	require.NoError(t, err)
+	require.Equal(t, len("value2"), res)
+
+	err = sm.GetStorage(nil, &[]string{"0x3a6b657932"}, &res)
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	var callRes StateCallResponse
+	err = sm.Call(nil, &StateCallRequest{Method: "Core_version", Data: []byte{1}, Block: block}, &callRes)
+	require.NoError(t, err)
+	require.Equal(t, []byte("Core_version\x01"), callRes.StateCallResponse)
 }
@@ -17,6 +17,7 @@
 package modules
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -49,6 +50,11 @@ type SystemPeersResponse struct {
 	Peers []common.PeerInfo `json:"peers"`
 }
 
+// SystemNetworkStatsResponse struct to marshal json
+type SystemNetworkStatsResponse struct {
+	NetworkStats common.NetworkStats `json:"networkStats"`
+}
+
 // NewSystemModule creates a new API instance
 func NewSystemModule(net NetworkAPI, sys SystemAPI) *SystemModule {
 	return &SystemModule{
@@ -102,6 +108,12 @@ func (sm *SystemModule) Peers(r *http.Request, req *EmptyRequest, res *SystemPee
 	return nil
 }
 
+// NetworkStats returns the bytes and messages the node has sent and received, in total, per protocol and per peer
+func (sm *SystemModule) NetworkStats(r *http.Request, req *EmptyRequest, res *SystemNetworkStatsResponse) error {
+	res.NetworkStats = sm.networkAPI.NetworkStats()
+	return nil
+}
+
 // NodeRoles Returns the roles the node is running as.
 func (sm *SystemModule) NodeRoles(r *http.Request, req *EmptyRequest, res *[]interface{}) error {
 	resultArray := []interface{}{}
@@ -124,3 +136,21 @@ func (sm *SystemModule) NodeRoles(r *http.Request, req *EmptyRequest, res *[]int
 	*res = resultArray
 	return nil
 }
+
+// AddReservedPeer adds the peer with the given multiaddress (including its peer ID) to the node's reserved peers
+func (sm *SystemModule) AddReservedPeer(r *http.Request, req *[]string, res *interface{}) error {
+	if req == nil || len(*req) != 1 {
+		return errors.New("expected a single peer address")
+	}
+
+	return sm.networkAPI.AddReservedPeer((*req)[0])
+}
+
+// RemoveReservedPeer removes the peer with the given ID from the node's reserved peers
+func (sm *SystemModule) RemoveReservedPeer(r *http.Request, req *[]string, res *interface{}) error {
+	if req == nil || len(*req) != 1 {
+		return errors.New("expected a single peer ID")
+	}
+
+	return sm.networkAPI.RemoveReservedPeer((*req)[0])
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,8 +44,8 @@ func (s *mockSyncer) CreateBlockResponse(msg *network.BlockRequestMessage) (*net
 	return nil, nil
 }
 
-func (s *mockSyncer) HandleBlockResponse(msg *network.BlockResponseMessage) *network.BlockRequestMessage {
-	return nil
+func (s *mockSyncer) HandleBlockResponse(msg *network.BlockResponseMessage) (*network.BlockRequestMessage, error) {
+	return nil, nil
 }
 
 func (s *mockSyncer) HandleBlockAnnounce(msg *network.BlockAnnounceMessage) *network.BlockRequestMessage {
@@ -55,6 +56,14 @@ func (s *mockSyncer) HandleSeenBlocks(num *big.Int) *network.BlockRequestMessage
 	return nil
 }
 
+func (s *mockSyncer) BlockRequests(peers []common.PeerInfo) []*network.ScheduledBlockRequest {
+	return nil
+}
+
+func (s *mockSyncer) SelectPeer(req *network.BlockRequestMessage, peers []common.PeerInfo) (peer.ID, bool) {
+	return "", false
+}
+
 func newNetworkService(t *testing.T) *network.Service {
 	testDir := path.Join(os.TempDir(), "test_data")
 
@@ -119,6 +128,17 @@ func TestSystemModule_Peers(t *testing.T) {
 	}
 }
 
+func TestSystemModule_NetworkStats(t *testing.T) {
+	net := newNetworkService(t)
+	sys := NewSystemModule(net, nil)
+
+	res := &SystemNetworkStatsResponse{}
+	err := sys.NetworkStats(nil, nil, res)
+	require.NoError(t, err)
+	require.Equal(t, net.NetworkStats(), res.NetworkStats)
+	require.Empty(t, res.NetworkStats.Peers)
+}
+
 func TestSystemModule_NodeRoles(t *testing.T) {
 	net := newNetworkService(t)
 	sys := NewSystemModule(net, nil)
@@ -129,3 +149,24 @@ func TestSystemModule_NodeRoles(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, res)
 }
+
+func TestSystemModule_ReservedPeers(t *testing.T) {
+	net := newNetworkService(t)
+	sys := NewSystemModule(net, nil)
+
+	addr := "/ip4/127.0.0.1/tcp/7002/p2p/12D3KooWDcCNBqAemRvguPa7rtmsbn2hpgLqAz8KsMMFsF2rdCUP"
+	err := sys.AddReservedPeer(nil, &[]string{addr}, nil)
+	require.NoError(t, err)
+
+	err = sys.RemoveReservedPeer(nil, &[]string{"12D3KooWDcCNBqAemRvguPa7rtmsbn2hpgLqAz8KsMMFsF2rdCUP"}, nil)
+	require.NoError(t, err)
+
+	err = sys.RemoveReservedPeer(nil, &[]string{"12D3KooWDcCNBqAemRvguPa7rtmsbn2hpgLqAz8KsMMFsF2rdCUP"}, nil)
+	require.Equal(t, network.ErrReservedPeerNotFound, err)
+
+	err = sys.AddReservedPeer(nil, &[]string{"noot"}, nil)
+	require.Error(t, err)
+
+	err = sys.AddReservedPeer(nil, &[]string{}, nil)
+	require.Error(t, err)
+}
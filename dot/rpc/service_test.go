@@ -27,7 +27,7 @@ func TestNewService(t *testing.T) {
 }
 
 func TestService_Methods(t *testing.T) {
-	qtySystemMethods := 8
+	qtySystemMethods := 11
 	qtyRPCMethods := 1
 	qtyAuthorMethods := 7
 
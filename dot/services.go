@@ -24,11 +24,13 @@ import (
 	"github.com/ChainSafe/gossamer/dot/core"
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/rpc"
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/sync"
 	"github.com/ChainSafe/gossamer/dot/system"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/babe"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
 	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
 	"github.com/ChainSafe/gossamer/lib/grandpa"
@@ -41,6 +43,10 @@ import (
 // ErrNoKeysProvided is returned when no keys are given for an authority node
 var ErrNoKeysProvided = errors.New("no keys provided for authority node")
 
+// ErrLightClientAuthority is returned when a light client is configured as a BABE or GRANDPA authority, which needs
+// the full state
+var ErrLightClientAuthority = errors.New("light clients cannot be authorities")
+
 // State Service
 
 // createStateService creates the state service and initialize state database
@@ -77,7 +83,7 @@ func createStateService(cfg *Config) (*state.Service, error) {
 
 func createRuntime(cfg *Config, st *state.Service, ks *keystore.Keystore) (*runtime.Runtime, error) {
 	// load runtime code from trie
-	code, err := st.Storage.GetStorage([]byte(":code"))
+	code, err := st.Storage.GetStorage(common.CodeKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve :code from trie: %s", err)
 	}
@@ -103,6 +109,18 @@ func createRuntime(cfg *Config, st *state.Service, ks *keystore.Keystore) (*runt
 	return rt, nil
 }
 
+// createBlockProducer creates the block producer for the configured consensus mode
+func createBlockProducer(cfg *Config, rt *runtime.Runtime, st *state.Service, ks *keystore.Keystore) (BlockProducer, error) {
+	switch cfg.Core.Consensus {
+	case "", BabeConsensus:
+		return createBABEService(cfg, rt, st, ks)
+	case ManualConsensus:
+		return createManualSealService(cfg, rt, st, ks)
+	default:
+		return nil, fmt.Errorf("unknown consensus: %s", cfg.Core.Consensus)
+	}
+}
+
 func createBABEService(cfg *Config, rt *runtime.Runtime, st *state.Service, ks *keystore.Keystore) (*babe.Service, error) {
 	logger.Info(
 		"creating BABE service...",
@@ -162,10 +180,63 @@ func createBABEService(cfg *Config, rt *runtime.Runtime, st *state.Service, ks *
 	return bs, nil
 }
 
+// createManualSealService creates a block producer that builds blocks when transactions are
+// submitted or when requested over RPC
+func createManualSealService(cfg *Config, rt *runtime.Runtime, st *state.Service, ks *keystore.Keystore) (*babe.ManualSealService, error) {
+	logger.Info(
+		"creating manual seal service...",
+		"authority", cfg.Core.Authority,
+	)
+
+	kps := ks.Sr25519Keypairs()
+	if len(kps) == 0 {
+		return nil, ErrNoKeysProvided
+	}
+
+	header, err := st.Block.BestBlockHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %s", err)
+	}
+
+	var bestSlot uint64
+	if header.Number.Cmp(big.NewInt(0)) != 0 {
+		bestSlot, err = st.Block.GetSlotForBlock(header.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get slot for latest block: %s", err)
+		}
+	}
+
+	lvl, err := log.LvlFromString(cfg.Log.BlockProducerLvl)
+	if err != nil {
+		return nil, err
+	}
+
+	mcfg := &babe.ManualSealConfig{
+		LogLvl:              lvl,
+		Keypair:             kps[0].(*sr25519.Keypair),
+		Runtime:             rt,
+		BlockState:          st.Block,
+		StorageState:        st.Storage,
+		TransactionQueue:    st.TransactionQueue,
+		TransactionNotifier: st.TransactionQueue,
+		StartSlot:           bestSlot + 1,
+		InstantSeal:         true,
+		Finalize:            !cfg.Core.GrandpaAuthority, // if GRANDPA is running, let it finalize blocks
+	}
+
+	ms, err := babe.NewManualSealService(mcfg)
+	if err != nil {
+		logger.Error("failed to initialize manual seal service", "error", err)
+		return nil, err
+	}
+
+	return ms, nil
+}
+
 // Core Service
 
 // createCoreService creates the core service from the provided core configuration
-func createCoreService(cfg *Config, bp BlockProducer, fg core.FinalityGadget, rt *runtime.Runtime, ks *keystore.Keystore, stateSrvc *state.Service, coreMsgs chan network.Message, networkMsgs chan network.Message) (*core.Service, error) {
+func createCoreService(cfg *Config, bp BlockProducer, fg core.FinalityGadget, observer *grandpa.Observer, rt *runtime.Runtime, ks *keystore.Keystore, stateSrvc *state.Service, coreMsgs chan network.Message, networkMsgs chan network.Message) (*core.Service, error) {
 	logger.Info(
 		"creating core service...",
 		"authority", cfg.Core.Authority,
@@ -176,11 +247,12 @@ func createCoreService(cfg *Config, bp BlockProducer, fg core.FinalityGadget, rt
 		return nil, err
 	}
 
+	// if we aren't a GRANDPA authority, observe the GRANDPA commit messages to track finality
 	var handler core.ConsensusMessageHandler
 	if gs, ok := fg.(*grandpa.Service); ok {
 		handler = grandpa.NewMessageHandler(gs, stateSrvc.Block)
 	} else {
-		handler = grandpa.NewMessageHandler(nil, stateSrvc.Block)
+		handler = grandpa.NewObserverMessageHandler(observer, stateSrvc.Block)
 	}
 
 	// set core configuration
@@ -196,7 +268,7 @@ func createCoreService(cfg *Config, bp BlockProducer, fg core.FinalityGadget, rt
 		Runtime:                 rt,
 		MsgRec:                  networkMsgs, // message channel from network service to core service
 		MsgSend:                 coreMsgs,    // message channel from core service to network service
-		IsBlockProducer:         cfg.Core.BabeAuthority,
+		IsBlockProducer:         BlockProducerEnabled(cfg),
 		IsFinalityAuthority:     cfg.Core.GrandpaAuthority,
 	}
 
@@ -213,7 +285,7 @@ func createCoreService(cfg *Config, bp BlockProducer, fg core.FinalityGadget, rt
 // Network Service
 
 // createNetworkService creates a network service from the command configuration and genesis data
-func createNetworkService(cfg *Config, stateSrvc *state.Service, coreMsgs chan network.Message, networkMsgs chan network.Message, syncer *sync.Service) (*network.Service, error) {
+func createNetworkService(cfg *Config, stateSrvc *state.Service, coreMsgs chan network.Message, networkMsgs chan network.Message, syncer *sync.Service, fg core.FinalityGadget, observer *grandpa.Observer, coreSrvc *core.Service) (*network.Service, error) {
 	logger.Info(
 		"creating network service...",
 		"roles", cfg.Core.Roles,
@@ -222,6 +294,12 @@ func createNetworkService(cfg *Config, stateSrvc *state.Service, coreMsgs chan n
 		"protocol", cfg.Network.ProtocolID,
 		"nobootstrap", cfg.Network.NoBootstrap,
 		"nomdns", cfg.Network.NoMDNS,
+		"min-peers", cfg.Network.MinPeers,
+		"max-peers", cfg.Network.MaxPeers,
+		"max-inbound", cfg.Network.MaxInbound,
+		"reserved-nodes", cfg.Network.ReservedNodes,
+		"reserved-only", cfg.Network.ReservedOnly,
+		"nocompression", cfg.Network.NoCompression,
 	)
 
 	lvl, err := log.LvlFromString(cfg.Log.NetworkLvl)
@@ -229,21 +307,57 @@ func createNetworkService(cfg *Config, stateSrvc *state.Service, coreMsgs chan n
 		return nil, err
 	}
 
+	// use the voter's gossip validator, so that it knows about our peers' views of the GRANDPA protocol
+	var validator network.GossipValidator
+	if gs, ok := fg.(*grandpa.Service); ok && gs != nil {
+		validator = gs.GossipValidator()
+	} else {
+		validator = grandpa.NewGossipValidator(stateSrvc.Block, observer)
+	}
+
+	finalityProofProvider, err := grandpa.NewFinalityProofProvider(stateSrvc.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	// validate the transactions received from peers with the core service, so that peers that send invalid
+	// transactions are penalised, and answer the requests of light clients from its state, unless we're a light
+	// client ourselves
+	var transactionHandler network.TransactionHandler
+	var lightServer network.LightServer
+	if coreSrvc != nil {
+		transactionHandler = coreSrvc
+		if !LightClientEnabled(cfg) {
+			lightServer = coreSrvc
+		}
+	}
+
 	// network service configuation
 	networkConfig := network.Config{
-		LogLvl:       lvl,
-		BlockState:   stateSrvc.Block,
-		NetworkState: stateSrvc.Network,
-		BasePath:     cfg.Global.BasePath,
-		Roles:        cfg.Core.Roles,
-		Port:         cfg.Network.Port,
-		Bootnodes:    cfg.Network.Bootnodes,
-		ProtocolID:   cfg.Network.ProtocolID,
-		NoBootstrap:  cfg.Network.NoBootstrap,
-		NoMDNS:       cfg.Network.NoMDNS,
-		MsgRec:       coreMsgs,    // message channel from core service to network service
-		MsgSend:      networkMsgs, // message channel from network service to core service
-		Syncer:       syncer,
+		LogLvl:        lvl,
+		BlockState:    stateSrvc.Block,
+		NetworkState:  stateSrvc.Network,
+		BasePath:      cfg.Global.BasePath,
+		Roles:         cfg.Core.Roles,
+		Port:          cfg.Network.Port,
+		Bootnodes:     cfg.Network.Bootnodes,
+		ProtocolID:    cfg.Network.ProtocolID,
+		NoBootstrap:   cfg.Network.NoBootstrap,
+		NoMDNS:        cfg.Network.NoMDNS,
+		MinPeers:      cfg.Network.MinPeers,
+		MaxPeers:      cfg.Network.MaxPeers,
+		MaxInbound:    cfg.Network.MaxInbound,
+		ReservedNodes: cfg.Network.ReservedNodes,
+		ReservedOnly:  cfg.Network.ReservedOnly,
+		NoCompression: cfg.Network.NoCompression,
+		MsgRec:        coreMsgs,    // message channel from core service to network service
+		MsgSend:       networkMsgs, // message channel from network service to core service
+		Syncer:        syncer,
+
+		GossipValidator:       validator,
+		TransactionHandler:    transactionHandler,
+		FinalityProofProvider: finalityProofProvider,
+		LightServer:           lightServer,
 	}
 
 	networkSrvc, err := network.NewService(&networkConfig)
@@ -258,7 +372,7 @@ func createNetworkService(cfg *Config, stateSrvc *state.Service, coreMsgs chan n
 // RPC Service
 
 // createRPCService creates the RPC service from the provided core configuration
-func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Service, networkSrvc *network.Service, bp BlockProducer, rt *runtime.Runtime, sysSrvc *system.Service) (*rpc.HTTPServer, error) {
+func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Service, networkSrvc *network.Service, bp BlockProducer, rt *runtime.Runtime, sysSrvc *system.Service, fg core.FinalityGadget, lightAPI modules.LightAPI) (*rpc.HTTPServer, error) {
 	logger.Info(
 		"creating rpc service...",
 		"host", cfg.RPC.Host,
@@ -274,6 +388,17 @@ func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Serv
 		return nil, err
 	}
 
+	// the grandpa module's voter methods are only available on GRANDPA voters, but any node can prove finality
+	var grandpaAPI modules.GrandpaAPI
+	if gs, ok := fg.(*grandpa.Service); ok && gs != nil {
+		grandpaAPI = gs
+	}
+
+	finalityProofProvider, err := grandpa.NewFinalityProofProvider(stateSrvc.Block)
+	if err != nil {
+		return nil, err
+	}
+
 	rpcConfig := &rpc.HTTPServerConfig{
 		LogLvl:              lvl,
 		BlockAPI:            stateSrvc.Block,
@@ -285,6 +410,9 @@ func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Serv
 		TransactionQueueAPI: stateSrvc.TransactionQueue,
 		RPCAPI:              rpcService,
 		SystemAPI:           sysSrvc,
+		GrandpaAPI:          grandpaAPI,
+		FinalityProofAPI:    finalityProofProvider,
+		LightAPI:            lightAPI,
 		Host:                cfg.RPC.Host,
 		RPCPort:             cfg.RPC.Port,
 		WSEnabled:           cfg.RPC.WSEnabled,
@@ -321,25 +449,55 @@ func createGRANDPAService(cfg *Config, rt *runtime.Runtime, st *state.Service, k
 	}
 
 	gsCfg := &grandpa.Config{
+		LogLvl:       lvl,
+		BlockState:   st.Block,
+		GrandpaState: st.Grandpa,
+		Voters:       voters,
+		Keypair:      keys[0].(*ed25519.Keypair),
+	}
+
+	// equivocations can only be reported if the runtime supports it
+	if rt.HasExport(runtime.GrandpaGenerateKeyOwnershipProof) && rt.HasExport(runtime.GrandpaSubmitReportEquivocation) {
+		gsCfg.EquivocationReporter = rt
+	}
+
+	return grandpa.NewService(gsCfg)
+}
+
+// createGRANDPAObserver creates a new GRANDPA observer for nodes that aren't GRANDPA authorities
+func createGRANDPAObserver(cfg *Config, rt *runtime.Runtime, st *state.Service) (*grandpa.Observer, error) {
+	ad, err := rt.GrandpaAuthorities()
+	if err != nil {
+		return nil, err
+	}
+
+	lvl, err := log.LvlFromString(cfg.Log.FinalityGadgetLvl)
+	if err != nil {
+		return nil, err
+	}
+
+	obsCfg := &grandpa.ObserverConfig{
 		LogLvl:     lvl,
 		BlockState: st.Block,
-		Voters:     voters,
-		Keypair:    keys[0].(*ed25519.Keypair),
+		Voters:     grandpa.NewVotersFromAuthorityData(ad),
 	}
 
-	return grandpa.NewService(gsCfg)
+	return grandpa.NewObserver(obsCfg)
 }
 
-func createSyncService(cfg *Config, st *state.Service, bp BlockProducer, fg core.FinalityGadget, rt *runtime.Runtime) (*sync.Service, error) {
-	var dh *core.DigestHandler
-	var err error
-	if cfg.Core.BabeAuthority || cfg.Core.GrandpaAuthority {
-		dh, err = core.NewDigestHandler(st.Block, bp, fg)
-		if err != nil {
-			return nil, err
-		}
+// createDigestHandler creates the handler of the consensus digests in imported blocks. changes to the GRANDPA voter set
+// are applied to the observer too, so that it verifies commits and justifications against the set that finalized their
+// blocks.
+func createDigestHandler(st *state.Service, bp BlockProducer, fg core.FinalityGadget, observer *grandpa.Observer) (*core.DigestHandler, error) {
+	var verifiers []core.FinalityVerifier
+	if observer != nil {
+		verifiers = append(verifiers, observer)
 	}
 
+	return core.NewDigestHandler(st.Block, bp, fg, verifiers...)
+}
+
+func createSyncService(cfg *Config, st *state.Service, bp BlockProducer, fg core.FinalityGadget, observer *grandpa.Observer, dh *core.DigestHandler, rt *runtime.Runtime) (*sync.Service, error) {
 	// load BABE verification data from runtime
 	// TODO: authority data may change
 	babeCfg, err := rt.BabeConfiguration()
@@ -380,20 +538,53 @@ func createSyncService(cfg *Config, st *state.Service, bp BlockProducer, fg core
 
 	logger.Info("verifier", "threshold", threshold)
 
+	// use the GRANDPA service to verify justifications if we are a GRANDPA authority, otherwise use the observer
+	var jv sync.JustificationVerifier
+	if gs, ok := fg.(*grandpa.Service); ok {
+		jv = gs
+	} else if observer != nil {
+		jv = observer
+	}
+
 	lvl, err := log.LvlFromString(cfg.Log.SyncLvl)
 	if err != nil {
 		return nil, err
 	}
 
 	syncCfg := &sync.Config{
-		LogLvl:           lvl,
-		BlockState:       st.Block,
-		TransactionQueue: st.TransactionQueue,
-		BlockProducer:    bp,
-		Verifier:         ver,
-		Runtime:          rt,
-		DigestHandler:    dh,
+		LogLvl:                lvl,
+		BlockState:            st.Block,
+		TransactionQueue:      st.TransactionQueue,
+		BlockProducer:         bp,
+		Verifier:              ver,
+		Runtime:               rt,
+		DigestHandler:         dh,
+		JustificationVerifier: jv,
+		IsLightClient:         LightClientEnabled(cfg),
+	}
+
+	// light clients don't have block bodies or state, so they can't execute blocks
+	if !syncCfg.IsLightClient {
+		syncCfg.StorageState = st.Storage
 	}
 
 	return sync.NewService(syncCfg)
 }
+
+// createLightClient creates the light client, which reads the state of the blocks we've synced from full peers
+func createLightClient(cfg *Config, st *state.Service, networkSrvc *network.Service) (*core.LightClient, error) {
+	logger.Info("creating light client...")
+
+	lvl, err := log.LvlFromString(cfg.Log.CoreLvl)
+	if err != nil {
+		return nil, err
+	}
+
+	lcCfg := &core.LightClientConfig{
+		LogLvl:     lvl,
+		BlockState: st.Block,
+		Network:    networkSrvc,
+	}
+
+	return core.NewLightClient(lcCfg)
+}
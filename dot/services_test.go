@@ -81,7 +81,7 @@ func TestCreateCoreService(t *testing.T) {
 	coreMsgs := make(chan network.Message)
 	networkMsgs := make(chan network.Message)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
+	coreSrvc, err := createCoreService(cfg, nil, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
 	require.Nil(t, err)
 
 	// TODO: improve dot tests #687
@@ -110,8 +110,11 @@ func TestCreateSyncService(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks)
 	require.NoError(t, err)
 
+	dh, err := createDigestHandler(stateSrvc, nil, nil, nil)
+	require.NoError(t, err)
+
 	cfg.Core.BabeThreshold = nil
-	_, err = createSyncService(cfg, stateSrvc, nil, nil, rt)
+	_, err = createSyncService(cfg, stateSrvc, nil, nil, nil, dh, rt)
 	require.NoError(t, err)
 }
 
@@ -136,7 +139,7 @@ func TestCreateNetworkService(t *testing.T) {
 	coreMsgs := make(chan network.Message)
 	networkMsgs := make(chan network.Message)
 
-	networkSrvc, err := createNetworkService(cfg, stateSrvc, coreMsgs, networkMsgs, nil)
+	networkSrvc, err := createNetworkService(cfg, stateSrvc, coreMsgs, networkMsgs, nil, nil, nil, nil)
 	require.Nil(t, err)
 
 	// TODO: improve dot tests #687
@@ -172,14 +175,14 @@ func TestCreateRPCService(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks)
 	require.NoError(t, err)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
+	coreSrvc, err := createCoreService(cfg, nil, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
 	require.Nil(t, err)
 
 	networkSrvc := &network.Service{} // TODO: rpc service without network service
 
 	sysSrvc := createSystemService(&cfg.System)
 
-	rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc)
+	rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc, nil, nil)
 	require.Nil(t, err)
 
 	// TODO: improve dot tests #687
@@ -291,14 +294,14 @@ func TestNewWebSocketServer(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks)
 	require.NoError(t, err)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
+	coreSrvc, err := createCoreService(cfg, nil, nil, nil, rt, ks, stateSrvc, coreMsgs, networkMsgs)
 	require.Nil(t, err)
 
 	networkSrvc := &network.Service{}
 
 	sysSrvc := createSystemService(&cfg.System)
 
-	rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc)
+	rpcSrvc, err := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc, nil, nil)
 	require.Nil(t, err)
 
 	err = rpcSrvc.Start()
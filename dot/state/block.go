@@ -420,7 +420,8 @@ func (bs *BlockState) CompareAndSetBlockData(bd *types.BlockData) error {
 		}
 	}
 
-	if bd.Body != nil && (existingData.Body == nil || (!existingData.Body.Exists && bd.Body.Exists)) {
+	// don't store an empty body for blocks whose body isn't included, such as those synced by light clients
+	if bd.Body != nil && bd.Body.Exists && (existingData.Body == nil || !existingData.Body.Exists) {
 		existingData.Body = bd.Body
 		err := bs.SetBlockBody(bd.Hash, types.NewBody(existingData.Body.Value))
 		if err != nil {
@@ -444,7 +445,7 @@ func (bs *BlockState) CompareAndSetBlockData(bd *types.BlockData) error {
 		}
 	}
 
-	if bd.Justification != nil && (existingData.Justification == nil || (!existingData.Justification.Exists() && bd.Justification.Exists())) {
+	if bd.Justification != nil && bd.Justification.Exists() && (existingData.Justification == nil || !existingData.Justification.Exists()) {
 		existingData.Justification = bd.Justification
 		err := bs.SetJustification(bd.Hash, existingData.Justification.Value())
 		if err != nil {
@@ -462,6 +463,38 @@ func (bs *BlockState) AddBlock(block *types.Block) error {
 
 // AddBlockWithArrivalTime adds a block to the blocktree and the DB with the given arrival time
 func (bs *BlockState) AddBlockWithArrivalTime(block *types.Block, arrivalTime uint64) error {
+	err := bs.addHeader(block, arrivalTime)
+	if err != nil {
+		return err
+	}
+
+	err = bs.SetBlockBody(block.Header.Hash(), types.NewBody(block.Body.AsOptional().Value))
+	if err != nil {
+		return err
+	}
+
+	go bs.notifyImported(block)
+	return err
+}
+
+// AddBlockHeader adds a block to the blocktree and the DB without its body, which is how light clients import blocks
+func (bs *BlockState) AddBlockHeader(header *types.Header) error {
+	block := &types.Block{
+		Header: header,
+		Body:   types.NewBody([]byte{}),
+	}
+
+	err := bs.addHeader(block, uint64(time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	go bs.notifyImported(block)
+	return nil
+}
+
+// addHeader adds the block to the blocktree and its header to the DB
+func (bs *BlockState) addHeader(block *types.Block, arrivalTime uint64) error {
 	err := bs.setArrivalTime(block.Header.Hash(), arrivalTime)
 	if err != nil {
 		return err
@@ -497,13 +530,7 @@ func (bs *BlockState) AddBlockWithArrivalTime(block *types.Block, arrivalTime ui
 		}
 	}
 
-	err = bs.SetBlockBody(block.Header.Hash(), types.NewBody(block.Body.AsOptional().Value))
-	if err != nil {
-		return err
-	}
-
-	go bs.notifyImported(block)
-	return err
+	return nil
 }
 
 // GetAllBlocksAtDepth returns all hashes with the depth of the given hash plus one
@@ -174,6 +174,28 @@ func TestAddBlock(t *testing.T) {
 	require.Equal(t, block1.Header.Hash(), bs.BestBlockHash(), "Latest Header Block Check Fail")
 }
 
+func TestAddBlockHeader(t *testing.T) {
+	bs := newTestBlockState(t, testGenesisHeader)
+
+	header := &types.Header{
+		ParentHash: testGenesisHeader.Hash(),
+		Number:     big.NewInt(1),
+		Digest:     [][]byte{},
+	}
+
+	err := bs.AddBlockHeader(header)
+	require.NoError(t, err)
+	require.Equal(t, header.Hash(), bs.BestBlockHash())
+
+	res, err := bs.GetHeaderByNumber(big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, header.Hash(), res.Hash())
+
+	// light clients don't have the bodies of the blocks they import
+	_, err = bs.GetBlockBody(header.Hash())
+	require.Error(t, err)
+}
+
 func TestGetSlotForBlock(t *testing.T) {
 	bs := newTestBlockState(t, testGenesisHeader)
 
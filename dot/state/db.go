@@ -17,6 +17,8 @@
 package state
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -27,6 +29,50 @@ import (
 	database "github.com/ChainSafe/chaindb"
 )
 
+// dbVersion is the version of the format the state database is written in. it's incremented whenever the format
+// changes, so that databases written in another format are detected when the node starts rather than misread.
+// databases written before the version was stored are version 0, which stored each trie as a single encoding under
+// its root hash. since version 1, tries are stored node by node.
+const dbVersion uint64 = 1
+
+// ErrDBVersionMismatch is returned when the database was written in a format this version of the node can't read
+var ErrDBVersionMismatch = errors.New("database format version mismatch")
+
+// StoreDBVersion stores the version of the format the database is written in at DBVersionKey
+func StoreDBVersion(db database.Database) error {
+	enc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(enc, dbVersion)
+	return db.Put(common.DBVersionKey, enc)
+}
+
+// CheckDBVersion returns an error wrapping ErrDBVersionMismatch if the database wasn't written in the current format
+func CheckDBVersion(db database.Database) error {
+	version := uint64(0)
+
+	has, err := db.Has(common.DBVersionKey)
+	if err != nil {
+		return err
+	}
+
+	if has {
+		enc, err := db.Get(common.DBVersionKey)
+		if err != nil {
+			return err
+		}
+
+		if len(enc) != 8 {
+			return fmt.Errorf("%w: invalid version %x", ErrDBVersionMismatch, enc)
+		}
+		version = binary.LittleEndian.Uint64(enc)
+	}
+
+	if version != dbVersion {
+		return fmt.Errorf("%w: database is version %d, but version %d is required; reinitialise the node with `gossamer init --force`", ErrDBVersionMismatch, version, dbVersion)
+	}
+
+	return nil
+}
+
 // StoreBestBlockHash stores the hash at the BestBlockHashKey
 func StoreBestBlockHash(db database.Database, hash common.Hash) error {
 	return db.Put(common.BestBlockHashKey, hash[:])
@@ -87,28 +133,13 @@ func LoadLatestStorageHash(db database.Database) (common.Hash, error) {
 	return common.NewHash(hashbytes), nil
 }
 
-// StoreTrie encodes the entire trie and writes it to the DB
-// The key to the DB entry is the root hash of the trie
+// StoreTrie writes the nodes of the trie that aren't in the DB yet to the DB, keyed by their hashes. the states of
+// consecutive blocks share most of their nodes, so only the nodes that changed are written for each block.
 func StoreTrie(db database.Database, t *trie.Trie) error {
-	enc, err := t.Encode()
-	if err != nil {
-		return err
-	}
-
-	roothash, err := t.Hash()
-	if err != nil {
-		return err
-	}
-
-	return db.Put(roothash[:], enc)
+	return t.Store(db)
 }
 
-// LoadTrie loads an encoded trie from the DB where the key is `root`
+// LoadTrie loads the trie whose root hash is `root` from its nodes in the DB
 func LoadTrie(db database.Database, t *trie.Trie, root common.Hash) error {
-	enctrie, err := db.Get(root[:])
-	if err != nil {
-		return err
-	}
-
-	return t.Decode(enctrie)
+	return t.LoadFromDB(db, root)
 }
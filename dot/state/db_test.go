@@ -2,6 +2,8 @@ package state
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -11,6 +13,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/trie"
 
 	database "github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTrie_StoreAndLoadFromDB(t *testing.T) {
@@ -155,3 +158,30 @@ func TestStoreAndLoadBestBlockHash(t *testing.T) {
 		t.Fatalf("Fail: got %x expected %x", res, hash)
 	}
 }
+
+func TestCheckDBVersion(t *testing.T) {
+	db := database.NewMemDatabase()
+
+	// databases written before the version was stored store tries in the old format
+	err := CheckDBVersion(db)
+	require.True(t, errors.Is(err, ErrDBVersionMismatch))
+
+	err = StoreDBVersion(db)
+	require.NoError(t, err)
+	require.NoError(t, CheckDBVersion(db))
+
+	// databases written in another format can't be read
+	enc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(enc, dbVersion+1)
+	err = db.Put(common.DBVersionKey, enc)
+	require.NoError(t, err)
+
+	err = CheckDBVersion(db)
+	require.True(t, errors.Is(err, ErrDBVersionMismatch))
+
+	err = db.Put(common.DBVersionKey, []byte{1})
+	require.NoError(t, err)
+
+	err = CheckDBVersion(db)
+	require.True(t, errors.Is(err, ErrDBVersionMismatch))
+}
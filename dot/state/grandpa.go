@@ -0,0 +1,140 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/chaindb"
+)
+
+var (
+	grandpaPrefix         = []byte("grandpa")
+	voterStateKey         = append(grandpaPrefix, []byte("voter")...)             // voterStateKey -> encoded GRANDPA voter state
+	equivocationPrefix    = append(grandpaPrefix, []byte("equivocation")...)      // equivocationPrefix + index -> encoded equivocation evidence
+	equivocationsCountKey = append(grandpaPrefix, []byte("equivocationcount")...) // equivocationsCountKey -> number of equivocations ever added
+)
+
+// maxStoredEquivocations is the number of equivocations whose evidence is stored. the evidence is stored in a ring, so
+// once it's full, each new piece of evidence replaces the oldest one.
+var maxStoredEquivocations uint64 = 1 << 10
+
+// equivocationKey returns the key of the equivocation evidence with the given index
+func equivocationKey(index uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index%maxStoredEquivocations)
+	return append(append([]byte{}, equivocationPrefix...), buf...)
+}
+
+// GrandpaState stores the state of the GRANDPA voter, so that it can resume voting after a restart,
+// as well as the evidence of any equivocations it observed
+type GrandpaState struct {
+	db   chaindb.Database
+	lock sync.Mutex
+}
+
+// NewGrandpaState returns a new GrandpaState
+func NewGrandpaState(db chaindb.Database) *GrandpaState {
+	return &GrandpaState{
+		db: db,
+	}
+}
+
+// SetVoterState stores the encoded voter state
+func (s *GrandpaState) SetVoterState(data []byte) error {
+	return s.db.Put(voterStateKey, data)
+}
+
+// GetVoterState returns the encoded voter state
+func (s *GrandpaState) GetVoterState() ([]byte, error) {
+	return s.db.Get(voterStateKey)
+}
+
+// HasVoterState returns true if a voter state has been stored
+func (s *GrandpaState) HasVoterState() (bool, error) {
+	return s.db.Has(voterStateKey)
+}
+
+// AddEquivocation stores the encoded equivocation evidence under its own key, replacing the oldest stored evidence if
+// maxStoredEquivocations are already stored
+func (s *GrandpaState) AddEquivocation(data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count, err := s.equivocationsCount()
+	if err != nil {
+		return err
+	}
+
+	// the evidence is stored before the count, so it's ignored if we fail to store the count
+	err = s.db.Put(equivocationKey(count), data)
+	if err != nil {
+		return err
+	}
+
+	enc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(enc, count+1)
+	return s.db.Put(equivocationsCountKey, enc)
+}
+
+// GetEquivocations returns the stored encoded equivocation evidence, oldest first
+func (s *GrandpaState) GetEquivocations() ([][]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count, err := s.equivocationsCount()
+	if err != nil {
+		return nil, err
+	}
+
+	var start uint64
+	if count > maxStoredEquivocations {
+		start = count - maxStoredEquivocations
+	}
+
+	evs := make([][]byte, 0, count-start)
+	for i := start; i < count; i++ {
+		ev, err := s.db.Get(equivocationKey(i))
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+
+	return evs, nil
+}
+
+// equivocationsCount returns the number of equivocations ever added
+func (s *GrandpaState) equivocationsCount() (uint64, error) {
+	has, err := s.db.Has(equivocationsCountKey)
+	if err != nil || !has {
+		return 0, err
+	}
+
+	enc, err := s.db.Get(equivocationsCountKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(enc) != 8 {
+		return 0, fmt.Errorf("invalid equivocations count length %d", len(enc))
+	}
+
+	return binary.LittleEndian.Uint64(enc), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrandpaState_VoterState(t *testing.T) {
+	gs := NewGrandpaState(chaindb.NewMemDatabase())
+
+	has, err := gs.HasVoterState()
+	require.NoError(t, err)
+	require.False(t, has)
+
+	data := []byte{1, 2, 3, 4}
+	err = gs.SetVoterState(data)
+	require.NoError(t, err)
+
+	has, err = gs.HasVoterState()
+	require.NoError(t, err)
+	require.True(t, has)
+
+	res, err := gs.GetVoterState()
+	require.NoError(t, err)
+	require.Equal(t, data, res)
+}
+
+func TestGrandpaState_Equivocations(t *testing.T) {
+	gs := NewGrandpaState(chaindb.NewMemDatabase())
+
+	evs, err := gs.GetEquivocations()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{}, evs)
+
+	expected := [][]byte{{1, 2, 3}, {4, 5, 6, 7}}
+	for _, ev := range expected {
+		err = gs.AddEquivocation(ev)
+		require.NoError(t, err)
+	}
+
+	evs, err = gs.GetEquivocations()
+	require.NoError(t, err)
+	require.Equal(t, expected, evs)
+}
+
+func TestGrandpaState_Equivocations_Pruned(t *testing.T) {
+	gs := NewGrandpaState(chaindb.NewMemDatabase())
+
+	prev := maxStoredEquivocations
+	maxStoredEquivocations = 2
+	defer func() {
+		maxStoredEquivocations = prev
+	}()
+
+	for _, ev := range [][]byte{{1}, {2}, {3}} {
+		err := gs.AddEquivocation(ev)
+		require.NoError(t, err)
+	}
+
+	// the oldest evidence is replaced
+	evs, err := gs.GetEquivocations()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{2}, {3}}, evs)
+}
@@ -41,6 +41,7 @@ type Service struct {
 	Block            *BlockState
 	Network          *NetworkState
 	TransactionQueue *TransactionQueue
+	Grandpa          *GrandpaState
 }
 
 // NewService create a new instance of Service
@@ -130,6 +131,7 @@ func (s *Service) Initialize(data *genesis.Data, header *types.Header, t *trie.T
 		// append storage state and block state to state service
 		s.Storage = storageState
 		s.Block = blockState
+		s.Grandpa = NewGrandpaState(db)
 
 	} else {
 
@@ -146,8 +148,14 @@ func (s *Service) Initialize(data *genesis.Data, header *types.Header, t *trie.T
 // storeInitialValues writes initial genesis values to the state database
 func (s *Service) storeInitialValues(db chaindb.Database, data *genesis.Data, header *types.Header, t *trie.Trie) error {
 
+	// write the version of the database format
+	err := StoreDBVersion(db)
+	if err != nil {
+		return fmt.Errorf("failed to write database version: %s", err)
+	}
+
 	// write genesis trie to database
-	err := StoreTrie(db, t)
+	err = StoreTrie(db, t)
 	if err != nil {
 		return fmt.Errorf("failed to write trie to database: %s", err)
 	}
@@ -195,6 +203,12 @@ func (s *Service) Start() error {
 		s.db = db
 	}
 
+	// the database needs to be reinitialised if it was written in another format
+	err := CheckDBVersion(db)
+	if err != nil {
+		return err
+	}
+
 	// retrieve latest header
 	bestHash, err := LoadBestBlockHash(db)
 	if err != nil {
@@ -235,6 +249,9 @@ func (s *Service) Start() error {
 		return fmt.Errorf("failed to get state root from database: %s", err)
 	}
 
+	// create grandpa state
+	s.Grandpa = NewGrandpaState(db)
+
 	// create network state
 	s.Network = NewNetworkState()
 
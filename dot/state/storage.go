@@ -28,7 +28,6 @@ import (
 )
 
 var storagePrefix = []byte("storage")
-var codeKey = []byte(":code")
 
 // StorageDB stores trie structure in an underlying database
 type StorageDB struct {
@@ -82,17 +81,50 @@ func NewStorageState(db chaindb.Database, t *trie.Trie) (*StorageState, error) {
 	}, nil
 }
 
-// StoreInDB encodes the entire trie and writes it to the DB
-// The key to the DB entry is the root hash of the trie
+// StoreInDB writes the trie's nodes to the DB, so that it can be loaded using its root hash
 func (s *StorageState) StoreInDB() error {
 	return StoreTrie(s.db.db, s.trie)
 }
 
-// LoadFromDB loads an encoded trie from the DB where the key is `root`
+// LoadFromDB loads the trie whose root hash is `root` from the DB
 func (s *StorageState) LoadFromDB(root common.Hash) error {
 	return LoadTrie(s.db.db, s.trie, root)
 }
 
+// TrieAt returns a copy of the storage trie with the given root, which is either the current state or a state that
+// was stored in the DB
+func (s *StorageState) TrieAt(root common.Hash) (*trie.Trie, error) {
+	t := trie.NewEmptyTrie()
+
+	s.lock.RLock()
+	current, err := s.trie.Hash()
+	if err != nil || current != root {
+		s.lock.RUnlock()
+		return t, LoadTrie(s.db.db, t, root)
+	}
+
+	enc, err := s.trie.Encode()
+	s.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return t, t.Decode(enc)
+}
+
+// StoreTrie writes the trie to the DB, so that it can be loaded with TrieAt using its root. it's used to store the
+// state of each imported block.
+func (s *StorageState) StoreTrie(t *trie.Trie) error {
+	return StoreTrie(s.db.db, t)
+}
+
+// SetTrie makes the trie the current state, eg. once the block whose state it is becomes our best block
+func (s *StorageState) SetTrie(t *trie.Trie) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.trie = t
+}
+
 // ExistsStorage check if the key exists in the storage trie
 func (s *StorageState) ExistsStorage(key []byte) (bool, error) {
 	s.lock.RLock()
@@ -196,7 +228,7 @@ func (s *StorageState) GetStorageFromChild(keyToChild, key []byte) ([]byte, erro
 
 // LoadCode returns the runtime code (located at :code)
 func (s *StorageState) LoadCode() ([]byte, error) {
-	return s.GetStorage(codeKey)
+	return s.GetStorage(common.CodeKey)
 }
 
 // LoadCodeHash returns the hash of the runtime code (located at :code)
@@ -25,7 +25,7 @@ func TestLoadCodeHash(t *testing.T) {
 	storage := newTestStorageState(t)
 	testCode := []byte("asdf")
 
-	err := storage.SetStorage(codeKey, testCode)
+	err := storage.SetStorage(common.CodeKey, testCode)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -74,3 +74,121 @@ func TestSetAndGetBalance(t *testing.T) {
 		t.Fatalf("Fail: got %d expected %d", res, bal)
 	}
 }
+
+func TestTrieAt(t *testing.T) {
+	storage := newTestStorageState(t)
+
+	err := storage.SetStorage([]byte("noot"), []byte("washere"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := storage.StorageRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.StoreInDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.SetStorage([]byte("noot"), []byte("wasnthere"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := storage.StorageRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the current state is copied, so changes to the copy don't affect it
+	tr, err := storage.TrieAt(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tr.Put([]byte("noot"), []byte("changed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := storage.GetStorage([]byte("noot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(val, []byte("wasnthere")) {
+		t.Fatalf("Fail: got %s expected wasnthere", val)
+	}
+
+	// older states are loaded from the DB
+	tr, err = storage.TrieAt(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err = tr.Get([]byte("noot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(val, []byte("washere")) {
+		t.Fatalf("Fail: got %s expected washere", val)
+	}
+
+	_, err = storage.TrieAt(common.Hash{0x1})
+	if err == nil {
+		t.Fatal("Fail: expected error for unknown state root")
+	}
+}
+
+func TestStoreTrie(t *testing.T) {
+	storage := newTestStorageState(t)
+
+	tr := trie.NewEmptyTrie()
+	err := tr.Put([]byte("noot"), []byte("washere"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := tr.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.StoreTrie(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the stored trie can be loaded, without changing the current state
+	loaded, err := storage.TrieAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := loaded.Get([]byte("noot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(val, []byte("washere")) {
+		t.Fatalf("Fail: got %s expected washere", val)
+	}
+
+	val, err = storage.GetStorage([]byte("noot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Fatalf("Fail: got %s expected nil", val)
+	}
+
+	storage.SetTrie(loaded)
+	current, err := storage.StorageRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != root {
+		t.Fatalf("Fail: got %s expected %s", current, root)
+	}
+}
@@ -1,6 +1,9 @@
 package state
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/transaction"
@@ -9,18 +12,29 @@ import (
 // TransactionQueue represents the queue of transactions
 type TransactionQueue struct {
 	queue *transaction.PriorityQueue
+
+	// transaction notifiers
+	pushed     map[byte]chan<- common.Hash
+	pushedLock sync.RWMutex
 }
 
 // NewTransactionQueue returns a new TransactionQueue
 func NewTransactionQueue() *TransactionQueue {
 	return &TransactionQueue{
-		queue: transaction.NewPriorityQueue(),
+		queue:  transaction.NewPriorityQueue(),
+		pushed: make(map[byte]chan<- common.Hash),
 	}
 }
 
 // Push pushes a transaction to the queue, ordered by priority
 func (q *TransactionQueue) Push(vt *transaction.ValidTransaction) (common.Hash, error) {
-	return q.queue.Push(vt)
+	hash, err := q.queue.Push(vt)
+	if err != nil {
+		return hash, err
+	}
+
+	q.notifyPushed(hash)
+	return hash, nil
 }
 
 // Pop removes and returns the head of the queue
@@ -42,3 +56,49 @@ func (q *TransactionQueue) Pending() []*transaction.ValidTransaction {
 func (q *TransactionQueue) RemoveExtrinsic(ext types.Extrinsic) {
 	q.queue.RemoveExtrinsic(ext)
 }
+
+// RegisterPushedChannel registers a channel for notification upon a transaction being pushed to the queue.
+// Notifications are dropped if the channel's buffer is full, so it should be buffered.
+// It returns the channel ID (used for unregistering the channel)
+func (q *TransactionQueue) RegisterPushedChannel(ch chan<- common.Hash) (byte, error) {
+	q.pushedLock.Lock()
+	defer q.pushedLock.Unlock()
+
+	if len(q.pushed) == 256 {
+		return 0, errors.New("channel limit reached")
+	}
+
+	var id byte
+	for {
+		id = generateID()
+		if q.pushed[id] == nil {
+			break
+		}
+	}
+
+	q.pushed[id] = ch
+	return id, nil
+}
+
+// UnregisterPushedChannel removes the transaction notification channel with the given ID.
+// Once it returns, nothing else is sent on the channel, so it can be closed.
+func (q *TransactionQueue) UnregisterPushedChannel(id byte) {
+	q.pushedLock.Lock()
+	defer q.pushedLock.Unlock()
+
+	delete(q.pushed, id)
+}
+
+// notifyPushed sends the hash of the pushed transaction to the registered channels without blocking. the sends are
+// done while holding the lock, so that channels can't be unregistered and closed while they're being sent to.
+func (q *TransactionQueue) notifyPushed(hash common.Hash) {
+	q.pushedLock.RLock()
+	defer q.pushedLock.RUnlock()
+
+	for _, ch := range q.pushed {
+		select {
+		case ch <- hash:
+		default:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushedChannel(t *testing.T) {
+	tq := NewTransactionQueue()
+
+	ch := make(chan common.Hash, 3)
+	id, err := tq.RegisterPushedChannel(ch)
+	require.NoError(t, err)
+
+	defer tq.UnregisterPushedChannel(id)
+
+	exts := []types.Extrinsic{{1}, {2}, {3}}
+	expected := make(map[common.Hash]bool)
+	for _, ext := range exts {
+		hash, err := tq.Push(transaction.NewValidTransaction(ext, &transaction.Validity{}))
+		require.NoError(t, err)
+		expected[hash] = true
+	}
+
+	for i := 0; i < len(exts); i++ {
+		select {
+		case hash := <-ch:
+			require.True(t, expected[hash])
+		case <-time.After(testMessageTimeout):
+			t.Fatal("did not receive pushed transaction")
+		}
+	}
+
+	// pushing an existing transaction should not notify
+	_, err = tq.Push(transaction.NewValidTransaction(exts[0], &transaction.Validity{}))
+	require.Error(t, err)
+
+	select {
+	case <-ch:
+		t.Fatal("should not receive notification for existing transaction")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestPushedChannel_FullAndClosed(t *testing.T) {
+	tq := NewTransactionQueue()
+
+	// pushing doesn't block on channels that aren't being read from
+	full := make(chan common.Hash)
+	_, err := tq.RegisterPushedChannel(full)
+	require.NoError(t, err)
+
+	ch := make(chan common.Hash, 1)
+	id, err := tq.RegisterPushedChannel(ch)
+	require.NoError(t, err)
+
+	_, err = tq.Push(transaction.NewValidTransaction([]byte{1}, &transaction.Validity{}))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ch))
+
+	// channels can be closed once they're unregistered
+	tq.UnregisterPushedChannel(id)
+	close(ch)
+
+	_, err = tq.Push(transaction.NewValidTransaction([]byte{2}, &transaction.Validity{}))
+	require.NoError(t, err)
+}
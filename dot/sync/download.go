@@ -0,0 +1,422 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/optional"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// maxDownloadChunks is the number of chunks of the missing range of blocks that are requested or waiting to be
+// imported at once
+var maxDownloadChunks = 16
+
+// maxPeerChunks is the number of chunks that are requested from the same peer at once
+var maxPeerChunks = 2
+
+// chunkTimeout is how long we wait for the response to a chunk's request before requesting it from another peer
+var chunkTimeout = 10 * time.Second
+
+// chunk is a range of blocks that we're downloading with a single block request
+type chunk struct {
+	start, end uint64 // the first and last blocks of the chunk
+	ids        []uint64
+	peer       peer.ID // the peer the chunk is requested from, if it's been assigned to one
+	requested  time.Time
+	failed     map[peer.ID]bool // peers that timed out or responded with invalid blocks for the chunk
+	forked     map[peer.ID]bool // peers that responded with blocks whose parent we don't have
+	resp       *network.BlockResponseMessage
+}
+
+func newChunk(start, end uint64) *chunk {
+	return &chunk{
+		start:  start,
+		end:    end,
+		failed: make(map[peer.ID]bool),
+		forked: make(map[peer.ID]bool),
+	}
+}
+
+// inFlight returns whether the chunk has been requested from a peer that we're still waiting for a response from
+func (c *chunk) inFlight(now time.Time) bool {
+	return c.resp == nil && c.peer != "" && now.Before(c.requested.Add(chunkTimeout))
+}
+
+// retry marks the chunk as needing to be requested again. if fail is true, the peer it was requested from won't be
+// picked for it again unless no other peer has it.
+func (c *chunk) retry(fail bool) {
+	if fail && c.peer != "" {
+		c.failed[c.peer] = true
+	}
+
+	c.peer = ""
+	c.requested = time.Time{}
+	c.resp = nil
+}
+
+// downloader splits the range of blocks that we're missing into chunks, which are requested concurrently from
+// different peers and imported in order as their responses arrive
+type downloader struct {
+	chunks []*chunk          // the chunks being downloaded, in order
+	ids    map[uint64]*chunk // the chunks by the IDs of the requests sent for them
+	next   uint64            // the first block that isn't part of a chunk yet
+	target uint64            // the last block to download
+}
+
+func newDownloader() *downloader {
+	return &downloader{
+		ids: make(map[uint64]*chunk),
+	}
+}
+
+// extend adds the blocks from start to target to the range being downloaded
+func (d *downloader) extend(start, target uint64) {
+	if len(d.chunks) == 0 && d.next < start {
+		d.next = start
+	}
+
+	if target > d.target {
+		d.target = target
+	}
+
+	d.fill()
+}
+
+// fill splits the start of the range that isn't part of a chunk yet into chunks, up to maxDownloadChunks
+func (d *downloader) fill() {
+	for len(d.chunks) < maxDownloadChunks && d.next != 0 && d.next <= d.target {
+		end := d.next + uint64(maxResponseSize) - 1
+		if end > d.target {
+			end = d.target
+		}
+
+		d.chunks = append(d.chunks, newChunk(d.next, end))
+		d.next = end + 1
+	}
+}
+
+// rewind adds chunks for the blocks from start up to the first chunk, so that they're imported before it
+func (d *downloader) rewind(start uint64) {
+	front := d.front()
+	if front == nil || start >= front.start {
+		return
+	}
+
+	chunks := []*chunk{}
+	for next := start; next < front.start; {
+		end := next + uint64(maxResponseSize) - 1
+		if end >= front.start {
+			end = front.start - 1
+		}
+
+		chunks = append(chunks, newChunk(next, end))
+		next = end + 1
+	}
+
+	d.chunks = append(chunks, d.chunks...)
+}
+
+// reset stops downloading all the chunks
+func (d *downloader) reset() {
+	d.chunks = nil
+	d.ids = make(map[uint64]*chunk)
+	d.next = 0
+	d.target = 0
+}
+
+// chunk returns the chunk that the request with the given ID was sent for
+func (d *downloader) chunk(id uint64) *chunk {
+	return d.ids[id]
+}
+
+// front returns the first chunk, which is the next one to be imported
+func (d *downloader) front() *chunk {
+	if len(d.chunks) == 0 {
+		return nil
+	}
+
+	return d.chunks[0]
+}
+
+// pop removes the first chunk once it's been imported, and adds a chunk for the following blocks
+func (d *downloader) pop() {
+	c := d.chunks[0]
+	for _, id := range c.ids {
+		delete(d.ids, id)
+	}
+
+	d.chunks = d.chunks[1:]
+	d.fill()
+}
+
+// due returns the chunks that need to be requested, ie. those that haven't been requested yet or whose requests
+// timed out. the peers that timed out won't be picked for the chunks again unless no other peer has them.
+func (d *downloader) due(now time.Time) []*chunk {
+	due := []*chunk{}
+	for _, c := range d.chunks {
+		if c.resp != nil {
+			continue
+		}
+
+		if c.requested.IsZero() {
+			due = append(due, c)
+			continue
+		}
+
+		if !now.Before(c.requested.Add(chunkTimeout)) {
+			c.retry(true)
+			due = append(due, c)
+		}
+	}
+
+	return due
+}
+
+// peerChunks returns the number of chunks that are in flight from each peer
+func (d *downloader) peerChunks(now time.Time) map[peer.ID]int {
+	count := make(map[peer.ID]int)
+	for _, c := range d.chunks {
+		if c.inFlight(now) {
+			count[c.peer]++
+		}
+	}
+
+	return count
+}
+
+// BlockRequests returns the block requests for the chunks of the missing range of blocks that need to be requested,
+// either because they haven't been requested yet or because their requests timed out, each with the peer assigned
+// to it. requests are only created for the chunks that are assigned to a peer, and the others are left to be
+// requested later.
+func (s *Service) BlockRequests(peers []common.PeerInfo) []*network.ScheduledBlockRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reqs := []*network.ScheduledBlockRequest{}
+	for _, c := range s.downloader.due(time.Now()) {
+		p, ok := s.assignChunk(c, peers)
+		if !ok {
+			continue
+		}
+
+		reqs = append(reqs, &network.ScheduledBlockRequest{
+			Peer:    p,
+			Request: s.createChunkRequest(c),
+		})
+	}
+
+	return reqs
+}
+
+// nextRequest returns the block request for the first chunk that needs to be requested, if any. the request is
+// returned by one of the Handle methods, so it's always sent, even if SelectPeer doesn't assign it to a peer.
+func (s *Service) nextRequest() *network.BlockRequestMessage {
+	now := time.Now()
+	due := s.downloader.due(now)
+	if len(due) == 0 {
+		return nil
+	}
+
+	due[0].requested = now
+	return s.createChunkRequest(due[0])
+}
+
+// createChunkRequest creates a block request for the blocks of the chunk. responses to any of the requests sent for
+// a chunk are accepted, so a response that arrives after the chunk was requested again isn't wasted.
+func (s *Service) createChunkRequest(c *chunk) *network.BlockRequestMessage {
+	req := s.createBlockRequest(int64(c.start))
+	req.Max = optional.NewUint32(true, uint32(c.end-c.start+1))
+
+	c.ids = append(c.ids, req.ID)
+	s.downloader.ids[req.ID] = c
+	return req
+}
+
+// assignChunk picks the peer to request the chunk from out of the full nodes and authorities whose best block is at
+// least the chunk's last block and that aren't busy with other chunks. peers that failed the chunk before are only
+// picked if no other peer has it.
+func (s *Service) assignChunk(c *chunk, peers []common.PeerInfo) (peer.ID, bool) {
+	now := time.Now()
+	busy := s.downloader.peerChunks(now)
+
+	candidates := []common.PeerInfo{}
+	for _, p := range peers {
+		if !p.StoresFullBlocks() || p.BestNumber < c.end {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	sortPeers(candidates)
+
+	for _, allowFailed := range []bool{false, true} {
+		for _, cand := range candidates {
+			id, err := peer.IDB58Decode(cand.PeerID)
+			if err != nil {
+				continue
+			}
+
+			if busy[id] >= maxPeerChunks || (c.failed[id] && !allowFailed) {
+				continue
+			}
+
+			c.peer = id
+			c.requested = now
+			s.logger.Trace("selected peer for chunk", "peer", id, "start", c.start, "end", c.end, "best", cand.BestNumber)
+			return id, true
+		}
+	}
+
+	return "", false
+}
+
+// handleChunkResponse buffers the response for its chunk, then imports the chunks at the front of the range whose
+// responses have arrived, in order. if one of them contains invalid blocks, it's requested again from another peer,
+// and an error is returned if it's the chunk this response is for, so that the peer that sent it is penalised.
+func (s *Service) handleChunkResponse(c *chunk, msg *network.BlockResponseMessage) (*network.BlockRequestMessage, error) {
+	if c.resp != nil {
+		return nil, nil
+	}
+
+	c.resp = msg
+	if c.requested.IsZero() {
+		c.requested = time.Now()
+	}
+
+	for front := s.downloader.front(); front != nil && front.resp != nil; front = s.downloader.front() {
+		done, err := s.importChunk(front)
+		if err != nil && front == c {
+			return nil, err
+		}
+
+		if !done {
+			break
+		}
+	}
+
+	if s.checkSynced() {
+		return nil, nil
+	}
+
+	return s.nextRequest(), nil
+}
+
+// importChunk processes the response of the first chunk and returns whether the whole chunk was imported. if the
+// response only contains the start of the chunk, the rest of it is requested again. if it contains invalid blocks,
+// the chunk is requested again from another peer and the error is returned.
+func (s *Service) importChunk(c *chunk) (bool, error) {
+	var stateErr *parentStateError
+	highestInResp, err := s.processBlockResponseData(c.resp)
+	switch {
+	case err == blocktree.ErrParentNotFound:
+		return false, s.handleForkedChunk(c)
+	case errors.As(err, &stateErr):
+		// the blocks since the last state we have need to be executed again, so they're downloaded again from the
+		// first one whose body is missing
+		s.logger.Debug("downloading blocks to restore missing state", "start", stateErr.start, "chunk", c.start)
+		c.retry(false)
+		s.downloader.rewind(stateErr.start)
+		return false, nil
+	case errors.Is(err, ErrInvalidBlockResponse):
+		s.logger.Debug("received invalid block response", "start", c.start, "peer", c.peer, "error", err)
+		c.retry(true)
+		return false, err
+	case err != nil:
+		s.logger.Error("failed to process block response", "start", c.start, "error", err)
+		c.retry(false)
+		return false, nil
+	}
+
+	switch {
+	case highestInResp < int64(c.start):
+		// the peer doesn't have the chunk
+		c.retry(true)
+		return false, nil
+	case highestInResp < int64(c.end):
+		c.start = uint64(highestInResp) + 1
+		c.retry(false)
+		return false, nil
+	}
+
+	s.downloader.pop()
+	return true, nil
+}
+
+// handleForkedChunk handles a response for the first chunk whose blocks don't connect to our chain. either our best
+// block is on another fork, in which case the chain needs to be downloaded from further back, or the peer sent us
+// blocks from a fork we don't know about. so the response is treated as invalid and the chunk is requested from
+// another peer, and the blocks before the chunk are only downloaded once a second peer's response confirms that we're
+// on another fork. if no other peer has the chunk, the same peer responding with the fork again confirms it.
+func (s *Service) handleForkedChunk(c *chunk) error {
+	if len(c.forked) == 0 {
+		s.logger.Debug("received block response with unknown parent", "start", c.start, "peer", c.peer)
+		c.forked[c.peer] = true
+		c.retry(true)
+		return invalidBlockResponse(blocktree.ErrParentNotFound)
+	}
+
+	// the peers that responded with the fork weren't at fault
+	for p := range c.forked {
+		delete(c.failed, p)
+	}
+	c.forked = make(map[peer.ID]bool)
+
+	start := int64(c.start) - maxResponseSize
+	if start <= 0 {
+		start = 1
+	}
+
+	s.logger.Trace("downloading blocks before chunk", "start", start, "chunk", c.start)
+	c.retry(false)
+	s.downloader.rewind(uint64(start))
+	return nil
+}
+
+// checkSynced checks whether our best block has caught up with the highest block we've seen, in which case the
+// download is finished and block production is resumed
+func (s *Service) checkSynced() bool {
+	bestNum, err := s.blockState.BestBlockNumber()
+	if err != nil {
+		s.logger.Error("failed to get best block number", "error", err)
+		return false
+	}
+
+	if bestNum.Cmp(s.highestSeenBlock) < 0 || bestNum.Sign() == 0 {
+		return false
+	}
+
+	s.logger.Debug("all synced up!", "number", bestNum)
+	s.benchmarker.end(bestNum.Uint64())
+	s.downloader.reset()
+
+	if !s.synced {
+		err = s.blockProducer.Resume()
+		if err != nil {
+			s.logger.Warn("failed to resume block production")
+		}
+		s.synced = true
+	}
+
+	return true
+}
@@ -0,0 +1,330 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+var testPeerIDs = []string{
+	"12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu",
+	"12D3KooWKRyzVWW6ChFjQjK4miCty85Niy49tpPV95XdKu1BcvMA",
+	"12D3KooWB1b3qZxWJanuhtseF3DmPggHCtG36KZ9ixkqHtdKH9fh",
+}
+
+func testPeerID(t *testing.T, i int) peer.ID {
+	p, err := peer.IDB58Decode(testPeerIDs[i])
+	require.NoError(t, err)
+	return p
+}
+
+func TestDownloader_Extend(t *testing.T) {
+	d := newDownloader()
+	d.extend(1, 30)
+	require.Equal(t, 3, len(d.chunks))
+	require.Equal(t, uint64(1), d.chunks[0].start)
+	require.Equal(t, uint64(12), d.chunks[0].end)
+	require.Equal(t, uint64(25), d.chunks[2].start)
+	require.Equal(t, uint64(30), d.chunks[2].end)
+
+	// only maxDownloadChunks chunks are added at once
+	d.extend(1, uint64(maxResponseSize)*uint64(maxDownloadChunks+4))
+	require.Equal(t, maxDownloadChunks, len(d.chunks))
+	require.Equal(t, uint64(30), d.chunks[2].end)
+	require.Equal(t, uint64(31), d.chunks[3].start)
+	require.Equal(t, uint64(42), d.chunks[3].end)
+
+	// chunks are added for the rest of the range as the first ones are imported
+	last := d.chunks[maxDownloadChunks-1].end
+	d.pop()
+	require.Equal(t, maxDownloadChunks, len(d.chunks))
+	require.Equal(t, last+1, d.chunks[maxDownloadChunks-1].start)
+}
+
+func TestBlockRequests(t *testing.T) {
+	syncer := newTestSyncer(t, nil)
+
+	req := syncer.HandleSeenBlocks(big.NewInt(40))
+	require.NotNil(t, req)
+	require.Equal(t, uint64(1), req.StartingBlock.Value().(uint64))
+
+	peers := []common.PeerInfo{
+		{PeerID: testPeerIDs[0], Roles: 1, BestNumber: 40},
+		{PeerID: testPeerIDs[1], Roles: 4, BestNumber: 36},
+		{PeerID: testPeerIDs[2], Roles: 2, BestNumber: 40},
+	}
+
+	// the first chunk's request is returned by HandleSeenBlocks, and the peer it's sent to is picked by SelectPeer
+	p, ok := syncer.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, testPeerID(t, 0), p)
+
+	// the other chunks are spread over the peers that have them, up to maxPeerChunks each. the last chunk isn't
+	// requested, since the peers that have it are busy.
+	reqs := syncer.BlockRequests(peers)
+	require.Equal(t, 2, len(reqs))
+	require.Equal(t, uint64(13), reqs[0].Request.StartingBlock.Value().(uint64))
+	require.Equal(t, testPeerID(t, 0), reqs[0].Peer)
+	require.Equal(t, uint64(25), reqs[1].Request.StartingBlock.Value().(uint64))
+	require.Equal(t, testPeerID(t, 1), reqs[1].Peer)
+
+	// no request is created for the chunk until a peer is available for it
+	ids := len(syncer.downloader.ids)
+	require.Equal(t, 0, len(syncer.BlockRequests(peers)))
+	require.Equal(t, 0, len(syncer.BlockRequests(nil)))
+	require.Equal(t, ids, len(syncer.downloader.ids))
+
+	peers = append(peers, common.PeerInfo{PeerID: testPeerIDs[2], Roles: 1, BestNumber: 40})
+	reqs = syncer.BlockRequests(peers)
+	require.Equal(t, 1, len(reqs))
+	require.Equal(t, uint64(37), reqs[0].Request.StartingBlock.Value().(uint64))
+	require.Equal(t, uint32(4), reqs[0].Request.Max.Value())
+	require.Equal(t, testPeerID(t, 2), reqs[0].Peer)
+}
+
+func TestBlockRequests_Timeout(t *testing.T) {
+	syncer := newTestSyncer(t, nil)
+
+	timeout := chunkTimeout
+	chunkTimeout = 10 * time.Millisecond
+	defer func() {
+		chunkTimeout = timeout
+	}()
+
+	req := syncer.HandleSeenBlocks(big.NewInt(12))
+	require.NotNil(t, req)
+
+	peers := []common.PeerInfo{
+		{PeerID: testPeerIDs[0], Roles: 1, BestNumber: 12},
+		{PeerID: testPeerIDs[1], Roles: 1, BestNumber: 12, Reputation: -1},
+	}
+
+	p, ok := syncer.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, testPeerID(t, 0), p)
+	require.Equal(t, 0, len(syncer.BlockRequests(peers)))
+
+	// the chunk is requested from another peer once it times out
+	time.Sleep(chunkTimeout)
+	reqs := syncer.BlockRequests(peers)
+	require.Equal(t, 1, len(reqs))
+	require.NotEqual(t, req.ID, reqs[0].Request.ID)
+	require.Equal(t, testPeerID(t, 1), reqs[0].Peer)
+
+	// a late response to the first request is still accepted
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 12, responder.blockState)
+	resp, err := responder.CreateBlockResponse(req)
+	require.NoError(t, err)
+
+	_, err = syncer.HandleBlockResponse(resp)
+	require.NoError(t, err)
+	require.True(t, syncer.synced)
+}
+
+func TestHandleBlockResponse_OutOfOrderChunks(t *testing.T) {
+	syncer := newTestSyncer(t, nil)
+
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 30, responder.blockState)
+
+	peers := []common.PeerInfo{
+		{PeerID: testPeerIDs[0], Roles: 1, BestNumber: 30},
+		{PeerID: testPeerIDs[1], Roles: 1, BestNumber: 30},
+	}
+
+	req := syncer.HandleSeenBlocks(big.NewInt(30))
+	reqs := []*network.BlockRequestMessage{req}
+	for _, r := range syncer.BlockRequests(peers) {
+		reqs = append(reqs, r.Request)
+	}
+	require.Equal(t, 3, len(reqs))
+
+	respond := func(req *network.BlockRequestMessage) {
+		resp, err := responder.CreateBlockResponse(req)
+		require.NoError(t, err)
+		require.Equal(t, int(req.Max.Value()), len(resp.BlockData))
+
+		_, err = syncer.HandleBlockResponse(resp)
+		require.NoError(t, err)
+	}
+
+	// the second chunk is buffered until the first one is imported
+	respond(reqs[1])
+	bestNum, err := syncer.blockState.BestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), bestNum)
+
+	respond(reqs[0])
+	bestNum, err = syncer.blockState.BestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(24), bestNum)
+	require.False(t, syncer.synced)
+
+	respond(reqs[2])
+	bestNum, err = syncer.blockState.BestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(30), bestNum)
+	require.True(t, syncer.synced)
+	require.Equal(t, 0, len(syncer.downloader.chunks))
+}
+
+func TestHandleBlockResponse_InvalidChunk(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		Verifier: &mockInvalidVerifier{},
+	})
+
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 12, responder.blockState)
+
+	req := syncer.HandleSeenBlocks(big.NewInt(12))
+	require.NotNil(t, req)
+
+	peers := []common.PeerInfo{
+		{PeerID: testPeerIDs[0], Roles: 1, BestNumber: 12},
+		{PeerID: testPeerIDs[1], Roles: 1, BestNumber: 12, Reputation: -1},
+	}
+
+	p, ok := syncer.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, testPeerID(t, 0), p)
+
+	resp, err := responder.CreateBlockResponse(req)
+	require.NoError(t, err)
+
+	_, err = syncer.HandleBlockResponse(resp)
+	require.True(t, errors.Is(err, ErrInvalidBlockResponse))
+
+	// the chunk is requested again, from another peer
+	reqs := syncer.BlockRequests(peers)
+	require.Equal(t, 1, len(reqs))
+	require.Equal(t, testPeerID(t, 1), reqs[0].Peer)
+}
+
+func TestDownloader_Rewind(t *testing.T) {
+	d := newDownloader()
+	d.extend(20, 30)
+	d.chunks[0].resp = &network.BlockResponseMessage{}
+
+	// chunks are added before the first one, which keeps its response
+	d.rewind(5)
+	require.Equal(t, 3, len(d.chunks))
+	require.Equal(t, uint64(5), d.chunks[0].start)
+	require.Equal(t, uint64(16), d.chunks[0].end)
+	require.Equal(t, uint64(17), d.chunks[1].start)
+	require.Equal(t, uint64(19), d.chunks[1].end)
+	require.NotNil(t, d.chunks[2].resp)
+
+	d.rewind(10)
+	require.Equal(t, 3, len(d.chunks))
+}
+
+func TestHandleBlockResponse_ForkedChunk(t *testing.T) {
+	syncer := newTestSyncer(t, nil)
+
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 24, responder.blockState)
+
+	// only download the second half of the range, so that the first chunk's parent is missing
+	req := syncer.HandleSeenBlocks(big.NewInt(24))
+	require.NotNil(t, req)
+	syncer.downloader.reset()
+	syncer.downloader.extend(13, 24)
+
+	peers := []common.PeerInfo{
+		{PeerID: testPeerIDs[0], Roles: 1, BestNumber: 24},
+		{PeerID: testPeerIDs[1], Roles: 1, BestNumber: 24, Reputation: -1},
+	}
+
+	respond := func(expected peer.ID) (*network.BlockRequestMessage, error) {
+		reqs := syncer.BlockRequests(peers)
+		require.Equal(t, 1, len(reqs))
+		require.Equal(t, expected, reqs[0].Peer)
+
+		resp, err := responder.CreateBlockResponse(reqs[0].Request)
+		require.NoError(t, err)
+
+		return syncer.HandleBlockResponse(resp)
+	}
+
+	// the first peer is penalised and the chunk is requested from another peer, without downloading the blocks before it
+	_, err := respond(testPeerID(t, 0))
+	require.True(t, errors.Is(err, ErrInvalidBlockResponse))
+	require.Equal(t, 1, len(syncer.downloader.chunks))
+	require.Equal(t, uint64(13), syncer.downloader.front().start)
+
+	// the second peer confirms that we're missing the blocks before the chunk
+	next, err := respond(testPeerID(t, 1))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), next.StartingBlock.Value().(uint64))
+	require.Equal(t, 2, len(syncer.downloader.chunks))
+	require.Equal(t, uint64(1), syncer.downloader.front().start)
+	require.False(t, syncer.downloader.chunks[1].failed[testPeerID(t, 0)])
+
+	reqs := syncer.BlockRequests(peers)
+	require.Equal(t, 1, len(reqs))
+	for _, r := range []*network.BlockRequestMessage{next, reqs[0].Request} {
+		resp, err := responder.CreateBlockResponse(r)
+		require.NoError(t, err)
+
+		_, err = syncer.HandleBlockResponse(resp)
+		require.NoError(t, err)
+	}
+
+	require.True(t, syncer.synced)
+}
+
+func TestHandleBlockResponse_MissingParentState(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	// block 1 was imported without its body or state
+	parent := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	parent.Header.StateRoot = common.Hash{0x1}
+	require.NoError(t, syncer.blockState.AddBlockHeader(parent.Header))
+
+	block := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	req := syncer.HandleSeenBlocks(big.NewInt(2))
+	require.NotNil(t, req)
+	require.Equal(t, uint64(2), syncer.downloader.front().start)
+
+	resp := &network.BlockResponseMessage{
+		ID: req.ID,
+		BlockData: []*types.BlockData{{
+			Hash:   block.Header.Hash(),
+			Header: block.Header.AsOptional(),
+			Body:   block.Body.AsOptional(),
+		}},
+	}
+
+	// block 1 is downloaded again, so that it can be executed before block 2, without penalising the peer
+	next, err := syncer.HandleBlockResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(syncer.downloader.chunks))
+	require.Equal(t, uint64(1), syncer.downloader.front().start)
+	require.Equal(t, uint64(1), next.StartingBlock.Value().(uint64))
+}
@@ -30,12 +30,49 @@ var ErrNilVerifier = errors.New("cannot have nil Verifier")
 // ErrNilRuntime is returned when trying to instantiate a Service or Syncer without a runtime
 var ErrNilRuntime = errors.New("cannot have nil runtime")
 
+// ErrNilJustificationVerifier is returned when a justification is received but there is no JustificationVerifier to verify it
+var ErrNilJustificationVerifier = errors.New("cannot verify justification without JustificationVerifier")
+
 // ErrServiceStopped is returned when the service has been stopped
 var ErrServiceStopped = errors.New("service has been stopped")
 
 // ErrInvalidBlock is returned when a block cannot be verified
 var ErrInvalidBlock = errors.New("could not verify block")
 
+// ErrStateRootMismatch is returned when the state root resulting from executing a block isn't the one in its header
+var ErrStateRootMismatch = errors.New("state root does not match block header")
+
+// ErrExtrinsicsRootMismatch is returned when the root of a block's extrinsics isn't the one in its header
+var ErrExtrinsicsRootMismatch = errors.New("extrinsics root does not match block header")
+
+// ErrParentStateNotFound is returned when we have a block's parent, but not the state to execute the block on, and
+// the state can't be restored from the blocks we've imported
+var ErrParentStateNotFound = errors.New("parent state not found")
+
+// parentStateError wraps ErrParentStateNotFound with the first block whose body is missing, which needs to be
+// downloaded again to restore the state
+type parentStateError struct {
+	start uint64
+	err   error
+}
+
+func (e *parentStateError) Error() string {
+	return fmt.Sprintf("%s: missing body of block %d: %s", ErrParentStateNotFound, e.start, e.err)
+}
+
+func (e *parentStateError) Unwrap() error {
+	return ErrParentStateNotFound
+}
+
+// ErrInvalidBlockResponse is returned when a block response contains blocks that cannot be decoded or verified
+var ErrInvalidBlockResponse = errors.New("invalid block response")
+
+// invalidBlockResponse wraps the error with ErrInvalidBlockResponse, so that the peer that sent the response is
+// penalised
+func invalidBlockResponse(err error) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBlockResponse, err)
+}
+
 // ErrNilChannel is returned if a channel is nil
 func ErrNilChannel(s string) error {
 	return fmt.Errorf("cannot have nil channel %s", s)
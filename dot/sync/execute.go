@@ -0,0 +1,250 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/scale"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// importState executes the block on a copy of its parent's state and checks that the resulting state root and the
+// root of its extrinsics match its header, in which case the state is stored under the block's state root and
+// returned. if the block was already imported, or there's no StorageState to execute blocks with, it returns nil.
+func (s *Service) importState(block *types.Block) (*trie.Trie, error) {
+	if s.storageState == nil || block.Header.Number.Sign() == 0 {
+		return nil, nil
+	}
+
+	// the bodies of imported blocks are stored, so the block doesn't need to be executed again
+	if _, err := s.blockState.GetBlockBody(block.Header.Hash()); err == nil {
+		return nil, nil
+	}
+
+	extRoot, err := extrinsicsRoot(block.Body)
+	if err != nil {
+		return nil, invalidBlockResponse(err)
+	}
+
+	if extRoot != block.Header.ExtrinsicsRoot {
+		return nil, invalidBlockResponse(fmt.Errorf("%w: expected %s, got %s", ErrExtrinsicsRootMismatch, block.Header.ExtrinsicsRoot, extRoot))
+	}
+
+	// we can only execute the block if we've imported its parent and stored its state
+	parent, err := s.blockState.GetHeader(block.Header.ParentHash)
+	if err != nil {
+		return nil, blocktree.ErrParentNotFound
+	}
+
+	state, err := s.parentState(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.executeAndStore(block, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// executeAndStore executes the block on its parent's state and stores the resulting state if its root matches the
+// block's header
+func (s *Service) executeAndStore(block *types.Block, state *trie.Trie) error {
+	err := s.execute(block, state)
+	if err != nil {
+		return err
+	}
+
+	root, err := state.Hash()
+	if err != nil {
+		return err
+	}
+
+	if root != block.Header.StateRoot {
+		return invalidBlockResponse(fmt.Errorf("%w: expected %s, got %s", ErrStateRootMismatch, block.Header.StateRoot, root))
+	}
+
+	return s.storageState.StoreTrie(state)
+}
+
+// parentState returns a copy of the state of the block's parent. if it's missing, it's restored by executing the
+// imported blocks since the last ancestor whose state we have again. if one of their bodies is missing too, an error
+// wrapping ErrParentStateNotFound is returned with the first block that needs to be downloaded again.
+func (s *Service) parentState(parent *types.Header) (*trie.Trie, error) {
+	state, err := s.storageState.TrieAt(parent.StateRoot)
+	if err == nil {
+		return state, nil
+	}
+
+	blocks := []*types.Block{}
+	for header := parent; err != nil; {
+		if header.Number.Sign() == 0 {
+			return nil, fmt.Errorf("genesis state not found: %s", err)
+		}
+
+		var body *types.Body
+		body, err = s.blockState.GetBlockBody(header.Hash())
+		if err != nil {
+			return nil, &parentStateError{start: header.Number.Uint64(), err: err}
+		}
+
+		blocks = append(blocks, &types.Block{Header: header, Body: body})
+
+		header, err = s.blockState.GetHeader(header.ParentHash)
+		if err != nil {
+			return nil, err
+		}
+
+		state, err = s.storageState.TrieAt(header.StateRoot)
+	}
+
+	s.logger.Debug("restoring missing state", "from", blocks[len(blocks)-1].Header.Number, "to", parent.Number)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		err = s.executeAndStore(blocks[i], state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// execute runs Core_execute_block for the block on the given state, with the runtime code stored in the state. the
+// runtime rejects blocks whose extrinsics fail or whose state root doesn't match, so errors returned by the call are
+// wrapped with ErrInvalidBlockResponse.
+func (s *Service) execute(block *types.Block, state *trie.Trie) error {
+	code, err := state.Get(common.CodeKey)
+	if err != nil {
+		return err
+	}
+
+	if len(code) == 0 {
+		return fmt.Errorf("no runtime code in state of block %s", block.Header.ParentHash)
+	}
+
+	rt, err := s.executorFor(code)
+	if err != nil {
+		return err
+	}
+
+	data, err := executionData(block)
+	if err != nil {
+		return invalidBlockResponse(err)
+	}
+
+	s.execStorage.SetTrie(state)
+	defer func() {
+		s.execStorage.SetTrie(trie.NewEmptyTrie())
+	}()
+
+	_, err = rt.Exec(runtime.CoreExecuteBlock, data)
+	if err != nil {
+		return invalidBlockResponse(err)
+	}
+
+	return nil
+}
+
+// executorFor returns the runtime instance to execute blocks with, which is created again when the code changes,
+// eg. after a runtime upgrade
+func (s *Service) executorFor(code []byte) (*runtime.Runtime, error) {
+	hash, err := common.Blake2bHash(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.executor != nil && hash == s.executorCode {
+		return s.executor, nil
+	}
+
+	rt, err := runtime.NewRuntime(code, &runtime.Config{
+		Storage:  s.execStorage,
+		Keystore: keystore.NewKeystore(),
+		Imports:  runtime.RegisterImports_NodeRuntime,
+		LogLvl:   -1, // don't change runtime package log level
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.executor != nil {
+		s.executor.Stop()
+	}
+
+	s.executor = rt
+	s.executorCode = hash
+	return rt, nil
+}
+
+// executionData returns the encoded block to pass to Core_execute_block. the seal is removed from the header, since
+// it's added after the block is built and isn't part of what the runtime executes.
+func executionData(block *types.Block) ([]byte, error) {
+	// copy block since we're going to modify it
+	b := block.DeepCopy()
+
+	digest := [][]byte{}
+	for _, d := range b.Header.Digest {
+		if len(d) > 0 && d[0] == types.SealDigestType {
+			continue
+		}
+		digest = append(digest, d)
+	}
+
+	b.Header.Digest = digest
+	return b.Encode()
+}
+
+// extrinsicsRoot returns the root of the trie of the body's extrinsics, keyed by their compact encoded indices
+func extrinsicsRoot(body *types.Body) (common.Hash, error) {
+	t := trie.NewEmptyTrie()
+	if body == nil {
+		return t.Hash()
+	}
+
+	exts, err := body.AsExtrinsics()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	for i, ext := range exts {
+		key, err := scale.Encode(big.NewInt(int64(i)))
+		if err != nil {
+			return common.Hash{}, err
+		}
+
+		value, err := scale.Encode([]byte(ext))
+		if err != nil {
+			return common.Hash{}, err
+		}
+
+		err = t.Put(key, value)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	}
+
+	return t.Hash()
+}
@@ -0,0 +1,206 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestExecutingSyncer returns a syncer that executes the blocks it imports on the state of the given genesis trie
+func newTestExecutingSyncer(t *testing.T, genesisTrie *trie.Trie) *Service {
+	stateSrvc := state.NewService("", log.LvlInfo)
+	stateSrvc.UseMemDB()
+
+	root, err := genesisTrie.Hash()
+	require.NoError(t, err)
+
+	genesisHeader := &types.Header{
+		Number:    big.NewInt(0),
+		StateRoot: root,
+	}
+
+	err = stateSrvc.Initialize(new(genesis.Data), genesisHeader, genesisTrie)
+	require.NoError(t, err)
+
+	err = stateSrvc.Start()
+	require.NoError(t, err)
+
+	return newTestSyncer(t, &Config{
+		BlockState:   stateSrvc.Block,
+		StorageState: stateSrvc.Storage,
+	})
+}
+
+// newTestChildBlock returns a block on top of the best block with the given body, whose extrinsics root is correct
+func newTestChildBlock(t *testing.T, s *Service, body *types.Body) *types.Block {
+	parent, err := s.blockState.GetHeader(s.blockState.BestBlockHash())
+	require.NoError(t, err)
+
+	extRoot, err := extrinsicsRoot(body)
+	require.NoError(t, err)
+
+	return &types.Block{
+		Header: &types.Header{
+			ParentHash:     parent.Hash(),
+			Number:         big.NewInt(0).Add(parent.Number, big.NewInt(1)),
+			StateRoot:      parent.StateRoot,
+			ExtrinsicsRoot: extRoot,
+			Digest:         [][]byte{},
+		},
+		Body: body,
+	}
+}
+
+func TestExtrinsicsRoot(t *testing.T) {
+	root, err := extrinsicsRoot(types.NewBody([]byte{}))
+	require.NoError(t, err)
+	require.Equal(t, trie.EmptyHash, root)
+
+	body, err := types.NewBodyFromExtrinsics([]types.Extrinsic{{1, 2}, {3, 4}})
+	require.NoError(t, err)
+	root, err = extrinsicsRoot(body)
+	require.NoError(t, err)
+	require.NotEqual(t, trie.EmptyHash, root)
+
+	// the root depends on the order of the extrinsics
+	reversed, err := types.NewBodyFromExtrinsics([]types.Extrinsic{{3, 4}, {1, 2}})
+	require.NoError(t, err)
+	reversedRoot, err := extrinsicsRoot(reversed)
+	require.NoError(t, err)
+	require.NotEqual(t, root, reversedRoot)
+}
+
+func TestExecutionData_RemovesSeal(t *testing.T) {
+	preDigest := (&types.PreRuntimeDigest{ConsensusEngineID: types.BabeEngineID, Data: []byte{1}}).Encode()
+	seal := (&types.SealDigest{ConsensusEngineID: types.BabeEngineID, Data: []byte{2}}).Encode()
+
+	block := &types.Block{
+		Header: &types.Header{
+			Number: big.NewInt(1),
+			Digest: [][]byte{preDigest, seal},
+		},
+		Body: types.NewBody([]byte{}),
+	}
+
+	data, err := executionData(block)
+	require.NoError(t, err)
+
+	expected := &types.Block{
+		Header: &types.Header{
+			Number: big.NewInt(1),
+			Digest: [][]byte{preDigest},
+		},
+		Body: types.NewBody([]byte{}),
+	}
+	enc, err := expected.Encode()
+	require.NoError(t, err)
+	require.Equal(t, enc, data)
+
+	// the block itself isn't modified
+	require.Equal(t, 2, len(block.Header.Digest))
+}
+
+func TestHandleBlock_ExtrinsicsRootMismatch(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	body, err := types.NewBodyFromExtrinsics([]types.Extrinsic{{1, 2}})
+	require.NoError(t, err)
+
+	block := newTestChildBlock(t, syncer, body)
+	block.Header.ExtrinsicsRoot = common.Hash{0x1}
+
+	err = syncer.handleBlock(block)
+	require.True(t, errors.Is(err, ErrInvalidBlockResponse))
+	require.Contains(t, err.Error(), ErrExtrinsicsRootMismatch.Error())
+
+	_, err = syncer.blockState.GetBlockBody(block.Header.Hash())
+	require.Error(t, err)
+}
+
+func TestHandleBlock_MissingParentState(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	block := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	block.Header.ParentHash = common.Hash{0x1}
+
+	err := syncer.handleBlock(block)
+	require.Equal(t, blocktree.ErrParentNotFound, err)
+}
+
+func TestHandleBlock_MissingParentTrie(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	// the parent's header was imported, but not its body or state
+	parent := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	parent.Header.StateRoot = common.Hash{0x1}
+	err := syncer.blockState.AddBlockHeader(parent.Header)
+	require.NoError(t, err)
+
+	block := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	require.Equal(t, parent.Header.Hash(), block.Header.ParentHash)
+
+	// the state can't be restored without the parent's body, which isn't the peer's fault
+	err = syncer.handleBlock(block)
+	require.True(t, errors.Is(err, ErrParentStateNotFound))
+	require.False(t, errors.Is(err, ErrInvalidBlockResponse))
+
+	var stateErr *parentStateError
+	require.True(t, errors.As(err, &stateErr))
+	require.Equal(t, uint64(1), stateErr.start)
+}
+
+func TestHandleBlock_RestoreParentTrie(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	// the parent was imported, but its state wasn't stored
+	parent := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	parent.Header.StateRoot = common.Hash{0x1}
+	err := syncer.blockState.AddBlock(parent)
+	require.NoError(t, err)
+
+	// the parent is executed again on the genesis state to restore its state, which fails since there's no runtime
+	block := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	err = syncer.handleBlock(block)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no runtime code")
+	require.False(t, errors.Is(err, ErrParentStateNotFound))
+}
+
+func TestHandleBlock_NoRuntimeCode(t *testing.T) {
+	syncer := newTestExecutingSyncer(t, trie.NewEmptyTrie())
+
+	// we can't execute blocks without the runtime, but that isn't the peer's fault
+	block := newTestChildBlock(t, syncer, types.NewBody([]byte{}))
+	err := syncer.handleBlock(block)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrInvalidBlockResponse))
+
+	_, err = syncer.blockState.GetBlockBody(block.Header.Hash())
+	require.Error(t, err)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/transaction"
+	"github.com/ChainSafe/gossamer/lib/trie"
 )
 
 // BlockState is the interface for the block state
@@ -29,6 +30,7 @@ type BlockState interface {
 	BestBlockHash() common.Hash
 	BestBlockNumber() (*big.Int, error)
 	AddBlock(*types.Block) error
+	AddBlockHeader(*types.Header) error
 	CompareAndSetBlockData(bd *types.BlockData) error
 	GetBlockByNumber(*big.Int) (*types.Block, error)
 	GetBlockBody(common.Hash) (*types.Body, error)
@@ -39,6 +41,15 @@ type BlockState interface {
 	GetReceipt(common.Hash) ([]byte, error)
 	GetMessageQueue(common.Hash) ([]byte, error)
 	GetJustification(common.Hash) ([]byte, error)
+	GetFinalizedHeader(uint64) (*types.Header, error)
+	SetFinalizedHash(common.Hash, uint64) error
+}
+
+// StorageState is the interface for the storage state, which imported blocks are executed on
+type StorageState interface {
+	TrieAt(root common.Hash) (*trie.Trie, error)
+	StoreTrie(t *trie.Trie) error
+	SetTrie(t *trie.Trie)
 }
 
 // TransactionQueue is the interface for transaction queue methods
@@ -57,8 +68,8 @@ type BlockProducer interface {
 
 // DigestHandler is the interface for the consensus digest handler
 type DigestHandler interface {
-	Start()
-	Stop()
+	Start() error
+	Stop() error
 	HandleConsensusDigest(*types.ConsensusDigest) error
 }
 
@@ -66,3 +77,8 @@ type DigestHandler interface {
 type Verifier interface {
 	VerifyBlock(header *types.Header) (bool, error)
 }
+
+// JustificationVerifier deals with block justification verification
+type JustificationVerifier interface {
+	VerifyBlockJustification(hash common.Hash, justification []byte) error
+}
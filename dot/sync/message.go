@@ -65,6 +65,11 @@ func (s *Service) CreateBlockResponse(blockRequest *network.BlockRequestMessage)
 		subchain = subchain[:maxResponseSize]
 	}
 
+	// only respond with the number of blocks requested, if it's smaller
+	if blockRequest.Max != nil && blockRequest.Max.Exists() && int(blockRequest.Max.Value()) < len(subchain) && blockRequest.Max.Value() != 0 {
+		subchain = subchain[:blockRequest.Max.Value()]
+	}
+
 	s.logger.Trace("subchain", "start", subchain[0], "end", subchain[len(subchain)-1])
 
 	responseData := []*types.BlockData{}
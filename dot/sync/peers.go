@@ -0,0 +1,100 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"sort"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// SelectPeer picks the peer to send the block request to. only full nodes and authorities whose best block is at
+// least the first requested block are considered. peers with non-negative reputations are preferred, then the peers
+// with the highest best blocks, then those with the highest reputations. requests for chunks of the missing range of
+// blocks that we're downloading are assigned to peers by assignChunk.
+func (s *Service) SelectPeer(req *network.BlockRequestMessage, peers []common.PeerInfo) (peer.ID, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if c := s.downloader.chunk(req.ID); c != nil {
+		return s.assignChunk(c, peers)
+	}
+
+	start := s.requestStartNumber(req)
+
+	candidates := []common.PeerInfo{}
+	for _, p := range peers {
+		if !p.StoresFullBlocks() || p.BestNumber < start {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	sortPeers(candidates)
+
+	for _, c := range candidates {
+		id, err := peer.IDB58Decode(c.PeerID)
+		if err != nil {
+			s.logger.Debug("failed to decode peer ID", "peer", c.PeerID, "error", err)
+			continue
+		}
+
+		s.logger.Trace("selected peer for block request", "peer", id, "start", start, "best", c.BestNumber)
+		return id, true
+	}
+
+	return "", false
+}
+
+// sortPeers sorts the peers in the order they're picked to send block requests to: peers with non-negative
+// reputations first, then the peers with the highest best blocks, then those with the highest reputations
+func sortPeers(peers []common.PeerInfo) {
+	sort.SliceStable(peers, func(i, j int) bool {
+		a, b := peers[i], peers[j]
+		if (a.Reputation >= 0) != (b.Reputation >= 0) {
+			return a.Reputation >= 0
+		}
+		if a.BestNumber != b.BestNumber {
+			return a.BestNumber > b.BestNumber
+		}
+		return a.Reputation > b.Reputation
+	})
+}
+
+// requestStartNumber returns the number of the first block requested. if the request starts at a block hash that
+// we don't have the header of, it returns zero, so that any peer can be picked.
+func (s *Service) requestStartNumber(req *network.BlockRequestMessage) uint64 {
+	if req.StartingBlock == nil {
+		return 0
+	}
+
+	switch start := req.StartingBlock.Value().(type) {
+	case uint64:
+		return start
+	case common.Hash:
+		header, err := s.blockState.GetHeader(start)
+		if err != nil || header.Number == nil {
+			return 0
+		}
+		return header.Number.Uint64()
+	default:
+		return 0
+	}
+}
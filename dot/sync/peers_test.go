@@ -0,0 +1,86 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/variadic"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPeer(t *testing.T) {
+	s := &Service{
+		logger:     log.New("pkg", "sync"),
+		downloader: newDownloader(),
+	}
+
+	ids := []string{
+		"12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu",
+		"12D3KooWKRyzVWW6ChFjQjK4miCty85Niy49tpPV95XdKu1BcvMA",
+		"12D3KooWB1b3qZxWJanuhtseF3DmPggHCtG36KZ9ixkqHtdKH9fh",
+	}
+
+	start, err := variadic.NewUint64OrHash(uint64(10))
+	require.NoError(t, err)
+	req := &network.BlockRequestMessage{
+		StartingBlock: start,
+	}
+
+	expected := func(id string) peer.ID {
+		p, decodeErr := peer.IDB58Decode(id)
+		require.NoError(t, decodeErr)
+		return p
+	}
+
+	// no peers have the requested blocks
+	_, ok := s.SelectPeer(req, []common.PeerInfo{{PeerID: ids[0], Roles: 1, BestNumber: 9}})
+	require.False(t, ok)
+
+	// light clients don't store blocks
+	_, ok = s.SelectPeer(req, []common.PeerInfo{{PeerID: ids[0], Roles: 2, BestNumber: 20}})
+	require.False(t, ok)
+
+	// the peer with the highest best block is picked
+	peers := []common.PeerInfo{
+		{PeerID: ids[0], Roles: 1, BestNumber: 12},
+		{PeerID: ids[1], Roles: 4, BestNumber: 15},
+		{PeerID: ids[2], Roles: 1, BestNumber: 9},
+	}
+	p, ok := s.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, expected(ids[1]), p)
+
+	// unless its reputation is negative
+	peers[1].Reputation = -1
+	p, ok = s.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, expected(ids[0]), p)
+
+	// peers with the same best block are ordered by reputation
+	peers[0].BestNumber = 15
+	peers[1].BestNumber = 15
+	peers[1].Reputation = 100
+	p, ok = s.SelectPeer(req, peers)
+	require.True(t, ok)
+	require.Equal(t, expected(ids[1]), p)
+}
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	mrand "math/rand"
 	"os"
+	gosync "sync"
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/network"
@@ -30,6 +31,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common/optional"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
 
 	log "github.com/ChainSafe/log15"
 	"golang.org/x/exp/rand"
@@ -40,35 +42,59 @@ type Service struct {
 	logger log.Logger
 
 	// State interfaces
-	blockState       BlockState // retrieve our current head of chain from BlockState
+	blockState       BlockState   // retrieve our current head of chain from BlockState
+	storageState     StorageState // imported blocks are executed on their parent's state, if it's set
 	transactionQueue TransactionQueue
 	blockProducer    BlockProducer
 
 	// Synchronization variables
+	lock             gosync.Mutex
 	synced           bool
-	requestStart     int64    // block number from which to begin block requests
-	highestSeenBlock *big.Int // highest block number we have seen
+	highestSeenBlock *big.Int    // highest block number we have seen
+	downloader       *downloader // the missing range of blocks, which is requested in chunks from several peers
 	runtime          *runtime.Runtime
 
+	// Block execution
+	executor     *runtime.Runtime     // runtime instance that imported blocks are executed with
+	executorCode common.Hash          // hash of the code the executor was created from
+	execStorage  *runtime.TrieStorage // storage of the executor, which is set to the state each block is executed on
+
 	// BABE verification
 	verifier Verifier
 
+	// GRANDPA justification verification
+	justificationVerifier JustificationVerifier
+
 	// Consensus digest handling
 	digestHandler DigestHandler
 
+	// light clients only sync and verify block headers
+	isLightClient bool
+
 	// Benchmarker
 	benchmarker *benchmarker
 }
 
 // Config is the configuration for the sync Service.
 type Config struct {
-	LogLvl           log.Lvl
-	BlockState       BlockState
-	BlockProducer    BlockProducer
+	LogLvl        log.Lvl
+	BlockState    BlockState
+	BlockProducer BlockProducer
+
+	// StorageState is used to execute imported blocks on their parent's state and store the resulting states. if it
+	// is nil, blocks are imported without being executed.
+	StorageState StorageState
+
 	TransactionQueue TransactionQueue
 	Runtime          *runtime.Runtime
 	Verifier         Verifier
 	DigestHandler    DigestHandler
+
+	// JustificationVerifier is used to verify received justifications. if it is nil, received justifications are discarded.
+	JustificationVerifier JustificationVerifier
+
+	// IsLightClient is true if we're a light client, which only requests and imports block headers
+	IsLightClient bool
 }
 
 // NewService returns a new *sync.Service
@@ -94,48 +120,64 @@ func NewService(cfg *Config) (*Service, error) {
 	logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
 
 	return &Service{
-		logger:           logger,
-		blockState:       cfg.BlockState,
-		blockProducer:    cfg.BlockProducer,
-		synced:           true,
-		requestStart:     1,
-		highestSeenBlock: big.NewInt(0),
-		transactionQueue: cfg.TransactionQueue,
-		runtime:          cfg.Runtime,
-		verifier:         cfg.Verifier,
-		digestHandler:    cfg.DigestHandler,
-		benchmarker:      newBenchmarker(logger),
+		logger:                logger,
+		blockState:            cfg.BlockState,
+		storageState:          cfg.StorageState,
+		blockProducer:         cfg.BlockProducer,
+		synced:                true,
+		highestSeenBlock:      big.NewInt(0),
+		downloader:            newDownloader(),
+		transactionQueue:      cfg.TransactionQueue,
+		runtime:               cfg.Runtime,
+		execStorage:           runtime.NewTrieStorage(trie.NewEmptyTrie()),
+		verifier:              cfg.Verifier,
+		justificationVerifier: cfg.JustificationVerifier,
+		digestHandler:         cfg.DigestHandler,
+		isLightClient:         cfg.IsLightClient,
+		benchmarker:           newBenchmarker(logger),
 	}, nil
 }
 
-// HandleSeenBlocks handles a block that is newly "seen" ie. a block that a peer claims to have through a StatusMessage
+// HandleSeenBlocks handles a block that is newly "seen" ie. a block that a peer claims to have through a StatusMessage.
+// The blocks up to it that we're missing are added to the range being downloaded, and the request for the first chunk
+// that needs to be requested is returned. The other chunks are requested through BlockRequests.
 func (s *Service) HandleSeenBlocks(blockNum *big.Int) *network.BlockRequestMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	if blockNum == nil || s.highestSeenBlock.Cmp(blockNum) != -1 {
 		return nil
 	}
 
 	// need to sync
 	if s.synced {
-		s.requestStart = s.highestSeenBlock.Add(s.highestSeenBlock, big.NewInt(1)).Int64()
 		s.synced = false
 
 		err := s.blockProducer.Pause()
 		if err != nil {
 			s.logger.Warn("failed to pause block production")
 		}
-	} else {
-		s.requestStart = s.highestSeenBlock.Int64()
+	}
+
+	bestNum, err := s.blockState.BestBlockNumber()
+	if err != nil {
+		s.logger.Error("failed to get best block number", "error", err)
+		bestNum = big.NewInt(0)
 	}
 
 	s.highestSeenBlock = blockNum
-	s.benchmarker.begin(uint64(s.requestStart))
-	return s.createBlockRequest()
+	s.benchmarker.begin(bestNum.Uint64() + 1)
+	s.downloader.extend(bestNum.Uint64()+1, blockNum.Uint64())
+	return s.nextRequest()
 }
 
 // HandleBlockAnnounce creates a block request message from the block
 // announce messages (block announce messages include the header but the full
 // block is required to execute `core_execute_block`).
 func (s *Service) HandleBlockAnnounce(msg *network.BlockAnnounceMessage) *network.BlockRequestMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.logger.Debug("received BlockAnnounceMessage")
 
 	// create header from message
@@ -170,6 +212,11 @@ func (s *Service) HandleBlockAnnounce(msg *network.BlockAnnounceMessage) *networ
 		)
 	}
 
+	// light clients don't have block bodies, so request the header if we haven't imported it yet
+	if s.isLightClient {
+		return s.handleHeaderAnnounce(header)
+	}
+
 	// check if block body is stored in block state (ie. if we have the full block already)
 	_, err = s.blockState.GetBlockBody(header.Hash())
 	if err != nil && err.Error() == "Key not found" {
@@ -179,8 +226,7 @@ func (s *Service) HandleBlockAnnounce(msg *network.BlockAnnounceMessage) *networ
 		)
 
 		// create block request to send
-		s.requestStart = header.Number.Int64()
-		return s.createBlockRequest()
+		return s.createBlockRequest(header.Number.Int64())
 	} else if err != nil {
 		s.logger.Error("failed to handle BlockAnnounce", "error", err)
 	}
@@ -188,9 +234,34 @@ func (s *Service) HandleBlockAnnounce(msg *network.BlockAnnounceMessage) *networ
 	return nil
 }
 
+// handleHeaderAnnounce creates a block request for an announced header that's higher than our best block
+func (s *Service) handleHeaderAnnounce(header *types.Header) *network.BlockRequestMessage {
+	bestNum, err := s.blockState.BestBlockNumber()
+	if err != nil {
+		s.logger.Error("failed to get best block number", "error", err)
+		return nil
+	}
+
+	if header.Number.Cmp(bestNum) <= 0 {
+		return nil
+	}
+
+	s.logger.Debug("requesting announced header", "number", header.Number)
+	return s.createBlockRequest(header.Number.Int64())
+}
+
 // HandleBlockResponse handles a BlockResponseMessage by processing the blocks found in it and adding them to the BlockState if necessary.
+// Responses for chunks of the missing range of blocks are buffered until the chunks before them are imported.
 // If the node is still not synced after processing, it creates and returns the next BlockRequestMessage to send.
-func (s *Service) HandleBlockResponse(msg *network.BlockResponseMessage) *network.BlockRequestMessage {
+// If the response contains blocks that cannot be decoded or verified, it returns an error wrapping ErrInvalidBlockResponse.
+func (s *Service) HandleBlockResponse(msg *network.BlockResponseMessage) (*network.BlockRequestMessage, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if c := s.downloader.chunk(msg.ID); c != nil {
+		return s.handleChunkResponse(c, msg)
+	}
+
 	// highestInResp will be the highest block in the response
 	// it's set to 0 if err != nil
 	highestInResp, err := s.processBlockResponseData(msg)
@@ -198,52 +269,54 @@ func (s *Service) HandleBlockResponse(msg *network.BlockResponseMessage) *networ
 	// if we cannot find the parent block in our blocktree, we are missing some blocks, and need to request
 	// blocks from farther back in the chain
 	if err == blocktree.ErrParentNotFound {
-		// set request start
-		s.requestStart = s.requestStart - maxResponseSize
-		if s.requestStart <= 0 {
-			s.requestStart = 1
+		start := responseStart(msg) - maxResponseSize
+		if start <= 0 {
+			start = 1
 		}
-		s.logger.Trace("Retrying block request", "start", s.requestStart)
-		return s.createBlockRequest()
+		s.logger.Trace("Retrying block request", "start", start)
+		return s.createBlockRequest(start), nil
+	} else if errors.Is(err, ErrInvalidBlockResponse) {
+		s.logger.Debug("received invalid block response", "error", err)
+		return nil, err
 	} else if err != nil {
 		s.logger.Error("failed to process block response", "error", err)
-		return nil
+		return nil, nil
 	}
 
 	// TODO: max retries before unlocking BlockProducer, in case no response is received
+	if s.checkSynced() {
+		return nil, nil
+	}
+
 	bestNum, err := s.blockState.BestBlockNumber()
 	if err != nil {
 		s.logger.Error("failed to get best block number", "error", err)
-		bestNum = big.NewInt(0)
+		bestNum = big.NewInt(highestInResp)
 	}
 
-	// check if we are synced or not
-	if bestNum.Cmp(s.highestSeenBlock) >= 0 && bestNum.Cmp(big.NewInt(0)) != 0 {
-		s.logger.Debug("all synced up!", "number", bestNum)
-		s.benchmarker.end(uint64(bestNum.Int64()))
+	// not yet synced, download the following blocks
+	s.downloader.extend(bestNum.Uint64()+1, s.highestSeenBlock.Uint64())
+	return s.nextRequest(), nil
+}
 
-		if !s.synced {
-			err = s.blockProducer.Resume()
-			if err != nil {
-				s.logger.Warn("failed to resume block production")
-			}
-			s.synced = true
+// responseStart returns the number of the first block in the response, or 1 if it doesn't contain any headers
+func responseStart(msg *network.BlockResponseMessage) int64 {
+	for _, bd := range msg.BlockData {
+		if bd.Header.Exists() {
+			return bd.Header.Value().Number.Int64()
 		}
-		return nil
 	}
 
-	// not yet synced, send another block request for the following blocks
-	s.requestStart = highestInResp + 1
-	return s.createBlockRequest()
+	return 1
 }
 
-func (s *Service) createBlockRequest() *network.BlockRequestMessage {
+func (s *Service) createBlockRequest(requestStart int64) *network.BlockRequestMessage {
 	// generate random ID
 	s1 := rand.NewSource(uint64(time.Now().UnixNano()))
 	seed := rand.New(s1).Uint64()
 	randomID := mrand.New(mrand.NewSource(int64(seed))).Uint64()
 
-	start, err := variadic.NewUint64OrHash(uint64(s.requestStart))
+	start, err := variadic.NewUint64OrHash(uint64(requestStart))
 	if err != nil {
 		s.logger.Error("failed to create block request start block", "error", err)
 		return nil
@@ -251,9 +324,14 @@ func (s *Service) createBlockRequest() *network.BlockRequestMessage {
 
 	s.logger.Trace("sending block request", "start", start)
 
+	requestedData := byte(19) // block header + body + justification
+	if s.isLightClient {
+		requestedData = 17 // block header + justification
+	}
+
 	blockRequest := &network.BlockRequestMessage{
 		ID:            randomID, // random
-		RequestedData: 3,        // block header + body
+		RequestedData: requestedData,
 		StartingBlock: start,
 		EndBlockHash:  optional.NewHash(false, common.Hash{}),
 		Direction:     1,
@@ -271,19 +349,26 @@ func (s *Service) processBlockResponseData(msg *network.BlockResponseMessage) (i
 		if bd.Header.Exists() {
 			header, err := types.NewHeaderFromOptional(bd.Header)
 			if err != nil {
-				return 0, err
+				return 0, invalidBlockResponse(err)
 			}
 
 			highestInResp, err = s.handleHeader(header)
 			if err != nil {
 				return 0, err
 			}
+
+			if s.isLightClient {
+				err = s.importHeader(header)
+				if err != nil {
+					return 0, err
+				}
+			}
 		}
 
 		if bd.Body.Exists {
 			body, err := types.NewBodyFromOptional(bd.Body)
 			if err != nil {
-				return 0, err
+				return 0, invalidBlockResponse(err)
 			}
 
 			err = s.handleBody(body)
@@ -295,12 +380,12 @@ func (s *Service) processBlockResponseData(msg *network.BlockResponseMessage) (i
 		if bd.Header.Exists() && bd.Body.Exists {
 			header, err := types.NewHeaderFromOptional(bd.Header)
 			if err != nil {
-				return 0, err
+				return 0, invalidBlockResponse(err)
 			}
 
 			body, err := types.NewBodyFromOptional(bd.Body)
 			if err != nil {
-				return 0, err
+				return 0, invalidBlockResponse(err)
 			}
 
 			block := &types.Block{
@@ -314,6 +399,15 @@ func (s *Service) processBlockResponseData(msg *network.BlockResponseMessage) (i
 			}
 		}
 
+		if bd.Justification != nil && bd.Justification.Exists() {
+			err := s.handleJustification(bd.Hash, bd.Justification.Value())
+			if err != nil {
+				// don't store justifications that we couldn't verify
+				s.logger.Warn("failed to handle justification", "hash", bd.Hash, "error", err)
+				bd.Justification = optional.NewBytes(false, nil)
+			}
+		}
+
 		err := s.blockState.CompareAndSetBlockData(bd)
 		if err != nil {
 			return highestInResp, err
@@ -323,6 +417,37 @@ func (s *Service) processBlockResponseData(msg *network.BlockResponseMessage) (i
 	return highestInResp, nil
 }
 
+// handleJustification verifies a justification included in a BlockResponse and, if it's valid,
+// marks the block as finalized
+func (s *Service) handleJustification(hash common.Hash, justification []byte) error {
+	if s.justificationVerifier == nil {
+		return ErrNilJustificationVerifier
+	}
+
+	err := s.justificationVerifier.VerifyBlockJustification(hash, justification)
+	if err != nil {
+		return err
+	}
+
+	header, err := s.blockState.GetHeader(hash)
+	if err != nil {
+		return err
+	}
+
+	finalized, err := s.blockState.GetFinalizedHeader(0)
+	if err != nil {
+		return err
+	}
+
+	// don't move the finalized head backwards
+	if header.Number.Cmp(finalized.Number) <= 0 {
+		return nil
+	}
+
+	s.logger.Debug("finalizing block from justification", "hash", hash, "number", header.Number)
+	return s.blockState.SetFinalizedHash(hash, 0)
+}
+
 // handleHeader handles headers included in BlockResponses
 func (s *Service) handleHeader(header *types.Header) (int64, error) {
 	highestInResp := int64(0)
@@ -344,11 +469,11 @@ func (s *Service) handleHeader(header *types.Header) (int64, error) {
 
 	ok, err := s.verifier.VerifyBlock(header)
 	if err != nil {
-		return 0, err
+		return 0, invalidBlockResponse(err)
 	}
 
 	if !ok {
-		return 0, ErrInvalidBlock
+		return 0, invalidBlockResponse(ErrInvalidBlock)
 	}
 
 	if header.Number.Int64() > highestInResp {
@@ -363,7 +488,7 @@ func (s *Service) handleBody(body *types.Body) error {
 	exts, err := body.AsExtrinsics()
 	if err != nil {
 		s.logger.Error("cannot parse body as extrinsics", "error", err)
-		return err
+		return invalidBlockResponse(err)
 	}
 
 	for _, ext := range exts {
@@ -373,15 +498,15 @@ func (s *Service) handleBody(body *types.Body) error {
 	return err
 }
 
-// handleHeader handles blocks (header+body) included in BlockResponses
+// handleBlock handles blocks (header+body) included in BlockResponses. blocks are executed on their parent's state,
+// which is stored under the block once it's been verified.
 func (s *Service) handleBlock(block *types.Block) error {
-	// TODO: needs to be fixed by #941
-	// _, err := s.executeBlock(block)
-	// if err != nil {
-	// 	return err
-	// }
+	state, err := s.importState(block)
+	if err != nil {
+		return err
+	}
 
-	err := s.blockState.AddBlock(block)
+	err = s.blockState.AddBlock(block)
 	if err != nil {
 		if err == blocktree.ErrParentNotFound && block.Header.Number.Cmp(big.NewInt(0)) != 0 {
 			return err
@@ -395,6 +520,11 @@ func (s *Service) handleBlock(block *types.Block) error {
 		s.logger.Debug("imported block", "header", block.Header, "body", block.Body)
 	}
 
+	// the state of our best block is the current state
+	if state != nil && s.blockState.BestBlockHash() == block.Header.Hash() {
+		s.storageState.SetTrie(state)
+	}
+
 	// TODO: if block is from the next epoch, increment epoch
 
 	// handle consensus digest for authority changes
@@ -408,15 +538,35 @@ func (s *Service) handleBlock(block *types.Block) error {
 	return nil
 }
 
+// importHeader adds a verified header to the blocktree, which is how light clients import blocks, since they don't
+// request block bodies
+func (s *Service) importHeader(header *types.Header) error {
+	err := s.blockState.AddBlockHeader(header)
+	if err != nil {
+		if err == blocktree.ErrParentNotFound && header.Number.Cmp(big.NewInt(0)) != 0 {
+			return err
+		} else if err == blocktree.ErrBlockExists || header.Number.Cmp(big.NewInt(0)) == 0 {
+			// this is fine
+		} else {
+			return err
+		}
+	} else {
+		s.logger.Info("imported header", "number", header.Number, "hash", header.Hash())
+	}
+
+	// handle consensus digest for authority changes
+	if s.digestHandler != nil {
+		return s.handleDigests(header)
+	}
+
+	return nil
+}
+
 // runs the block through runtime function Core_execute_block
 //  It doesn't seem to return data on success (although the spec say it should return
 //  a boolean value that indicate success.  will error if the call isn't successful
 func (s *Service) executeBlock(block *types.Block) ([]byte, error) {
-	// copy block since we're going to modify it
-	b := block.DeepCopy()
-
-	b.Header.Digest = [][]byte{}
-	bdEnc, err := b.Encode()
+	bdEnc, err := executionData(block)
 	if err != nil {
 		return nil, err
 	}
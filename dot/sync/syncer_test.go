@@ -19,6 +19,7 @@ package sync
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
@@ -96,7 +97,7 @@ func TestHandleSeenBlocks(t *testing.T) {
 	req := syncer.HandleSeenBlocks(number)
 	require.NotNil(t, req)
 	require.Equal(t, uint64(1), req.StartingBlock.Value().(uint64))
-	require.Equal(t, int64(1), syncer.requestStart)
+	require.Equal(t, uint32(maxResponseSize), req.Max.Value())
 	require.Equal(t, number, syncer.highestSeenBlock)
 }
 
@@ -126,7 +127,7 @@ func TestHandleSeenBlocks_GreaterThanHighestSeen_NotSynced(t *testing.T) {
 	req = syncer.HandleSeenBlocks(number)
 	require.NotNil(t, req)
 	require.Equal(t, number, syncer.highestSeenBlock)
-	require.Equal(t, req.StartingBlock.Value().(uint64), uint64(12))
+	require.Equal(t, uint64(13), req.StartingBlock.Value().(uint64))
 }
 
 func TestHandleSeenBlocks_GreaterThanHighestSeen_Synced(t *testing.T) {
@@ -167,7 +168,8 @@ func TestHandleBlockResponse(t *testing.T) {
 	resp, err := responder.CreateBlockResponse(req)
 	require.NoError(t, err)
 
-	req2 := syncer.HandleBlockResponse(resp)
+	req2, err := syncer.HandleBlockResponse(resp)
+	require.NoError(t, err)
 	require.NotNil(t, req2)
 
 	// msg should contain blocks 1 to 13 (maxResponseSize # of blocks)
@@ -175,7 +177,8 @@ func TestHandleBlockResponse(t *testing.T) {
 
 	resp2, err := responder.CreateBlockResponse(req)
 	require.NoError(t, err)
-	syncer.HandleBlockResponse(resp2)
+	_, err = syncer.HandleBlockResponse(resp2)
+	require.NoError(t, err)
 	// response should contain blocks 13 to 20, and we should be synced
 	require.True(t, syncer.synced)
 }
@@ -188,7 +191,6 @@ func TestHandleBlockResponse_MissingBlocks(t *testing.T) {
 	addTestBlocksToState(t, 16, responder.blockState)
 
 	startNum := 16
-	syncer.requestStart = int64(startNum)
 
 	start, err := variadic.NewUint64OrHash(startNum)
 	require.NoError(t, err)
@@ -203,11 +205,87 @@ func TestHandleBlockResponse_MissingBlocks(t *testing.T) {
 	require.NoError(t, err)
 	syncer.synced = false
 
-	req2 := syncer.HandleBlockResponse(resp)
+	req2, err := syncer.HandleBlockResponse(resp)
+	require.NoError(t, err)
 	require.NotNil(t, req2)
 	require.Equal(t, uint64(startNum-int(maxResponseSize)), req2.StartingBlock.Value().(uint64))
 }
 
+func TestHandleBlockResponse_InvalidBlock(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		Verifier: &mockInvalidVerifier{},
+	})
+	syncer.highestSeenBlock = big.NewInt(20)
+
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 16, responder.blockState)
+
+	start, err := variadic.NewUint64OrHash(1)
+	require.NoError(t, err)
+
+	req := &network.BlockRequestMessage{
+		ID:            1,
+		RequestedData: 3,
+		StartingBlock: start,
+	}
+
+	resp, err := responder.CreateBlockResponse(req)
+	require.NoError(t, err)
+
+	req2, err := syncer.HandleBlockResponse(resp)
+	require.True(t, errors.Is(err, ErrInvalidBlockResponse))
+	require.Nil(t, req2)
+}
+
+func TestHandleBlockResponse_LightClient(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		IsLightClient: true,
+	})
+	syncer.highestSeenBlock = big.NewInt(16)
+
+	responder := newTestSyncer(t, nil)
+	addTestBlocksToState(t, 16, responder.blockState)
+
+	req := syncer.createBlockRequest(1)
+	require.Equal(t, byte(17), req.RequestedData)
+
+	resp, err := responder.CreateBlockResponse(req)
+	require.NoError(t, err)
+
+	req2, err := syncer.HandleBlockResponse(resp)
+	require.NoError(t, err)
+	require.NotNil(t, req2)
+
+	// the headers are imported without their bodies
+	bestNum, err := syncer.blockState.BestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(maxResponseSize), bestNum)
+
+	_, err = syncer.blockState.GetBlockBody(syncer.blockState.BestBlockHash())
+	require.Error(t, err)
+}
+
+func TestHandleBlockAnnounce_LightClient(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		IsLightClient: true,
+	})
+
+	msg := &network.BlockAnnounceMessage{
+		ParentHash: common.Hash{0x1},
+		Number:     big.NewInt(3),
+		Digest:     [][]byte{},
+	}
+
+	req := syncer.HandleBlockAnnounce(msg)
+	require.NotNil(t, req)
+	require.Equal(t, uint64(3), req.StartingBlock.Value().(uint64))
+	require.Equal(t, byte(17), req.RequestedData)
+
+	// blocks that aren't higher than our best block aren't requested
+	msg.Number = big.NewInt(0)
+	require.Nil(t, syncer.HandleBlockAnnounce(msg))
+}
+
 func TestRemoveIncludedExtrinsics(t *testing.T) {
 	syncer := newTestSyncer(t, nil)
 
@@ -324,6 +402,56 @@ func TestHandleBlockResponse_BlockData(t *testing.T) {
 	require.Equal(t, int64(0), res)
 }
 
+func newTestJustificationBlockData(t *testing.T, syncer *Service) *types.BlockData {
+	addTestBlocksToState(t, 2, syncer.blockState)
+
+	return &types.BlockData{
+		Hash:          syncer.blockState.BestBlockHash(),
+		Header:        optional.NewHeader(false, nil),
+		Body:          optional.NewBody(false, nil),
+		Justification: optional.NewBytes(true, []byte{1, 2, 3}),
+	}
+}
+
+func TestHandleBlockResponse_Justification(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		JustificationVerifier: &mockJustificationVerifier{},
+	})
+
+	bd := newTestJustificationBlockData(t, syncer)
+	_, err := syncer.processBlockResponseData(&network.BlockResponseMessage{
+		BlockData: []*types.BlockData{bd},
+	})
+	require.NoError(t, err)
+
+	just, err := syncer.blockState.GetJustification(bd.Hash)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, just)
+
+	finalized, err := syncer.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, bd.Hash, finalized.Hash())
+}
+
+func TestHandleBlockResponse_InvalidJustification(t *testing.T) {
+	syncer := newTestSyncer(t, &Config{
+		JustificationVerifier: &mockJustificationVerifier{err: errors.New("invalid justification")},
+	})
+
+	bd := newTestJustificationBlockData(t, syncer)
+	_, err := syncer.processBlockResponseData(&network.BlockResponseMessage{
+		BlockData: []*types.BlockData{bd},
+	})
+	require.NoError(t, err)
+
+	_, err = syncer.blockState.GetJustification(bd.Hash)
+	require.Error(t, err)
+
+	finalized, err := syncer.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, testGenesisHeader.Hash(), finalized.Hash())
+}
+
 func newBlockBuilder(t *testing.T, cfg *babe.ServiceConfig) *babe.Service { //nolint
 	if cfg.Runtime == nil {
 		cfg.Runtime = runtime.NewTestRuntime(t, runtime.SUBSTRATE_TEST_RUNTIME)
@@ -18,6 +18,7 @@ package sync
 
 import (
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 )
 
 // mockVerifier implements the Verifier interface
@@ -28,6 +29,14 @@ func (v *mockVerifier) VerifyBlock(header *types.Header) (bool, error) {
 	return true, nil
 }
 
+// mockInvalidVerifier implements the Verifier interface, failing to verify every block
+type mockInvalidVerifier struct{}
+
+// VerifyBlock mocks failing to verify a block
+func (v *mockInvalidVerifier) VerifyBlock(header *types.Header) (bool, error) {
+	return false, nil
+}
+
 // mockBlockProducer implements the BlockProducer interface
 type mockBlockProducer struct {
 	auths []*types.BABEAuthorityData
@@ -48,3 +57,13 @@ func (bp *mockBlockProducer) Pause() error {
 func (bp *mockBlockProducer) Resume() error {
 	return nil
 }
+
+// mockJustificationVerifier implements the JustificationVerifier interface
+type mockJustificationVerifier struct {
+	err error
+}
+
+// VerifyBlockJustification mocks verifying a justification
+func (v *mockJustificationVerifier) VerifyBlockJustification(_ common.Hash, _ []byte) error {
+	return v.err
+}
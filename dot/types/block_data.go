@@ -17,6 +17,7 @@
 package types
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -160,7 +161,12 @@ func DecodeBlockDataArray(r io.Reader) ([]*BlockData, error) {
 	}
 
 	length := int(l.(int32))
-	bds := make([]*BlockData, length)
+	if length < 0 {
+		return nil, fmt.Errorf("invalid block data array length %d", length)
+	}
+
+	// the length isn't trusted, so the array grows as block data is decoded
+	bds := []*BlockData{}
 
 	for i := 0; i < length; i++ {
 		bd := new(BlockData)
@@ -169,7 +175,7 @@ func DecodeBlockDataArray(r io.Reader) ([]*BlockData, error) {
 			return bds, err
 		}
 
-		bds[i] = bd
+		bds = append(bds, bd)
 	}
 
 	return bds, err
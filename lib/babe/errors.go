@@ -29,3 +29,12 @@ var ErrNilBlockState = errors.New("cannot have nil BlockState")
 
 // ErrNotAuthorized is returned when the node is not authorized to produce a block
 var ErrNotAuthorized = errors.New("not authorized to produce block")
+
+// ErrNoPendingTransactions is returned when a block with no extrinsics would be built, but an empty block was not requested
+var ErrNoPendingTransactions = errors.New("no pending transactions to include in block")
+
+// ErrBlockProductionPaused is returned when a block is requested while block production is paused
+var ErrBlockProductionPaused = errors.New("block production is paused")
+
+// ErrBlockImportTimeout is returned when a sealed block is not imported in time
+var ErrBlockImportTimeout = errors.New("timed out waiting for block to be imported")
@@ -0,0 +1,347 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package babe
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+
+	log "github.com/ChainSafe/log15"
+)
+
+// importTimeout is how long SealBlock waits for a sealed block to be imported before giving up
+var importTimeout = time.Second * 10
+
+// ManualSealService is a block producer for development that builds blocks on demand instead of once per slot.
+// Blocks are built either immediately when a transaction enters the queue (instant seal) or when SealBlock
+// is called, for example by the dev RPC module. Blocks are built and sealed by an underlying BABE service
+// which always wins the slot lottery, so they are valid BABE blocks.
+type ManualSealService struct {
+	logger log.Logger
+	babe   *Service
+
+	blockState          BlockState
+	transactionQueue    TransactionQueue
+	transactionNotifier TransactionNotifier
+
+	// Instant seal configuration
+	instantSeal bool
+	finalize    bool // finalize instantly sealed blocks
+
+	// Sealing state; slot is the slot number of the last sealed block
+	sealLock sync.Mutex
+	slot     uint64
+
+	// Blocks we are waiting to be imported by the core service
+	waiting     map[common.Hash]chan struct{}
+	waitingLock sync.Mutex
+
+	// Notification channels
+	imported   chan *types.Block
+	importedID byte
+	pushed     chan common.Hash
+	pushedID   byte
+
+	// State variables
+	stop    chan struct{}
+	paused  atomic.Value
+	started atomic.Value
+}
+
+// ManualSealConfig represents a manual seal block producer configuration
+type ManualSealConfig struct {
+	LogLvl              log.Lvl
+	BlockState          BlockState
+	StorageState        StorageState
+	TransactionQueue    TransactionQueue
+	TransactionNotifier TransactionNotifier // required if InstantSeal is true
+	Keypair             *sr25519.Keypair
+	Runtime             *runtime.Runtime
+	AuthData            []*types.BABEAuthorityData
+	StartSlot           uint64 // slot of the first sealed block
+	InstantSeal         bool   // build a block whenever a transaction enters the queue
+	Finalize            bool   // finalize blocks built by instant seal
+}
+
+// NewManualSealService returns a new manual seal block producer
+func NewManualSealService(cfg *ManualSealConfig) (*ManualSealService, error) {
+	if cfg.TransactionQueue == nil {
+		return nil, errors.New("transactionQueue is nil")
+	}
+
+	if cfg.InstantSeal && cfg.TransactionNotifier == nil {
+		return nil, errors.New("cannot use instant seal without a transaction notifier")
+	}
+
+	babeCfg := &ServiceConfig{
+		LogLvl:           cfg.LogLvl,
+		BlockState:       cfg.BlockState,
+		StorageState:     cfg.StorageState,
+		TransactionQueue: cfg.TransactionQueue,
+		Keypair:          cfg.Keypair,
+		Runtime:          cfg.Runtime,
+		AuthData:         cfg.AuthData,
+		EpochThreshold:   MaxThreshold, // always authorized to produce a block
+		StartSlot:        cfg.StartSlot,
+	}
+
+	bs, err := NewService(babeCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.New("pkg", "babe", "mode", "manual")
+	h := log.StreamHandler(os.Stdout, log.TerminalFormat())
+	logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+
+	ms := &ManualSealService{
+		logger:              logger,
+		babe:                bs,
+		blockState:          cfg.BlockState,
+		transactionQueue:    cfg.TransactionQueue,
+		transactionNotifier: cfg.TransactionNotifier,
+		instantSeal:         cfg.InstantSeal,
+		finalize:            cfg.Finalize,
+		waiting:             make(map[common.Hash]chan struct{}),
+		stop:                make(chan struct{}),
+	}
+
+	// the first sealed block is in StartSlot
+	if cfg.StartSlot > 0 {
+		ms.slot = cfg.StartSlot - 1
+	}
+
+	ms.paused.Store(false)
+	ms.started.Store(false)
+
+	logger.Info("created manual seal service", "instant seal", cfg.InstantSeal, "finalize", cfg.Finalize)
+	return ms, nil
+}
+
+// Start starts the service. Blocks are only built once the service is started.
+func (m *ManualSealService) Start() error {
+	m.imported = make(chan *types.Block, 16)
+	id, err := m.blockState.RegisterImportedChannel(m.imported)
+	if err != nil {
+		return err
+	}
+	m.importedID = id
+
+	if m.instantSeal {
+		m.pushed = make(chan common.Hash, 16)
+		m.pushedID, err = m.transactionNotifier.RegisterPushedChannel(m.pushed)
+		if err != nil {
+			m.blockState.UnregisterImportedChannel(m.importedID)
+			return err
+		}
+
+		go m.handlePushed()
+	}
+
+	go m.handleImported()
+
+	m.babe.started.Store(true)
+	m.started.Store(true)
+	return nil
+}
+
+// Stop stops the service. If stop is called, it cannot be resumed.
+func (m *ManualSealService) Stop() error {
+	if !m.started.Load().(bool) {
+		return nil
+	}
+
+	m.started.Store(false)
+	m.blockState.UnregisterImportedChannel(m.importedID)
+	if m.instantSeal {
+		m.transactionNotifier.UnregisterPushedChannel(m.pushedID)
+	}
+
+	close(m.stop)
+	return m.babe.Stop()
+}
+
+// Pause pauses the service ie. no blocks are built until it is resumed
+func (m *ManualSealService) Pause() error {
+	m.paused.Store(true)
+	m.logger.Info("service paused")
+	return nil
+}
+
+// Resume resumes the service
+func (m *ManualSealService) Resume() error {
+	m.paused.Store(false)
+	m.logger.Info("service resumed")
+	return nil
+}
+
+// IsPaused returns true if the service is paused
+func (m *ManualSealService) IsPaused() bool {
+	return m.paused.Load().(bool)
+}
+
+// GetBlockChannel returns the channel where new blocks are passed
+func (m *ManualSealService) GetBlockChannel() <-chan types.Block {
+	return m.babe.GetBlockChannel()
+}
+
+// SetRuntime sets the service's runtime
+func (m *ManualSealService) SetRuntime(rt *runtime.Runtime) error {
+	return m.babe.SetRuntime(rt)
+}
+
+// Authorities returns the current BABE authorities
+func (m *ManualSealService) Authorities() []*types.BABEAuthorityData {
+	return m.babe.Authorities()
+}
+
+// SetAuthorities sets the current BABE authorities
+func (m *ManualSealService) SetAuthorities(a []*types.BABEAuthorityData) {
+	m.babe.SetAuthorities(a)
+}
+
+// SealBlock builds a block on top of the current best block and waits for it to be imported.
+// If createEmpty is false and there are no pending transactions, no block is built.
+// If finalize is true, the block is finalized once it has been imported.
+func (m *ManualSealService) SealBlock(createEmpty, finalize bool) (common.Hash, error) {
+	m.sealLock.Lock()
+	defer m.sealLock.Unlock()
+
+	if !m.started.Load().(bool) {
+		return common.Hash{}, errors.New("service has not been started")
+	}
+
+	if m.IsPaused() {
+		return common.Hash{}, ErrBlockProductionPaused
+	}
+
+	if !createEmpty && m.transactionQueue.Peek() == nil {
+		return common.Hash{}, ErrNoPendingTransactions
+	}
+
+	parentHeader, err := m.blockState.BestBlockHeader()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	slot := Slot{
+		start:    uint64(time.Now().Unix()),
+		duration: m.babe.config.SlotDuration,
+		number:   m.slot + 1,
+	}
+
+	proof, err := m.babe.runLottery(slot.number)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if proof == nil {
+		return common.Hash{}, ErrNotAuthorized
+	}
+
+	m.babe.slotToProof[slot.number] = proof
+	defer delete(m.babe.slotToProof, slot.number)
+
+	block, err := m.babe.buildBlock(parentHeader.DeepCopy(), slot)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	m.slot = slot.number
+	hash := block.Header.Hash()
+	m.logger.Info("[babe]", "sealed block", hash.String(), "number", block.Header.Number, "slot", slot.number)
+
+	imported := make(chan struct{})
+	m.waitingLock.Lock()
+	m.waiting[hash] = imported
+	m.waitingLock.Unlock()
+
+	defer func() {
+		m.waitingLock.Lock()
+		delete(m.waiting, hash)
+		m.waitingLock.Unlock()
+	}()
+
+	err = m.babe.safeSend(*block)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	select {
+	case <-imported:
+	case <-time.After(importTimeout):
+		return common.Hash{}, ErrBlockImportTimeout
+	}
+
+	if finalize {
+		err = m.blockState.SetFinalizedHash(hash, 0)
+		if err != nil {
+			return common.Hash{}, err
+		}
+
+		m.logger.Debug("finalized sealed block", "hash", hash)
+	}
+
+	return hash, nil
+}
+
+// handlePushed builds a block whenever a transaction is pushed to the queue
+func (m *ManualSealService) handlePushed() {
+	for {
+		select {
+		case hash := <-m.pushed:
+			m.logger.Trace("transaction pushed to queue", "hash", hash)
+
+			// transactions pushed while a previous block was being built may already be included
+			_, err := m.SealBlock(false, m.finalize)
+			if err != nil && err != ErrNoPendingTransactions && err != ErrBlockProductionPaused {
+				m.logger.Error("failed to seal block", "error", err)
+			}
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// handleImported signals blocks that SealBlock is waiting on once they are imported
+func (m *ManualSealService) handleImported() {
+	for {
+		select {
+		case block := <-m.imported:
+			if block == nil || block.Header == nil {
+				continue
+			}
+
+			m.waitingLock.Lock()
+			if ch, has := m.waiting[block.Header.Hash()]; has {
+				close(ch)
+				delete(m.waiting, block.Header.Hash())
+			}
+			m.waitingLock.Unlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
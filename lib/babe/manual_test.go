@@ -0,0 +1,167 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package babe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestManualSealService(t *testing.T, instantSeal bool) (*ManualSealService, *state.Service) {
+	tt := trie.NewEmptyTrie()
+	rt := runtime.NewTestRuntimeWithTrie(t, runtime.NODE_RUNTIME, tt, log.LvlCrit)
+
+	kp, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	dbSrv := state.NewService("", log.LvlInfo)
+	dbSrv.UseMemDB()
+
+	err = dbSrv.Initialize(new(genesis.Data), genesisHeader, tt)
+	require.NoError(t, err)
+
+	err = dbSrv.Start()
+	require.NoError(t, err)
+
+	cfg := &ManualSealConfig{
+		LogLvl:              log.LvlInfo,
+		BlockState:          dbSrv.Block,
+		StorageState:        dbSrv.Storage,
+		TransactionQueue:    dbSrv.TransactionQueue,
+		TransactionNotifier: dbSrv.TransactionQueue,
+		Keypair:             kp,
+		Runtime:             rt,
+		AuthData: []*types.BABEAuthorityData{
+			{ID: kp.Public().(*sr25519.PublicKey), Weight: 1},
+		},
+		StartSlot:   1,
+		InstantSeal: instantSeal,
+	}
+
+	ms, err := NewManualSealService(cfg)
+	require.NoError(t, err)
+	return ms, dbSrv
+}
+
+// importBlocks imports blocks sent by the service, as the core service would
+func importBlocks(t *testing.T, ms *ManualSealService, bs *state.BlockState) {
+	go func() {
+		for block := range ms.GetBlockChannel() {
+			block := block
+			err := bs.AddBlock(&block)
+			require.NoError(t, err)
+		}
+	}()
+}
+
+func TestNewManualSealService_NoNotifier(t *testing.T) {
+	_, err := NewManualSealService(&ManualSealConfig{
+		TransactionQueue: state.NewTransactionQueue(),
+		InstantSeal:      true,
+	})
+	require.Error(t, err)
+}
+
+func TestManualSeal_SealBlock(t *testing.T) {
+	ms, dbSrv := createTestManualSealService(t, false)
+	err := ms.Start()
+	require.NoError(t, err)
+	defer ms.Stop()
+
+	importBlocks(t, ms, dbSrv.Block)
+
+	hash, err := ms.SealBlock(true, false)
+	require.NoError(t, err)
+	require.Equal(t, hash, dbSrv.Block.BestBlockHash())
+
+	slot, err := dbSrv.Block.GetSlotForBlock(hash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), slot)
+
+	finalized, err := dbSrv.Block.GetFinalizedHash(0)
+	require.NoError(t, err)
+	require.Equal(t, genesisHeader.Hash(), finalized)
+
+	hash, err = ms.SealBlock(true, true)
+	require.NoError(t, err)
+	require.Equal(t, hash, dbSrv.Block.BestBlockHash())
+
+	slot, err = dbSrv.Block.GetSlotForBlock(hash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), slot)
+
+	finalized, err = dbSrv.Block.GetFinalizedHash(0)
+	require.NoError(t, err)
+	require.Equal(t, hash, finalized)
+}
+
+func TestManualSeal_SealBlock_NoPendingTransactions(t *testing.T) {
+	ms, _ := createTestManualSealService(t, false)
+	err := ms.Start()
+	require.NoError(t, err)
+	defer ms.Stop()
+
+	_, err = ms.SealBlock(false, false)
+	require.Equal(t, ErrNoPendingTransactions, err)
+}
+
+func TestManualSeal_SealBlock_Paused(t *testing.T) {
+	ms, _ := createTestManualSealService(t, false)
+	err := ms.Start()
+	require.NoError(t, err)
+	defer ms.Stop()
+
+	err = ms.Pause()
+	require.NoError(t, err)
+
+	_, err = ms.SealBlock(true, false)
+	require.Equal(t, ErrBlockProductionPaused, err)
+
+	err = ms.Resume()
+	require.NoError(t, err)
+	require.False(t, ms.IsPaused())
+}
+
+func TestManualSeal_SealBlock_ImportTimeout(t *testing.T) {
+	ms, _ := createTestManualSealService(t, false)
+	err := ms.Start()
+	require.NoError(t, err)
+	defer ms.Stop()
+
+	importTimeout = time.Millisecond * 100
+	defer func() {
+		importTimeout = time.Second * 10
+	}()
+
+	// receive the block, but never import it
+	go func() {
+		<-ms.GetBlockChannel()
+	}()
+
+	_, err = ms.SealBlock(true, false)
+	require.Equal(t, ErrBlockImportTimeout, err)
+}
@@ -43,6 +43,9 @@ type BlockState interface {
 	HighestBlockHash() common.Hash
 	HighestBlockNumber() *big.Int
 	GetFinalizedHeader(uint64) (*types.Header, error)
+	SetFinalizedHash(common.Hash, uint64) error
+	RegisterImportedChannel(ch chan<- *types.Block) (byte, error)
+	UnregisterImportedChannel(id byte)
 }
 
 // StorageState interface for storage state methods
@@ -57,3 +60,9 @@ type TransactionQueue interface {
 	Pop() *transaction.ValidTransaction
 	Peek() *transaction.ValidTransaction
 }
+
+// TransactionNotifier is the interface for registering channels that are notified of new transactions
+type TransactionNotifier interface {
+	RegisterPushedChannel(ch chan<- common.Hash) (byte, error)
+	UnregisterPushedChannel(id byte)
+}
@@ -29,4 +29,6 @@ var (
 	BlockTreeKey = []byte("block_tree")
 	// LatestFinalizedRoundKey is the key where the last finalized grandpa round is stored
 	LatestFinalizedRoundKey = []byte("latest_finalized_round")
+	// DBVersionKey is the db location of the version of the format the database is written in
+	DBVersionKey = []byte("db_version")
 )
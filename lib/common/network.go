@@ -28,6 +28,13 @@ type NetworkState struct {
 	PeerID string
 }
 
+// roles of nodes, which they announce to their peers (see Table D.2)
+const (
+	FullNodeRole    byte = 1
+	LightClientRole byte = 2
+	AuthorityRole   byte = 4
+)
+
 // PeerInfo is network information about peers needed for the rpc server
 type PeerInfo struct {
 	PeerID          string
@@ -35,4 +42,29 @@ type PeerInfo struct {
 	ProtocolVersion uint32
 	BestHash        Hash
 	BestNumber      uint64
+	FinalizedNumber uint64
+	Reputation      int32
+}
+
+// StoresFullBlocks returns true if the peer is a full node or an authority, which store full blocks and their state,
+// so they can answer our block and state requests
+func (p *PeerInfo) StoresFullBlocks() bool {
+	return p.Roles&(FullNodeRole|AuthorityRole) != 0
+}
+
+// TrafficStats is the traffic sent and received by the host, in total, over a protocol or with a peer
+type TrafficStats struct {
+	BytesIn     int64
+	BytesOut    int64
+	RateIn      float64 // bytes per second
+	RateOut     float64 // bytes per second
+	MessagesIn  uint64
+	MessagesOut uint64
+}
+
+// NetworkStats is network traffic information about host needed for the rpc server and the metrics endpoint
+type NetworkStats struct {
+	Total     TrafficStats
+	Protocols map[string]TrafficStats // keyed by protocol ID
+	Peers     map[string]TrafficStats // keyed by peer ID, only includes connected peers
 }
@@ -15,3 +15,23 @@
 // along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
 
 package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerInfo_StoresFullBlocks(t *testing.T) {
+	for roles, expected := range map[byte]bool{
+		0:                               false,
+		FullNodeRole:                    true,
+		LightClientRole:                 false,
+		AuthorityRole:                   true,
+		FullNodeRole | AuthorityRole:    true,
+		LightClientRole | AuthorityRole: true,
+	} {
+		p := &PeerInfo{Roles: roles}
+		require.Equal(t, expected, p.StoresFullBlocks(), "roles %d", roles)
+	}
+}
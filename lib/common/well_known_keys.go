@@ -1,5 +1,8 @@
 package common
 
+// CodeKey is the storage key of the runtime code
+var CodeKey = []byte(":code")
+
 // BalanceKey returns the storage trie key for the balance of the account with the given public key
 func BalanceKey(key [32]byte) ([]byte, error) {
 	accKey := append([]byte("balance:"), key[:]...)
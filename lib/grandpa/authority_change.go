@@ -0,0 +1,235 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// pauseInterval is how often a paused voter checks whether it has been resumed
+var pauseInterval = time.Millisecond * 100
+
+// authorityChange is a scheduled change to the voter set, which is enacted when its block is finalized
+type authorityChange struct {
+	voters  []*Voter
+	atBlock uint64
+}
+
+// voterSet is a voter set, along with the number of the block after which it finalizes blocks
+type voterSet struct {
+	state *State
+	start uint64
+}
+
+// voterSets is the history of the voter sets that finalized the chain, so that justifications are verified against
+// the set that was active at their block. voters record their own changes, while the nodes that verify finality
+// without voting are told about changes once they're enacted.
+type voterSets struct {
+	lock sync.RWMutex
+	sets []*voterSet // ordered by start block
+}
+
+func newVoterSets(voters []*Voter, setID uint64) *voterSets {
+	return &voterSets{
+		sets: []*voterSet{{state: NewState(voters, setID, 0)}},
+	}
+}
+
+// add adds the next voter set, which finalizes the blocks after the given block
+func (vs *voterSets) add(voters []*Voter, start uint64) {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	last := vs.sets[len(vs.sets)-1]
+	vs.sets = append(vs.sets, &voterSet{
+		state: NewState(voters, last.state.setID+1, 0),
+		start: start,
+	})
+}
+
+// reset forgets the history, and sets the given voter set as the only known one
+func (vs *voterSets) reset(voters []*Voter, setID uint64) {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	vs.sets = []*voterSet{{state: NewState(voters, setID, 0)}}
+}
+
+// current returns the latest voter set
+func (vs *voterSets) current() *State {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return vs.sets[len(vs.sets)-1].state
+}
+
+// at returns the voter set that finalizes the block with the given number. the first set we know of is returned for
+// the blocks before it, since we don't know which sets finalized them.
+func (vs *voterSets) at(number uint64) *State {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+
+	for i := len(vs.sets) - 1; i > 0; i-- {
+		if number > vs.sets[i].start {
+			return vs.sets[i].state
+		}
+	}
+
+	return vs.sets[0].state
+}
+
+// bySetID returns the voter set with the given set ID, or nil if we don't know of it
+func (vs *voterSets) bySetID(setID uint64) *State {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+
+	for _, set := range vs.sets {
+		if set.state.setID == setID {
+			return set.state
+		}
+	}
+
+	return nil
+}
+
+// forBlock returns the voter set that finalizes the block with the given hash and number. the number is taken from
+// a message, so if we have the block, it's checked against the block's number.
+func (vs *voterSets) forBlock(bs BlockState, hash common.Hash, number uint64) (*State, error) {
+	if bs != nil {
+		header, err := bs.GetHeader(hash)
+		if err == nil && header.Number.Uint64() != number {
+			return nil, ErrJustificationHashMismatch
+		}
+	}
+
+	return vs.at(number), nil
+}
+
+// ScheduleAuthorityChange schedules a change to the voter set, which is enacted when the block with the given number
+// is finalized. until then, we don't vote for blocks past it, since they must be finalized by the next set.
+func (s *Service) ScheduleAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	s.logger.Info("scheduled authority change", "setID", s.state.setID+1, "block", atBlock, "voters", Voters(NewVotersFromAuthorityData(ad)))
+
+	s.pendingChange = &authorityChange{
+		voters:  NewVotersFromAuthorityData(ad),
+		atBlock: atBlock.Uint64(),
+	}
+}
+
+// ForceAuthorityChange changes the voter set without waiting for a block to be finalized. it's used when finality
+// has stalled, so the current round is abandoned, and the next set starts voting from our latest finalized block.
+func (s *Service) ForceAuthorityChange(ad []*types.GrandpaAuthorityData) {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	s.logger.Info("forced authority change", "setID", s.state.setID+1, "voters", Voters(NewVotersFromAuthorityData(ad)))
+
+	s.nextAuthorities = NewVotersFromAuthorityData(ad)
+	s.pendingChange = nil
+	s.forced = true
+}
+
+// Pause stops us from voting until Resume is called. the current round is abandoned.
+func (s *Service) Pause() {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	s.logger.Info("pausing voting", "setID", s.state.setID, "round", s.state.round)
+	s.paused = true
+}
+
+// Resume resumes voting, starting with the next round in the current voter set
+func (s *Service) Resume() {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	s.logger.Info("resuming voting", "setID", s.state.setID, "round", s.state.round)
+	s.paused = false
+}
+
+// IsPaused returns true if voting is paused
+func (s *Service) IsPaused() bool {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+	return s.paused
+}
+
+// roundInterrupted returns true if the current round must be abandoned, because the voter set was changed by a forced
+// change or voting was paused
+func (s *Service) roundInterrupted() bool {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	forced := s.forced
+	s.forced = false
+	return forced || s.paused
+}
+
+// waitWhilePaused blocks until voting is resumed or the service is stopped
+func (s *Service) waitWhilePaused() {
+	for s.IsPaused() && !s.stopped {
+		time.Sleep(pauseInterval)
+	}
+}
+
+// enactScheduledChange schedules the pending change to the voter set for the next round, if the block that enacts
+// it has been finalized. the next set's first round starts from that block.
+func (s *Service) enactScheduledChange() {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	pc := s.pendingChange
+	if pc == nil || s.head.Number.Uint64() < pc.atBlock {
+		return
+	}
+
+	s.logger.Info("enacting scheduled authority change", "setID", s.state.setID+1, "block", s.head.Hash(), "number", s.head.Number)
+	s.nextAuthorities = pc.voters
+	s.pendingChange = nil
+}
+
+// limitVote returns the ancestor of the voted block that enacts the pending change to the voter set, if the vote is
+// for a later block. otherwise, it returns the vote.
+func (s *Service) limitVote(v *Vote) (*Vote, error) {
+	s.mapLock.Lock()
+	pc := s.pendingChange
+	s.mapLock.Unlock()
+
+	if pc == nil || v.number <= pc.atBlock {
+		return v, nil
+	}
+
+	header, err := s.blockState.GetHeader(v.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	for header.Number.Uint64() > pc.atBlock {
+		header, err = s.blockState.GetHeader(header.ParentHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewVoteFromHeader(header), nil
+}
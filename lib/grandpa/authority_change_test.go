@@ -0,0 +1,268 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAuthorityData returns authority data for the given keypairs
+func newTestAuthorityData(kps []*ed25519.Keypair) []*types.GrandpaAuthorityData {
+	ad := make([]*types.GrandpaAuthorityData, len(kps))
+	for i, kp := range kps {
+		ad[i] = &types.GrandpaAuthorityData{
+			Key: kp.Public().(*ed25519.PublicKey),
+			ID:  uint64(i),
+		}
+	}
+	return ad
+}
+
+// playTestRound has each of the voters pre-vote and pre-commit, given that they all see each other's votes,
+// and finalize the round
+func playTestRound(t *testing.T, gss []*Service) {
+	prevotes := make(map[ed25519.PublicKeyBytes]*Vote)
+	for _, gs := range gss {
+		pv, err := gs.determinePreVote()
+		require.NoError(t, err)
+		prevotes[gs.publicKeyBytes()] = pv
+	}
+
+	precommits := make(map[ed25519.PublicKeyBytes]*Vote)
+	for _, gs := range gss {
+		gs.prevotes = prevotes
+		pc, err := gs.determinePreCommit()
+		require.NoError(t, err)
+		precommits[gs.publicKeyBytes()] = pc
+	}
+
+	for _, gs := range gss {
+		gs.precommits = precommits
+		err := gs.finalize()
+		require.NoError(t, err)
+	}
+}
+
+func TestLimitVote(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 4)
+
+	// without a pending change, votes aren't limited
+	v, err := gs.limitVote(NewVoteFromHeader(headers[3]))
+	require.NoError(t, err)
+	require.Equal(t, NewVoteFromHeader(headers[3]), v)
+
+	gs.ScheduleAuthorityChange(newTestAuthorityData(kr.Keys[:3]), headers[1].Number)
+
+	v, err = gs.limitVote(NewVoteFromHeader(headers[3]))
+	require.NoError(t, err)
+	require.Equal(t, NewVoteFromHeader(headers[1]), v)
+
+	v, err = gs.limitVote(NewVoteFromHeader(headers[0]))
+	require.NoError(t, err)
+	require.Equal(t, NewVoteFromHeader(headers[0]), v)
+}
+
+func TestDeterminePreVote_PendingChange(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 4)
+	gs.ScheduleAuthorityChange(newTestAuthorityData(kr.Keys[:3]), headers[1].Number)
+
+	pv, err := gs.determinePreVote()
+	require.NoError(t, err)
+	require.Equal(t, headers[1].Hash(), pv.hash)
+}
+
+func TestGrandpa_ScheduledChange(t *testing.T) {
+	// all voters schedule the same change, and stop at its block, so that the next set finalizes the later blocks
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	next := kr.Keys[:4]
+	atBlock := big.NewInt(3)
+
+	gss := make([]*Service, len(kr.Keys))
+	for i := range gss {
+		gss[i], _, _, _ = setupGrandpa(t, kr.Keys[i])
+		state.AddBlocksToState(t, gss[i].blockState.(*state.BlockState), 6)
+		gss[i].ScheduleAuthorityChange(newTestAuthorityData(next), atBlock)
+		gss[i].state.round = 1
+	}
+
+	playTestRound(t, gss)
+
+	for _, gs := range gss {
+		require.Equal(t, atBlock, gs.head.Number)
+		require.Equal(t, gss[0].head.Hash(), gs.head.Hash())
+		require.Nil(t, gs.pendingChange)
+
+		// the next set starts from the first round after the change
+		gs.updateAuthorities()
+		require.Equal(t, uint64(1), gs.state.setID)
+		require.Equal(t, uint64(0), gs.state.round)
+		require.Equal(t, NewVotersFromAuthorityData(newTestAuthorityData(next)), gs.state.voters)
+	}
+
+	// the next set can finalize blocks past the change
+	for _, gs := range gss[:len(next)] {
+		gs.state.round = 1
+	}
+
+	playTestRound(t, gss[:len(next)])
+
+	for _, gs := range gss[:len(next)] {
+		require.Equal(t, int64(6), gs.head.Number.Int64())
+
+		enc, err := gs.blockState.GetJustification(gs.head.Hash())
+		require.NoError(t, err)
+		just, err := DecodeBlockJustification(enc)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), just.SetID)
+	}
+}
+
+func TestGrandpa_ForcedChange(t *testing.T) {
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	next := kr.Keys[:4]
+	gss := make([]*Service, len(kr.Keys))
+	for i := range gss {
+		gss[i], _, _, _ = setupGrandpa(t, kr.Keys[i])
+		state.AddBlocksToState(t, gss[i].blockState.(*state.BlockState), 4)
+		gss[i].state.round = 1
+		gss[i].preVotedBlock[0] = NewVoteFromHeader(gss[i].head)
+		gss[i].bestFinalCandidate[0] = NewVoteFromHeader(gss[i].head)
+	}
+
+	// finality has stalled, so the round is abandoned without finalizing anything
+	for _, gs := range gss {
+		gs.ForceAuthorityChange(newTestAuthorityData(next))
+
+		err = gs.attemptToFinalize()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), gs.head.Number.Int64())
+
+		gs.updateAuthorities()
+		require.Equal(t, uint64(1), gs.state.setID)
+		require.Equal(t, len(next), len(gs.state.voters))
+	}
+
+	// the next set starts voting from the last finalized block
+	for _, gs := range gss[:len(next)] {
+		gs.state.round = 1
+		gs.preVotedBlock[0] = NewVoteFromHeader(gs.head)
+		gs.bestFinalCandidate[0] = NewVoteFromHeader(gs.head)
+	}
+
+	playTestRound(t, gss[:len(next)])
+
+	for _, gs := range gss[:len(next)] {
+		require.Equal(t, int64(4), gs.head.Number.Int64())
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	gs.stopped = false
+
+	gs.Pause()
+	require.True(t, gs.IsPaused())
+
+	// the current round is abandoned
+	require.True(t, gs.roundInterrupted())
+
+	done := make(chan struct{})
+	go func() {
+		gs.waitWhilePaused()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("stopped waiting while paused")
+	case <-time.After(pauseInterval * 3):
+	}
+
+	gs.Resume()
+	require.False(t, gs.IsPaused())
+	require.False(t, gs.roundInterrupted())
+
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatal("did not stop waiting after resuming")
+	}
+}
+
+func TestVoterSets_At(t *testing.T) {
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	genesis := NewVotersFromAuthorityData(newTestAuthorityData(kr.Keys))
+	sets := newVoterSets(genesis, 0)
+	sets.add(genesis[:2], 5)
+	sets.add(genesis[:1], 8)
+	require.Equal(t, uint64(2), sets.current().setID)
+
+	// the change's block is finalized by the previous set
+	for number, setID := range map[uint64]uint64{0: 0, 1: 0, 5: 0, 6: 1, 8: 1, 9: 2, 100: 2} {
+		require.Equal(t, setID, sets.at(number).setID, "block %d", number)
+	}
+	require.Equal(t, 2, len(sets.at(6).voters))
+
+	sets.reset(genesis, 7)
+	require.Equal(t, uint64(7), sets.at(1).setID)
+	require.Equal(t, uint64(7), sets.current().setID)
+}
+
+func TestService_VerifyBlockJustification_PreviousSet(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	old := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0]))
+
+	// the next set votes on the blocks after our finalized head
+	gs.head = headers[0]
+	gs.UpdateAuthorities(newTestAuthorityData(kr.Keys[:4]))
+	gs.updateAuthorities()
+	require.Equal(t, uint64(1), gs.state.setID)
+
+	// justifications for blocks up to the change are verified against the previous set
+	err := verifyTestJustification(t, gs, old)
+	require.NoError(t, err)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	just.Precommits = just.Precommits[:4]
+	err = verifyTestJustification(t, gs, just)
+	require.NoError(t, err)
+
+	// the previous set can't finalize blocks after the change
+	gs.state.setID = 0
+	just = createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	err = verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrSetIDMismatch, err)
+}
@@ -0,0 +1,221 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+)
+
+// catchUpThreshold is the number of rounds another voter must be ahead of us before we request to catch up
+var catchUpThreshold uint64 = 2
+
+// requestCatchUp broadcasts a CatchUpRequest for the current round, if we haven't already done so this round
+func (s *Service) requestCatchUp() {
+	s.mapLock.Lock()
+	if s.catchUpRequested {
+		s.mapLock.Unlock()
+		return
+	}
+	s.catchUpRequested = true
+	req := &CatchUpRequest{
+		Round: s.state.round,
+		SetID: s.state.setID,
+	}
+	s.mapLock.Unlock()
+
+	s.logger.Debug("requesting catch-up", "round", req.Round, "setID", req.SetID)
+	s.broadcast(req)
+}
+
+// handleCatchUpRequest responds to a CatchUpRequest with the votes of the last completed round,
+// if that round is not behind the requester's round. the response is only sent to the peers that requested it;
+// see GossipValidator.SendFilter.
+func (s *Service) handleCatchUpRequest(req *CatchUpRequest) error {
+	s.mapLock.Lock()
+	setID := s.state.setID
+	resp := s.lastCompleted
+	s.mapLock.Unlock()
+
+	if req.SetID != setID {
+		return ErrSetIDMismatch
+	}
+
+	// we can't help the requester catch up
+	if resp == nil || resp.SetID != req.SetID || resp.Round < req.Round {
+		return nil
+	}
+
+	s.logger.Debug("responding to catch-up request", "round", req.Round, "response round", resp.Round)
+	s.broadcast(resp)
+	return nil
+}
+
+// handleCatchUpResponse verifies a CatchUpResponse and, if it's for a round we haven't completed yet,
+// saves it so that we fast-forward to that round at the end of the current one
+func (s *Service) handleCatchUpResponse(resp *CatchUpResponse) error {
+	round, setID := s.roundAndSetID()
+	if resp.SetID != setID {
+		return ErrSetIDMismatch
+	}
+
+	// we might receive responses from several peers, for rounds we've already completed
+	if resp.Round < round {
+		return nil
+	}
+
+	err := s.verifyCatchUpResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	if s.catchUp == nil || resp.Round > s.catchUp.Round {
+		s.logger.Debug("received valid catch-up response", "round", resp.Round, "hash", resp.Hash)
+		s.catchUp = resp
+	}
+
+	return nil
+}
+
+// verifyCatchUpResponse checks that the pre-votes and pre-commits in the response are signed by our voters,
+// that each subround has votes from at least 2/3 of the voters, and that the pre-commits are for the completed block
+// or its descendants
+func (s *Service) verifyCatchUpResponse(resp *CatchUpResponse) error {
+	has, err := s.blockState.HasHeader(resp.Hash)
+	if err != nil {
+		return err
+	}
+
+	if !has {
+		return ErrBlockDoesNotExist
+	}
+
+	err = s.verifyCatchUpVotes(resp.PreVoteJustification, prevote, resp.Round, resp.SetID, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.verifyCatchUpVotes(resp.PreCommitJustification, precommit, resp.Round, resp.SetID, NewVote(resp.Hash, resp.Number))
+}
+
+// verifyCatchUpVotes checks that the votes for a subround are signed by at least 2/3 of the voters.
+// if target is not nil, only votes for the target block or its descendants are counted.
+func (s *Service) verifyCatchUpVotes(justs []*Justification, stage subround, round, setID uint64, target *Vote) error {
+	voters := make(map[ed25519.PublicKeyBytes]struct{})
+
+	for _, j := range justs {
+		pk, err := ed25519.NewPublicKey(j.AuthorityID[:])
+		if err != nil {
+			return err
+		}
+
+		_, err = s.state.pubkeyToVoter(pk)
+		if err != nil {
+			return err
+		}
+
+		err = validateJustificationSignature(pk, j, stage, round, setID)
+		if err != nil {
+			return err
+		}
+
+		// we must have all the voted-for blocks, so there's no need for any ancestry headers
+		if target != nil && validateJustificationAncestry(s.blockState, nil, target, j.Vote) != nil {
+			continue
+		}
+
+		voters[pk.AsBytes()] = struct{}{}
+	}
+
+	if len(voters) == 0 || uint64(len(voters)) < s.state.threshold() {
+		return ErrMinVotesNotMet
+	}
+
+	return nil
+}
+
+// caughtUp returns true if we've received a valid catch-up response that we haven't applied yet
+func (s *Service) caughtUp() bool {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+	return s.catchUp != nil
+}
+
+// applyCatchUp fast-forwards our round state to the round in the pending catch-up response, if there is one.
+// the block completed in that round is finalized, and its justification is stored.
+func (s *Service) applyCatchUp() error {
+	s.mapLock.Lock()
+	resp := s.catchUp
+	s.catchUp = nil
+	round, setID := s.state.round, s.state.setID
+	s.mapLock.Unlock()
+
+	// the response may be outdated if the voter set changed or we completed the round ourselves
+	if resp == nil || resp.SetID != setID || resp.Round < round {
+		return nil
+	}
+
+	s.logger.Info("catching up", "from round", round, "to round", resp.Round, "hash", resp.Hash)
+
+	target := NewVote(resp.Hash, resp.Number)
+
+	s.mapLock.Lock()
+	s.state.round = resp.Round
+	s.preVotedBlock[resp.Round] = target
+	s.bestFinalCandidate[resp.Round] = target
+	s.estimates[resp.Round] = target
+	s.lastCompleted = resp
+	s.mapLock.Unlock()
+
+	// we've already finalized the block, or one of its descendants
+	if resp.Number <= s.head.Number.Uint64() {
+		return nil
+	}
+
+	header, err := s.blockState.GetHeader(resp.Hash)
+	if err != nil {
+		return err
+	}
+
+	just, err := newBlockJustification(s.blockState, resp.Round, resp.SetID, target, resp.PreCommitJustification)
+	if err != nil {
+		return err
+	}
+
+	enc, err := just.Encode()
+	if err != nil {
+		return err
+	}
+
+	err = s.blockState.SetJustification(resp.Hash, enc)
+	if err != nil {
+		return err
+	}
+
+	s.head = header
+
+	// set finalized head for round in db
+	err = s.blockState.SetFinalizedHash(resp.Hash, resp.Round)
+	if err != nil {
+		return err
+	}
+
+	// set latest finalized head in db
+	return s.blockState.SetFinalizedHash(resp.Hash, 0)
+}
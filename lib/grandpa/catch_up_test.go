@@ -0,0 +1,238 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signVotesForRound(t *testing.T, gs *Service, round uint64, vote *Vote, stage subround, keys []*ed25519.Keypair) []*Justification {
+	prev := gs.state.round
+	gs.state.round = round
+	defer func() {
+		gs.state.round = prev
+	}()
+
+	justs := []*Justification{}
+	for _, kp := range keys {
+		vm, err := gs.createVoteMessage(vote, stage, kp)
+		require.NoError(t, err)
+
+		justs = append(justs, &Justification{
+			Vote:        vote,
+			Signature:   vm.Message.Signature,
+			AuthorityID: vm.Message.AuthorityID,
+		})
+	}
+
+	return justs
+}
+
+func newTestCatchUpResponse(t *testing.T, gs *Service, kr *keystore.Ed25519Keyring, round uint64) *CatchUpResponse {
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 4)
+	target := NewVoteFromHeader(headers[2])
+	best := NewVoteFromHeader(headers[3])
+
+	return &CatchUpResponse{
+		SetID:                  gs.state.setID,
+		Round:                  round,
+		PreVoteJustification:   signVotesForRound(t, gs, round, best, prevote, kr.Keys),
+		PreCommitJustification: signVotesForRound(t, gs, round, target, precommit, kr.Keys),
+		Hash:                   target.hash,
+		Number:                 target.number,
+	}
+}
+
+func TestCatchUpMessages_Decode(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+
+	req := &CatchUpRequest{
+		Round: 77,
+		SetID: 1,
+	}
+
+	cm, err := req.ToConsensusMessage()
+	require.NoError(t, err)
+
+	msg, err := decodeMessage(cm)
+	require.NoError(t, err)
+	require.Equal(t, req, msg)
+
+	resp := newTestCatchUpResponse(t, gs, kr, 77)
+	cm, err = resp.ToConsensusMessage()
+	require.NoError(t, err)
+
+	msg, err = decodeMessage(cm)
+	require.NoError(t, err)
+	require.Equal(t, resp, msg)
+}
+
+func TestHandleCatchUpRequest(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.stopped = false
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	gs.lastCompleted = resp
+
+	err := gs.handleCatchUpRequest(&CatchUpRequest{Round: 3, SetID: 0})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-gs.out:
+		require.Equal(t, resp, msg)
+	case <-time.After(time.Second):
+		t.Fatal("did not receive CatchUpResponse")
+	}
+
+	// we can't help a voter that's ahead of us
+	err = gs.handleCatchUpRequest(&CatchUpRequest{Round: 6, SetID: 0})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-gs.out:
+		t.Fatalf("should not have responded: %v", msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	err = gs.handleCatchUpRequest(&CatchUpRequest{Round: 3, SetID: 1})
+	require.Equal(t, ErrSetIDMismatch, err)
+}
+
+func TestHandleCatchUpResponse(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.state.round = 1
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	err := gs.handleCatchUpResponse(resp)
+	require.NoError(t, err)
+	require.True(t, gs.caughtUp())
+
+	err = gs.applyCatchUp()
+	require.NoError(t, err)
+	require.False(t, gs.caughtUp())
+	require.Equal(t, uint64(5), gs.state.round)
+	require.Equal(t, resp.Hash, gs.head.Hash())
+	require.Equal(t, NewVote(resp.Hash, resp.Number), gs.bestFinalCandidate[5])
+
+	finalized, err := gs.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, resp.Hash, finalized.Hash())
+
+	just, err := gs.blockState.GetJustification(resp.Hash)
+	require.NoError(t, err)
+	err = gs.VerifyBlockJustification(resp.Hash, just)
+	require.NoError(t, err)
+}
+
+func TestHandleCatchUpResponse_OldRound(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.state.round = 6
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	err := gs.handleCatchUpResponse(resp)
+	require.NoError(t, err)
+	require.False(t, gs.caughtUp())
+}
+
+func TestHandleCatchUpResponse_MinVotesNotMet(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.state.round = 1
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	prevotes := resp.PreVoteJustification
+	resp.PreVoteJustification = prevotes[:2]
+	err := gs.handleCatchUpResponse(resp)
+	require.Equal(t, ErrMinVotesNotMet, err)
+
+	// pre-commits that aren't for the completed block or its descendants aren't counted
+	resp.PreVoteJustification = prevotes
+	resp.Hash = gs.blockState.BestBlockHash()
+	resp.Number = resp.Number + 1
+	err = gs.handleCatchUpResponse(resp)
+	require.Equal(t, ErrMinVotesNotMet, err)
+	require.False(t, gs.caughtUp())
+}
+
+func TestHandleCatchUpResponse_InvalidSignature(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.state.round = 1
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	resp.Round = 4
+	err := gs.handleCatchUpResponse(resp)
+	require.Equal(t, ErrInvalidSignature, err)
+	require.False(t, gs.caughtUp())
+}
+
+func TestValidateMessage_RequestsCatchUp(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	gs.stopped = false
+
+	gs.state.round = 1 + catchUpThreshold + 1
+	vm, err := gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, kr.Bob)
+	require.NoError(t, err)
+	gs.state.round = 1
+
+	_, err = gs.validateMessage(vm)
+	require.Equal(t, ErrRoundMismatch, err)
+
+	select {
+	case msg := <-gs.out:
+		require.Equal(t, &CatchUpRequest{Round: 1, SetID: 0}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("did not send CatchUpRequest")
+	}
+
+	// only request once per round
+	_, err = gs.validateMessage(vm)
+	require.Equal(t, ErrRoundMismatch, err)
+
+	select {
+	case msg := <-gs.out:
+		t.Fatalf("should not have requested catch-up again: %v", msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestValidateMessage_NonVoterDoesNotRequestCatchUp(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	gs.stopped = false
+
+	kp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	gs.state.round = 1 + catchUpThreshold + 1
+	vm, err := gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, kp)
+	require.NoError(t, err)
+	gs.state.round = 1
+
+	_, err = gs.validateMessage(vm)
+	require.Equal(t, ErrVoterNotFound, err)
+
+	select {
+	case msg := <-gs.out:
+		t.Fatalf("should not have requested catch-up for a non-voter's vote: %v", msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
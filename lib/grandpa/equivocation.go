@@ -0,0 +1,295 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+)
+
+// maxEquivocations is the number of equivocations whose evidence we keep in memory. the evidence of older ones is
+// dropped.
+var maxEquivocations = 1 << 10
+
+// maxPendingReports is the number of equivocations that can wait to be persisted and reported
+const maxPendingReports = 64
+
+// Equivocation is the evidence that a voter cast two different votes in the same subround of a round.
+// both votes are signed by the voter, so the evidence can be verified by anyone who knows the voter set.
+type Equivocation struct {
+	SetID  uint64
+	Round  uint64
+	Stage  subround
+	First  *Justification
+	Second *Justification
+}
+
+// Encode returns the SCALE encoded Equivocation
+func (e *Equivocation) Encode() ([]byte, error) {
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf[:8], e.SetID)
+	binary.LittleEndian.PutUint64(buf[8:], e.Round)
+	buf = append(buf, byte(e.Stage))
+
+	for _, j := range []*Justification{e.First, e.Second} {
+		enc, err := j.Encode()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, enc...)
+	}
+
+	return buf, nil
+}
+
+// Decode returns the SCALE decoded Equivocation
+func (e *Equivocation) Decode(r io.Reader) (*Equivocation, error) {
+	if e == nil {
+		e = new(Equivocation)
+	}
+
+	var err error
+	e.SetID, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Round, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Stage, err = new(subround).Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e.First, err = (&Justification{Vote: new(Vote)}).Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Second, err = (&Justification{Vote: new(Vote)}).Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// DecodeEquivocation decodes a SCALE encoded Equivocation
+func DecodeEquivocation(in []byte) (*Equivocation, error) {
+	return new(Equivocation).Decode(bytes.NewBuffer(in))
+}
+
+// Offender returns the public key of the voter that equivocated
+func (e *Equivocation) Offender() ed25519.PublicKeyBytes {
+	return e.First.AuthorityID
+}
+
+// Verify checks that both votes are from the same voter, are for different blocks, and are correctly signed
+func (e *Equivocation) Verify() error {
+	if e.First.AuthorityID != e.Second.AuthorityID || e.First.Vote.hash == e.Second.Vote.hash {
+		return ErrInvalidEquivocation
+	}
+
+	pk, err := ed25519.NewPublicKey(e.First.AuthorityID[:])
+	if err != nil {
+		return err
+	}
+
+	for _, j := range []*Justification{e.First, e.Second} {
+		err = validateJustificationSignature(pk, j, e.Stage, e.Round, e.SetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeProof returns the equivocation encoded as the runtime's EquivocationProof, which consists of the set ID
+// followed by the subround's equivocation: the round, the offender, and both votes with their signatures.
+// block numbers are encoded as u32, the block number type of substrate-based runtimes.
+func (e *Equivocation) encodeProof() []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, e.SetID)
+	buf = append(buf, byte(e.Stage))
+
+	round := make([]byte, 8)
+	binary.LittleEndian.PutUint64(round, e.Round)
+	buf = append(buf, round...)
+
+	offender := e.Offender()
+	buf = append(buf, offender[:]...)
+
+	for _, j := range []*Justification{e.First, e.Second} {
+		number := make([]byte, 4)
+		binary.LittleEndian.PutUint32(number, uint32(j.Vote.number))
+
+		buf = append(buf, j.Vote.hash[:]...)
+		buf = append(buf, number...)
+		buf = append(buf, j.Signature[:]...)
+	}
+
+	return buf
+}
+
+// Equivocations returns the evidence of all the equivocations we've observed
+func (s *Service) Equivocations() []*Equivocation {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	evs := make([]*Equivocation, len(s.equivocations))
+	copy(evs, s.equivocations)
+	return evs
+}
+
+// newEquivocation returns the evidence that the voter who cast the given vote already voted for a different block in
+// this subround, or nil if we don't have the voter's previous signed vote
+func (s *Service) newEquivocation(stage subround, second *Justification) *Equivocation {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	justs := s.pvJustifications
+	if stage == precommit {
+		justs = s.pcJustifications
+	}
+
+	for hash, js := range justs {
+		if hash == second.Vote.hash {
+			continue
+		}
+
+		for _, j := range js {
+			if j.AuthorityID == second.AuthorityID {
+				return &Equivocation{
+					SetID:  s.state.setID,
+					Round:  s.state.round,
+					Stage:  stage,
+					First:  j,
+					Second: second,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordEquivocation saves the evidence of an equivocation, and queues it to be persisted and, if the runtime supports
+// it, submitted to the runtime. each voter's equivocation is only recorded once per subround. it doesn't wait for the
+// evidence to be reported, so that vote validation isn't held up by the runtime.
+func (s *Service) recordEquivocation(ev *Equivocation) {
+	s.mapLock.Lock()
+	for _, prev := range s.equivocations {
+		if prev.SetID == ev.SetID && prev.Round == ev.Round && prev.Stage == ev.Stage && prev.Offender() == ev.Offender() {
+			s.mapLock.Unlock()
+			return
+		}
+	}
+
+	s.equivocations = append(s.equivocations, ev)
+	if len(s.equivocations) > maxEquivocations {
+		s.equivocations = s.equivocations[len(s.equivocations)-maxEquivocations:]
+	}
+	s.mapLock.Unlock()
+
+	s.logger.Warn("voter equivocated", "voter", ev.Offender(), "setID", ev.SetID, "round", ev.Round, "stage", ev.Stage,
+		"first", ev.First.Vote, "second", ev.Second.Vote)
+
+	select {
+	case s.reports <- ev:
+	default:
+		s.logger.Error("too many equivocations waiting to be reported, dropping evidence", "voter", ev.Offender())
+	}
+}
+
+// handleReports persists and reports the queued equivocations until the service is stopped
+func (s *Service) handleReports() {
+	for {
+		select {
+		case ev := <-s.reports:
+			err := s.reportEquivocation(ev)
+			if err != nil {
+				s.logger.Error("failed to report equivocation", "voter", ev.Offender(), "error", err)
+			}
+		case <-s.stopReports:
+			return
+		}
+	}
+}
+
+// reportEquivocation persists the evidence of an equivocation and, if the runtime supports it, submits it to the
+// runtime
+func (s *Service) reportEquivocation(ev *Equivocation) error {
+	if s.grandpaState != nil {
+		enc, err := ev.Encode()
+		if err != nil {
+			return err
+		}
+
+		err = s.grandpaState.AddEquivocation(enc)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.reporter == nil {
+		return nil
+	}
+
+	keyOwnershipProof, err := s.reporter.GrandpaGenerateKeyOwnershipProof(ev.SetID, ev.Offender())
+	if err != nil {
+		return err
+	}
+
+	return s.reporter.GrandpaSubmitReportEquivocation(ev.encodeProof(), keyOwnershipProof)
+}
+
+// loadEquivocations loads the evidence of the equivocations we observed before restarting
+func (s *Service) loadEquivocations() error {
+	if s.grandpaState == nil {
+		return nil
+	}
+
+	encs, err := s.grandpaState.GetEquivocations()
+	if err != nil {
+		return err
+	}
+
+	evs := make([]*Equivocation, len(encs))
+	for i, enc := range encs {
+		evs[i], err = DecodeEquivocation(enc)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(evs) > maxEquivocations {
+		evs = evs[len(evs)-maxEquivocations:]
+	}
+
+	s.mapLock.Lock()
+	s.equivocations = evs
+	s.mapLock.Unlock()
+	return nil
+}
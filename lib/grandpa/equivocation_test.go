@@ -0,0 +1,222 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockEquivocationReporter sends the equivocation proofs submitted to it on its channel
+type mockEquivocationReporter struct {
+	proofs chan []byte
+}
+
+func newMockEquivocationReporter() *mockEquivocationReporter {
+	return &mockEquivocationReporter{
+		proofs: make(chan []byte, 8),
+	}
+}
+
+// waitForProof returns the next proof submitted to the reporter
+func (r *mockEquivocationReporter) waitForProof(t *testing.T) []byte {
+	select {
+	case proof := <-r.proofs:
+		return proof
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for equivocation to be reported")
+		return nil
+	}
+}
+
+func (r *mockEquivocationReporter) GrandpaGenerateKeyOwnershipProof(setID uint64, authorityID ed25519.PublicKeyBytes) ([]byte, error) {
+	return authorityID[:], nil
+}
+
+func (r *mockEquivocationReporter) GrandpaSubmitReportEquivocation(equivocationProof, keyOwnershipProof []byte) error {
+	r.proofs <- equivocationProof
+	return nil
+}
+
+// addTestBranches adds blocks to the state until there are two branches, and returns the head of each
+func addTestBranches(t *testing.T, st *state.Service) (*types.Header, *types.Header) {
+	var branches []*types.Header
+	for {
+		_, branches = state.AddBlocksToState(t, st.Block, 8)
+		if len(branches) != 0 {
+			break
+		}
+	}
+
+	h, err := st.Block.BestBlockHeader()
+	require.NoError(t, err)
+	return h, branches[0]
+}
+
+// equivocate has Alice vote for both of the given blocks in the given subround
+func equivocate(t *testing.T, gs *Service, kr *keystore.Ed25519Keyring, first, second *types.Header, stage subround) error {
+	msg, err := gs.createVoteMessage(NewVoteFromHeader(first), stage, kr.Alice)
+	require.NoError(t, err)
+	_, err = gs.validateMessage(msg)
+	require.NoError(t, err)
+
+	msg, err = gs.createVoteMessage(NewVoteFromHeader(second), stage, kr.Alice)
+	require.NoError(t, err)
+	_, err = gs.validateMessage(msg)
+	return err
+}
+
+func TestEquivocation_EncodeDecode(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 2)
+
+	ev := &Equivocation{
+		SetID:  1,
+		Round:  77,
+		Stage:  precommit,
+		First:  signPrecommit(t, gs, NewVoteFromHeader(headers[0]), kr.Alice),
+		Second: signPrecommit(t, gs, NewVoteFromHeader(headers[1]), kr.Alice),
+	}
+
+	enc, err := ev.Encode()
+	require.NoError(t, err)
+	res, err := DecodeEquivocation(enc)
+	require.NoError(t, err)
+	require.Equal(t, ev, res)
+	require.Equal(t, kr.Alice.Public().(*ed25519.PublicKey).AsBytes(), res.Offender())
+}
+
+func TestEquivocation_Verify(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 2)
+
+	ev := &Equivocation{
+		SetID:  gs.state.setID,
+		Round:  gs.state.round,
+		Stage:  precommit,
+		First:  signPrecommit(t, gs, NewVoteFromHeader(headers[0]), kr.Alice),
+		Second: signPrecommit(t, gs, NewVoteFromHeader(headers[1]), kr.Alice),
+	}
+	require.NoError(t, ev.Verify())
+
+	// the votes must have been cast in the equivocation's round
+	ev.Round++
+	require.Equal(t, ErrInvalidSignature, ev.Verify())
+	ev.Round--
+
+	// the votes must be from the same voter
+	ev.Second = signPrecommit(t, gs, NewVoteFromHeader(headers[1]), kr.Bob)
+	require.Equal(t, ErrInvalidEquivocation, ev.Verify())
+
+	// the votes must be for different blocks
+	ev.Second = signPrecommit(t, gs, NewVoteFromHeader(headers[0]), kr.Alice)
+	require.Equal(t, ErrInvalidEquivocation, ev.Verify())
+}
+
+func TestValidateMessage_Equivocation_Reported(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	reporter := newMockEquivocationReporter()
+	gs := newTestServiceWithState(t, st, kr.Bob, withGrandpaState(st), withEquivocationReporter(reporter))
+	first, second := addTestBranches(t, st)
+
+	err = equivocate(t, gs, kr, first, second, prevote)
+	require.Equal(t, ErrEquivocation, err)
+
+	evs := gs.Equivocations()
+	require.Equal(t, 1, len(evs))
+	ev := evs[0]
+	require.Equal(t, prevote, ev.Stage)
+	require.Equal(t, first.Hash(), ev.First.Vote.hash)
+	require.Equal(t, second.Hash(), ev.Second.Vote.hash)
+	require.NoError(t, ev.Verify())
+
+	// the evidence is persisted and submitted to the runtime
+	require.Equal(t, ev.encodeProof(), reporter.waitForProof(t))
+	encs, err := st.Grandpa.GetEquivocations()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(encs))
+
+	// further votes from the voter in the same subround aren't reported again
+	msg, err := gs.createVoteMessage(NewVoteFromHeader(first), prevote, kr.Alice)
+	require.NoError(t, err)
+	_, err = gs.validateMessage(msg)
+	require.Equal(t, ErrEquivocation, err)
+	require.Equal(t, 1, len(gs.Equivocations()))
+
+	// an equivocation in the other subround is reported separately
+	err = equivocate(t, gs, kr, first, second, precommit)
+	require.Equal(t, ErrEquivocation, err)
+	require.Equal(t, 2, len(gs.Equivocations()))
+	require.Equal(t, gs.Equivocations()[1].encodeProof(), reporter.waitForProof(t))
+	require.Empty(t, reporter.proofs)
+}
+
+func TestLoadEquivocations(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	reporter := newMockEquivocationReporter()
+	gs := newTestServiceWithState(t, st, kr.Bob, withGrandpaState(st), withEquivocationReporter(reporter))
+	first, second := addTestBranches(t, st)
+
+	err = equivocate(t, gs, kr, first, second, precommit)
+	require.Equal(t, ErrEquivocation, err)
+	reporter.waitForProof(t)
+
+	// the evidence is still available after restarting
+	restarted := newTestServiceWithState(t, st, kr.Bob, withGrandpaState(st))
+	err = restarted.loadEquivocations()
+	require.NoError(t, err)
+	require.Equal(t, gs.Equivocations(), restarted.Equivocations())
+}
+
+func TestRecordEquivocation_Pruned(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 2)
+
+	prev := maxEquivocations
+	maxEquivocations = 1
+	defer func() {
+		maxEquivocations = prev
+	}()
+
+	for round := uint64(1); round <= 2; round++ {
+		gs.recordEquivocation(&Equivocation{
+			SetID:  gs.state.setID,
+			Round:  round,
+			Stage:  precommit,
+			First:  signPrecommit(t, gs, NewVoteFromHeader(headers[0]), kr.Alice),
+			Second: signPrecommit(t, gs, NewVoteFromHeader(headers[1]), kr.Alice),
+		})
+	}
+
+	// only the latest evidence is kept in memory
+	evs := gs.Equivocations()
+	require.Equal(t, 1, len(evs))
+	require.Equal(t, uint64(2), evs[0].Round)
+}
@@ -65,3 +65,30 @@ var ErrInvalidMessageType = errors.New("cannot decode invalid message type")
 
 // ErrNotFinalizationMessage is returned when calling GetFinalizedHash on a message that isn't a FinalizationMessage
 var ErrNotFinalizationMessage = errors.New("cannot get finalized hash from VoteMessage")
+
+// ErrInvalidLength is returned when the encoded length of a list received from a peer is invalid
+var ErrInvalidLength = errors.New("invalid encoded length")
+
+// ErrJustificationHashMismatch is returned when a justification is for a different block than the one it was received for
+var ErrJustificationHashMismatch = errors.New("justification is not for the given block")
+
+// ErrMinVotesNotMet is returned when a justification does not contain pre-commits from at least 2/3 of the voters
+var ErrMinVotesNotMet = errors.New("minimum number of votes not met in justification")
+
+// ErrNilObserver is returned when a commit message is received by a MessageHandler without a voter or observer to verify it
+var ErrNilObserver = errors.New("cannot verify commit message without a voter or observer")
+
+// ErrInvalidEquivocation is returned when equivocation evidence doesn't consist of two different votes signed by the same voter
+var ErrInvalidEquivocation = errors.New("invalid equivocation evidence")
+
+// ErrNotPrimary is returned when a primary proposal is received from a voter that isn't the round's primary
+var ErrNotPrimary = errors.New("proposal is not from the round's primary")
+
+// ErrBlockNotFinalized is returned when a finality proof is requested for a block that isn't on the finalized chain
+var ErrBlockNotFinalized = errors.New("block is not finalized")
+
+// ErrNoJustification is returned when a finality proof cannot be built since no justification is stored for the block or its finalized descendants
+var ErrNoJustification = errors.New("cannot find justification for block or its finalized descendants")
+
+// ErrInvalidFinalityProof is returned when the headers in a finality proof don't link the block to the justified block
+var ErrInvalidFinalityProof = errors.New("finality proof headers do not link block to justified block")
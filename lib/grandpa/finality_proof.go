@@ -0,0 +1,230 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// maxFinalityProofHeaders is the maximum number of headers in the finality proofs we build. it bounds the number of
+// blocks we look up to answer a peer's request, and the size of our response.
+var maxFinalityProofHeaders = 512
+
+// FinalityProof is the proof that a block is final. since not every finalized block has a justification, it contains
+// the justification of the first finalized descendant of the block that has one (or of the block itself), as well as
+// the headers linking the block to that descendant.
+// https://github.com/paritytech/substrate/blob/master/client/finality-grandpa/src/finality_proof.rs
+type FinalityProof struct {
+	Block         common.Hash     // hash of the block the justification is for
+	Justification []byte          // SCALE encoded BlockJustification
+	Headers       []*types.Header // headers from the child of the proven block up to and including the justified block
+}
+
+// Encode returns the SCALE encoded FinalityProof
+func (p *FinalityProof) Encode() ([]byte, error) {
+	buf := p.Block.ToBytes()
+
+	enc, err := scale.Encode(p.Justification)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, enc...)
+
+	enc, err = scale.Encode(big.NewInt(int64(len(p.Headers))))
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, enc...)
+
+	for _, h := range p.Headers {
+		enc, err = h.Encode()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, enc...)
+	}
+
+	return buf, nil
+}
+
+// Decode returns the SCALE decoded FinalityProof
+func (p *FinalityProof) Decode(r io.Reader) (*FinalityProof, error) {
+	if p == nil {
+		p = new(FinalityProof)
+	}
+
+	var err error
+	p.Block, err = common.ReadHash(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sd := &scale.Decoder{Reader: r}
+	p.Justification, err = sd.DecodeByteArray()
+	if err != nil {
+		return nil, err
+	}
+
+	p.Headers, err = decodeHeaders(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DecodeFinalityProof decodes a SCALE encoded FinalityProof
+func DecodeFinalityProof(in []byte) (*FinalityProof, error) {
+	return new(FinalityProof).Decode(bytes.NewBuffer(in))
+}
+
+// FinalityProofProvider builds finality proofs from the justifications stored in the block state
+type FinalityProofProvider struct {
+	blockState BlockState
+}
+
+// NewFinalityProofProvider returns a new FinalityProofProvider
+func NewFinalityProofProvider(blockState BlockState) (*FinalityProofProvider, error) {
+	if blockState == nil {
+		return nil, ErrNilBlockState
+	}
+
+	return &FinalityProofProvider{
+		blockState: blockState,
+	}, nil
+}
+
+// ProveFinality returns the encoded FinalityProof for the block with the given hash. it returns ErrBlockNotFinalized
+// if the block isn't on the finalized chain, and ErrNoJustification if none of the block and its next
+// maxFinalityProofHeaders finalized descendants has a stored justification.
+func (p *FinalityProofProvider) ProveFinality(hash common.Hash) ([]byte, error) {
+	header, err := p.blockState.GetHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	finalized, err := p.blockState.GetFinalizedHeader(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Number.Cmp(finalized.Number) > 0 {
+		return nil, ErrBlockNotFinalized
+	}
+
+	proof := &FinalityProof{
+		Headers: []*types.Header{},
+	}
+
+	curr := header
+	for {
+		just, err := p.blockState.GetJustification(curr.Hash())
+		if err == nil && len(just) > 0 {
+			proof.Block = curr.Hash()
+			proof.Justification = just
+			return proof.Encode()
+		}
+
+		if curr.Number.Cmp(finalized.Number) >= 0 {
+			if curr.Hash() != finalized.Hash() {
+				return nil, ErrBlockNotFinalized
+			}
+			return nil, ErrNoJustification
+		}
+
+		if len(proof.Headers) >= maxFinalityProofHeaders {
+			return nil, ErrNoJustification
+		}
+
+		next, err := p.blockState.GetHeaderByNumber(big.NewInt(0).Add(curr.Number, big.NewInt(1)))
+		if err != nil {
+			return nil, err
+		}
+
+		// the block is only final if it's an ancestor of the latest finalized block
+		if next.ParentHash != curr.Hash() {
+			return nil, ErrBlockNotFinalized
+		}
+
+		proof.Headers = append(proof.Headers, next)
+		curr = next
+	}
+}
+
+// FinalityProofVerifier verifies finality proofs against the known voter sets. it doesn't require a block state, so it
+// can be used by light clients.
+type FinalityProofVerifier struct {
+	sets *voterSets
+}
+
+// NewFinalityProofVerifier returns a new FinalityProofVerifier for the given voter set
+func NewFinalityProofVerifier(voters []*Voter, setID uint64) *FinalityProofVerifier {
+	return &FinalityProofVerifier{
+		sets: newVoterSets(voters, setID),
+	}
+}
+
+// ApplyAuthorityChange changes the voter set to the given authorities, which finalize the blocks after the given block
+func (v *FinalityProofVerifier) ApplyAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	v.sets.add(NewVotersFromAuthorityData(ad), atBlock.Uint64())
+}
+
+// VerifyFinalityProof verifies that the given encoded FinalityProof proves that the block with the given header is
+// final. the header must be one the caller has verified, since the voter set is chosen by its number. it checks that
+// the headers in the proof link the block to the justified block, and that the justification is for the justified
+// block's number and is valid for the voter set at that number. it returns the decoded proof.
+func (v *FinalityProofVerifier) VerifyFinalityProof(header *types.Header, proof []byte) (*FinalityProof, error) {
+	fp, err := DecodeFinalityProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	curr, number := header.Hash(), header.Number.Uint64()
+	for _, h := range fp.Headers {
+		if h.ParentHash != curr || h.Number.Uint64() != number+1 {
+			return nil, ErrInvalidFinalityProof
+		}
+		curr, number = h.Hash(), number+1
+	}
+
+	if curr != fp.Block {
+		return nil, ErrInvalidFinalityProof
+	}
+
+	just, err := DecodeBlockJustification(fp.Justification)
+	if err != nil {
+		return nil, err
+	}
+
+	// the voter set is chosen by the number in the justification, so it must be the justified block's number
+	if just.Commit.number != number {
+		return nil, ErrInvalidFinalityProof
+	}
+
+	err = verifyJustification(nil, v.sets, fp.Block, just)
+	if err != nil {
+		return nil, err
+	}
+
+	return fp, nil
+}
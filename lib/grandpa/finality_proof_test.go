@@ -0,0 +1,261 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFinalityProofChain adds 5 blocks to the state, finalizes block 4 and stores a justification for block 3
+func newTestFinalityProofChain(t *testing.T) (*Service, []*types.Header, *FinalityProofProvider) {
+	gs, kr := newTestJustificationService(t)
+	bs := gs.blockState.(*state.BlockState)
+	headers, _ := state.AddBlocksToState(t, bs, 5)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[2]))
+	enc, err := just.Encode()
+	require.NoError(t, err)
+	err = bs.SetJustification(headers[2].Hash(), enc)
+	require.NoError(t, err)
+
+	err = bs.SetFinalizedHash(headers[3].Hash(), 0)
+	require.NoError(t, err)
+
+	p, err := NewFinalityProofProvider(bs)
+	require.NoError(t, err)
+	return gs, headers, p
+}
+
+func TestFinalityProof_EncodeDecode(t *testing.T) {
+	_, headers, _ := newTestFinalityProofChain(t)
+
+	proof := &FinalityProof{
+		Block:         headers[2].Hash(),
+		Justification: []byte{1, 2, 3},
+		Headers:       headers[1:3],
+	}
+
+	enc, err := proof.Encode()
+	require.NoError(t, err)
+
+	res, err := DecodeFinalityProof(enc)
+	require.NoError(t, err)
+	require.Equal(t, proof.Block, res.Block)
+	require.Equal(t, proof.Justification, res.Justification)
+	require.Equal(t, len(proof.Headers), len(res.Headers))
+
+	for i, h := range proof.Headers {
+		require.Equal(t, h.Hash(), res.Headers[i].Hash())
+	}
+}
+
+func TestDecodeFinalityProof_HugeLength(t *testing.T) {
+	_, headers, _ := newTestFinalityProofChain(t)
+
+	proof := &FinalityProof{
+		Block:         headers[2].Hash(),
+		Justification: []byte{1, 2, 3},
+		Headers:       []*types.Header{},
+	}
+
+	enc, err := proof.Encode()
+	require.NoError(t, err)
+
+	// the last byte is the length of the empty headers
+	prefix := enc[:len(enc)-1]
+	for _, length := range [][]byte{
+		{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f}, // 2^60-1
+		{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // overflows int64
+	} {
+		_, err = DecodeFinalityProof(append(append([]byte{}, prefix...), length...))
+		require.Error(t, err)
+	}
+
+	// truncated proofs can't be decoded
+	proof.Headers = headers[1:3]
+	enc, err = proof.Encode()
+	require.NoError(t, err)
+	for i := 0; i < len(enc); i++ {
+		_, err = DecodeFinalityProof(enc[:i])
+		require.Error(t, err)
+	}
+}
+
+func TestProveFinality(t *testing.T) {
+	gs, headers, p := newTestFinalityProofChain(t)
+
+	enc, err := p.ProveFinality(headers[0].Hash())
+	require.NoError(t, err)
+
+	proof, err := DecodeFinalityProof(enc)
+	require.NoError(t, err)
+	require.Equal(t, headers[2].Hash(), proof.Block)
+	require.Equal(t, 2, len(proof.Headers))
+	require.Equal(t, headers[1].Hash(), proof.Headers[0].Hash())
+	require.Equal(t, headers[2].Hash(), proof.Headers[1].Hash())
+
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID)
+	res, err := v.VerifyFinalityProof(headers[0], enc)
+	require.NoError(t, err)
+	require.Equal(t, headers[2].Hash(), res.Block)
+}
+
+func TestProveFinality_JustifiedBlock(t *testing.T) {
+	gs, headers, p := newTestFinalityProofChain(t)
+
+	enc, err := p.ProveFinality(headers[2].Hash())
+	require.NoError(t, err)
+
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID)
+	res, err := v.VerifyFinalityProof(headers[2], enc)
+	require.NoError(t, err)
+	require.Equal(t, headers[2].Hash(), res.Block)
+	require.Equal(t, 0, len(res.Headers))
+}
+
+func TestProveFinality_NotFinalized(t *testing.T) {
+	_, headers, p := newTestFinalityProofChain(t)
+
+	_, err := p.ProveFinality(headers[4].Hash())
+	require.Equal(t, ErrBlockNotFinalized, err)
+}
+
+func TestProveFinality_MaxHeaders(t *testing.T) {
+	_, headers, p := newTestFinalityProofChain(t)
+
+	prev := maxFinalityProofHeaders
+	maxFinalityProofHeaders = 1
+	defer func() {
+		maxFinalityProofHeaders = prev
+	}()
+
+	// the justified block is too far from the block
+	_, err := p.ProveFinality(headers[0].Hash())
+	require.Equal(t, ErrNoJustification, err)
+
+	enc, err := p.ProveFinality(headers[1].Hash())
+	require.NoError(t, err)
+	proof, err := DecodeFinalityProof(enc)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(proof.Headers))
+}
+
+func TestProveFinality_NoJustification(t *testing.T) {
+	_, headers, p := newTestFinalityProofChain(t)
+
+	_, err := p.ProveFinality(headers[3].Hash())
+	require.Equal(t, ErrNoJustification, err)
+}
+
+func TestVerifyFinalityProof_InvalidHeaders(t *testing.T) {
+	gs, headers, p := newTestFinalityProofChain(t)
+
+	enc, err := p.ProveFinality(headers[0].Hash())
+	require.NoError(t, err)
+
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID)
+	_, err = v.VerifyFinalityProof(headers[1], enc)
+	require.Equal(t, ErrInvalidFinalityProof, err)
+
+	proof, err := DecodeFinalityProof(enc)
+	require.NoError(t, err)
+	proof.Headers = proof.Headers[:1]
+	enc, err = proof.Encode()
+	require.NoError(t, err)
+
+	_, err = v.VerifyFinalityProof(headers[0], enc)
+	require.Equal(t, ErrInvalidFinalityProof, err)
+}
+
+func TestVerifyFinalityProof_WrongVoterSet(t *testing.T) {
+	gs, headers, p := newTestFinalityProofChain(t)
+
+	enc, err := p.ProveFinality(headers[0].Hash())
+	require.NoError(t, err)
+
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID+1)
+	_, err = v.VerifyFinalityProof(headers[0], enc)
+	require.Equal(t, ErrSetIDMismatch, err)
+
+	v = NewFinalityProofVerifier(gs.state.voters[:1], gs.state.setID)
+	_, err = v.VerifyFinalityProof(headers[0], enc)
+	require.Error(t, err)
+}
+
+func TestVerifyFinalityProof_WrongNumber(t *testing.T) {
+	gs, headers, p := newTestFinalityProofChain(t)
+
+	enc, err := p.ProveFinality(headers[2].Hash())
+	require.NoError(t, err)
+
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID)
+
+	// the proven block's number is checked against the headers linking it to the justified block
+	header := *headers[2]
+	header.Number = big.NewInt(7)
+	_, err = v.VerifyFinalityProof(&header, enc)
+	require.Equal(t, ErrInvalidFinalityProof, err)
+}
+
+func TestVerifyFinalityProof_RetiredVoterSet(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	kp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	// the voters of set 0 are retired after block 1
+	v := NewFinalityProofVerifier(gs.state.voters, gs.state.setID)
+	v.ApplyAuthorityChange([]*types.GrandpaAuthorityData{{Key: kp.Public().(*ed25519.PublicKey)}}, headers[0].Number)
+
+	// a justification from the retired set that claims an old number for a later block is rejected
+	just := createTestJustification(t, gs, kr, &Vote{hash: headers[2].Hash(), number: headers[0].Number.Uint64()})
+	encJust, err := just.Encode()
+	require.NoError(t, err)
+
+	enc, err := (&FinalityProof{
+		Block:         headers[2].Hash(),
+		Justification: encJust,
+		Headers:       []*types.Header{},
+	}).Encode()
+	require.NoError(t, err)
+
+	_, err = v.VerifyFinalityProof(headers[2], enc)
+	require.Equal(t, ErrInvalidFinalityProof, err)
+
+	// with the right number, the justification is checked against the next set
+	just = createTestJustification(t, gs, kr, NewVoteFromHeader(headers[2]))
+	encJust, err = just.Encode()
+	require.NoError(t, err)
+
+	enc, err = (&FinalityProof{
+		Block:         headers[2].Hash(),
+		Justification: encJust,
+		Headers:       []*types.Header{},
+	}).Encode()
+	require.NoError(t, err)
+
+	_, err = v.VerifyFinalityProof(headers[2], enc)
+	require.Equal(t, ErrSetIDMismatch, err)
+}
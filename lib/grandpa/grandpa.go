@@ -17,7 +17,6 @@
 package grandpa
 
 import (
-	"bytes"
 	"os"
 	"sync"
 	"time"
@@ -35,28 +34,49 @@ var interval = time.Second
 // Service represents the current state of the grandpa protocol
 type Service struct {
 	// preliminaries
-	logger     log.Logger
-	blockState BlockState
-	keypair    *ed25519.Keypair
-	mapLock    sync.Mutex
-	chanLock   sync.Mutex
-	stopped    bool
+	logger       log.Logger
+	blockState   BlockState
+	grandpaState GrandpaState         // if nil, the voter state isn't persisted
+	reporter     EquivocationReporter // if nil, equivocations aren't reported to the runtime
+	keypair      *ed25519.Keypair
+	mapLock      sync.Mutex
+	chanLock     sync.Mutex
+	stopped      bool
 
 	// current state information
 	state            *State                             // current state
+	sets             *voterSets                         // history of the voter sets, used to verify justifications
 	prevotes         map[ed25519.PublicKeyBytes]*Vote   // pre-votes for the current round
 	precommits       map[ed25519.PublicKeyBytes]*Vote   // pre-commits for the current round
+	pvJustifications map[common.Hash][]*Justification   // pre-vote justifications for the current round
 	pcJustifications map[common.Hash][]*Justification   // pre-commit justifications for the current round
 	pvEquivocations  map[ed25519.PublicKeyBytes][]*Vote // equivocatory votes for current pre-vote stage
 	pcEquivocations  map[ed25519.PublicKeyBytes][]*Vote // equivocatory votes for current pre-commit stage
 	tracker          *tracker                           // tracker of vote messages we may need in the future
+	validator        *GossipValidator                   // validator of gossiped messages, which tracks our and our peers' views
 	head             *types.Header                      // most recently finalized block
 	nextAuthorities  []*Voter                           // if not nil, the updated authorities for the next round
+	proposal         *Vote                              // the primary's proposal for the current round, if it made one
+	pendingChange    *authorityChange                   // if not nil, the scheduled change to the voter set
+	forced           bool                               // whether the voter set was changed by a forced change during the current round
+	paused           bool                               // whether voting is paused
 
 	// historical information
 	preVotedBlock      map[uint64]*Vote            // map of round number -> pre-voted block
 	bestFinalCandidate map[uint64]*Vote            // map of round number -> best final candidate
+	estimates          map[uint64]*Vote            // map of round number -> round estimate, proposed by the next round's primary
 	justification      map[uint64][]*Justification // map of round number -> round justification
+	lastCompleted      *CatchUpResponse            // votes that completed the last round, served to voters that are catching up
+	equivocations      []*Equivocation             // evidence of the latest maxEquivocations equivocations we've observed
+	reports            chan *Equivocation          // equivocations waiting to be persisted and reported, see handleReports
+	stopReports        chan struct{}
+
+	// catch-up information
+	catchUp          *CatchUpResponse // verified catch-up response to fast-forward to, if any
+	catchUpRequested bool             // whether we've sent a catch-up request in the current round
+
+	// voter state loaded on start, whose votes are restored when we resume its round
+	resumed *VoterState
 
 	// channels for communication with other services
 	in        chan FinalityMessage // only used to receive *VoteMessage
@@ -66,11 +86,13 @@ type Service struct {
 
 // Config represents a GRANDPA service configuration
 type Config struct {
-	LogLvl     log.Lvl
-	BlockState BlockState
-	Voters     []*Voter
-	SetID      uint64
-	Keypair    *ed25519.Keypair
+	LogLvl               log.Lvl
+	BlockState           BlockState
+	GrandpaState         GrandpaState
+	EquivocationReporter EquivocationReporter
+	Voters               []*Voter
+	SetID                uint64
+	Keypair              *ed25519.Keypair
 }
 
 // NewService returns a new GRANDPA Service instance.
@@ -101,28 +123,48 @@ func NewService(cfg *Config) (*Service, error) {
 	s := &Service{
 		logger:             logger,
 		state:              NewState(cfg.Voters, cfg.SetID, 0),
+		sets:               newVoterSets(cfg.Voters, cfg.SetID),
 		blockState:         cfg.BlockState,
+		grandpaState:       cfg.GrandpaState,
+		reporter:           cfg.EquivocationReporter,
 		keypair:            cfg.Keypair,
 		prevotes:           make(map[ed25519.PublicKeyBytes]*Vote),
 		precommits:         make(map[ed25519.PublicKeyBytes]*Vote),
+		pvJustifications:   make(map[common.Hash][]*Justification),
 		pcJustifications:   make(map[common.Hash][]*Justification),
 		pvEquivocations:    make(map[ed25519.PublicKeyBytes][]*Vote),
 		pcEquivocations:    make(map[ed25519.PublicKeyBytes][]*Vote),
 		preVotedBlock:      make(map[uint64]*Vote),
 		bestFinalCandidate: make(map[uint64]*Vote),
+		estimates:          make(map[uint64]*Vote),
 		justification:      make(map[uint64][]*Justification),
 		head:               head,
 		in:                 in,
 		out:                make(chan FinalityMessage, 128),
 		finalized:          make(chan FinalityMessage, 128),
 		stopped:            true,
+		reports:            make(chan *Equivocation, maxPendingReports),
+		stopReports:        make(chan struct{}),
 	}
 
+	s.validator = newGossipValidator(cfg.BlockState, s.sets, s)
+	go s.handleReports()
 	return s, nil
 }
 
 // Start begins the GRANDPA finality service
 func (s *Service) Start() error {
+	// resume from our voter state before restarting, if there is one
+	err := s.loadVoterState()
+	if err != nil {
+		return err
+	}
+
+	err = s.loadEquivocations()
+	if err != nil {
+		return err
+	}
+
 	s.stopped = false
 
 	go func() {
@@ -142,6 +184,7 @@ func (s *Service) Stop() error {
 
 	s.stopped = true
 	close(s.out)
+	close(s.stopReports)
 	s.tracker.stop()
 	return nil
 }
@@ -169,19 +212,35 @@ func (s *Service) UpdateAuthorities(ad []*types.GrandpaAuthorityData) {
 		}
 	}
 
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
 	s.nextAuthorities = v
 }
 
 // updateAuthorities updates the grandpa voter set, increments the setID, and resets the round numbers
 func (s *Service) updateAuthorities() {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
 	if s.nextAuthorities != nil {
 		s.state.voters = s.nextAuthorities
 		s.state.setID++
 		s.state.round = 0
 		s.nextAuthorities = nil
+
+		// the new set votes on the blocks after our latest finalized block
+		s.sets.add(s.state.voters, s.head.Number.Uint64())
 	}
 }
 
+// roundAndSetID returns the current round and set ID. they're read by the message handlers while the voting loop
+// updates them, so they're accessed under the map lock.
+func (s *Service) roundAndSetID() (round, setID uint64) {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+	return s.state.round, s.state.setID
+}
+
 func (s *Service) publicKeyBytes() ed25519.PublicKeyBytes {
 	return s.keypair.Public().(*ed25519.PublicKey).AsBytes()
 }
@@ -191,27 +250,56 @@ func (s *Service) initiate() error {
 	// if there is an authority change, execute it
 	s.updateAuthorities()
 
+	// if we've caught up with the rest of the network, fast-forward to the round we caught up to
+	err := s.applyCatchUp()
+	if err != nil {
+		return err
+	}
+
 	if s.state.round == 0 {
 		s.chanLock.Lock()
 		s.mapLock.Lock()
 		s.preVotedBlock[0] = NewVoteFromHeader(s.head)
 		s.bestFinalCandidate[0] = NewVoteFromHeader(s.head)
+		s.estimates[0] = NewVoteFromHeader(s.head)
 		s.mapLock.Unlock()
 		s.chanLock.Unlock()
 	}
 
+	s.mapLock.Lock()
 	s.state.round++
+	s.mapLock.Unlock()
+
+	// let our peers know which round we're in
+	s.sendNeighborMessage()
+
 	if s.tracker != nil {
 		s.tracker.stop()
 	}
 
-	var err error
+	s.mapLock.Lock()
 	s.prevotes = make(map[ed25519.PublicKeyBytes]*Vote)
 	s.precommits = make(map[ed25519.PublicKeyBytes]*Vote)
+	s.pvJustifications = make(map[common.Hash][]*Justification)
 	s.pcJustifications = make(map[common.Hash][]*Justification)
 	s.pvEquivocations = make(map[ed25519.PublicKeyBytes][]*Vote)
 	s.pcEquivocations = make(map[ed25519.PublicKeyBytes][]*Vote)
 	s.justification = make(map[uint64][]*Justification)
+	s.proposal = nil
+	s.catchUpRequested = false
+	s.mapLock.Unlock()
+
+	// if we were voting in this round before restarting, we must cast the same votes
+	err = s.restoreVotes()
+	if err != nil {
+		return err
+	}
+
+	err = s.saveVoterState()
+	if err != nil {
+		return err
+	}
+
 	s.tracker, err = newTracker(s.blockState, s.in)
 	if err != nil {
 		return err
@@ -235,6 +323,12 @@ func (s *Service) initiate() error {
 		}
 	}
 
+	// don't vote while voting is paused
+	s.waitWhilePaused()
+	if s.stopped {
+		return nil
+	}
+
 	for {
 		err := s.playGrandpaRound()
 		if err != nil {
@@ -260,13 +354,16 @@ func (s *Service) playGrandpaRound() error {
 	// save start time
 	start := time.Now()
 
-	// derive primary
-	primary := s.derivePrimary()
-
-	// if primary, broadcast the best final candidate from the previous round
-	if bytes.Equal(primary.key.Encode(), s.keypair.Public().Encode()) {
+	// if primary, broadcast the best final candidate from the previous round, and propose the previous round's
+	// estimate for this round
+	if s.isPrimary() {
 		msg := s.newFinalizationMessage(s.head, s.state.round-1)
 		s.finalized <- msg
+
+		err := s.sendPrimaryProposal()
+		if err != nil {
+			s.logger.Error("could not send primary proposal", "error", err)
+		}
 	}
 
 	s.logger.Debug("receiving pre-vote messages...")
@@ -288,16 +385,13 @@ func (s *Service) playGrandpaRound() error {
 
 	time.Sleep(interval * 2)
 
-	// broadcast pre-vote
-	pv, err := s.determinePreVote()
+	// broadcast pre-vote, unless we already pre-voted in this round before restarting
+	pv, err := s.castVote(prevote, s.determinePreVote)
 	if err != nil {
 		return err
 	}
 
-	s.mapLock.Lock()
-	s.prevotes[s.publicKeyBytes()] = pv
-	s.logger.Debug("sending pre-vote message...", "vote", pv, "prevotes", s.prevotes)
-	s.mapLock.Unlock()
+	s.logger.Debug("sending pre-vote message...", "vote", pv)
 
 	finalized := false
 
@@ -337,16 +431,13 @@ func (s *Service) playGrandpaRound() error {
 
 	time.Sleep(interval * 2)
 
-	// broadcast pre-commit
-	pc, err := s.determinePreCommit()
+	// broadcast pre-commit, unless we already pre-committed in this round before restarting
+	pc, err := s.castVote(precommit, s.determinePreCommit)
 	if err != nil {
 		return err
 	}
 
-	s.mapLock.Lock()
-	s.precommits[s.publicKeyBytes()] = pc
-	s.logger.Debug("sending pre-commit message...", "vote", pc, "precommits", s.precommits)
-	s.mapLock.Unlock()
+	s.logger.Debug("sending pre-commit message...", "vote", pc)
 
 	// continue to send precommit messages until round is done
 	go func(finalized *bool) {
@@ -406,8 +497,18 @@ func (s *Service) playGrandpaRound() error {
 	return nil
 }
 
-// attemptToFinalize loops until the round is finalizable
+// attemptToFinalize loops until the round is finalizable, or until we've caught up to a later round
 func (s *Service) attemptToFinalize() error {
+	if s.caughtUp() {
+		s.logger.Debug("caught up to later round, ending current round", "round", s.state.round)
+		return nil
+	}
+
+	if s.roundInterrupted() {
+		s.logger.Debug("voter set changed or voting paused, ending current round", "round", s.state.round)
+		return nil
+	}
+
 	bfc, err := s.getBestFinalCandidate()
 	if err != nil {
 		return err
@@ -439,27 +540,30 @@ func (s *Service) attemptToFinalize() error {
 
 // determinePreVote determines what block is our pre-voted block for the current round
 func (s *Service) determinePreVote() (*Vote, error) {
-	var vote *Vote
-
-	// if we receive a vote message from the primary with a block that's greater than or equal to the current pre-voted block
-	// and greater than the best final candidate from the last round, we choose that.
-	// otherwise, we simply choose the head of our chain.
+	// if the primary proposed a block that's a descendant of the estimate from the last round, we choose that,
+	// since the other voters will choose it too. otherwise, we simply choose the head of our chain.
 	s.mapLock.Lock()
-	prm := s.prevotes[s.derivePrimary().PublicKeyBytes()]
+	proposal := s.proposal
 	s.mapLock.Unlock()
 
-	if prm != nil && prm.number >= uint64(s.head.Number.Int64()) {
-		vote = prm
-	} else {
-		header, err := s.blockState.BestBlockHeader()
+	if proposal != nil {
+		isDescendant, err := s.blockState.IsDescendantOf(s.prevEstimate().hash, proposal.hash)
 		if err != nil {
 			return nil, err
 		}
 
-		vote = NewVoteFromHeader(header)
+		if isDescendant {
+			return s.limitVote(proposal)
+		}
+	}
+
+	header, err := s.blockState.BestBlockHeader()
+	if err != nil {
+		return nil, err
 	}
 
-	return vote, nil
+	// we don't vote past the block that enacts a pending change to the voter set
+	return s.limitVote(NewVoteFromHeader(header))
 }
 
 // determinePreCommit determines what block is our pre-committed block for the current round
@@ -473,6 +577,44 @@ func (s *Service) determinePreCommit() (*Vote, error) {
 	return &pvb, nil
 }
 
+// getEstimate returns the round's estimate, ie. the highest ancestor of the pre-voted block (or the block itself) that
+// could still get pre-commits from at least 2/3 of the voters, if the voters that haven't pre-committed yet
+// pre-committed for it. it's never lower than the best final candidate, which already has pre-commits from 2/3 of
+// the voters, but it may be higher, in which case it's proposed by the next round's primary.
+func (s *Service) getEstimate(pvb, bfc *Vote) (*Vote, error) {
+	s.mapLock.Lock()
+	missing := len(s.state.voters) - len(s.precommits) - len(s.pcEquivocations)
+	s.mapLock.Unlock()
+
+	if missing < 0 {
+		missing = 0
+	}
+
+	curr := pvb
+	for curr.number > bfc.number {
+		votes, err := s.getTotalVotesForBlock(curr.hash, precommit)
+		if err != nil {
+			return nil, err
+		}
+
+		if votes+uint64(missing) >= s.state.threshold() {
+			return curr, nil
+		}
+
+		header, err := s.blockState.GetHeader(curr.hash)
+		if err != nil {
+			return nil, err
+		}
+
+		curr, err = NewVoteFromHash(header.ParentHash, s.blockState)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bfc, nil
+}
+
 // isFinalizable returns true is the round is finalizable, false otherwise.
 func (s *Service) isFinalizable(round uint64) (bool, error) {
 	var pvb Vote
@@ -531,14 +673,28 @@ func (s *Service) finalize() error {
 	if err != nil {
 		return err
 	}
+
+	est, err := s.getEstimate(&pv, bfc)
+	if err != nil {
+		return err
+	}
+
 	s.mapLock.Lock()
 	s.preVotedBlock[s.state.round] = &pv
 
 	// set best final candidate
 	s.bestFinalCandidate[s.state.round] = bfc
-
-	// set justification
-	s.justification[s.state.round] = s.pcJustifications[bfc.hash]
+	s.estimates[s.state.round] = est
+
+	// save the votes that completed the round
+	s.lastCompleted = &CatchUpResponse{
+		SetID:                  s.state.setID,
+		Round:                  s.state.round,
+		PreVoteJustification:   flattenJustifications(s.pvJustifications),
+		PreCommitJustification: flattenJustifications(s.pcJustifications),
+		Hash:                   bfc.hash,
+		Number:                 bfc.number,
+	}
 	s.mapLock.Unlock()
 
 	s.head, err = s.blockState.GetHeader(bfc.hash)
@@ -546,6 +702,27 @@ func (s *Service) finalize() error {
 		return err
 	}
 
+	// store the justification for the finalized block, so that it can be served to syncing peers
+	just, err := s.createJustification(bfc)
+	if err != nil {
+		return err
+	}
+
+	// set the round's justification, which is included in our commit message for the round
+	s.mapLock.Lock()
+	s.justification[s.state.round] = just.Precommits
+	s.mapLock.Unlock()
+
+	enc, err := just.Encode()
+	if err != nil {
+		return err
+	}
+
+	err = s.blockState.SetJustification(bfc.hash, enc)
+	if err != nil {
+		return err
+	}
+
 	// set finalized head for round in db
 	err = s.blockState.SetFinalizedHash(bfc.hash, s.state.round)
 	if err != nil {
@@ -553,7 +730,41 @@ func (s *Service) finalize() error {
 	}
 
 	// set latest finalized head in db
-	return s.blockState.SetFinalizedHash(bfc.hash, 0)
+	err = s.blockState.SetFinalizedHash(bfc.hash, 0)
+	if err != nil {
+		return err
+	}
+
+	// if we finalized the block that enacts a scheduled change, the next round is voted on by the next set
+	s.enactScheduledChange()
+
+	// save the completed round, so that we don't replay it after restarting
+	return s.saveVoterState()
+}
+
+// castVote returns our vote for the given stage of the current round. if we haven't voted yet, the vote is determined
+// using the given function, and is recorded and persisted before being returned to be sent.
+func (s *Service) castVote(stage subround, determine func() (*Vote, error)) (*Vote, error) {
+	if v := s.ownVote(stage); v != nil {
+		return v, nil
+	}
+
+	v, err := determine()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordOwnVote(v, stage)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.saveVoterState()
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
 }
 
 // derivePrimary returns the primary for the current round
@@ -71,6 +71,38 @@ func newTestVoters(t *testing.T) []*Voter {
 	return voters
 }
 
+// newTestServiceWithState returns a service for the voters of newTestVoters that uses the block state of st and votes
+// with kp. the config can be changed with the given options before the service is created.
+func newTestServiceWithState(t *testing.T, st *state.Service, kp *ed25519.Keypair, opts ...func(*Config)) *Service {
+	cfg := &Config{
+		BlockState: st.Block,
+		Voters:     newTestVoters(t),
+		Keypair:    kp,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	gs, err := NewService(cfg)
+	require.NoError(t, err)
+	return gs
+}
+
+// withGrandpaState has the test service persist its state in the grandpa state of st
+func withGrandpaState(st *state.Service) func(*Config) {
+	return func(cfg *Config) {
+		cfg.GrandpaState = st.Grandpa
+	}
+}
+
+// withEquivocationReporter has the test service report equivocations with the given reporter
+func withEquivocationReporter(reporter EquivocationReporter) func(*Config) {
+	return func(cfg *Config) {
+		cfg.EquivocationReporter = reporter
+	}
+}
+
 func TestUpdateAuthorities(t *testing.T) {
 	st := newTestState(t)
 	voters := newTestVoters(t)
@@ -1145,65 +1177,6 @@ func TestDeterminePreVote_NoPrimaryPreVote(t *testing.T) {
 	require.Equal(t, header.Hash(), pv.hash)
 }
 
-func TestDeterminePreVote_WithPrimaryPreVote(t *testing.T) {
-	st := newTestState(t)
-	voters := newTestVoters(t)
-	kr, err := keystore.NewEd25519Keyring()
-	require.NoError(t, err)
-
-	cfg := &Config{
-		BlockState: st.Block,
-		Voters:     voters,
-		Keypair:    kr.Alice,
-	}
-
-	gs, err := NewService(cfg)
-	require.NoError(t, err)
-
-	state.AddBlocksToState(t, st.Block, 3)
-	header, err := st.Block.BestBlockHeader()
-	require.NoError(t, err)
-	state.AddBlocksToState(t, st.Block, 1)
-
-	primary := gs.derivePrimary().PublicKeyBytes()
-	gs.prevotes[primary] = NewVoteFromHeader(header)
-
-	pv, err := gs.determinePreVote()
-	require.NoError(t, err)
-	require.Equal(t, gs.prevotes[primary], pv)
-}
-
-func TestDeterminePreVote_WithInvalidPrimaryPreVote(t *testing.T) {
-	st := newTestState(t)
-	voters := newTestVoters(t)
-	kr, err := keystore.NewEd25519Keyring()
-	require.NoError(t, err)
-
-	cfg := &Config{
-		BlockState: st.Block,
-		Voters:     voters,
-		Keypair:    kr.Alice,
-	}
-
-	gs, err := NewService(cfg)
-	require.NoError(t, err)
-
-	state.AddBlocksToState(t, st.Block, 3)
-	header, err := st.Block.BestBlockHeader()
-	require.NoError(t, err)
-
-	primary := gs.derivePrimary().PublicKeyBytes()
-	gs.prevotes[primary] = NewVoteFromHeader(header)
-
-	state.AddBlocksToState(t, st.Block, 5)
-	gs.head, err = st.Block.BestBlockHeader()
-	require.NoError(t, err)
-
-	pv, err := gs.determinePreVote()
-	require.NoError(t, err)
-	require.Equal(t, gs.head.Hash(), pv.hash)
-}
-
 func TestIsFinalizable_True(t *testing.T) {
 	st := newTestState(t)
 	voters := newTestVoters(t)
@@ -0,0 +1,398 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// BlockJustification is the proof that a block was finalized. it contains the pre-commits for the block (or its
+// descendants) that were observed in the round it was finalized in, as well as the headers required to prove that
+// every pre-committed block is a descendant of the finalized block.
+// https://github.com/paritytech/substrate/blob/master/client/finality-grandpa/src/justification.rs
+type BlockJustification struct {
+	Round           uint64
+	SetID           uint64
+	Commit          *Vote
+	Precommits      []*Justification
+	VotesAncestries []*types.Header
+}
+
+// Encode returns the SCALE encoded BlockJustification
+func (j *BlockJustification) Encode() ([]byte, error) {
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf[:8], j.Round)
+	binary.LittleEndian.PutUint64(buf[8:], j.SetID)
+
+	enc, err := j.Commit.Encode()
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, enc...)
+
+	enc, err = scale.Encode(big.NewInt(int64(len(j.Precommits))))
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, enc...)
+
+	for _, pc := range j.Precommits {
+		enc, err = pc.Encode()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, enc...)
+	}
+
+	enc, err = scale.Encode(big.NewInt(int64(len(j.VotesAncestries))))
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, enc...)
+
+	for _, h := range j.VotesAncestries {
+		enc, err = h.Encode()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, enc...)
+	}
+
+	return buf, nil
+}
+
+// Decode returns the SCALE decoded BlockJustification
+func (j *BlockJustification) Decode(r io.Reader) (*BlockJustification, error) {
+	if j == nil {
+		j = new(BlockJustification)
+	}
+
+	var err error
+	j.Round, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	j.SetID, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	j.Commit, err = new(Vote).Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sd := &scale.Decoder{Reader: r}
+	num, err := sd.DecodeInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	if num < 0 {
+		return nil, ErrInvalidLength
+	}
+
+	j.Precommits = make([]*Justification, 0, scale.Preallocation(num))
+	for i := int64(0); i < num; i++ {
+		pc := &Justification{Vote: new(Vote)}
+		pc, err = pc.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		j.Precommits = append(j.Precommits, pc)
+	}
+
+	j.VotesAncestries, err = decodeHeaders(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// decodeHeaders decodes a SCALE encoded list of headers
+func decodeHeaders(r io.Reader) ([]*types.Header, error) {
+	sd := &scale.Decoder{Reader: r}
+	num, err := sd.DecodeInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	if num < 0 {
+		return nil, ErrInvalidLength
+	}
+
+	headers := make([]*types.Header, 0, scale.Preallocation(num))
+	for i := int64(0); i < num; i++ {
+		h := &types.Header{Number: big.NewInt(0)}
+		h, err = h.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+
+	return headers, nil
+}
+
+// DecodeBlockJustification decodes a SCALE encoded BlockJustification
+func DecodeBlockJustification(in []byte) (*BlockJustification, error) {
+	return new(BlockJustification).Decode(bytes.NewBuffer(in))
+}
+
+// createJustification returns the justification for the given finalized block in the current round.
+func (s *Service) createJustification(bfc *Vote) (*BlockJustification, error) {
+	s.mapLock.Lock()
+	pcs := flattenJustifications(s.pcJustifications)
+	s.mapLock.Unlock()
+
+	return newBlockJustification(s.blockState, s.state.round, s.state.setID, bfc, pcs)
+}
+
+// newBlockJustification returns a justification for the target block. it includes all the given pre-commits that were
+// cast for the target block or one of its descendants, as well as the headers linking those descendants to the target.
+func newBlockJustification(bs BlockState, round, setID uint64, target *Vote, precommits []*Justification) (*BlockJustification, error) {
+	just := &BlockJustification{
+		Round:           round,
+		SetID:           setID,
+		Commit:          target,
+		Precommits:      []*Justification{},
+		VotesAncestries: []*types.Header{},
+	}
+
+	ancestries := make(map[common.Hash]struct{})
+
+	for _, pc := range precommits {
+		if pc.Vote.hash != target.hash {
+			isDescendant, err := bs.IsDescendantOf(target.hash, pc.Vote.hash)
+			if err != nil || !isDescendant {
+				continue
+			}
+
+			// add the headers from the pre-committed block back to (but not including) the target block
+			curr := pc.Vote.hash
+			for curr != target.hash {
+				if _, has := ancestries[curr]; has {
+					break
+				}
+
+				h, err := bs.GetHeader(curr)
+				if err != nil {
+					return nil, err
+				}
+
+				ancestries[curr] = struct{}{}
+				just.VotesAncestries = append(just.VotesAncestries, h)
+				curr = h.ParentHash
+			}
+		}
+
+		just.Precommits = append(just.Precommits, pc)
+	}
+
+	return just, nil
+}
+
+// flattenJustifications returns all the justifications in a map of block hash -> justifications
+func flattenJustifications(m map[common.Hash][]*Justification) []*Justification {
+	justs := []*Justification{}
+	for _, js := range m {
+		justs = append(justs, js...)
+	}
+	return justs
+}
+
+// VerifyBlockJustification verifies that the given encoded justification finalizes the block with the given hash,
+// using the voter set that was active at the block
+func (s *Service) VerifyBlockJustification(hash common.Hash, justification []byte) error {
+	_, err := verifyBlockJustification(s.blockState, s.sets, hash, justification)
+	return err
+}
+
+// JustificationVerifier verifies block justifications for nodes that do not run a GRANDPA voter
+type JustificationVerifier struct {
+	blockState BlockState
+	sets       *voterSets
+}
+
+// NewJustificationVerifier returns a new JustificationVerifier for the given voter set
+func NewJustificationVerifier(blockState BlockState, voters []*Voter, setID uint64) (*JustificationVerifier, error) {
+	if blockState == nil {
+		return nil, ErrNilBlockState
+	}
+
+	return &JustificationVerifier{
+		blockState: blockState,
+		sets:       newVoterSets(voters, setID),
+	}, nil
+}
+
+// ApplyAuthorityChange changes the voter set to the given authorities, which finalize the blocks after the given block
+func (v *JustificationVerifier) ApplyAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	v.sets.add(NewVotersFromAuthorityData(ad), atBlock.Uint64())
+}
+
+// VerifyBlockJustification verifies that the given encoded justification finalizes the block with the given hash,
+// using the voter set that was active at the block
+func (v *JustificationVerifier) VerifyBlockJustification(hash common.Hash, justification []byte) error {
+	_, err := verifyBlockJustification(v.blockState, v.sets, hash, justification)
+	return err
+}
+
+// verifyBlockJustification decodes and verifies a justification. it checks that the justification is for the given
+// block and for the voter set that was active at the block, that every pre-commit is correctly signed by a voter and
+// is for a descendant of the finalized block, and that the pre-commits come from at least 2/3 of the voters.
+func verifyBlockJustification(bs BlockState, sets *voterSets, hash common.Hash, justification []byte) (*BlockJustification, error) {
+	just, err := DecodeBlockJustification(justification)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyJustification(bs, sets, hash, just)
+	if err != nil {
+		return nil, err
+	}
+
+	return just, nil
+}
+
+// verifyJustification verifies the decoded justification for the block with the given hash. see
+// verifyBlockJustification.
+func verifyJustification(bs BlockState, sets *voterSets, hash common.Hash, just *BlockJustification) error {
+	if just.Commit.hash != hash {
+		return ErrJustificationHashMismatch
+	}
+
+	st, err := sets.forBlock(bs, hash, just.Commit.number)
+	if err != nil {
+		return err
+	}
+
+	if just.SetID != st.setID {
+		return ErrSetIDMismatch
+	}
+
+	ancestries := make(map[common.Hash]*types.Header)
+	for _, h := range just.VotesAncestries {
+		ancestries[h.Hash()] = h
+	}
+
+	return verifyPrecommits(bs, st, just.Round, just.SetID, just.Commit, just.Precommits, ancestries)
+}
+
+// verifyPrecommits checks that every pre-commit is correctly signed by a voter and is for the target block or one of
+// its descendants, and that the pre-commits come from at least 2/3 of the voters.
+func verifyPrecommits(bs BlockState, st *State, round, setID uint64, target *Vote, precommits []*Justification, ancestries map[common.Hash]*types.Header) error {
+	voters := make(map[ed25519.PublicKeyBytes]struct{})
+
+	for _, pc := range precommits {
+		pk, err := ed25519.NewPublicKey(pc.AuthorityID[:])
+		if err != nil {
+			return err
+		}
+
+		_, err = st.pubkeyToVoter(pk)
+		if err != nil {
+			return err
+		}
+
+		err = validateJustificationSignature(pk, pc, precommit, round, setID)
+		if err != nil {
+			return err
+		}
+
+		err = validateJustificationAncestry(bs, ancestries, target, pc.Vote)
+		if err != nil {
+			return err
+		}
+
+		voters[pk.AsBytes()] = struct{}{}
+	}
+
+	if len(voters) == 0 || uint64(len(voters)) < st.threshold() {
+		return ErrMinVotesNotMet
+	}
+
+	return nil
+}
+
+func validateJustificationSignature(pk *ed25519.PublicKey, j *Justification, stage subround, round, setID uint64) error {
+	msg, err := scale.Encode(&FullVote{
+		Stage: stage,
+		Vote:  j.Vote,
+		Round: round,
+		SetID: setID,
+	})
+	if err != nil {
+		return err
+	}
+
+	ok, err := pk.Verify(msg, j.Signature[:])
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// validateJustificationAncestry checks that the pre-committed block is the finalized block or one of its descendants,
+// using the headers included in the justification and falling back to the block state
+func validateJustificationAncestry(bs BlockState, ancestries map[common.Hash]*types.Header, target, vote *Vote) error {
+	curr := vote.hash
+
+	for curr != target.hash {
+		h, has := ancestries[curr]
+		if !has {
+			// without a block state, e.g. when verifying a finality proof, the justification must contain every header
+			if bs == nil {
+				return ErrDescendantNotFound
+			}
+
+			isDescendant, err := bs.IsDescendantOf(target.hash, curr)
+			if err != nil {
+				return err
+			}
+
+			if !isDescendant {
+				return ErrDescendantNotFound
+			}
+
+			return nil
+		}
+
+		if h.Number.Uint64() <= target.number {
+			return ErrDescendantNotFound
+		}
+
+		curr = h.ParentHash
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJustificationService(t *testing.T) (*Service, *keystore.Ed25519Keyring) {
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, newTestState(t), kr.Alice)
+	return gs, kr
+}
+
+func signPrecommit(t *testing.T, gs *Service, vote *Vote, kp *ed25519.Keypair) *Justification {
+	vm, err := gs.createVoteMessage(vote, precommit, kp)
+	require.NoError(t, err)
+
+	return &Justification{
+		Vote:        vote,
+		Signature:   vm.Message.Signature,
+		AuthorityID: vm.Message.AuthorityID,
+	}
+}
+
+func createTestJustification(t *testing.T, gs *Service, kr *keystore.Ed25519Keyring, vote *Vote) *BlockJustification {
+	just := &BlockJustification{
+		Round:  gs.state.round,
+		SetID:  gs.state.setID,
+		Commit: vote,
+	}
+
+	for _, kp := range kr.Keys {
+		just.Precommits = append(just.Precommits, signPrecommit(t, gs, vote, kp))
+	}
+
+	return just
+}
+
+func verifyTestJustification(t *testing.T, gs *Service, just *BlockJustification) error {
+	enc, err := just.Encode()
+	require.NoError(t, err)
+	return gs.VerifyBlockJustification(just.Commit.hash, enc)
+}
+
+func TestBlockJustification_EncodeDecode(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0]))
+	just.VotesAncestries = headers[1:]
+
+	enc, err := just.Encode()
+	require.NoError(t, err)
+
+	res, err := DecodeBlockJustification(enc)
+	require.NoError(t, err)
+	require.Equal(t, just.Round, res.Round)
+	require.Equal(t, just.SetID, res.SetID)
+	require.Equal(t, just.Commit, res.Commit)
+	require.Equal(t, just.Precommits, res.Precommits)
+	require.Equal(t, len(just.VotesAncestries), len(res.VotesAncestries))
+
+	for i, h := range just.VotesAncestries {
+		require.Equal(t, h.Hash(), res.VotesAncestries[i].Hash())
+	}
+}
+
+func TestDecodeBlockJustification_HugeLength(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 1)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0]))
+	just.Precommits = nil
+
+	enc, err := just.Encode()
+	require.NoError(t, err)
+
+	// the lengths are the last two bytes, for the empty pre-commits and ancestries
+	prefix := enc[:len(enc)-2]
+	for _, length := range [][]byte{
+		{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f}, // 2^60-1
+		{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // overflows int64
+	} {
+		in := append(append([]byte{}, prefix...), length...)
+		_, err = DecodeBlockJustification(in)
+		require.Error(t, err)
+
+		in = append(append(append([]byte{}, prefix...), 0), length...)
+		_, err = DecodeBlockJustification(in)
+		require.Error(t, err)
+	}
+}
+
+func TestDecodeBlockJustification_Fuzz(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0]))
+	just.VotesAncestries = headers[1:]
+
+	enc, err := just.Encode()
+	require.NoError(t, err)
+
+	// malformed inputs must be rejected without panicking
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 2000; i++ {
+		in := append([]byte{}, enc[:r.Intn(len(enc)+1)]...)
+		for j := 0; j < 1+r.Intn(4) && len(in) > 0; j++ {
+			in[r.Intn(len(in))] = byte(r.Intn(256))
+		}
+
+		_, _ = DecodeBlockJustification(in)
+	}
+}
+
+func TestVerifyBlockJustification(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	err := verifyTestJustification(t, gs, just)
+	require.NoError(t, err)
+}
+
+func TestVerifyBlockJustification_HashMismatch(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	enc, err := just.Encode()
+	require.NoError(t, err)
+
+	err = gs.VerifyBlockJustification(headers[0].Hash(), enc)
+	require.Equal(t, ErrJustificationHashMismatch, err)
+}
+
+func TestVerifyBlockJustification_SetIDMismatch(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	just.SetID = 1
+	err := verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrSetIDMismatch, err)
+}
+
+func TestVerifyBlockJustification_InvalidSignature(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	just.Precommits[0].Signature = just.Precommits[1].Signature
+	err := verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyBlockJustification_UnknownVoter(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	kp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+	just.Precommits = append(just.Precommits, signPrecommit(t, gs, just.Commit, kp))
+	err = verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrVoterNotFound, err)
+}
+
+func TestVerifyBlockJustification_MinVotesNotMet(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1]))
+
+	// duplicate pre-commits from the same voter are only counted once
+	for i := range just.Precommits {
+		just.Precommits[i] = just.Precommits[0]
+	}
+
+	err := verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrMinVotesNotMet, err)
+
+	just.Precommits = nil
+	err = verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrMinVotesNotMet, err)
+}
+
+func TestVerifyBlockJustification_NotDescendant(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	branches := make(map[int]int)
+	branches[2] = 1
+	state.AddBlocksToStateWithFixedBranches(t, gs.blockState.(*state.BlockState), 4, branches, byte(rand.Intn(256)))
+	leaves := gs.blockState.Leaves()
+
+	voteA, err := NewVoteFromHash(leaves[0], gs.blockState)
+	require.NoError(t, err)
+	voteB, err := NewVoteFromHash(leaves[1], gs.blockState)
+	require.NoError(t, err)
+
+	just := createTestJustification(t, gs, kr, voteA)
+	just.Precommits[0] = signPrecommit(t, gs, voteB, kr.Keys[0])
+	err = verifyTestJustification(t, gs, just)
+	require.Equal(t, ErrDescendantNotFound, err)
+}
+
+func TestVerifyBlockJustification_VotesAncestries(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	// the verifier only knows about the finalized block, the descendants are proven by the ancestries
+	verifier, err := NewJustificationVerifier(newTestState(t).Block, gs.state.voters, gs.state.setID)
+	require.NoError(t, err)
+
+	just := createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0]))
+	for i, kp := range kr.Keys[:4] {
+		just.Precommits[i] = signPrecommit(t, gs, NewVoteFromHeader(headers[2]), kp)
+	}
+	just.VotesAncestries = []*types.Header{headers[2], headers[1]}
+
+	enc, err := just.Encode()
+	require.NoError(t, err)
+	err = verifier.VerifyBlockJustification(just.Commit.hash, enc)
+	require.NoError(t, err)
+
+	// without the ancestries, the descendant pre-commits cannot be verified
+	just.VotesAncestries = []*types.Header{headers[2]}
+	enc, err = just.Encode()
+	require.NoError(t, err)
+	err = verifier.VerifyBlockJustification(just.Commit.hash, enc)
+	require.Error(t, err)
+}
+
+func TestJustificationVerifier_ApplyAuthorityChange(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	verifier, err := NewJustificationVerifier(gs.blockState, gs.state.voters, gs.state.setID)
+	require.NoError(t, err)
+	verifier.ApplyAuthorityChange(newTestAuthorityData(kr.Keys), headers[0].Number)
+
+	verify := func(just *BlockJustification) error {
+		enc, err := just.Encode()
+		require.NoError(t, err)
+		return verifier.VerifyBlockJustification(just.Commit.hash, enc)
+	}
+
+	// the change's block is finalized by the previous set, and the later blocks by the new set
+	err = verify(createTestJustification(t, gs, kr, NewVoteFromHeader(headers[0])))
+	require.NoError(t, err)
+	err = verify(createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1])))
+	require.Equal(t, ErrSetIDMismatch, err)
+
+	gs.state.setID = 1
+	err = verify(createTestJustification(t, gs, kr, NewVoteFromHeader(headers[1])))
+	require.NoError(t, err)
+
+	// the set is chosen by the block's number, so the commit can't claim another number
+	just := createTestJustification(t, gs, kr, NewVote(headers[1].Hash(), headers[0].Number.Uint64()))
+	err = verify(just)
+	require.Equal(t, ErrJustificationHashMismatch, err)
+}
+
+func TestFinalize_SetsJustification(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	voteA := NewVoteFromHeader(headers[1])
+	voteB := NewVoteFromHeader(headers[2])
+
+	for i, kp := range kr.Keys {
+		voter := kp.Public().(*ed25519.PublicKey).AsBytes()
+		vote := voteA
+		if i%2 == 0 {
+			vote = voteB
+		}
+
+		gs.prevotes[voter] = vote
+		gs.precommits[voter] = vote
+		gs.pcJustifications[vote.hash] = append(gs.pcJustifications[vote.hash], signPrecommit(t, gs, vote, kp))
+	}
+
+	err := gs.finalize()
+	require.NoError(t, err)
+	require.Equal(t, voteA.hash, gs.head.Hash())
+
+	enc, err := gs.blockState.GetJustification(voteA.hash)
+	require.NoError(t, err)
+
+	just, err := DecodeBlockJustification(enc)
+	require.NoError(t, err)
+	require.Equal(t, len(kr.Keys), len(just.Precommits))
+	require.Equal(t, 1, len(just.VotesAncestries))
+	require.Equal(t, big.NewInt(3), just.VotesAncestries[0].Number)
+
+	err = gs.VerifyBlockJustification(voteA.hash, enc)
+	require.NoError(t, err)
+}
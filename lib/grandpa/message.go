@@ -35,8 +35,11 @@ func (s *Service) GetFinalizedChannel() <-chan FinalityMessage {
 
 var (
 	// TODO: determine correct prefixes
-	voteType         byte = 0
-	finalizationType byte = 1
+	voteType            byte = 0
+	finalizationType    byte = 1
+	neighborType        byte = 2
+	catchUpRequestType  byte = 3
+	catchUpResponseType byte = 4
 )
 
 // FullVote represents a vote with additional information about the state
@@ -106,12 +109,18 @@ func (j *Justification) Encode() ([]byte, error) {
 func (j *Justification) Decode(r io.Reader) (*Justification, error) {
 	sd := &scale.Decoder{Reader: r}
 	i, err := sd.Decode(j)
-	return i.(*Justification), err
+	if err != nil {
+		return nil, err
+	}
+
+	return i.(*Justification), nil
 }
 
-// FinalizationMessage represents a network finalization message
+// FinalizationMessage represents a network commit message. it's broadcast by voters when a round completes, and contains
+// the block finalized in the round along with the pre-commits that justify it, so that observers can verify it.
 type FinalizationMessage struct {
 	Round         uint64
+	SetID         uint64
 	Vote          *Vote
 	Justification []*Justification
 }
@@ -130,9 +139,57 @@ func (f *FinalizationMessage) ToConsensusMessage() (*ConsensusMessage, error) {
 }
 
 func (s *Service) newFinalizationMessage(header *types.Header, round uint64) *FinalizationMessage {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
 	return &FinalizationMessage{
 		Round:         round,
+		SetID:         s.state.setID,
 		Vote:          NewVoteFromHeader(header),
 		Justification: s.justification[round],
 	}
 }
+
+// CatchUpRequest represents a request for the votes that completed a round later than the given one.
+// it's sent by a voter that has fallen behind the rest of the network.
+type CatchUpRequest struct {
+	Round uint64
+	SetID uint64
+}
+
+// ToConsensusMessage converts the CatchUpRequest into a network-level consensus message
+func (r *CatchUpRequest) ToConsensusMessage() (*ConsensusMessage, error) {
+	enc, err := scale.Encode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              append([]byte{catchUpRequestType}, enc...),
+	}, nil
+}
+
+// CatchUpResponse represents a response to a CatchUpRequest. it contains the pre-votes and pre-commits
+// that completed the given round, as well as the block that was finalized in the round.
+type CatchUpResponse struct {
+	SetID                  uint64
+	Round                  uint64
+	PreVoteJustification   []*Justification
+	PreCommitJustification []*Justification
+	Hash                   common.Hash
+	Number                 uint64
+}
+
+// ToConsensusMessage converts the CatchUpResponse into a network-level consensus message
+func (r *CatchUpResponse) ToConsensusMessage() (*ConsensusMessage, error) {
+	enc, err := scale.Encode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              append([]byte{catchUpResponseType}, enc...),
+	}, nil
+}
@@ -23,10 +23,11 @@ import (
 // MessageHandler handles GRANDPA consensus messages
 type MessageHandler struct {
 	grandpa    *Service
+	observer   *Observer
 	blockState BlockState
 }
 
-// NewMessageHandler returns a new MessageHandler
+// NewMessageHandler returns a new MessageHandler for a GRANDPA voter
 func NewMessageHandler(grandpa *Service, blockState BlockState) *MessageHandler {
 	return &MessageHandler{
 		grandpa:    grandpa,
@@ -34,28 +35,37 @@ func NewMessageHandler(grandpa *Service, blockState BlockState) *MessageHandler
 	}
 }
 
+// NewObserverMessageHandler returns a new MessageHandler for a node that isn't a GRANDPA voter.
+// it only handles commit messages, which are verified and applied by the observer.
+func NewObserverMessageHandler(observer *Observer, blockState BlockState) *MessageHandler {
+	return &MessageHandler{
+		observer:   observer,
+		blockState: blockState,
+	}
+}
+
 // HandleMessage handles a GRANDPA consensus message
-// if it is a FinalizationMessage, it updates the BlockState
+// if it is a FinalizationMessage (ie. a commit message), it verifies it and updates the BlockState
 // if it is a VoteMessage, it sends it to the GRANDPA service
+// if it is a CatchUpRequest or CatchUpResponse, it passes it to the GRANDPA service to respond to or to catch up with
 func (h *MessageHandler) HandleMessage(msg *ConsensusMessage) error {
 	m, err := decodeMessage(msg)
 	if err != nil {
 		return err
 	}
 
-	fm, ok := m.(*FinalizationMessage)
-	if ok {
-		// set finalized head for round in db
-		err = h.blockState.SetFinalizedHash(fm.Vote.hash, fm.Round)
-		if err != nil {
-			return err
+	if h.grandpa != nil {
+		switch cm := m.(type) {
+		case *CatchUpRequest:
+			return h.grandpa.handleCatchUpRequest(cm)
+		case *CatchUpResponse:
+			return h.grandpa.handleCatchUpResponse(cm)
 		}
+	}
 
-		// set latest finalized head in db
-		err = h.blockState.SetFinalizedHash(fm.Vote.hash, 0)
-		if err != nil {
-			return err
-		}
+	fm, ok := m.(*FinalizationMessage)
+	if ok {
+		return h.handleCommit(fm)
 	}
 
 	vm, ok := m.(*VoteMessage)
@@ -67,17 +77,39 @@ func (h *MessageHandler) HandleMessage(msg *ConsensusMessage) error {
 	return nil
 }
 
-// decodeMessage decodes a network-level consensus message into a GRANDPA VoteMessage or FinalizationMessage
+// handleCommit verifies and applies a commit message, using the voter's or observer's voter set
+func (h *MessageHandler) handleCommit(fm *FinalizationMessage) error {
+	if h.grandpa != nil {
+		return h.grandpa.handleCommit(fm)
+	}
+
+	if h.observer != nil {
+		return h.observer.HandleCommit(fm)
+	}
+
+	return ErrNilObserver
+}
+
+// decodeMessage decodes a network-level consensus message into a GRANDPA VoteMessage, FinalizationMessage,
+// NeighborMessage, CatchUpRequest or CatchUpResponse
 func decodeMessage(msg *ConsensusMessage) (m FinalityMessage, err error) {
+	if len(msg.Data) == 0 {
+		return nil, ErrInvalidMessageType
+	}
+
 	var mi interface{}
 
 	switch msg.Data[0] {
 	case voteType:
 		mi, err = scale.Decode(msg.Data[1:], &VoteMessage{Message: new(SignedMessage)})
-		m = mi.(*VoteMessage)
 	case finalizationType:
 		mi, err = scale.Decode(msg.Data[1:], &FinalizationMessage{})
-		m = mi.(*FinalizationMessage)
+	case neighborType:
+		mi, err = scale.Decode(msg.Data[1:], &NeighborMessage{})
+	case catchUpRequestType:
+		mi, err = scale.Decode(msg.Data[1:], &CatchUpRequest{})
+	case catchUpResponseType:
+		mi, err = scale.Decode(msg.Data[1:], &CatchUpResponse{})
 	default:
 		return nil, ErrInvalidMessageType
 	}
@@ -86,5 +118,10 @@ func decodeMessage(msg *ConsensusMessage) (m FinalityMessage, err error) {
 		return nil, err
 	}
 
+	m, ok := mi.(FinalityMessage)
+	if !ok {
+		return nil, ErrInvalidMessageType
+	}
+
 	return m, nil
 }
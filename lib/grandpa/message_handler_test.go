@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
@@ -62,7 +63,7 @@ func TestDecodeMessage_FinalizationMessage(t *testing.T) {
 
 	cm := &ConsensusMessage{
 		ConsensusEngineID: types.GrandpaEngineID,
-		Data:              common.MustHexToBytes("0x014d000000000000007db9db5ed9967b80143100189ba69d9e4deab85ac3570e5df25686cabe32964a0000000000000000040a0b0c0d00000000000000000000000000000000000000000000000000000000e7030000000000000102030400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000034602b88f60513f1c805d87ef52896934baf6a662bc37414dbdbf69356b1a691"),
+		Data:              common.MustHexToBytes("0x014d0000000000000000000000000000007db9db5ed9967b80143100189ba69d9e4deab85ac3570e5df25686cabe32964a0000000000000000040a0b0c0d00000000000000000000000000000000000000000000000000000000e7030000000000000102030400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000034602b88f60513f1c805d87ef52896934baf6a662bc37414dbdbf69356b1a691"),
 	}
 
 	msg, err := decodeMessage(cm)
@@ -126,41 +127,74 @@ func TestMessageHandler_VoteMessage(t *testing.T) {
 }
 
 func TestMessageHandler_FinalizationMessage(t *testing.T) {
-	st := newTestState(t)
-	voters := newTestVoters(t)
-	kr, err := keystore.NewEd25519Keyring()
-	require.NoError(t, err)
+	gs, kr := newTestJustificationService(t)
+	st := gs.blockState.(*state.BlockState)
+	headers, _ := state.AddBlocksToState(t, st, 3)
 
-	cfg := &Config{
-		BlockState: st.Block,
-		Voters:     voters,
-		Keypair:    kr.Alice,
+	gs.state.round = 77
+	vote := NewVoteFromHeader(headers[1])
+	fm := &FinalizationMessage{
+		Round:         77,
+		SetID:         gs.state.setID,
+		Vote:          vote,
+		Justification: createTestJustification(t, gs, kr, vote).Precommits,
 	}
 
-	gs, err := NewService(cfg)
+	cm, err := fm.ToConsensusMessage()
 	require.NoError(t, err)
 
-	gs.justification[77] = []*Justification{
-		{
-			Vote:        testVote,
-			Signature:   testSignature,
-			AuthorityID: gs.publicKeyBytes(),
-		},
-	}
-
-	fm := gs.newFinalizationMessage(gs.head, 77)
-	cm, err := fm.ToConsensusMessage()
+	observer, err := NewObserver(&ObserverConfig{
+		BlockState: st,
+		Voters:     gs.state.voters,
+		SetID:      gs.state.setID,
+	})
 	require.NoError(t, err)
 
-	h := NewMessageHandler(nil, st.Block)
+	h := NewObserverMessageHandler(observer, st)
 	err = h.HandleMessage(cm)
 	require.NoError(t, err)
 
-	hash, err := st.Block.GetFinalizedHash(0)
+	hash, err := st.GetFinalizedHash(0)
 	require.NoError(t, err)
 	require.Equal(t, fm.Vote.hash, hash)
 
-	hash, err = st.Block.GetFinalizedHash(fm.Round)
+	hash, err = st.GetFinalizedHash(fm.Round)
 	require.NoError(t, err)
 	require.Equal(t, fm.Vote.hash, hash)
+
+	just, err := st.GetJustification(fm.Vote.hash)
+	require.NoError(t, err)
+	err = observer.VerifyBlockJustification(fm.Vote.hash, just)
+	require.NoError(t, err)
+}
+
+func TestMessageHandler_FinalizationMessage_Invalid(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	st := gs.blockState.(*state.BlockState)
+	headers, _ := state.AddBlocksToState(t, st, 3)
+
+	gs.state.round = 77
+	vote := NewVoteFromHeader(headers[1])
+	fm := &FinalizationMessage{
+		Round:         77,
+		SetID:         gs.state.setID,
+		Vote:          vote,
+		Justification: createTestJustification(t, gs, kr, vote).Precommits[:2],
+	}
+
+	cm, err := fm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	h := NewMessageHandler(gs, st)
+	err = h.HandleMessage(cm)
+	require.Equal(t, ErrMinVotesNotMet, err)
+
+	hash, err := st.GetFinalizedHash(0)
+	require.NoError(t, err)
+	require.Equal(t, testGenesisHeader.Hash(), hash)
+
+	// commit messages can't be verified without a voter set
+	h = NewMessageHandler(nil, st)
+	err = h.HandleMessage(cm)
+	require.Equal(t, ErrNilObserver, err)
 }
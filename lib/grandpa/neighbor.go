@@ -0,0 +1,317 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/scale"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var _ network.GossipValidator = &GossipValidator{}
+
+// neighborMessageVersion is the current version of NeighborMessage
+var neighborMessageVersion byte = 1
+
+// NeighborMessage represents a network-level neighbor packet. it tells our peers which round and set ID we are
+// voting in and which block we last finalized, so that they only send us the messages we need.
+// https://github.com/paritytech/substrate/blob/master/client/finality-grandpa/src/communication/gossip.rs#L396
+type NeighborMessage struct {
+	Version byte
+	Round   uint64
+	SetID   uint64
+	Number  uint64
+}
+
+// ToConsensusMessage converts the NeighborMessage into a network-level consensus message
+func (m *NeighborMessage) ToConsensusMessage() (*ConsensusMessage, error) {
+	enc, err := scale.Encode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsensusMessage{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              append([]byte{neighborType}, enc...),
+	}, nil
+}
+
+// view represents a voter's view of the GRANDPA protocol, as announced in its neighbor packets
+type view struct {
+	round     uint64
+	setID     uint64
+	finalized uint64
+}
+
+// String returns the view as a string
+func (v *view) String() string {
+	return fmt.Sprintf("round=%d setID=%d finalized=%d", v.round, v.setID, v.finalized)
+}
+
+// acceptsVote returns true if a vote for the given round and set ID is useful to a voter with this view,
+// ie. it's for the same set and is at most one round away from the view's round
+func (v *view) acceptsVote(round, setID uint64) bool {
+	return setID == v.setID && round+1 >= v.round && round <= v.round+1
+}
+
+// GossipValidator validates GRANDPA messages received from the network, and decides which peers to propagate
+// them to. it uses the views announced by our peers in their neighbor packets, as well as our own view,
+// to drop messages for stale or far-future rounds and set IDs.
+type GossipValidator struct {
+	blockState BlockState
+	sets       *voterSets // used to check that votes are signed by a voter; if nil, votes are dropped
+	grandpa    *Service   // if set, used to catch up when a neighbor is far ahead of us
+
+	lock     sync.RWMutex
+	local    *view // our own view; nil if we aren't voting
+	peers    map[peer.ID]*view
+	catchUps map[peer.ID]*CatchUpRequest // catch-up requests received from peers that we haven't responded to
+}
+
+// NewGossipValidator returns a new GossipValidator for a node that isn't a GRANDPA voter. votes are checked against
+// the voter sets known to the given observer. voters should use the validator returned by Service.GossipValidator.
+func NewGossipValidator(blockState BlockState, observer *Observer) *GossipValidator {
+	var sets *voterSets
+	if observer != nil {
+		sets = observer.sets
+	}
+
+	return newGossipValidator(blockState, sets, nil)
+}
+
+func newGossipValidator(blockState BlockState, sets *voterSets, grandpa *Service) *GossipValidator {
+	return &GossipValidator{
+		blockState: blockState,
+		sets:       sets,
+		grandpa:    grandpa,
+		peers:      make(map[peer.ID]*view),
+		catchUps:   make(map[peer.ID]*CatchUpRequest),
+	}
+}
+
+// GossipValidator returns the service's GossipValidator
+func (s *Service) GossipValidator() *GossipValidator {
+	return s.validator
+}
+
+// sendNeighborMessage updates our local view and broadcasts it to our peers
+func (s *Service) sendNeighborMessage() {
+	msg := &NeighborMessage{
+		Version: neighborMessageVersion,
+		Round:   s.state.round,
+		SetID:   s.state.setID,
+		Number:  s.head.Number.Uint64(),
+	}
+
+	s.validator.setLocalView(msg.Round, msg.SetID, msg.Number)
+	s.broadcast(msg)
+}
+
+// setLocalView sets our own view of the protocol
+func (v *GossipValidator) setLocalView(round, setID, finalized uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.local = &view{
+		round:     round,
+		setID:     setID,
+		finalized: finalized,
+	}
+}
+
+// peerView returns the last view announced by the given peer, or nil if it hasn't sent a neighbor packet
+func (v *GossipValidator) peerView(p peer.ID) *view {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.peers[p]
+}
+
+// Validate is called upon receipt of a GRANDPA message from a peer. it returns whether the message should be
+// passed on to be processed, and whether it should be propagated to our other peers. it returns an error if the
+// message cannot be decoded, or if it's a vote with an invalid signature or from a non-voter, so that the peer is
+// penalised.
+func (v *GossipValidator) Validate(from peer.ID, msg *ConsensusMessage) (process, propagate bool, err error) {
+	if msg.ConsensusEngineID != types.GrandpaEngineID {
+		return true, true, nil
+	}
+
+	m, err := decodeMessage(msg)
+	if err != nil {
+		return false, false, err
+	}
+
+	v.lock.RLock()
+	local := v.local
+	v.lock.RUnlock()
+
+	switch m := m.(type) {
+	case *NeighborMessage:
+		v.handleNeighborMessage(from, m, local)
+		// neighbor packets are only meant for the peer that they were sent to
+		return false, false, nil
+	case *VoteMessage:
+		// if we aren't voting, we can't tell which votes are useful, so just pass them on
+		if local != nil && !local.acceptsVote(m.Round, m.SetID) {
+			return false, false, nil
+		}
+
+		pk, err := ed25519.NewPublicKey(m.Message.AuthorityID[:])
+		if err != nil {
+			return false, false, err
+		}
+
+		err = validateMessageSignature(pk, m)
+		if err != nil {
+			return false, false, err
+		}
+
+		// votes for sets we don't know of can't be checked, so they aren't passed on
+		if v.sets == nil {
+			return false, false, nil
+		}
+
+		set := v.sets.bySetID(m.SetID)
+		if set == nil {
+			return false, false, nil
+		}
+
+		_, err = set.pubkeyToVoter(pk)
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	case *FinalizationMessage:
+		finalized, err := v.blockState.GetFinalizedHeader(0)
+		if err != nil {
+			return false, false, nil
+		}
+
+		// drop messages for blocks that are already finalized
+		if m.Vote.number <= finalized.Number.Uint64() {
+			return false, false, nil
+		}
+		return true, true, nil
+	case *CatchUpRequest:
+		if local == nil || m.SetID != local.setID {
+			return false, false, nil
+		}
+
+		// remember the request, so that our response is only sent to the requester
+		v.lock.Lock()
+		v.catchUps[from] = m
+		v.lock.Unlock()
+
+		// catch-up messages are only meant for the peers of the requester
+		return true, false, nil
+	case *CatchUpResponse:
+		if local == nil || m.SetID != local.setID || m.Round < local.round {
+			return false, false, nil
+		}
+		return true, false, nil
+	}
+
+	return false, false, nil
+}
+
+// handleNeighborMessage saves the peer's view, and requests to catch up if the peer is far ahead of us
+func (v *GossipValidator) handleNeighborMessage(from peer.ID, m *NeighborMessage, local *view) {
+	pv := &view{
+		round:     m.Round,
+		setID:     m.SetID,
+		finalized: m.Number,
+	}
+
+	v.lock.Lock()
+	v.peers[from] = pv
+	v.lock.Unlock()
+
+	if v.grandpa != nil && local != nil && pv.setID == local.setID && pv.round > local.round+catchUpThreshold {
+		go v.grandpa.requestCatchUp()
+	}
+}
+
+// takeCatchUpRequest returns whether the given peer requested the catch-up response, in which case the request
+// is removed so that the peer is only sent one response for it
+func (v *GossipValidator) takeCatchUpRequest(p peer.ID, resp *CatchUpResponse) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	req, has := v.catchUps[p]
+	if !has || req.SetID != resp.SetID || resp.Round < req.Round {
+		return false
+	}
+
+	delete(v.catchUps, p)
+	return true
+}
+
+// SendFilter is called when a GRANDPA message is broadcast. it decodes the message once, and returns a function
+// that returns whether the message should be sent to a given peer, according to the peer's view. if the peer hasn't
+// sent us a neighbor packet yet, all messages are sent to it, apart from catch-up responses, which are only sent
+// to the peers that requested them.
+func (v *GossipValidator) SendFilter(msg *ConsensusMessage) func(to peer.ID) bool {
+	if msg.ConsensusEngineID != types.GrandpaEngineID {
+		return func(peer.ID) bool { return true }
+	}
+
+	m, err := decodeMessage(msg)
+	if err != nil {
+		return func(peer.ID) bool { return false }
+	}
+
+	return func(to peer.ID) bool {
+		return v.shouldSend(to, m)
+	}
+}
+
+// shouldSend returns whether the decoded GRANDPA message should be sent to the given peer
+func (v *GossipValidator) shouldSend(to peer.ID, m FinalityMessage) bool {
+	if resp, ok := m.(*CatchUpResponse); ok {
+		return v.takeCatchUpRequest(to, resp)
+	}
+
+	pv := v.peerView(to)
+	if pv == nil {
+		return true
+	}
+
+	switch m := m.(type) {
+	case *VoteMessage:
+		return pv.acceptsVote(m.Round, m.SetID)
+	case *FinalizationMessage:
+		return m.Vote.number > pv.finalized
+	case *CatchUpRequest:
+		return m.SetID == pv.setID
+	}
+
+	return true
+}
+
+// PeerDisconnected removes the view and pending catch-up request of a peer that we're no longer connected to
+func (v *GossipValidator) PeerDisconnected(p peer.ID) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	delete(v.peers, p)
+	delete(v.catchUps, p)
+}
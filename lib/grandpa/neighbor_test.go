@@ -0,0 +1,356 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+var testPeer = peer.ID("noot")
+
+func newTestNeighborMessage(t *testing.T, round, setID, number uint64) *ConsensusMessage {
+	cm, err := (&NeighborMessage{
+		Version: neighborMessageVersion,
+		Round:   round,
+		SetID:   setID,
+		Number:  number,
+	}).ToConsensusMessage()
+	require.NoError(t, err)
+	return cm
+}
+
+func newTestVoteConsensusMessage(t *testing.T, gs *Service, round, setID uint64) *ConsensusMessage {
+	prevRound, prevSetID := gs.state.round, gs.state.setID
+	gs.state.round, gs.state.setID = round, setID
+	defer func() {
+		gs.state.round, gs.state.setID = prevRound, prevSetID
+	}()
+
+	vm, err := gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, gs.keypair)
+	require.NoError(t, err)
+
+	cm, err := vm.ToConsensusMessage()
+	require.NoError(t, err)
+	return cm
+}
+
+func TestNeighborMessage_Decode(t *testing.T) {
+	cm := newTestNeighborMessage(t, 77, 1, 99)
+
+	msg, err := decodeMessage(cm)
+	require.NoError(t, err)
+	require.Equal(t, &NeighborMessage{
+		Version: neighborMessageVersion,
+		Round:   77,
+		SetID:   1,
+		Number:  99,
+	}, msg)
+}
+
+func TestDecodeMessage_Invalid(t *testing.T) {
+	_, err := decodeMessage(&ConsensusMessage{ConsensusEngineID: types.GrandpaEngineID})
+	require.Equal(t, ErrInvalidMessageType, err)
+
+	_, err = decodeMessage(&ConsensusMessage{ConsensusEngineID: types.GrandpaEngineID, Data: []byte{99}})
+	require.Equal(t, ErrInvalidMessageType, err)
+}
+
+func TestSendNeighborMessage(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	gs.stopped = false
+	gs.state.round = 3
+
+	gs.sendNeighborMessage()
+
+	select {
+	case msg := <-gs.out:
+		require.Equal(t, &NeighborMessage{
+			Version: neighborMessageVersion,
+			Round:   3,
+			SetID:   0,
+			Number:  0,
+		}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("did not send NeighborMessage")
+	}
+
+	require.Equal(t, &view{round: 3, setID: 0, finalized: 0}, gs.validator.local)
+}
+
+func TestGossipValidator_Validate_NeighborMessage(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+
+	process, propagate, err := v.Validate(testPeer, newTestNeighborMessage(t, 4, 0, 2))
+	require.NoError(t, err)
+	require.False(t, process)
+	require.False(t, propagate)
+	require.Equal(t, &view{round: 4, setID: 0, finalized: 2}, v.peerView(testPeer))
+}
+
+func TestGossipValidator_Validate_VoteMessage(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+
+	// if we aren't voting, all votes from voters in a set we know of are accepted
+	process, propagate, err := v.Validate(testPeer, newTestVoteConsensusMessage(t, gs, 77, 0))
+	require.NoError(t, err)
+	require.True(t, process)
+	require.True(t, propagate)
+
+	// votes for a set we don't know of can't be checked
+	process, propagate, err = v.Validate(testPeer, newTestVoteConsensusMessage(t, gs, 77, 1))
+	require.NoError(t, err)
+	require.False(t, process)
+	require.False(t, propagate)
+
+	v.setLocalView(5, 0, 0)
+
+	for _, round := range []uint64{4, 5, 6} {
+		process, propagate, err = v.Validate(testPeer, newTestVoteConsensusMessage(t, gs, round, 0))
+		require.NoError(t, err)
+		require.True(t, process, "round %d", round)
+		require.True(t, propagate, "round %d", round)
+	}
+
+	// stale and far-future rounds, and other set IDs, are dropped
+	for _, cm := range []*ConsensusMessage{
+		newTestVoteConsensusMessage(t, gs, 3, 0),
+		newTestVoteConsensusMessage(t, gs, 7, 0),
+		newTestVoteConsensusMessage(t, gs, 5, 1),
+	} {
+		process, propagate, err = v.Validate(testPeer, cm)
+		require.NoError(t, err)
+		require.False(t, process)
+		require.False(t, propagate)
+	}
+}
+
+func TestGossipValidator_Validate_InvalidVoteSignature(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+
+	vm, err := gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, gs.keypair)
+	require.NoError(t, err)
+	vm.Message.Signature[0] ^= 0xff
+
+	cm, err := vm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	process, propagate, err := v.Validate(testPeer, cm)
+	require.Equal(t, ErrInvalidSignature, err)
+	require.False(t, process)
+	require.False(t, propagate)
+}
+
+func TestGossipValidator_Validate_NonVoter(t *testing.T) {
+	o, gs, _, _ := newTestObserver(t)
+
+	kp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	vm, err := gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, kp)
+	require.NoError(t, err)
+
+	cm, err := vm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	// the vote is correctly signed, but not by a voter in its set
+	for _, v := range []*GossipValidator{gs.GossipValidator(), NewGossipValidator(gs.blockState, o)} {
+		process, propagate, err := v.Validate(testPeer, cm)
+		require.Equal(t, ErrVoterNotFound, err)
+		require.False(t, process)
+		require.False(t, propagate)
+	}
+
+	// once the observer knows of the next set, votes from its voters are accepted
+	o.ApplyAuthorityChange([]*types.GrandpaAuthorityData{{Key: kp.Public().(*ed25519.PublicKey)}}, big.NewInt(1))
+
+	gs.state.setID = 1
+	vm, err = gs.createVoteMessage(NewVoteFromHeader(gs.head), prevote, kp)
+	require.NoError(t, err)
+
+	cm, err = vm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	process, propagate, err := NewGossipValidator(gs.blockState, o).Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.True(t, propagate)
+}
+
+func TestGossipValidator_Validate_Undecodable(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+
+	_, _, err := v.Validate(testPeer, &ConsensusMessage{ConsensusEngineID: types.GrandpaEngineID, Data: []byte{99}})
+	require.Equal(t, ErrInvalidMessageType, err)
+}
+
+func TestGossipValidator_Validate_FinalizationMessage(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 3)
+
+	err := gs.blockState.SetFinalizedHash(headers[1].Hash(), 0)
+	require.NoError(t, err)
+
+	fm := &FinalizationMessage{Round: 1, Vote: NewVoteFromHeader(headers[0])}
+	cm, err := fm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	process, propagate, err := v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.False(t, process)
+	require.False(t, propagate)
+
+	fm.Vote = NewVoteFromHeader(headers[2])
+	cm, err = fm.ToConsensusMessage()
+	require.NoError(t, err)
+
+	process, propagate, err = v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.True(t, propagate)
+}
+
+func TestGossipValidator_Validate_CatchUpMessages(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	v := gs.GossipValidator()
+	v.setLocalView(3, 0, 0)
+
+	cm, err := (&CatchUpRequest{Round: 1, SetID: 0}).ToConsensusMessage()
+	require.NoError(t, err)
+	process, propagate, err := v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.False(t, propagate)
+
+	cm, err = (&CatchUpRequest{Round: 1, SetID: 1}).ToConsensusMessage()
+	require.NoError(t, err)
+	process, _, err = v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.False(t, process)
+
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	cm, err = resp.ToConsensusMessage()
+	require.NoError(t, err)
+	process, propagate, err = v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.True(t, process)
+	require.False(t, propagate)
+
+	// we've already completed the round
+	v.setLocalView(6, 0, 0)
+	process, _, err = v.Validate(testPeer, cm)
+	require.NoError(t, err)
+	require.False(t, process)
+}
+
+func TestGossipValidator_RequestsCatchUp(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	gs.stopped = false
+	gs.state.round = 1
+	v := gs.GossipValidator()
+	v.setLocalView(1, 0, 0)
+
+	v.Validate(testPeer, newTestNeighborMessage(t, 1+catchUpThreshold+1, 0, 0))
+
+	select {
+	case msg := <-gs.out:
+		require.Equal(t, &CatchUpRequest{Round: 1, SetID: 0}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("did not send CatchUpRequest")
+	}
+}
+
+func TestGossipValidator_SendFilter(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+	vote := newTestVoteConsensusMessage(t, gs, 8, 0)
+
+	// we don't know the peer's view yet
+	require.True(t, v.SendFilter(vote)(testPeer))
+
+	v.Validate(testPeer, newTestNeighborMessage(t, 5, 0, 0))
+	require.False(t, v.SendFilter(vote)(testPeer))
+	require.True(t, v.SendFilter(newTestVoteConsensusMessage(t, gs, 6, 0))(testPeer))
+	require.False(t, v.SendFilter(newTestVoteConsensusMessage(t, gs, 6, 1))(testPeer))
+
+	// messages for other consensus engines are always sent
+	require.True(t, v.SendFilter(&ConsensusMessage{ConsensusEngineID: types.BabeEngineID})(testPeer))
+}
+
+func TestGossipValidator_SendFilter_CatchUpResponse(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+	v := gs.GossipValidator()
+	v.setLocalView(6, 0, 0)
+	otherPeer := peer.ID("other")
+
+	resp := newTestCatchUpResponse(t, gs, kr, 4)
+	cm, err := resp.ToConsensusMessage()
+	require.NoError(t, err)
+
+	// nobody requested the response
+	require.False(t, v.SendFilter(cm)(testPeer))
+	require.False(t, v.SendFilter(cm)(otherPeer))
+
+	req, err := (&CatchUpRequest{Round: 5, SetID: 0}).ToConsensusMessage()
+	require.NoError(t, err)
+	process, _, err := v.Validate(testPeer, req)
+	require.NoError(t, err)
+	require.True(t, process)
+
+	// the response is behind the requester's round
+	require.False(t, v.SendFilter(cm)(testPeer))
+
+	resp.Round = 5
+	cm, err = resp.ToConsensusMessage()
+	require.NoError(t, err)
+	shouldSend := v.SendFilter(cm)
+	require.False(t, shouldSend(otherPeer))
+	require.True(t, shouldSend(testPeer))
+
+	// the requester is only sent one response
+	require.False(t, shouldSend(testPeer))
+}
+
+func TestGossipValidator_PeerDisconnected(t *testing.T) {
+	gs, _ := newTestJustificationService(t)
+	v := gs.GossipValidator()
+	v.setLocalView(6, 0, 0)
+
+	v.Validate(testPeer, newTestNeighborMessage(t, 5, 0, 0))
+	req, err := (&CatchUpRequest{Round: 5, SetID: 0}).ToConsensusMessage()
+	require.NoError(t, err)
+	v.Validate(testPeer, req)
+	require.NotNil(t, v.peerView(testPeer))
+	require.Len(t, v.catchUps, 1)
+
+	v.PeerDisconnected(testPeer)
+	require.Nil(t, v.peerView(testPeer))
+	require.Empty(t, v.catchUps)
+}
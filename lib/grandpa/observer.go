@@ -0,0 +1,160 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"os"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	log "github.com/ChainSafe/log15"
+)
+
+// Observer follows GRANDPA finality on nodes that aren't voters. it verifies the commit messages that voters
+// broadcast when a round completes, and finalizes the committed blocks.
+type Observer struct {
+	logger     log.Logger
+	blockState BlockState
+	sets       *voterSets
+}
+
+// ObserverConfig represents a GRANDPA observer configuration
+type ObserverConfig struct {
+	LogLvl     log.Lvl
+	BlockState BlockState
+	Voters     []*Voter
+	SetID      uint64
+}
+
+// NewObserver returns a new GRANDPA Observer for the given voter set
+func NewObserver(cfg *ObserverConfig) (*Observer, error) {
+	if cfg.BlockState == nil {
+		return nil, ErrNilBlockState
+	}
+
+	logger := log.New("pkg", "grandpa", "module", "observer")
+	h := log.StreamHandler(os.Stdout, log.TerminalFormat())
+	logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+
+	logger.Info("creating observer", "voter set", Voters(cfg.Voters), "setID", cfg.SetID)
+
+	return &Observer{
+		logger:     logger,
+		blockState: cfg.BlockState,
+		sets:       newVoterSets(cfg.Voters, cfg.SetID),
+	}, nil
+}
+
+// ApplyAuthorityChange changes the voter set to the given authorities, which finalize the blocks after the given
+// block. it's called once the change is enacted, ie. once its block is finalized for scheduled changes, or imported
+// on the best chain for forced changes.
+func (o *Observer) ApplyAuthorityChange(ad []*types.GrandpaAuthorityData, atBlock *big.Int) {
+	voters := NewVotersFromAuthorityData(ad)
+	o.sets.add(voters, atBlock.Uint64())
+	o.logger.Info("changed voter set", "setID", o.sets.current().setID, "block", atBlock, "voters", Voters(voters))
+}
+
+// HandleCommit verifies a commit message against the voter set that was active at its block, and finalizes its block
+// if it's later than our latest finalized block
+func (o *Observer) HandleCommit(fm *FinalizationMessage) error {
+	st, err := o.sets.forBlock(o.blockState, fm.Vote.hash, fm.Vote.number)
+	if err != nil {
+		return err
+	}
+
+	return applyCommit(o.logger, o.blockState, st, fm)
+}
+
+// VerifyBlockJustification verifies that the given encoded justification finalizes the block with the given hash,
+// using the voter set that was active at the block
+func (o *Observer) VerifyBlockJustification(hash common.Hash, justification []byte) error {
+	_, err := verifyBlockJustification(o.blockState, o.sets, hash, justification)
+	return err
+}
+
+// handleCommit verifies a commit message received by a voter and finalizes its block in the block state, if it's
+// later than the latest finalized block. the voter's rounds are unaffected; it catches up through catch-up messages.
+func (s *Service) handleCommit(fm *FinalizationMessage) error {
+	return applyCommit(s.logger, s.blockState, s.state, fm)
+}
+
+// verifyCommit checks that the commit message is for the given voter set, and that its pre-commits are for the
+// committed block or its descendants and come from at least 2/3 of the voters
+func verifyCommit(bs BlockState, st *State, fm *FinalizationMessage) error {
+	if fm.SetID != st.setID {
+		return ErrSetIDMismatch
+	}
+
+	has, err := bs.HasHeader(fm.Vote.hash)
+	if err != nil {
+		return err
+	}
+
+	// we can't finalize a block we don't have; it will be finalized when it's synced with its justification
+	if !has {
+		return ErrBlockDoesNotExist
+	}
+
+	return verifyPrecommits(bs, st, fm.Round, fm.SetID, fm.Vote, fm.Justification, nil)
+}
+
+// applyCommit verifies the commit message and, if it's for a block later than the latest finalized block, stores its
+// justification and sets the block as finalized
+func applyCommit(logger log.Logger, bs BlockState, st *State, fm *FinalizationMessage) error {
+	finalized, err := bs.GetFinalizedHeader(0)
+	if err != nil {
+		return err
+	}
+
+	// commit messages are gossiped, so we'll receive commits for blocks we've already finalized
+	if fm.Vote.number <= finalized.Number.Uint64() {
+		return nil
+	}
+
+	err = verifyCommit(bs, st, fm)
+	if err != nil {
+		return err
+	}
+
+	just, err := newBlockJustification(bs, fm.Round, fm.SetID, fm.Vote, fm.Justification)
+	if err != nil {
+		return err
+	}
+
+	enc, err := just.Encode()
+	if err != nil {
+		return err
+	}
+
+	err = bs.SetJustification(fm.Vote.hash, enc)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("finalizing block from commit", "round", fm.Round, "setID", fm.SetID, "hash", fm.Vote.hash, "number", fm.Vote.number)
+
+	// set finalized head for round in db
+	err = bs.SetFinalizedHash(fm.Vote.hash, fm.Round)
+	if err != nil {
+		return err
+	}
+
+	// set latest finalized head in db
+	return bs.SetFinalizedHash(fm.Vote.hash, 0)
+}
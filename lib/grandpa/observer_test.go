@@ -0,0 +1,171 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestObserver(t *testing.T) (*Observer, *Service, *keystore.Ed25519Keyring, []*types.Header) {
+	gs, kr := newTestJustificationService(t)
+	headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 4)
+
+	o, err := NewObserver(&ObserverConfig{
+		BlockState: gs.blockState,
+		Voters:     gs.state.voters,
+		SetID:      gs.state.setID,
+	})
+	require.NoError(t, err)
+	return o, gs, kr, headers
+}
+
+func newTestCommit(t *testing.T, gs *Service, kr *keystore.Ed25519Keyring, vote *Vote) *FinalizationMessage {
+	return &FinalizationMessage{
+		Round:         gs.state.round,
+		SetID:         gs.state.setID,
+		Vote:          vote,
+		Justification: createTestJustification(t, gs, kr, vote).Precommits,
+	}
+}
+
+func TestNewObserver_NilBlockState(t *testing.T) {
+	_, err := NewObserver(&ObserverConfig{})
+	require.Equal(t, ErrNilBlockState, err)
+}
+
+func TestObserver_HandleCommit(t *testing.T) {
+	o, gs, kr, headers := newTestObserver(t)
+
+	// pre-commits for descendants of the committed block are counted
+	vote := NewVoteFromHeader(headers[1])
+	fm := newTestCommit(t, gs, kr, vote)
+	for i, kp := range kr.Keys[:3] {
+		fm.Justification[i] = signPrecommit(t, gs, NewVoteFromHeader(headers[3]), kp)
+	}
+
+	err := o.HandleCommit(fm)
+	require.NoError(t, err)
+
+	finalized, err := gs.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, vote.hash, finalized.Hash())
+
+	// the stored justification includes the headers linking the descendants to the committed block
+	enc, err := gs.blockState.GetJustification(vote.hash)
+	require.NoError(t, err)
+	just, err := DecodeBlockJustification(enc)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(just.VotesAncestries))
+
+	// commits for blocks at or below the finalized block are ignored
+	old := newTestCommit(t, gs, kr, NewVoteFromHeader(headers[0]))
+	old.Justification = nil
+	err = o.HandleCommit(old)
+	require.NoError(t, err)
+
+	finalized, err = gs.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, vote.hash, finalized.Hash())
+}
+
+func TestObserver_HandleCommit_SetIDMismatch(t *testing.T) {
+	o, gs, kr, headers := newTestObserver(t)
+
+	fm := newTestCommit(t, gs, kr, NewVoteFromHeader(headers[1]))
+	fm.SetID = 1
+	err := o.HandleCommit(fm)
+	require.Equal(t, ErrSetIDMismatch, err)
+}
+
+func TestObserver_ApplyAuthorityChange(t *testing.T) {
+	o, gs, kr, headers := newTestObserver(t)
+
+	o.ApplyAuthorityChange(newTestAuthorityData(kr.Keys), big.NewInt(0))
+	require.Equal(t, uint64(1), o.sets.current().setID)
+
+	// commits from the previous set are rejected
+	fm := newTestCommit(t, gs, kr, NewVoteFromHeader(headers[1]))
+	err := o.HandleCommit(fm)
+	require.Equal(t, ErrSetIDMismatch, err)
+
+	gs.state.setID = 1
+	fm = newTestCommit(t, gs, kr, NewVoteFromHeader(headers[1]))
+	err = o.HandleCommit(fm)
+	require.NoError(t, err)
+
+	finalized, err := gs.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, headers[1].Hash(), finalized.Hash())
+
+	// the new set must sign with its own keys
+	o.ApplyAuthorityChange(newTestAuthorityData(kr.Keys[:1]), big.NewInt(1))
+	gs.state.setID = 2
+	fm = newTestCommit(t, gs, kr, NewVoteFromHeader(headers[2]))
+	err = o.HandleCommit(fm)
+	require.Equal(t, ErrVoterNotFound, err)
+}
+
+func TestObserver_HandleCommit_UnknownBlock(t *testing.T) {
+	o, gs, kr, _ := newTestObserver(t)
+
+	fm := newTestCommit(t, gs, kr, NewVote(common.Hash{1}, 77))
+	err := o.HandleCommit(fm)
+	require.Equal(t, ErrBlockDoesNotExist, err)
+}
+
+func TestObserver_HandleCommit_InvalidSignature(t *testing.T) {
+	o, gs, kr, headers := newTestObserver(t)
+
+	fm := newTestCommit(t, gs, kr, NewVoteFromHeader(headers[1]))
+	fm.Round++
+	err := o.HandleCommit(fm)
+	require.Equal(t, ErrInvalidSignature, err)
+
+	finalized, err := gs.blockState.GetFinalizedHeader(0)
+	require.NoError(t, err)
+	require.Equal(t, testGenesisHeader.Hash(), finalized.Hash())
+}
+
+func TestFinalize_CommitMessage(t *testing.T) {
+	o, gs, kr, headers := newTestObserver(t)
+	vote := NewVoteFromHeader(headers[1])
+
+	for _, kp := range kr.Keys {
+		voter := kp.Public().(*ed25519.PublicKey).AsBytes()
+		gs.prevotes[voter] = vote
+		gs.precommits[voter] = vote
+		gs.pcJustifications[vote.hash] = append(gs.pcJustifications[vote.hash], signPrecommit(t, gs, vote, kp))
+	}
+
+	err := gs.finalize()
+	require.NoError(t, err)
+
+	// the voter's commit message for the round can be verified by the observer
+	fm := gs.newFinalizationMessage(gs.head, gs.state.round)
+	require.Equal(t, len(kr.Keys), len(fm.Justification))
+	err = verifyCommit(o.blockState, o.sets.current(), fm)
+	require.NoError(t, err)
+}
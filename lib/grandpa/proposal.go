@@ -0,0 +1,76 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+// isPrimary returns true if we're the primary for the current round
+func (s *Service) isPrimary() bool {
+	return s.derivePrimary().PublicKeyBytes() == s.publicKeyBytes()
+}
+
+// prevEstimate returns the estimate from the last round. if we don't have it, the last finalized block is used.
+func (s *Service) prevEstimate() *Vote {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	if s.state.round > 0 && s.estimates[s.state.round-1] != nil {
+		return s.estimates[s.state.round-1]
+	}
+
+	return NewVoteFromHeader(s.head)
+}
+
+// sendPrimaryProposal broadcasts the estimate from the last round as our proposal for the current round, if it's
+// later than the last finalized block. it must only be called by the round's primary.
+func (s *Service) sendPrimaryProposal() error {
+	est := s.prevEstimate()
+	if est.number <= s.head.Number.Uint64() {
+		return nil
+	}
+
+	s.mapLock.Lock()
+	s.proposal = est
+	s.mapLock.Unlock()
+
+	s.logger.Debug("sending primary proposal...", "round", s.state.round, "vote", est)
+	return s.sendMessage(est, primaryProposal)
+}
+
+// validateProposal checks that a primary proposal is from the current round's primary and is for a block that's
+// a descendant of the last finalized block. if so, it's saved as the round's proposal.
+func (s *Service) validateProposal(m *VoteMessage, voter *Voter, vote *Vote) (*Vote, error) {
+	if voter.PublicKeyBytes() != s.derivePrimary().PublicKeyBytes() {
+		return nil, ErrNotPrimary
+	}
+
+	err := s.validateVote(vote)
+	if err == ErrBlockDoesNotExist {
+		s.tracker.add(m)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	// the primary only proposes once in a round, so we keep the first proposal we receive
+	if s.proposal == nil {
+		s.proposal = vote
+	}
+
+	return vote, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProposalService(t *testing.T) (*Service, *keystore.Ed25519Keyring, []*types.Header) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, st, kr.Bob)
+	headers, _ := state.AddBlocksToState(t, st.Block, 4)
+	return gs, kr, headers
+}
+
+// finalizeTestRound finalizes round 1, in which every voter pre-votes for headers[3], and 2/3 of the voters pre-commit
+// for headers[1] or its descendants, while the other voters haven't pre-committed yet. the round finalizes headers[1],
+// but its estimate is headers[3], since it could still get pre-commits from 2/3 of the voters. the service is left
+// in round 2.
+func finalizeTestRound(t *testing.T, gs *Service, kr *keystore.Ed25519Keyring, headers []*types.Header) {
+	gs.state.round = 1
+	for i, kp := range kr.Keys {
+		voter := kp.Public().(*ed25519.PublicKey).AsBytes()
+		gs.prevotes[voter] = NewVoteFromHeader(headers[3])
+
+		if uint64(i) >= gs.state.threshold() {
+			continue
+		}
+
+		pc := NewVoteFromHeader(headers[3])
+		if i >= 4 {
+			pc = NewVoteFromHeader(headers[1])
+		}
+		gs.precommits[voter] = pc
+		gs.pcJustifications[pc.hash] = append(gs.pcJustifications[pc.hash], signPrecommit(t, gs, pc, kp))
+	}
+
+	err := gs.finalize()
+	require.NoError(t, err)
+	require.Equal(t, headers[1].Hash(), gs.head.Hash())
+	require.Equal(t, NewVoteFromHeader(headers[3]), gs.estimates[1])
+
+	gs.state.round = 2
+}
+
+func TestDeterminePreVote_WithPrimaryProposal(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	finalizeTestRound(t, gs, kr, headers)
+	gs.proposal = NewVoteFromHeader(headers[3])
+
+	pv, err := gs.determinePreVote()
+	require.NoError(t, err)
+	require.Equal(t, gs.proposal, pv)
+}
+
+func TestDeterminePreVote_ProposalNotDescendantOfEstimate(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	finalizeTestRound(t, gs, kr, headers)
+	gs.proposal = NewVoteFromHeader(headers[2])
+
+	pv, err := gs.determinePreVote()
+	require.NoError(t, err)
+
+	best, err := gs.blockState.BestBlockHeader()
+	require.NoError(t, err)
+	require.Equal(t, best.Hash(), pv.hash)
+}
+
+func TestValidateMessage_PrimaryProposal(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	primary := kr.Keys[gs.state.round%uint64(len(kr.Keys))]
+	require.Equal(t, gs.derivePrimary().PublicKeyBytes(), primary.Public().(*ed25519.PublicKey).AsBytes())
+
+	vote := NewVoteFromHeader(headers[2])
+	msg, err := gs.createVoteMessage(vote, primaryProposal, primary)
+	require.NoError(t, err)
+
+	res, err := gs.validateMessage(msg)
+	require.NoError(t, err)
+	require.Equal(t, vote, res)
+	require.Equal(t, vote, gs.proposal)
+
+	// a proposal isn't a pre-vote
+	require.Equal(t, 0, len(gs.prevotes))
+	require.Equal(t, 0, len(gs.pvJustifications))
+
+	// only the first proposal in the round is used
+	msg, err = gs.createVoteMessage(NewVoteFromHeader(headers[3]), primaryProposal, primary)
+	require.NoError(t, err)
+	_, err = gs.validateMessage(msg)
+	require.NoError(t, err)
+	require.Equal(t, vote, gs.proposal)
+}
+
+func TestValidateMessage_PrimaryProposal_NotPrimary(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	gs.state.round = 1
+
+	msg, err := gs.createVoteMessage(NewVoteFromHeader(headers[2]), primaryProposal, kr.Charlie)
+	require.NoError(t, err)
+
+	_, err = gs.validateMessage(msg)
+	require.Equal(t, ErrNotPrimary, err)
+	require.Nil(t, gs.proposal)
+}
+
+func TestSendPrimaryProposal(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	gs.stopped = false
+	finalizeTestRound(t, gs, kr, headers)
+
+	// the last round's estimate is later than the block it finalized, so it's proposed
+	err := gs.sendPrimaryProposal()
+	require.NoError(t, err)
+	require.Equal(t, NewVoteFromHeader(headers[3]), gs.proposal)
+
+	msg := (<-gs.out).(*VoteMessage)
+	require.Equal(t, primaryProposal, msg.Stage)
+	require.Equal(t, headers[3].Hash(), msg.Message.Hash)
+
+	// the proposal can be decoded by other voters
+	cm, err := msg.ToConsensusMessage()
+	require.NoError(t, err)
+	res, err := decodeMessage(cm)
+	require.NoError(t, err)
+	require.Equal(t, msg, res)
+
+	// the estimate isn't proposed once it's been finalized
+	gs.proposal = nil
+	gs.head = headers[3]
+	err = gs.sendPrimaryProposal()
+	require.NoError(t, err)
+	require.Nil(t, gs.proposal)
+	require.Equal(t, 0, len(gs.out))
+}
+
+func TestGrandpa_PrimaryProposal_SplitChains(t *testing.T) {
+	// voters with chains of different lengths pre-vote for different blocks, unless they all pre-vote for the
+	// primary's proposal, in which case the proposed block is finalized by all of them
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gss := make([]*Service, len(kr.Keys))
+	prevotes := make(map[ed25519.PublicKeyBytes]*Vote)
+	var proposal *Vote
+
+	for i := range gss {
+		gs, _, _, _ := setupGrandpa(t, kr.Keys[i])
+		gss[i] = gs
+
+		headers, _ := state.AddBlocksToState(t, gs.blockState.(*state.BlockState), 4+i)
+		if proposal == nil {
+			proposal = NewVoteFromHeader(headers[2])
+		}
+	}
+
+	for _, gs := range gss {
+		err = gs.validateVote(proposal)
+		require.NoError(t, err)
+		gs.proposal = proposal
+
+		prevotes[gs.publicKeyBytes()], err = gs.determinePreVote()
+		require.NoError(t, err)
+		require.Equal(t, proposal, prevotes[gs.publicKeyBytes()])
+	}
+
+	for _, gs := range gss {
+		gs.prevotes = prevotes
+		pc, err := gs.determinePreCommit()
+		require.NoError(t, err)
+		require.Equal(t, proposal, pc)
+	}
+
+	for _, gs := range gss {
+		gs.precommits = make(map[ed25519.PublicKeyBytes]*Vote)
+		for k := range prevotes {
+			gs.precommits[k] = proposal
+		}
+
+		err = gs.finalize()
+		require.NoError(t, err)
+		require.Equal(t, proposal.hash, gs.head.Hash())
+	}
+}
+
+func TestGetEstimate(t *testing.T) {
+	gs, kr, headers := newTestProposalService(t)
+	finalizeTestRound(t, gs, kr, headers)
+	gs.state.round = 1
+
+	pvb, bfc := NewVoteFromHeader(headers[3]), NewVoteFromHeader(headers[1])
+
+	// once the other voters pre-commit for the finalized block, its descendants can't get 2/3 of the pre-commits
+	for _, kp := range kr.Keys[gs.state.threshold():] {
+		gs.precommits[kp.Public().(*ed25519.PublicKey).AsBytes()] = bfc
+	}
+
+	est, err := gs.getEstimate(pvb, bfc)
+	require.NoError(t, err)
+	require.Equal(t, bfc, est)
+}
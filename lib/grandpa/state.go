@@ -21,6 +21,7 @@ import (
 
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
 )
 
 // BlockState is the interface required by GRANDPA into the block state
@@ -33,6 +34,8 @@ type BlockState interface {
 	HighestCommonAncestor(a, b common.Hash) (common.Hash, error)
 	GetFinalizedHeader(uint64) (*types.Header, error)
 	SetFinalizedHash(common.Hash, uint64) error
+	SetJustification(hash common.Hash, data []byte) error
+	GetJustification(hash common.Hash) ([]byte, error)
 	BestBlockHeader() (*types.Header, error)
 	BestBlockHash() common.Hash
 	Leaves() []common.Hash
@@ -40,3 +43,18 @@ type BlockState interface {
 	RegisterImportedChannel(ch chan<- *types.Block) (byte, error)
 	UnregisterImportedChannel(id byte)
 }
+
+// GrandpaState is the interface required by GRANDPA to persist its voter state and the equivocations it observes
+type GrandpaState interface {
+	SetVoterState(data []byte) error
+	GetVoterState() ([]byte, error)
+	HasVoterState() (bool, error)
+	AddEquivocation(data []byte) error
+	GetEquivocations() ([][]byte, error)
+}
+
+// EquivocationReporter is the interface required by GRANDPA to report equivocations to the runtime
+type EquivocationReporter interface {
+	GrandpaGenerateKeyOwnershipProof(setID uint64, authorityID ed25519.PublicKeyBytes) ([]byte, error)
+	GrandpaSubmitReportEquivocation(equivocationProof, keyOwnershipProof []byte) error
+}
@@ -31,6 +31,7 @@ type subround byte
 
 var prevote subround = 0
 var precommit subround = 1
+var primaryProposal subround = 2
 
 func (s subround) Encode() ([]byte, error) {
 	return []byte{byte(s)}, nil
@@ -46,6 +47,8 @@ func (s subround) Decode(r io.Reader) (subround, error) {
 		return prevote, nil
 	} else if b == 1 {
 		return precommit, nil
+	} else if b == 2 {
+		return primaryProposal, nil
 	} else {
 		return 255, ErrCannotDecodeSubround
 	}
@@ -56,6 +59,8 @@ func (s subround) String() string {
 		return "prevote"
 	} else if s == precommit {
 		return "precommit"
+	} else if s == primaryProposal {
+		return "primary proposal"
 	}
 
 	return "unknown"
@@ -188,6 +193,16 @@ func NewVoteFromHash(hash common.Hash, blockState BlockState) (*Vote, error) {
 	return NewVoteFromHeader(h), nil
 }
 
+// Hash returns the hash of the block the vote is for
+func (v *Vote) Hash() common.Hash {
+	return v.hash
+}
+
+// Number returns the number of the block the vote is for
+func (v *Vote) Number() uint64 {
+	return v.number
+}
+
 // Encode returns the SCALE encoding of a Vote
 func (v *Vote) Encode() ([]byte, error) {
 	buf := make([]byte, 8)
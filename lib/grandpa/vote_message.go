@@ -68,17 +68,22 @@ func (s *Service) sendMessage(vote *Vote, stage subround) error {
 		return err
 	}
 
+	s.broadcast(msg)
+	s.logger.Trace("sent VoteMessage", "msg", msg)
+
+	return nil
+}
+
+// broadcast sends a message to the network through the out channel
+func (s *Service) broadcast(msg FinalityMessage) {
 	s.chanLock.Lock()
 	defer s.chanLock.Unlock()
 
 	if s.stopped {
-		return nil
+		return
 	}
 
 	s.out <- msg
-	s.logger.Trace("sent VoteMessage", "msg", msg)
-
-	return nil
 }
 
 // createVoteMessage returns a signed VoteMessage given a header
@@ -132,17 +137,22 @@ func (s *Service) validateMessage(m *VoteMessage) (*Vote, error) {
 		return nil, ErrSetIDMismatch
 	}
 
-	// check that vote is for current round
-	if m.Round != s.state.round {
-		return nil, ErrRoundMismatch
-	}
-
-	// check for equivocation ie. multiple votes within one subround
+	// check that the vote is from a voter, before a vote from anyone else can make us request to catch up
 	voter, err := s.state.pubkeyToVoter(pk)
 	if err != nil {
 		return nil, err
 	}
 
+	// check that vote is for current round
+	if m.Round != s.state.round {
+		// if the vote is for a much later round, we've fallen behind the rest of the network
+		if m.Round > s.state.round+catchUpThreshold {
+			s.requestCatchUp()
+		}
+
+		return nil, ErrRoundMismatch
+	}
+
 	vote := NewVote(m.Message.Hash, m.Message.Number)
 
 	// if the vote is from ourselves, ignore
@@ -151,8 +161,23 @@ func (s *Service) validateMessage(m *VoteMessage) (*Vote, error) {
 		return vote, nil
 	}
 
+	// primary proposals aren't votes, so they can't be equivocations
+	if m.Stage == primaryProposal {
+		return s.validateProposal(m, voter, vote)
+	}
+
+	just := &Justification{
+		Vote:        vote,
+		Signature:   m.Message.Signature,
+		AuthorityID: pk.AsBytes(),
+	}
+
 	equivocated := s.checkForEquivocation(voter, vote, m.Stage)
 	if equivocated {
+		if ev := s.newEquivocation(m.Stage, just); ev != nil {
+			s.recordEquivocation(ev)
+		}
+
 		return nil, ErrEquivocation
 	}
 
@@ -167,14 +192,9 @@ func (s *Service) validateMessage(m *VoteMessage) (*Vote, error) {
 	s.mapLock.Lock()
 	defer s.mapLock.Unlock()
 
-	just := &Justification{
-		Vote:        vote,
-		Signature:   m.Message.Signature,
-		AuthorityID: pk.AsBytes(),
-	}
-
 	if m.Stage == prevote {
 		s.prevotes[pk.AsBytes()] = vote
+		s.pvJustifications[m.Message.Hash] = append(s.pvJustifications[m.Message.Hash], just)
 	} else if m.Stage == precommit {
 		s.precommits[pk.AsBytes()] = vote
 		s.pcJustifications[m.Message.Hash] = append(s.pcJustifications[m.Message.Hash], just)
@@ -0,0 +1,301 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// VoterState is the state of the voter that is persisted, so that the voter doesn't cast conflicting votes
+// (ie. equivocate) in a round it already voted in before restarting.
+type VoterState struct {
+	SetID         uint64
+	Round         uint64
+	Prevote       *Vote            // our pre-vote in the round; nil if we haven't pre-voted yet
+	Precommit     *Vote            // our pre-commit in the round; nil if we haven't pre-committed yet
+	LastCompleted *CatchUpResponse // the votes that completed the last round; nil if we haven't completed a round
+}
+
+// Encode returns the SCALE encoded VoterState
+func (vs *VoterState) Encode() ([]byte, error) {
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf[:8], vs.SetID)
+	binary.LittleEndian.PutUint64(buf[8:], vs.Round)
+
+	for _, v := range []*Vote{vs.Prevote, vs.Precommit} {
+		if v == nil {
+			buf = append(buf, 0)
+			continue
+		}
+
+		enc, err := v.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, 1)
+		buf = append(buf, enc...)
+	}
+
+	if vs.LastCompleted == nil {
+		return append(buf, 0), nil
+	}
+
+	enc, err := scale.Encode(vs.LastCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	buf = append(buf, 1)
+	return append(buf, enc...), nil
+}
+
+// Decode returns the SCALE decoded VoterState
+func (vs *VoterState) Decode(r io.Reader) (*VoterState, error) {
+	if vs == nil {
+		vs = new(VoterState)
+	}
+
+	var err error
+	vs.SetID, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.Round, err = common.ReadUint64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.Prevote, err = decodeOptionalVote(r)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.Precommit, err = decodeOptionalVote(r)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := common.ReadByte(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists == 0 {
+		vs.LastCompleted = nil
+		return vs, nil
+	}
+
+	// the last completed round is always encoded last
+	enc, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := scale.Decode(enc, &CatchUpResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	vs.LastCompleted = resp.(*CatchUpResponse)
+	return vs, nil
+}
+
+// DecodeVoterState decodes a SCALE encoded VoterState
+func DecodeVoterState(in []byte) (*VoterState, error) {
+	return new(VoterState).Decode(bytes.NewBuffer(in))
+}
+
+func decodeOptionalVote(r io.Reader) (*Vote, error) {
+	exists, err := common.ReadByte(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists == 0 {
+		return nil, nil
+	}
+
+	return new(Vote).Decode(r)
+}
+
+// voterState returns our current voter state
+func (s *Service) voterState() *VoterState {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	return &VoterState{
+		SetID:         s.state.setID,
+		Round:         s.state.round,
+		Prevote:       s.prevotes[s.publicKeyBytes()],
+		Precommit:     s.precommits[s.publicKeyBytes()],
+		LastCompleted: s.lastCompleted,
+	}
+}
+
+// saveVoterState persists our current voter state. it must be called before any of our votes are sent.
+func (s *Service) saveVoterState() error {
+	if s.grandpaState == nil {
+		return nil
+	}
+
+	enc, err := s.voterState().Encode()
+	if err != nil {
+		return err
+	}
+
+	return s.grandpaState.SetVoterState(enc)
+}
+
+// loadVoterState loads our persisted voter state, if there is one, so that initiate resumes from it. if the saved round
+// wasn't completed, our votes in that round are restored by initiate, so that we don't cast different ones.
+func (s *Service) loadVoterState() error {
+	if s.grandpaState == nil {
+		return nil
+	}
+
+	has, err := s.grandpaState.HasVoterState()
+	if err != nil || !has {
+		return err
+	}
+
+	enc, err := s.grandpaState.GetVoterState()
+	if err != nil {
+		return err
+	}
+
+	vs, err := DecodeVoterState(enc)
+	if err != nil {
+		return err
+	}
+
+	// the voter set has changed since the state was saved
+	if vs.SetID < s.state.setID || vs.Round == 0 {
+		return nil
+	}
+
+	s.logger.Info("resuming from saved voter state", "setID", vs.SetID, "round", vs.Round)
+
+	// initiate increments the round, so we start from the round before the one we were voting in, unless we
+	// had already completed it
+	prev := vs.Round - 1
+	if vs.LastCompleted != nil && vs.LastCompleted.SetID == vs.SetID && vs.LastCompleted.Round >= vs.Round {
+		prev = vs.LastCompleted.Round
+	}
+
+	// we can only tell which block was finalized in the previous round if we completed it
+	completed := NewVoteFromHeader(s.head)
+	if vs.LastCompleted != nil && vs.LastCompleted.SetID == vs.SetID && vs.LastCompleted.Round == prev {
+		completed = NewVote(vs.LastCompleted.Hash, vs.LastCompleted.Number)
+	}
+
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	// we don't know when the sets before the saved one were active
+	if vs.SetID != s.state.setID {
+		s.sets.reset(s.state.voters, vs.SetID)
+	}
+
+	s.state.setID = vs.SetID
+	s.state.round = prev
+	s.preVotedBlock[prev] = completed
+	s.bestFinalCandidate[prev] = completed
+	s.estimates[prev] = completed
+	s.lastCompleted = vs.LastCompleted
+
+	if prev < vs.Round {
+		s.resumed = vs
+	}
+
+	return nil
+}
+
+// restoreVotes restores our votes in the current round from the loaded voter state, if it was saved in this round
+func (s *Service) restoreVotes() error {
+	vs := s.resumed
+	s.resumed = nil
+
+	if vs == nil || vs.SetID != s.state.setID || vs.Round != s.state.round {
+		return nil
+	}
+
+	if vs.Prevote != nil {
+		s.logger.Debug("restoring pre-vote", "round", vs.Round, "vote", vs.Prevote)
+		err := s.recordOwnVote(vs.Prevote, prevote)
+		if err != nil {
+			return err
+		}
+	}
+
+	if vs.Precommit != nil {
+		s.logger.Debug("restoring pre-commit", "round", vs.Round, "vote", vs.Precommit)
+		err := s.recordOwnVote(vs.Precommit, precommit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ownVote returns our vote for the given stage of the current round, or nil if we haven't voted yet
+func (s *Service) ownVote(stage subround) *Vote {
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	if stage == prevote {
+		return s.prevotes[s.publicKeyBytes()]
+	}
+
+	return s.precommits[s.publicKeyBytes()]
+}
+
+// recordOwnVote signs our vote for the given stage of the current round and adds it to the round's votes,
+// so that it can be served to voters that are catching up and included in the round's justification
+func (s *Service) recordOwnVote(v *Vote, stage subround) error {
+	vm, err := s.createVoteMessage(v, stage, s.keypair)
+	if err != nil {
+		return err
+	}
+
+	just := &Justification{
+		Vote:        v,
+		Signature:   vm.Message.Signature,
+		AuthorityID: vm.Message.AuthorityID,
+	}
+
+	s.mapLock.Lock()
+	defer s.mapLock.Unlock()
+
+	if stage == prevote {
+		s.prevotes[s.publicKeyBytes()] = v
+		s.pvJustifications[v.hash] = append(s.pvJustifications[v.hash], just)
+		return nil
+	}
+
+	s.precommits[s.publicKeyBytes()] = v
+	s.pcJustifications[v.hash] = append(s.pcJustifications[v.hash], just)
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package grandpa
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+// resumeTestRound performs the steps of initiate that resume the round from the loaded voter state
+func resumeTestRound(t *testing.T, gs *Service) {
+	err := gs.loadVoterState()
+	require.NoError(t, err)
+	gs.state.round++
+	err = gs.restoreVotes()
+	require.NoError(t, err)
+}
+
+func TestVoterState_EncodeDecode(t *testing.T) {
+	gs, kr := newTestJustificationService(t)
+
+	vs := &VoterState{
+		SetID: 1,
+		Round: 77,
+	}
+
+	enc, err := vs.Encode()
+	require.NoError(t, err)
+	res, err := DecodeVoterState(enc)
+	require.NoError(t, err)
+	require.Equal(t, vs, res)
+
+	resp := newTestCatchUpResponse(t, gs, kr, 76)
+	vs.Prevote = NewVote(resp.Hash, resp.Number)
+	vs.Precommit = NewVote(testGenesisHeader.Hash(), 0)
+	vs.LastCompleted = resp
+
+	enc, err = vs.Encode()
+	require.NoError(t, err)
+	res, err = DecodeVoterState(enc)
+	require.NoError(t, err)
+	require.Equal(t, vs, res)
+}
+
+func TestCastVote_SavesVoterState(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	gs.state.round = 3
+	headers, _ := state.AddBlocksToState(t, st.Block, 2)
+	vote := NewVoteFromHeader(headers[1])
+
+	pv, err := gs.castVote(prevote, func() (*Vote, error) {
+		return vote, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, vote, pv)
+	require.Equal(t, 1, len(gs.pvJustifications[vote.hash]))
+
+	enc, err := st.Grandpa.GetVoterState()
+	require.NoError(t, err)
+	vs, err := DecodeVoterState(enc)
+	require.NoError(t, err)
+	require.Equal(t, &VoterState{SetID: 0, Round: 3, Prevote: vote}, vs)
+
+	// once we've voted, we don't vote again
+	pv, err = gs.castVote(prevote, func() (*Vote, error) {
+		return NewVoteFromHeader(headers[0]), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, vote, pv)
+}
+
+func TestLoadVoterState_RestoresVotes(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	gs.state.round = 3
+	headers, _ := state.AddBlocksToState(t, st.Block, 2)
+	vote := NewVoteFromHeader(headers[1])
+
+	_, err = gs.castVote(prevote, func() (*Vote, error) {
+		return vote, nil
+	})
+	require.NoError(t, err)
+	_, err = gs.castVote(precommit, func() (*Vote, error) {
+		return vote, nil
+	})
+	require.NoError(t, err)
+
+	// after restarting, we resume the round we were voting in, and cast the same votes
+	restarted := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	resumeTestRound(t, restarted)
+	require.Equal(t, uint64(3), restarted.state.round)
+	require.NotNil(t, restarted.bestFinalCandidate[2])
+
+	other := func() (*Vote, error) {
+		return NewVoteFromHeader(headers[0]), nil
+	}
+
+	pv, err := restarted.castVote(prevote, other)
+	require.NoError(t, err)
+	require.Equal(t, vote, pv)
+	pc, err := restarted.castVote(precommit, other)
+	require.NoError(t, err)
+	require.Equal(t, vote, pc)
+	require.Equal(t, gs.pcJustifications, restarted.pcJustifications)
+}
+
+func TestLoadVoterState_CompletedRound(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	resp := newTestCatchUpResponse(t, gs, kr, 5)
+	gs.state.round = 5
+	gs.lastCompleted = resp
+	err = gs.saveVoterState()
+	require.NoError(t, err)
+
+	// we completed round 5 before restarting, so we continue with round 6
+	restarted := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	resumeTestRound(t, restarted)
+	require.Equal(t, uint64(6), restarted.state.round)
+	require.Nil(t, restarted.ownVote(prevote))
+	require.Equal(t, resp, restarted.lastCompleted)
+	require.Equal(t, NewVote(resp.Hash, resp.Number), restarted.bestFinalCandidate[5])
+}
+
+func TestLoadVoterState_OldSetID(t *testing.T) {
+	st := newTestState(t)
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	gs := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	gs.state.round = 5
+	err = gs.saveVoterState()
+	require.NoError(t, err)
+
+	restarted := newTestServiceWithState(t, st, kr.Alice, withGrandpaState(st))
+	restarted.state.setID = 1
+	err = restarted.loadVoterState()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), restarted.state.round)
+	require.Nil(t, restarted.resumed)
+}
@@ -1,10 +1,12 @@
 package runtime
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
 	"github.com/ChainSafe/gossamer/lib/scale"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 
@@ -22,6 +24,12 @@ var ErrInvalidTransaction = &json2.Error{Code: 1010, Message: "Invalid Transacti
 //  value of [1, 1, x]
 var ErrUnknownTransaction = &json2.Error{Code: 1011, Message: "Unknown Transaction Validity"}
 
+// ErrNoKeyOwnershipProof is returned if the runtime cannot generate a key ownership proof for a GRANDPA authority
+var ErrNoKeyOwnershipProof = errors.New("runtime did not generate key ownership proof")
+
+// ErrEquivocationReportNotSubmitted is returned if the runtime fails to submit a GRANDPA equivocation report
+var ErrEquivocationReportNotSubmitted = errors.New("runtime did not submit equivocation report")
+
 // ValidateTransaction runs the extrinsic through runtime function TaggedTransactionQueue_validate_transaction and returns *Validity
 func (r *Runtime) ValidateTransaction(e types.Extrinsic) (*transaction.Validity, error) {
 	ret, err := r.Exec(TaggedTransactionQueueValidateTransaction, e)
@@ -89,6 +97,47 @@ func (r *Runtime) GrandpaAuthorities() ([]*types.GrandpaAuthorityData, error) {
 	return types.GrandpaAuthorityDataRawToAuthorityData(adr.([]*types.GrandpaAuthorityDataRaw))
 }
 
+// GrandpaGenerateKeyOwnershipProof calls runtime API function GrandpaApi_generate_key_ownership_proof and returns the
+// SCALE encoded proof that the authority was a member of the given voter set. it returns ErrNoKeyOwnershipProof if the
+// runtime cannot generate the proof.
+func (r *Runtime) GrandpaGenerateKeyOwnershipProof(setID uint64, authorityID ed25519.PublicKeyBytes) ([]byte, error) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, setID)
+	buf = append(buf, authorityID[:]...)
+
+	ret, err := r.Exec(GrandpaGenerateKeyOwnershipProof, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// the runtime returns Option<OpaqueKeyOwnershipProof>
+	if len(ret) == 0 || ret[0] == 0 {
+		return nil, ErrNoKeyOwnershipProof
+	}
+
+	proof := make([]byte, len(ret)-1)
+	copy(proof, ret[1:])
+	return proof, nil
+}
+
+// GrandpaSubmitReportEquivocation calls runtime API function GrandpaApi_submit_report_equivocation_unsigned_extrinsic
+// with the SCALE encoded equivocation proof and key ownership proof
+func (r *Runtime) GrandpaSubmitReportEquivocation(equivocationProof, keyOwnershipProof []byte) error {
+	data := append(append([]byte{}, equivocationProof...), keyOwnershipProof...)
+
+	ret, err := r.Exec(GrandpaSubmitReportEquivocation, data)
+	if err != nil {
+		return err
+	}
+
+	// the runtime returns Option<()>
+	if len(ret) == 0 || ret[0] == 0 {
+		return ErrEquivocationReportNotSubmitted
+	}
+
+	return nil
+}
+
 // InitializeBlock calls runtime API function Core_initialize_block
 func (r *Runtime) InitializeBlock(header *types.Header) error {
 	encodedHeader, err := scale.Encode(header)
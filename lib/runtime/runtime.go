@@ -135,6 +135,12 @@ func (r *Runtime) Load(location, length int32) []byte {
 	return mem[location : location+length]
 }
 
+// HasExport returns true if the runtime exports the given function
+func (r *Runtime) HasExport(function string) bool {
+	_, ok := r.vm.Exports[function]
+	return ok
+}
+
 // Exec func
 func (r *Runtime) Exec(function string, data []byte) ([]byte, error) {
 	ptr, err := r.malloc(uint32(len(data)))
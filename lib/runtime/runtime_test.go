@@ -96,3 +96,9 @@ func TestConcurrentRuntimeCalls(t *testing.T) {
 		_, _ = runtime.Exec(CoreVersion, []byte{})
 	}()
 }
+
+func TestHasExport(t *testing.T) {
+	runtime := NewTestRuntime(t, NODE_RUNTIME)
+	require.True(t, runtime.HasExport(CoreVersion))
+	require.False(t, runtime.HasExport("Core_does_not_exist"))
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"encoding/binary"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// StorageTrie is the state that a TrieStorage reads and writes, which is implemented by *trie.Trie
+type StorageTrie interface {
+	Put(key, value []byte) error
+	Get(key []byte) ([]byte, error)
+	Delete(key []byte) error
+	Hash() (common.Hash, error)
+	Entries() map[string][]byte
+	PutChild(keyToChild []byte, child *trie.Trie) error
+	PutIntoChild(keyToChild, key, value []byte) error
+	GetFromChild(keyToChild, key []byte) ([]byte, error)
+}
+
+// TrieStorage implements Storage on top of a StorageTrie
+type TrieStorage struct {
+	t StorageTrie
+}
+
+// NewTrieStorage returns a new TrieStorage that uses the given trie
+func NewTrieStorage(t StorageTrie) *TrieStorage {
+	return &TrieStorage{
+		t: t,
+	}
+}
+
+// SetTrie sets the trie used by the storage
+func (s *TrieStorage) SetTrie(t StorageTrie) {
+	s.t = t
+}
+
+// SetStorage sets the value of the key in the trie
+func (s *TrieStorage) SetStorage(key []byte, value []byte) error {
+	return s.t.Put(key, value)
+}
+
+// GetStorage returns the value of the key in the trie
+func (s *TrieStorage) GetStorage(key []byte) ([]byte, error) {
+	return s.t.Get(key)
+}
+
+// StorageRoot returns the root of the trie
+func (s *TrieStorage) StorageRoot() (common.Hash, error) {
+	return s.t.Hash()
+}
+
+// SetStorageChild sets the child trie at the key
+func (s *TrieStorage) SetStorageChild(keyToChild []byte, child *trie.Trie) error {
+	return s.t.PutChild(keyToChild, child)
+}
+
+// SetStorageIntoChild sets the value of the key in the child trie at keyToChild
+func (s *TrieStorage) SetStorageIntoChild(keyToChild, key, value []byte) error {
+	return s.t.PutIntoChild(keyToChild, key, value)
+}
+
+// GetStorageFromChild returns the value of the key in the child trie at keyToChild
+func (s *TrieStorage) GetStorageFromChild(keyToChild, key []byte) ([]byte, error) {
+	return s.t.GetFromChild(keyToChild, key)
+}
+
+// ClearStorage deletes the key from the trie
+func (s *TrieStorage) ClearStorage(key []byte) error {
+	return s.t.Delete(key)
+}
+
+// Entries returns the key-value pairs of the trie
+func (s *TrieStorage) Entries() map[string][]byte {
+	return s.t.Entries()
+}
+
+// SetBalance sets the balance for an account with the given public key
+func (s *TrieStorage) SetBalance(key [32]byte, balance uint64) error {
+	skey, err := common.BalanceKey(key)
+	if err != nil {
+		return err
+	}
+
+	bb := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bb, balance)
+
+	return s.SetStorage(skey, bb)
+}
+
+// GetBalance gets the balance for an account with the given public key
+func (s *TrieStorage) GetBalance(key [32]byte) (uint64, error) {
+	skey, err := common.BalanceKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	bal, err := s.GetStorage(skey)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(bal) != 8 {
+		return 0, nil
+	}
+
+	return binary.LittleEndian.Uint64(bal), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrieStorage_Balance(t *testing.T) {
+	tr := trie.NewEmptyTrie()
+	s := NewTrieStorage(tr)
+
+	key := [32]byte{1, 2, 3}
+	bal, err := s.GetBalance(key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), bal)
+
+	err = s.SetBalance(key, 99)
+	require.NoError(t, err)
+
+	bal, err = s.GetBalance(key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(99), bal)
+
+	root, err := s.StorageRoot()
+	require.NoError(t, err)
+	expected, err := tr.Hash()
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+}
@@ -90,6 +90,10 @@ var (
 	TaggedTransactionQueueValidateTransaction = "TaggedTransactionQueue_validate_transaction"
 	// GrandpaAuthorities is the runtime API call GrandpaApi_grandpa_authorities
 	GrandpaAuthorities = "GrandpaApi_grandpa_authorities"
+	// GrandpaGenerateKeyOwnershipProof is the runtime API call GrandpaApi_generate_key_ownership_proof
+	GrandpaGenerateKeyOwnershipProof = "GrandpaApi_generate_key_ownership_proof"
+	// GrandpaSubmitReportEquivocation is the runtime API call GrandpaApi_submit_report_equivocation_unsigned_extrinsic
+	GrandpaSubmitReportEquivocation = "GrandpaApi_submit_report_equivocation_unsigned_extrinsic"
 	// BabeAPIConfiguration is the runtime API call BabeApi_configuration
 	BabeAPIConfiguration = "BabeApi_configuration"
 	// BlockBuilderInherentExtrinsics is the runtime API call BlockBuilder_inherent_extrinsics
@@ -30,6 +30,18 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
+// maxPreallocation is the maximum number of elements allocated up front when decoding a list. the length prefix of
+// an encoding may claim far more elements than the input contains, so larger lists grow as they're decoded.
+const maxPreallocation = 1 << 16
+
+// preallocation returns the capacity to allocate up front for a slice with the given encoded length
+func Preallocation(length int64) int {
+	if length > maxPreallocation {
+		return maxPreallocation
+	}
+	return int(length)
+}
+
 // Decoder is a wrapping around io.Reader
 type Decoder struct {
 	Reader io.Reader
@@ -283,13 +295,13 @@ func (sd *Decoder) DecodeByteArray() (o []byte, err error) {
 		return nil, err
 	}
 
-	b := make([]byte, length)
-	_, err = sd.Reader.Read(b)
+	buf := bytes.NewBuffer(make([]byte, 0, Preallocation(length)))
+	_, err = io.CopyN(buf, sd.Reader, length)
 	if err != nil {
 		return nil, errors.New("could not decode invalid byte array: reached early EOF")
 	}
 
-	return b, nil
+	return buf.Bytes(), nil
 }
 
 // DecodeBool accepts a byte array representing a SCALE encoded bool and performs SCALE decoding
@@ -343,9 +355,10 @@ func (sd *Decoder) DecodeArray(t interface{}) (interface{}, error) {
 		return t, nil
 	}
 
-	sl := reflect.MakeSlice(v.Type(), int(length), int(length))
+	sl := reflect.MakeSlice(v.Type(), 0, Preallocation(length))
 
 	for i := 0; i < int(length); i++ {
+		sl = reflect.Append(sl, reflect.Zero(v.Type().Elem()))
 		arrayValue := sl.Index(i)
 
 		switch ptr := arrayValue.Addr().Interface().(type) {
@@ -527,11 +540,11 @@ func (sd *Decoder) DecodeIntArray() ([]int, error) {
 		return nil, err
 	}
 
-	o := make([]int, length)
-	for i := range o {
+	o := make([]int, 0, Preallocation(length))
+	for i := int64(0); i < length; i++ {
 		var t int64
 		t, err = sd.DecodeInteger()
-		o[i] = int(t)
+		o = append(o, int(t))
 		if err != nil {
 			break
 		}
@@ -546,11 +559,11 @@ func (sd *Decoder) DecodeBigIntArray() ([]*big.Int, error) {
 		return nil, err
 	}
 
-	o := make([]*big.Int, length)
-	for i := range o {
+	o := make([]*big.Int, 0, Preallocation(length))
+	for i := int64(0); i < length; i++ {
 		var t *big.Int
 		t, err = sd.DecodeBigInt()
-		o[i] = t
+		o = append(o, t)
 		if err != nil {
 			break
 		}
@@ -565,9 +578,11 @@ func (sd *Decoder) DecodeBoolArray() ([]bool, error) {
 		return nil, err
 	}
 
-	o := make([]bool, length)
-	for i := range o {
-		o[i], err = sd.DecodeBool()
+	o := make([]bool, 0, Preallocation(length))
+	for i := int64(0); i < length; i++ {
+		var b bool
+		b, err = sd.DecodeBool()
+		o = append(o, b)
 		if err != nil {
 			break
 		}
@@ -581,14 +596,14 @@ func (sd *Decoder) DecodeStringArray() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	s := make([]string, length)
+	s := make([]string, 0, Preallocation(length))
 
-	for i := 0; i < int(length); i++ {
+	for i := int64(0); i < length; i++ {
 		o, err := sd.DecodeByteArray()
 		if err != nil {
 			return nil, err
 		}
-		s[i] = string(o[:]) // cast []byte into string
+		s = append(s, string(o[:])) // cast []byte into string
 	}
 	return s, nil
 }
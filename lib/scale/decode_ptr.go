@@ -61,7 +61,7 @@ func DecodePtr(in []byte, t interface{}) error {
 func (sd *Decoder) DecodeCustom(t interface{}) (interface{}, error) {
 	someType := reflect.TypeOf(t)
 	val := reflect.ValueOf(t)
-	if val.IsNil() {
+	if val.Kind() == reflect.Ptr && val.IsNil() {
 		n := reflect.New(someType.Elem())
 		t = n.Interface()
 	}
@@ -264,10 +264,10 @@ func (sd *Decoder) DecodePtrIntArray(t interface{}) error {
 		return err
 	}
 
-	sl := make([]int, length)
-	for i := range sl {
+	sl := make([]int, 0, Preallocation(length))
+	for i := int64(0); i < length; i++ {
 		temp, err := sd.DecodeInteger()
-		sl[i] = int(temp)
+		sl = append(sl, int(temp))
 		if err != nil {
 			break
 		}
@@ -497,6 +497,26 @@ func TestDecodeByteArrays(t *testing.T) {
 	}
 }
 
+func TestDecodeByteArrays_InvalidLength(t *testing.T) {
+	// the length prefix claims 2^48 bytes, but the input only contains three
+	_, err := Decode([]byte{0x0f, 0, 0, 0, 0, 0, 0, 1, 1, 2, 3}, []byte{})
+	if err == nil {
+		t.Error("did not error for byte array longer than input")
+	}
+
+	// the length prefix claims five bytes, but the input only contains three
+	_, err = Decode([]byte{0x14, 1, 2, 3}, []byte{})
+	if err == nil {
+		t.Error("did not error for truncated byte array")
+	}
+
+	// the length prefix claims 2^48 byte arrays, but the input only contains one
+	_, err = Decode([]byte{0x0f, 0, 0, 0, 0, 0, 0, 1, 0x04, 1}, [][]byte{})
+	if err == nil {
+		t.Error("did not error for array longer than input")
+	}
+}
+
 func TestDecodeBool(t *testing.T) {
 	for _, test := range decodeBoolTests {
 		output, err := Decode([]byte{test.val}, true)
@@ -0,0 +1,139 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// Database is a key-value store that trie nodes are written to and loaded from, keyed by the hashes of their encodings
+type Database interface {
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Has(key []byte) (bool, error)
+}
+
+// Store writes the nodes of the trie to the database, keyed by the hashes of their encodings, so that the trie can be
+// loaded with LoadFromDB using its root hash. nodes that are already in the database are skipped along with their
+// children, so storing a trie that shares most of its nodes with one that was stored before, such as the state of the
+// next block, only writes the nodes that changed.
+func (t *Trie) Store(db Database) error {
+	if t.root == nil {
+		return nil
+	}
+
+	return storeNode(db, t.root, true)
+}
+
+// storeNode writes the node to the database after its children, so that a node being in the database means that all
+// of its children are too
+func storeNode(db Database, n node, isRoot bool) error {
+	enc, err := encode(n)
+	if err != nil {
+		return err
+	}
+
+	// nodes whose encodings are shorter than 32 bytes are inlined in their parent's encoding, along with their children
+	if !isRoot && len(enc) < 32 {
+		return nil
+	}
+
+	hash, err := common.Blake2bHash(enc)
+	if err != nil {
+		return err
+	}
+
+	has, err := db.Has(hash[:])
+	if err != nil || has {
+		return err
+	}
+
+	if b, ok := n.(*branch); ok {
+		for _, child := range b.children {
+			if child == nil {
+				continue
+			}
+
+			err = storeNode(db, child, false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return db.Put(hash[:], enc)
+}
+
+// LoadFromDB sets the receiver to the trie with the given root hash, whose nodes were written to the database by Store
+func (t *Trie) LoadFromDB(db Database, root common.Hash) error {
+	if root == EmptyHash {
+		t.root = nil
+		return nil
+	}
+
+	enc, err := db.Get(root[:])
+	if err != nil {
+		return err
+	}
+
+	t.root, err = loadNode(db, enc)
+	return err
+}
+
+// loadNode decodes the node and loads its children, which are either inlined in its encoding or loaded from the
+// database by their hashes
+func loadNode(db Database, enc []byte) (node, error) {
+	n, err := decode(bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := n.(*branch)
+	if !ok {
+		return n, nil
+	}
+
+	// decode stubs the branch's children, so they're replaced with the nodes their hashes or encodings refer to
+	refs, err := decodeProofNode(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, ref := range refs.children {
+		if ref == nil {
+			continue
+		}
+
+		childEnc := ref
+		if len(ref) >= 32 {
+			childEnc, err = db.Get(ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load child %d of node: %w", i, err)
+			}
+		}
+
+		b.children[i], err = loadNode(db, childEnc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
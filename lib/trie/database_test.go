@@ -0,0 +1,158 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDB is an in-memory Database that counts the nodes written to it
+type testDB struct {
+	kv   map[string][]byte
+	puts int
+}
+
+func newTestDB() *testDB {
+	return &testDB{
+		kv: make(map[string][]byte),
+	}
+}
+
+func (db *testDB) Get(key []byte) ([]byte, error) {
+	v, has := db.kv[string(key)]
+	if !has {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (db *testDB) Put(key, value []byte) error {
+	db.puts++
+	db.kv[string(key)] = value
+	return nil
+}
+
+func (db *testDB) Has(key []byte) (bool, error) {
+	_, has := db.kv[string(key)]
+	return has, nil
+}
+
+func newRandomTrie(t *testing.T, size int) (*Trie, []Test) {
+	trie := NewEmptyTrie()
+	rt := GenerateRandomTests(t, size)
+	for _, test := range rt {
+		require.NoError(t, trie.Put(test.key, test.value))
+	}
+
+	return trie, rt
+}
+
+func TestStoreAndLoadFromDB(t *testing.T) {
+	trie, rt := newRandomTrie(t, 1000)
+	db := newTestDB()
+	require.NoError(t, trie.Store(db))
+
+	root, err := trie.Hash()
+	require.NoError(t, err)
+
+	loaded := NewEmptyTrie()
+	require.NoError(t, loaded.LoadFromDB(db, root))
+
+	loadedRoot, err := loaded.Hash()
+	require.NoError(t, err)
+	require.Equal(t, root, loadedRoot)
+
+	for _, test := range rt {
+		value, err := loaded.Get(test.key)
+		require.NoError(t, err)
+		require.Equal(t, test.value, value)
+	}
+}
+
+func TestStore_OnlyChangedNodes(t *testing.T) {
+	trie, rt := newRandomTrie(t, 1000)
+	db := newTestDB()
+	require.NoError(t, trie.Store(db))
+
+	root, err := trie.Hash()
+	require.NoError(t, err)
+
+	// storing the same trie again doesn't write anything
+	puts := db.puts
+	require.NoError(t, trie.Store(db))
+	require.Equal(t, puts, db.puts)
+
+	// only the nodes on the path to the changed key are written
+	changed := NewEmptyTrie()
+	require.NoError(t, changed.LoadFromDB(db, root))
+	require.NoError(t, changed.Put(rt[0].key, []byte("changed")))
+	require.NoError(t, changed.Store(db))
+	require.Less(t, db.puts-puts, 16)
+
+	changedRoot, err := changed.Hash()
+	require.NoError(t, err)
+
+	loaded := NewEmptyTrie()
+	require.NoError(t, loaded.LoadFromDB(db, changedRoot))
+	value, err := loaded.Get(rt[0].key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("changed"), value)
+
+	// the previous state can still be loaded
+	require.NoError(t, loaded.LoadFromDB(db, root))
+	value, err = loaded.Get(rt[0].key)
+	require.NoError(t, err)
+	require.Equal(t, rt[0].value, value)
+}
+
+func TestLoadFromDB_EmptyTrie(t *testing.T) {
+	db := newTestDB()
+	require.NoError(t, NewEmptyTrie().Store(db))
+	require.Equal(t, 0, db.puts)
+
+	loaded := NewEmptyTrie()
+	require.NoError(t, loaded.LoadFromDB(db, EmptyHash))
+	require.Nil(t, loaded.RootNode())
+}
+
+func TestLoadFromDB_MissingNode(t *testing.T) {
+	trie, _ := newRandomTrie(t, 100)
+	db := newTestDB()
+	require.NoError(t, trie.Store(db))
+
+	root, err := trie.Hash()
+	require.NoError(t, err)
+
+	// remove one of the nodes below the root
+	for k := range db.kv {
+		if k != string(root[:]) {
+			delete(db.kv, k)
+			break
+		}
+	}
+
+	err = NewEmptyTrie().LoadFromDB(db, root)
+	require.Error(t, err)
+
+	err = NewEmptyTrie().LoadFromDB(db, common.Hash{0x1})
+	require.Error(t, err)
+}
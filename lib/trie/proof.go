@@ -0,0 +1,195 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// ErrIncompleteProof is returned when a proof doesn't contain a node that's needed to look up a key
+var ErrIncompleteProof = errors.New("proof is missing a node needed to look up the key")
+
+// ErrInvalidProof is returned when a proof contains a node that cannot be decoded
+var ErrInvalidProof = errors.New("proof contains an invalid node")
+
+// GenerateProof returns the encoded nodes on the paths from the root of the trie to each of the keys, which prove
+// the values of the keys, or that they aren't in the trie, against the trie's root hash. nodes whose encodings are
+// shorter than 32 bytes are inlined in their parent's encoding, so only the root and the longer nodes are included.
+func (t *Trie) GenerateProof(keys [][]byte) ([][]byte, error) {
+	proof := [][]byte{}
+	seen := make(map[string]bool)
+
+	for _, key := range keys {
+		err := generateProof(t.root, keyToNibbles(key), true, &proof, seen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return proof, nil
+}
+
+func generateProof(n node, key []byte, isRoot bool, proof *[][]byte, seen map[string]bool) error {
+	if n == nil {
+		return nil
+	}
+
+	enc, err := encode(n)
+	if err != nil {
+		return err
+	}
+
+	if (isRoot || len(enc) >= 32) && !seen[string(enc)] {
+		seen[string(enc)] = true
+		*proof = append(*proof, enc)
+	}
+
+	b, ok := n.(*branch)
+	if !ok || !bytes.HasPrefix(key, b.key) || len(key) == len(b.key) {
+		return nil
+	}
+
+	key = key[len(b.key):]
+	return generateProof(b.children[key[0]], key[1:], false, proof, seen)
+}
+
+// VerifyProof returns the value of the key in the trie with the given root hash, looked up in the proof returned by
+// GenerateProof. the value is nil if the proof shows that the key isn't in the trie.
+func VerifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte)
+	for _, enc := range proof {
+		hash, err := common.Blake2bHash(enc)
+		if err != nil {
+			return nil, err
+		}
+		nodes[hash] = enc
+	}
+
+	enc, has := nodes[root]
+	if !has {
+		return nil, ErrIncompleteProof
+	}
+
+	k := keyToNibbles(key)
+	for {
+		n, err := decodeProofNode(enc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidProof, err)
+		}
+
+		if !bytes.HasPrefix(k, n.key) {
+			return nil, nil
+		}
+
+		if !n.isBranch {
+			if len(k) != len(n.key) {
+				return nil, nil
+			}
+			return n.value, nil
+		}
+
+		k = k[len(n.key):]
+		if len(k) == 0 {
+			return n.value, nil
+		}
+
+		child := n.children[k[0]]
+		k = k[1:]
+
+		switch {
+		case child == nil:
+			return nil, nil
+		case len(child) < 32:
+			// the child is inlined in its parent's encoding
+			enc = child
+		default:
+			enc, has = nodes[common.BytesToHash(child)]
+			if !has {
+				return nil, ErrIncompleteProof
+			}
+		}
+	}
+}
+
+// proofNode is a node decoded from a proof or a database. unlike the nodes decoded by decode, it keeps the hashes or
+// inlined encodings of a branch's children, so that they can be looked up in the proof or the database.
+type proofNode struct {
+	isBranch bool
+	key      []byte
+	value    []byte
+	children [16][]byte
+}
+
+func decodeProofNode(enc []byte) (*proofNode, error) {
+	r := bytes.NewReader(enc)
+	header, err := readByte(r)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeType := header >> 6
+	if nodeType == 0 {
+		return nil, errors.New("invalid node type")
+	}
+
+	n := &proofNode{
+		isBranch: nodeType != 1,
+	}
+
+	n.key, err = decodeKey(r, header&0x3f)
+	if err != nil {
+		return nil, err
+	}
+
+	sd := &scale.Decoder{Reader: r}
+	if !n.isBranch {
+		n.value, err = sd.DecodeByteArray()
+		if len(n.value) == 0 {
+			n.value = nil
+		}
+		return n, err
+	}
+
+	bitmap := make([]byte, 2)
+	_, err = r.Read(bitmap)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeType == 3 {
+		n.value, err = sd.DecodeByteArray()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < 16; i++ {
+		if (bitmap[i/8]>>(i%8))&1 == 1 {
+			n.children[i], err = sd.DecodeByteArray()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+func TestProof(t *testing.T) {
+	trie := NewEmptyTrie()
+	rt := GenerateRandomTests(t, 1000)
+	for _, test := range rt {
+		err := trie.Put(test.key, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := trie.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := [][]byte{}
+	for _, test := range rt[:10] {
+		keys = append(keys, test.key)
+	}
+
+	absent := []byte("not in the trie")
+	keys = append(keys, absent)
+
+	proof, err := trie.GenerateProof(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range rt[:10] {
+		value, err := VerifyProof(root, test.key, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(value, test.value) {
+			t.Errorf("Fail: got %x expected %x", value, test.value)
+		}
+	}
+
+	value, err := VerifyProof(root, absent, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != nil {
+		t.Errorf("Fail: got %x for absent key", value)
+	}
+
+	// keys that weren't proven cannot be looked up, unless their paths share all of their nodes with proven keys
+	_, err = VerifyProof(root, rt[500].key, proof[:1])
+	if !errors.Is(err, ErrIncompleteProof) {
+		t.Errorf("Fail: expected ErrIncompleteProof, got %v", err)
+	}
+
+	_, err = VerifyProof(common.Hash{0x1}, rt[0].key, proof)
+	if !errors.Is(err, ErrIncompleteProof) {
+		t.Errorf("Fail: expected ErrIncompleteProof for wrong root, got %v", err)
+	}
+}
+
+func TestProof_SmallTrie(t *testing.T) {
+	trie := NewEmptyTrie()
+	entries := map[string][]byte{
+		"a":   {1},
+		"ab":  {2},
+		"abc": {3},
+		"b":   {4},
+	}
+	for k, v := range entries {
+		err := trie.Put([]byte(k), v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := trie.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every node is inlined in the root's encoding
+	proof, err := trie.GenerateProof([][]byte{[]byte("abc")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != 1 {
+		t.Fatalf("Fail: expected only the root in the proof, got %d nodes", len(proof))
+	}
+
+	for k, v := range entries {
+		value, err := VerifyProof(root, []byte(k), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(value, v) {
+			t.Errorf("Fail: got %x expected %x for key %s", value, v, k)
+		}
+	}
+
+	// a proof with a tampered node doesn't hash to the root
+	tampered := append([]byte{}, proof[0]...)
+	tampered[len(tampered)-1]++
+	_, err = VerifyProof(root, []byte("abc"), [][]byte{tampered})
+	if !errors.Is(err, ErrIncompleteProof) {
+		t.Errorf("Fail: expected ErrIncompleteProof for tampered proof, got %v", err)
+	}
+}
+
+func TestProof_EmptyTrie(t *testing.T) {
+	trie := NewEmptyTrie()
+	proof, err := trie.GenerateProof([][]byte{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != 0 {
+		t.Errorf("Fail: expected empty proof, got %d nodes", len(proof))
+	}
+}